@@ -0,0 +1,215 @@
+// Package bridgeadapter sends asynchronous events, EventBridge events, SQS
+// messages and SNS notifications, to the http.Handler that serves the API
+// traffic of the function, so both are routed by the same routing table.
+// Each event becomes a synthetic request built from a route: a method and a
+// path template filled in from the fields of the event, with the payload of
+// the event as the body.
+package bridgeadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+// ErrUnsupportedEvent is returned by Invoke for events that are not
+// EventBridge, SQS or SNS events when no HTTP handler was set with
+// SetHTTPHandler.
+var ErrUnsupportedEvent = errors.New("Unsupported event type")
+
+// Route is the method and path template of the requests built from events.
+// The template can reference fields of the event in braces, such as
+// /events/{source}/{detail-type} for EventBridge, /queues/{queue} for SQS or
+// /topics/{topic} for SNS. Values are escaped as path segments and unknown
+// fields are left empty. See the Handle and Set methods of Bridge for the
+// fields of each event type.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// defaultRoute is used for events without a route, like the default route
+// of core.RequestAccessorSNS.
+var defaultRoute = Route{Method: http.MethodPost, Path: "/"}
+
+// Bridge sends EventBridge, SQS and SNS events to an http.Handler.
+type Bridge struct {
+	handler http.Handler
+
+	mu          sync.RWMutex
+	eventBridge map[string]Route
+	sqs         Route
+	sns         Route
+
+	sqsRunner *core.BatchRunner[events.SQSMessage]
+	snsRunner *core.BatchRunner[events.SNSEventRecord]
+	ebRunner  *core.BatchRunner[events.EventBridgeEvent]
+
+	httpHandler func(ctx context.Context, payload []byte) ([]byte, error)
+}
+
+// New returns a Bridge that sends the events to handler. Events are sent
+// as a POST to "/" until routes are set.
+func New(handler http.Handler) *Bridge {
+	b := &Bridge{
+		handler:     handler,
+		eventBridge: make(map[string]Route),
+		sqs:         defaultRoute,
+		sns:         defaultRoute,
+	}
+	b.sqsRunner = core.NewBatchRunner(b.sqsRequest)
+	b.snsRunner = core.NewBatchRunner(b.snsRequest)
+	b.ebRunner = core.NewBatchRunner(b.eventBridgeRequest)
+	return b
+}
+
+// HandleEventBridge sets the route of EventBridge events with the detail
+// type, or of the events whose detail type has no route when detailType is
+// empty. The path template can use the {id}, {source}, {detail-type},
+// {account} and {region} fields of the event, and {detail.name} for a top
+// level field of the detail.
+func (b *Bridge) HandleEventBridge(detailType string, route Route) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.eventBridge[detailType] = route
+}
+
+// SetSQSRoute sets the route of SQS messages. The path template can use the
+// {queue} name, the {messageId}, and {attributes.name} for the string value
+// of a message attribute.
+func (b *Bridge) SetSQSRoute(route Route) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sqs = route
+}
+
+// SetSNSRoute sets the route of SNS notifications. The path template can use
+// the {topic} name, the {messageId}, the {subject} and {attributes.name} for
+// the value of a message attribute.
+func (b *Bridge) SetSNSRoute(route Route) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sns = route
+}
+
+// SetHTTPHandler sets the function Invoke sends the events that are not
+// EventBridge, SQS or SNS events to, such as the ProxyRaw method of an
+// adapter for the API Gateway events of the function.
+func (b *Bridge) SetHTTPHandler(handler func(ctx context.Context, payload []byte) ([]byte, error)) {
+	b.httpHandler = handler
+}
+
+// ProxyEventBridge sends the EventBridge event to the handler. It returns an
+// error, so that Lambda retries the event, if the handler did not respond
+// with a status in the 2xx range.
+func (b *Bridge) ProxyEventBridge(ctx context.Context, event events.EventBridgeEvent) error {
+	results := b.ebRunner.Run(ctx, []events.EventBridgeEvent{event}, b.handler)
+	if len(results.Failed()) > 0 {
+		return fmt.Errorf("Could not process EventBridge event %s", event.ID)
+	}
+	return nil
+}
+
+// ProxySQS sends every message of the event to the handler in order. The
+// messages that could not be converted or got a response with a status
+// outside of the 2xx range are returned as batch item failures, for event
+// source mappings with ReportBatchItemFailures enabled.
+func (b *Bridge) ProxySQS(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	response := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}
+	for _, message := range b.sqsRunner.Run(ctx, event.Records, b.handler).Failed() {
+		response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: message.MessageId})
+	}
+	return response, nil
+}
+
+// ProxySNS sends every record of the event to the handler in order. Like
+// core.RequestAccessorSNS.ProxyBatch it returns an error if any record was
+// not processed successfully.
+func (b *Bridge) ProxySNS(ctx context.Context, event events.SNSEvent) error {
+	failed := b.snsRunner.Run(ctx, event.Records, b.handler).Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	ids := make([]string, len(failed))
+	for i, record := range failed {
+		ids[i] = record.SNS.MessageID
+	}
+	return fmt.Errorf("Could not process SNS messages: %s", strings.Join(ids, ", "))
+}
+
+// Invoke implements the lambda.Handler interface. It detects the type of
+// the event, sends EventBridge, SQS and SNS events to the matching Proxy
+// method and the other events to the handler set with SetHTTPHandler, so a
+// single function can serve its API and its asynchronous events.
+func (b *Bridge) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	var probe struct {
+		DetailType *string `json:"detail-type"`
+		Records    []struct {
+			EventSource string `json:"eventSource"`
+		} `json:"Records"`
+	}
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case probe.DetailType != nil:
+		var event events.EventBridgeEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+		return nil, b.ProxyEventBridge(ctx, event)
+	case len(probe.Records) > 0 && probe.Records[0].EventSource == "aws:sqs":
+		var event events.SQSEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+		response, err := b.ProxySQS(ctx, event)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(response)
+	case len(probe.Records) > 0 && probe.Records[0].EventSource == "aws:sns":
+		var event events.SNSEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+		return nil, b.ProxySNS(ctx, event)
+	}
+
+	if b.httpHandler == nil {
+		return nil, ErrUnsupportedEvent
+	}
+	return b.httpHandler(ctx, payload)
+}
+
+func (b *Bridge) eventBridgeRoute(detailType string) Route {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if route, ok := b.eventBridge[detailType]; ok {
+		return route
+	}
+	if route, ok := b.eventBridge[""]; ok {
+		return route
+	}
+	return defaultRoute
+}
+
+func (b *Bridge) sqsRoute() Route {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.sqs
+}
+
+func (b *Bridge) snsRoute() Route {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.sns
+}
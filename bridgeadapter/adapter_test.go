@@ -0,0 +1,151 @@
+package bridgeadapter_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/bridgeadapter"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bridge tests", func() {
+	var (
+		mux      *http.ServeMux
+		received []string
+	)
+
+	BeforeEach(func() {
+		received = nil
+		mux = http.NewServeMux()
+		mux.HandleFunc("/orders/", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			received = append(received, fmt.Sprintf("%s %s %s", r.Method, r.URL.EscapedPath(), body))
+			if string(body) == "fail" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		})
+	})
+
+	Context("EventBridge", func() {
+		It("Routes events by detail type", func() {
+			bridge := bridgeadapter.New(mux)
+			bridge.HandleEventBridge("Order Placed", bridgeadapter.Route{Method: "PUT", Path: "/orders/{detail.orderId}/{source}"})
+
+			var headers http.Header
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				headers = r.Header
+				event, ok := bridgeadapter.GetEventBridgeEvent(r.Context())
+				Expect(ok).To(BeTrue())
+				Expect(event.ID).To(Equal("evt-2"))
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			err := bridge.ProxyEventBridge(context.Background(), events.EventBridgeEvent{
+				ID:         "evt-1",
+				DetailType: "Order Placed",
+				Source:     "shop/checkout",
+				Region:     "us-east-1",
+				Detail:     json.RawMessage(`{"orderId":"42"}`),
+			})
+			Expect(err).To(BeNil())
+			Expect(received).To(Equal([]string{`PUT /orders/42/shop%2Fcheckout {"orderId":"42"}`}))
+
+			err = bridge.ProxyEventBridge(context.Background(), events.EventBridgeEvent{
+				ID:         "evt-2",
+				DetailType: "Order Shipped",
+				Source:     "shop",
+				Detail:     json.RawMessage(`{}`),
+			})
+			Expect(err).To(BeNil())
+			Expect(headers.Get(bridgeadapter.EventBridgeDetailTypeHeader)).To(Equal("Order Shipped"))
+			Expect(headers.Get("Content-Type")).To(Equal("application/json"))
+		})
+
+		It("Returns an error for failed events", func() {
+			bridge := bridgeadapter.New(mux)
+			bridge.HandleEventBridge("", bridgeadapter.Route{Path: "/orders/{id}"})
+
+			err := bridge.ProxyEventBridge(context.Background(), events.EventBridgeEvent{ID: "evt-1", Detail: json.RawMessage(`{}`)})
+			Expect(err).To(BeNil())
+
+			mux.HandleFunc("/orders/evt-2", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			})
+			err = bridge.ProxyEventBridge(context.Background(), events.EventBridgeEvent{ID: "evt-2", Detail: json.RawMessage(`{}`)})
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Context("SQS", func() {
+		It("Reports the failed messages as batch item failures", func() {
+			bridge := bridgeadapter.New(mux)
+			bridge.SetSQSRoute(bridgeadapter.Route{Path: "/orders/{queue}/{attributes.tenant}"})
+
+			tenant := "acme"
+			resp, err := bridge.ProxySQS(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+				{MessageId: "m1", Body: "ok", EventSourceARN: "arn:aws:sqs:us-east-1:123456789012:orders", MessageAttributes: map[string]events.SQSMessageAttribute{"tenant": {StringValue: &tenant, DataType: "String"}}},
+				{MessageId: "m2", Body: "fail", EventSourceARN: "arn:aws:sqs:us-east-1:123456789012:orders"},
+			}})
+			Expect(err).To(BeNil())
+			Expect(received).To(Equal([]string{"POST /orders/orders/acme ok", "POST /orders/orders/ fail"}))
+			Expect(resp.BatchItemFailures).To(Equal([]events.SQSBatchItemFailure{{ItemIdentifier: "m2"}}))
+		})
+	})
+
+	Context("SNS", func() {
+		It("Keeps the SNS headers and context", func() {
+			bridge := bridgeadapter.New(mux)
+			bridge.SetSNSRoute(bridgeadapter.Route{Path: "/orders/{topic}?subject={subject}"})
+
+			mux.HandleFunc("/orders/order-events", func(w http.ResponseWriter, r *http.Request) {
+				record, ok := core.GetSNSRecordFromContext(r.Context())
+				Expect(ok).To(BeTrue())
+				Expect(record.SNS.MessageID).To(Equal(r.Header.Get(core.SNSMessageIDHeader)))
+				Expect(r.URL.Query().Get("subject")).To(Equal("Placed"))
+				w.WriteHeader(http.StatusOK)
+			})
+
+			err := bridge.ProxySNS(context.Background(), events.SNSEvent{Records: []events.SNSEventRecord{{
+				SNS: events.SNSEntity{MessageID: "n1", TopicArn: "arn:aws:sns:us-east-1:123456789012:order-events", Subject: "Placed", Message: "{}"},
+			}}})
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Context("Invoke", func() {
+		It("Detects the event type and falls back to the HTTP handler", func() {
+			bridge := bridgeadapter.New(mux)
+			bridge.SetSQSRoute(bridgeadapter.Route{Path: "/orders/{messageId}"})
+			bridge.SetHTTPHandler(httpadapter.New(mux).ProxyRaw)
+
+			payload, err := bridge.Invoke(context.Background(), []byte(`{"Records":[{"messageId":"m1","body":"fail","eventSource":"aws:sqs"}]}`))
+			Expect(err).To(BeNil())
+			Expect(string(payload)).To(Equal(`{"batchItemFailures":[{"itemIdentifier":"m1"}]}`))
+
+			_, err = bridge.Invoke(context.Background(), []byte(`{"Records":[{"EventSource":"aws:sns","Sns":{"MessageId":"n1","Message":"fail"}}]}`))
+			Expect(err).ToNot(BeNil())
+
+			payload, err = bridge.Invoke(context.Background(), []byte(`{"httpMethod":"DELETE","path":"/orders/7","requestContext":{}}`))
+			Expect(err).To(BeNil())
+			var resp events.APIGatewayProxyResponse
+			Expect(json.Unmarshal(payload, &resp)).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+			Expect(received).To(ContainElement("DELETE /orders/7 "))
+		})
+
+		It("Rejects unknown events without an HTTP handler", func() {
+			_, err := bridgeadapter.New(mux).Invoke(context.Background(), []byte(`{"path":"/"}`))
+			Expect(err).To(Equal(bridgeadapter.ErrUnsupportedEvent))
+		})
+	})
+})
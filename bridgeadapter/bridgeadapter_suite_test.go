@@ -0,0 +1,13 @@
+package bridgeadapter_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBridgeAdapter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "BridgeAdapter Suite")
+}
@@ -0,0 +1,205 @@
+package bridgeadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+const (
+	// EventBridgeIDHeader is the header that holds the ID of an EventBridge event.
+	EventBridgeIDHeader = "X-Eventbridge-Id"
+	// EventBridgeSourceHeader is the header that holds the source of an EventBridge event.
+	EventBridgeSourceHeader = "X-Eventbridge-Source"
+	// EventBridgeDetailTypeHeader is the header that holds the detail type of an EventBridge event.
+	EventBridgeDetailTypeHeader = "X-Eventbridge-Detail-Type"
+
+	// SQSMessageIDHeader is the header that holds the ID of an SQS message.
+	SQSMessageIDHeader = "X-Sqs-Message-Id"
+	// SQSQueueArnHeader is the header that holds the ARN of the queue of an SQS message.
+	SQSQueueArnHeader = "X-Sqs-Queue-Arn"
+	// SQSMessageAttributeHeaderPrefix prefixes the name of each string
+	// message attribute to build the header that holds its value.
+	SQSMessageAttributeHeaderPrefix = "X-Sqs-Attribute-"
+)
+
+type eventBridgeKey struct{}
+
+type sqsKey struct{}
+
+// GetEventBridgeEvent returns the EventBridge event a request was built from.
+func GetEventBridgeEvent(ctx context.Context) (events.EventBridgeEvent, bool) {
+	event, ok := ctx.Value(eventBridgeKey{}).(events.EventBridgeEvent)
+	return event, ok
+}
+
+// GetSQSMessage returns the SQS message a request was built from.
+func GetSQSMessage(ctx context.Context) (events.SQSMessage, bool) {
+	message, ok := ctx.Value(sqsKey{}).(events.SQSMessage)
+	return message, ok
+}
+
+// eventBridgeRequest builds the request for an EventBridge event. The body
+// is the detail of the event.
+func (b *Bridge) eventBridgeRequest(ctx context.Context, event events.EventBridgeEvent) (*http.Request, error) {
+	// only detail objects have fields for the path template, other values
+	// are still sent as the body
+	var detail map[string]interface{}
+	json.Unmarshal(event.Detail, &detail)
+
+	route := b.eventBridgeRoute(event.DetailType)
+	path := expandPath(route.Path, func(name string) string {
+		switch name {
+		case "id":
+			return event.ID
+		case "source":
+			return event.Source
+		case "detail-type":
+			return event.DetailType
+		case "account":
+			return event.AccountID
+		case "region":
+			return event.Region
+		}
+		if field := strings.TrimPrefix(name, "detail."); field != name {
+			return detailValue(detail[field])
+		}
+		return ""
+	})
+
+	req, err := newRequest(ctx, route.Method, "https://events."+event.Region+".amazonaws.com", path, string(event.Detail))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventBridgeIDHeader, event.ID)
+	req.Header.Set(EventBridgeSourceHeader, event.Source)
+	req.Header.Set(EventBridgeDetailTypeHeader, event.DetailType)
+	return req.WithContext(context.WithValue(req.Context(), eventBridgeKey{}, event)), nil
+}
+
+// sqsRequest builds the request for an SQS message. The body is the body
+// of the message.
+func (b *Bridge) sqsRequest(ctx context.Context, message events.SQSMessage) (*http.Request, error) {
+	route := b.sqsRoute()
+	path := expandPath(route.Path, func(name string) string {
+		switch name {
+		case "queue":
+			return arnResource(message.EventSourceARN)
+		case "messageId":
+			return message.MessageId
+		}
+		if attribute := strings.TrimPrefix(name, "attributes."); attribute != name {
+			if value, ok := message.MessageAttributes[attribute]; ok && value.StringValue != nil {
+				return *value.StringValue
+			}
+		}
+		return ""
+	})
+
+	req, err := newRequest(ctx, route.Method, "https://sqs."+message.AWSRegion+".amazonaws.com", path, message.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(SQSMessageIDHeader, message.MessageId)
+	req.Header.Set(SQSQueueArnHeader, message.EventSourceARN)
+	for name, value := range message.MessageAttributes {
+		if value.StringValue != nil {
+			req.Header.Set(SQSMessageAttributeHeaderPrefix+name, *value.StringValue)
+		}
+	}
+	return req.WithContext(context.WithValue(req.Context(), sqsKey{}, message)), nil
+}
+
+// snsRequest builds the request for an SNS record with a
+// core.RequestAccessorSNS, so the SNS headers are set and
+// core.GetSNSRecordFromContext works, and moves it to the route.
+func (b *Bridge) snsRequest(ctx context.Context, record events.SNSEventRecord) (*http.Request, error) {
+	accessor := core.RequestAccessorSNS{}
+	req, err := accessor.EventToRequestWithContext(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	route := b.snsRoute()
+	path := expandPath(route.Path, func(name string) string {
+		switch name {
+		case "topic":
+			return arnResource(record.SNS.TopicArn)
+		case "messageId":
+			return record.SNS.MessageID
+		case "subject":
+			return record.SNS.Subject
+		}
+		if attribute := strings.TrimPrefix(name, "attributes."); attribute != name {
+			return req.Header.Get(core.SNSMessageAttributeHeaderPrefix + attribute)
+		}
+		return ""
+	})
+	routed, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	req.Method = methodOrDefault(route.Method)
+	req.URL.Path, req.URL.RawPath, req.URL.RawQuery = routed.Path, routed.RawPath, routed.RawQuery
+	req.RequestURI = req.URL.RequestURI()
+	return req, nil
+}
+
+func newRequest(ctx context.Context, method string, serverAddress string, path string, body string) (*http.Request, error) {
+	if customAddress, ok := os.LookupEnv(core.CustomHostVariable); ok {
+		serverAddress = customAddress
+	}
+	req, err := http.NewRequestWithContext(ctx, methodOrDefault(method), serverAddress+path, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.RequestURI = req.URL.RequestURI()
+	return req, nil
+}
+
+func methodOrDefault(method string) string {
+	if method == "" {
+		return http.MethodPost
+	}
+	return strings.ToUpper(method)
+}
+
+var placeholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// expandPath fills in the placeholders of the path template with the
+// values returned by lookup, escaped as path segments.
+func expandPath(template string, lookup func(name string) string) string {
+	if !strings.HasPrefix(template, "/") {
+		template = "/" + template
+	}
+	return placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		return url.PathEscape(lookup(match[1 : len(match)-1]))
+	})
+}
+
+// detailValue returns a field of the detail of an EventBridge event, JSON
+// encoded when it is not a string.
+func detailValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+// arnResource returns the resource name at the end of an ARN, such as the
+// queue name of arn:aws:sqs:us-east-1:123456789012:orders.
+func arnResource(arn string) string {
+	return arn[strings.LastIndex(arn, ":")+1:]
+}
@@ -14,9 +14,22 @@ import (
 
 // ChiLambda makes it easy to send API Gateway proxy events to a Chi
 // Mux. The library transforms the proxy event into an HTTP request and then
-// creates a proxy response object from the http.ResponseWriter
+// creates a proxy response object from the http.ResponseWriter.
+// The embedded RequestAccessor and APIGatewayProxyHandler configure REST API
+// (v1) events, the exported fields configure HTTP API (v2), Application Load
+// Balancer and Function URL events.
 type ChiLambda struct {
 	core.RequestAccessor
+	*core.APIGatewayProxyHandler
+
+	RequestAccessorV2        core.RequestAccessorV2
+	APIGatewayV2ProxyHandler *core.APIGatewayV2ProxyHandler
+
+	RequestAccessorALB core.RequestAccessorALB
+	ALBProxyHandler    *core.ALBProxyHandler
+
+	RequestAccessorFnURL    core.RequestAccessorFnURL
+	FunctionURLProxyHandler *core.FunctionURLProxyHandler
 
 	chiMux *chi.Mux
 }
@@ -25,38 +38,101 @@ type ChiLambda struct {
 // Receives an initialized *chi.Mux object - normally created with chi.NewRouter().
 // It returns the initialized instance of the ChiLambda object.
 func New(chi *chi.Mux) *ChiLambda {
-	return &ChiLambda{chiMux: chi}
+	g := &ChiLambda{chiMux: chi}
+	g.APIGatewayProxyHandler = core.NewAPIGatewayProxyHandler(&g.RequestAccessor, http.HandlerFunc(g.serveHTTP))
+	g.APIGatewayV2ProxyHandler = core.NewAPIGatewayV2ProxyHandler(&g.RequestAccessorV2, http.HandlerFunc(g.serveHTTP))
+	g.ALBProxyHandler = core.NewALBProxyHandler(&g.RequestAccessorALB, http.HandlerFunc(g.serveHTTP))
+	g.FunctionURLProxyHandler = core.NewFunctionURLProxyHandler(&g.RequestAccessorFnURL, http.HandlerFunc(g.serveHTTP))
+	return g
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
 // object, and sends it to the chi.Mux for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (g *ChiLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	chiRequest, err := g.ProxyEventToHTTPRequest(req)
-	return g.proxyInternal(chiRequest, err)
+	return g.APIGatewayProxyHandler.Proxy(req)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
 // transforms them into an http.Request object, and sends it to the chi.Mux for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (g *ChiLambda) ProxyWithContext(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	chiRequest, err := g.EventToRequestWithContext(ctx, req)
-	return g.proxyInternal(chiRequest, err)
+	return g.APIGatewayProxyHandler.ProxyWithContext(ctx, req)
 }
 
-func (g *ChiLambda) proxyInternal(chiRequest *http.Request, err error) (events.APIGatewayProxyResponse, error) {
+// ProxyV2 receives an API Gateway HTTP API (v2) event, transforms it into an
+// http.Request object, and sends it to the chi.Mux for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (g *ChiLambda) ProxyV2(req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return g.APIGatewayV2ProxyHandler.Proxy(req)
+}
 
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
-	}
+// ProxyWithContextV2 receives context and an API Gateway HTTP API (v2) event,
+// transforms them into an http.Request object, and sends it to the chi.Mux for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (g *ChiLambda) ProxyWithContextV2(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return g.APIGatewayV2ProxyHandler.ProxyWithContext(ctx, req)
+}
+
+// ProxyALB receives an ALB target group event, transforms it into an
+// http.Request object, and sends it to the chi.Mux for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (g *ChiLambda) ProxyALB(req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return g.ALBProxyHandler.Proxy(req)
+}
 
-	respWriter := core.NewProxyResponseWriter()
-	g.chiMux.ServeHTTP(http.ResponseWriter(respWriter), chiRequest)
+// ProxyWithContextALB receives context and an ALB target group event,
+// transforms them into an http.Request object, and sends it to the chi.Mux for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (g *ChiLambda) ProxyWithContextALB(ctx context.Context, req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return g.ALBProxyHandler.ProxyWithContext(ctx, req)
+}
+
+// ProxyFunctionURL receives a Function URL event, transforms it into an
+// http.Request object, and sends it to the chi.Mux for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (g *ChiLambda) ProxyFunctionURL(req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return g.FunctionURLProxyHandler.Proxy(req)
+}
 
-	proxyResponse, err := respWriter.GetProxyResponse()
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
+// ProxyWithContextFunctionURL receives context and a Function URL event,
+// transforms them into an http.Request object, and sends it to the chi.Mux for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (g *ChiLambda) ProxyWithContextFunctionURL(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return g.FunctionURLProxyHandler.ProxyWithContext(ctx, req)
+}
+
+// serveHTTP adds the path parameters resolved by API Gateway to the chi URL
+// parameters of the request, so chi.URLParam returns them even for routes
+// chi did not match itself, such as the {proxy+} resource. Parameters chi
+// matches take precedence. They are only available for events sent through
+// ProxyWithContext. The route pattern chi matched is returned by
+// core.GetMatchedRoute.
+func (g *ChiLambda) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	r = r.WithContext(core.WithMatchedRoute(r.Context(), routePattern))
+	if params := core.GetPathParameters(r.Context()); len(params) > 0 {
+		rctx := chi.NewRouteContext()
+		rctx.Routes = g.chiMux
+		for key, value := range params {
+			rctx.URLParams.Add(key, value)
+		}
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
 	}
+	g.chiMux.ServeHTTP(w, r)
+}
+
+// routePattern returns the pattern of the route chi matched, read from the
+// chi route context of the handler.
+func routePattern(ctx context.Context) (string, bool) {
+	rctx := chi.RouteContext(ctx)
+	if rctx == nil || rctx.RoutePattern() == "" {
+		return "", false
+	}
+	return rctx.RoutePattern(), true
+}
 
-	return proxyResponse, nil
+// GetStageVariables returns the API Gateway stage variables of a request
+// routed by the chi.Mux, or nil if the event did not carry any.
+func GetStageVariables(r *http.Request) map[string]string {
+	return core.GetStageVariables(r.Context())
 }
@@ -2,11 +2,14 @@ package chiadapter_test
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
 	chiadapter "github.com/awslabs/aws-lambda-go-api-proxy/chi"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/awslabs/aws-lambda-go-api-proxy/proxytest"
 	"github.com/go-chi/chi"
 
 	. "github.com/onsi/ginkgo"
@@ -40,4 +43,201 @@ var _ = Describe("ChiLambda tests", func() {
 			Expect(resp.StatusCode).To(Equal(200))
 		})
 	})
+
+	Context("Stage variables", func() {
+		It("Exposes the stage variables to the handler", func() {
+			r := chi.NewRouter()
+			r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(chiadapter.GetStageVariables(r)["feature"]))
+			})
+
+			adapter := chiadapter.New(r)
+
+			req := events.APIGatewayProxyRequest{
+				Path:           "/ping",
+				HTTPMethod:     "GET",
+				StageVariables: map[string]string{"feature": "enabled"},
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("enabled"))
+		})
+	})
+
+	Context("API Gateway path parameters", func() {
+		It("Exposes the path parameters through chi.URLParam", func() {
+			r := chi.NewRouter()
+			r.Get("/*", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(chi.URLParam(r, "tenant") + " " + chi.URLParam(r, "proxy")))
+			})
+
+			adapter := chiadapter.New(r)
+
+			req := events.APIGatewayProxyRequest{
+				Path:           "/acme/users/42",
+				HTTPMethod:     "GET",
+				PathParameters: map[string]string{"tenant": "acme", "proxy": "users/42"},
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("acme users/42"))
+		})
+
+		It("Prefers the parameters matched by chi", func() {
+			r := chi.NewRouter()
+			r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(chi.URLParam(r, "id")))
+			})
+
+			adapter := chiadapter.New(r)
+
+			req := events.APIGatewayProxyRequest{
+				Path:           "/items/42",
+				HTTPMethod:     "GET",
+				PathParameters: map[string]string{"id": "other"},
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("42"))
+		})
+	})
+
+	Context("Matched route", func() {
+		It("Exposes the route pattern to the handler", func() {
+			r := chi.NewRouter()
+			r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+				route, _ := core.GetMatchedRoute(r.Context())
+				w.Write([]byte(route))
+			})
+
+			adapter := chiadapter.New(r)
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{Path: "/users/42", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("/users/{id}"))
+		})
+	})
+
+	Context("HTTP API v2 request", func() {
+		It("Proxies the event correctly", func() {
+			r := chi.NewRouter()
+			r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+				session, _ := r.Cookie("session")
+				fmt.Fprintf(w, "%s %s %s", r.URL.Query().Get("name"), session.Value, r.Header.Get("X-Tenant"))
+			})
+
+			adapter := chiadapter.New(r)
+
+			req := events.APIGatewayV2HTTPRequest{
+				RawPath:        "/ping",
+				RawQueryString: "name=go",
+				Cookies:        []string{"session=abc"},
+				Headers:        map[string]string{"x-tenant": "acme"},
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET", Path: "/ping"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContextV2(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.ProxyV2(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Application Load Balancer request", func() {
+		It("Proxies the event correctly", func() {
+			r := chi.NewRouter()
+			r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+				session, _ := r.Cookie("session")
+				fmt.Fprintf(w, "%s %s %s", r.URL.Query().Get("name"), session.Value, r.Header.Get("X-Tenant"))
+			})
+
+			adapter := chiadapter.New(r)
+
+			req := events.ALBTargetGroupRequest{
+				HTTPMethod:                      "GET",
+				Path:                            "/ping",
+				MultiValueQueryStringParameters: map[string][]string{"name": {"go"}},
+				MultiValueHeaders: map[string][]string{
+					"cookie":   {"session=abc"},
+					"x-tenant": {"acme"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContextALB(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.StatusDescription).To(Equal("200 OK"))
+			Expect(resp.MultiValueHeaders).To(HaveKey("Content-Type"))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.ProxyALB(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Function URL request", func() {
+		It("Proxies the event correctly", func() {
+			r := chi.NewRouter()
+			r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+				session, _ := r.Cookie("session")
+				fmt.Fprintf(w, "%s %s %s", r.URL.Query().Get("name"), session.Value, r.Header.Get("X-Tenant"))
+			})
+
+			adapter := chiadapter.New(r)
+
+			req := events.LambdaFunctionURLRequest{
+				RawPath:        "/ping",
+				RawQueryString: "name=go",
+				Cookies:        []string{"session=abc"},
+				Headers:        map[string]string{"x-tenant": "acme"},
+				RequestContext: events.LambdaFunctionURLRequestContext{
+					HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET", Path: "/ping"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContextFunctionURL(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.ProxyFunctionURL(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Concurrent requests", func() {
+		It("Keeps the state of simultaneous events apart", func() {
+			r := chi.NewRouter()
+			r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(chi.URLParam(r, "id")))
+			})
+
+			adapter := chiadapter.New(r)
+
+			Expect(proxytest.ProxyConcurrently(100, adapter.ProxyWithContext, adapter.ProxyWithContextV2)).To(BeEmpty())
+		})
+	})
 })
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// accessLogEntry is the structured line written by AccessLogMiddleware.
+type accessLogEntry struct {
+	RequestID  string                 `json:"requestId"`
+	Method     string                 `json:"method"`
+	Path       string                 `json:"path"`
+	Status     int                    `json:"status"`
+	Size       int                    `json:"size"`
+	DurationMs float64                `json:"durationMs"`
+	Meta       map[string]interface{} `json:"meta,omitempty"`
+}
+
+// AccessLogMiddleware returns a net/http middleware that writes one JSON line
+// per request to the given logger with the method, path, status, response size,
+// duration and request ID. The request ID is read from the API Gateway context
+// stored by EventToRequestWithContext. Status and size are recorded from what
+// the handler writes, for every event type and behind buffering middlewares,
+// so the size is the body before the encoding of a Compressor the middleware
+// is wrapped in. Metadata the handler attached with SetResponseMeta is logged
+// in the meta field.
+func AccessLogMiddleware(logger Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := newResponseRecorder(w)
+			next.ServeHTTP(rec, r)
+
+			status, size := rec.stats()
+			requestID, _ := getRequestID(r.Context())
+			line, err := json.Marshal(accessLogEntry{
+				RequestID:  requestID,
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Status:     status,
+				Size:       size,
+				DurationMs: float64(time.Since(start)) / float64(time.Millisecond),
+				Meta:       GetResponseMeta(r.Context()),
+			})
+			if err != nil {
+				logger.Printf("Could not marshal access log entry: %v", err)
+				return
+			}
+			logger.Printf("%s", line)
+		})
+	}
+}
+
+// getRequestID looks up the request ID in the API Gateway context stored in
+// ctx, then the ID generated by the accessor, falling back to the Lambda
+// runtime context.
+func getRequestID(ctx context.Context) (string, bool) {
+	switch rc := ctx.Value(ctxKey{}).(type) {
+	case requestContext:
+		if rc.gatewayProxyContext.RequestID != "" {
+			return rc.gatewayProxyContext.RequestID, true
+		}
+		if rc.requestID != "" {
+			return rc.requestID, true
+		}
+		if rc.lambdaContext != nil && rc.lambdaContext.AwsRequestID != "" {
+			return rc.lambdaContext.AwsRequestID, true
+		}
+	case requestContextV2:
+		if rc.gatewayProxyContext.RequestID != "" {
+			return rc.gatewayProxyContext.RequestID, true
+		}
+		if rc.lambdaContext != nil && rc.lambdaContext.AwsRequestID != "" {
+			return rc.lambdaContext.AwsRequestID, true
+		}
+	case requestContextFnURL:
+		if rc.fnURLContext.RequestID != "" {
+			return rc.fnURLContext.RequestID, true
+		}
+		if rc.lambdaContext != nil && rc.lambdaContext.AwsRequestID != "" {
+			return rc.lambdaContext.AwsRequestID, true
+		}
+	case requestContextALB:
+		if rc.requestID != "" {
+			return rc.requestID, true
+		}
+		if rc.lambdaContext != nil && rc.lambdaContext.AwsRequestID != "" {
+			return rc.lambdaContext.AwsRequestID, true
+		}
+	case requestContextKinesis:
+		if rc.lambdaContext != nil && rc.lambdaContext.AwsRequestID != "" {
+			return rc.lambdaContext.AwsRequestID, true
+		}
+	case requestContextSNS:
+		if rc.lambdaContext != nil && rc.lambdaContext.AwsRequestID != "" {
+			return rc.lambdaContext.AwsRequestID, true
+		}
+	case requestContextEdge:
+		if rc.cf.Config.RequestID != "" {
+			return rc.cf.Config.RequestID, true
+		}
+		if rc.lambdaContext != nil && rc.lambdaContext.AwsRequestID != "" {
+			return rc.lambdaContext.AwsRequestID, true
+		}
+	case requestContextWebSocket:
+		if rc.wsContext.RequestID != "" {
+			return rc.wsContext.RequestID, true
+		}
+		if rc.lambdaContext != nil && rc.lambdaContext.AwsRequestID != "" {
+			return rc.lambdaContext.AwsRequestID, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,99 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AccessLogMiddleware tests", func() {
+	It("Logs one structured line per request", func() {
+		var buf bytes.Buffer
+		logger := log.New(&buf, "", 0)
+
+		handler := core.AccessLogMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hello"))
+		}))
+		proxy := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, handler)
+
+		req := getProxyRequest("/hello", "GET")
+		req.RequestContext = getRequestContext()
+		resp, err := proxy.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect(http.StatusOK).To(Equal(resp.StatusCode))
+
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		Expect(1).To(Equal(len(lines)))
+
+		entry := map[string]interface{}{}
+		Expect(json.Unmarshal(lines[0], &entry)).To(BeNil())
+		Expect("x").To(Equal(entry["requestId"]))
+		Expect("GET").To(Equal(entry["method"]))
+		Expect("/hello").To(Equal(entry["path"]))
+		Expect(float64(200)).To(Equal(entry["status"]))
+		Expect(float64(5)).To(Equal(entry["size"]))
+		Expect(entry).To(HaveKey("durationMs"))
+	})
+
+	Context("Other writers", func() {
+		logLine := func(buf *bytes.Buffer) map[string]interface{} {
+			entry := map[string]interface{}{}
+			Expect(json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry)).To(BeNil())
+			return entry
+		}
+		created := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("created"))
+		})
+
+		It("Records the status and size of ALB responses", func() {
+			var buf bytes.Buffer
+			proxy := core.NewALBProxyHandler(&core.RequestAccessorALB{}, core.AccessLogMiddleware(log.New(&buf, "", 0))(created))
+			_, err := proxy.ProxyWithContext(context.Background(), getALBRequest("/items", "POST"))
+			Expect(err).To(BeNil())
+
+			entry := logLine(&buf)
+			Expect(float64(http.StatusCreated)).To(Equal(entry["status"]))
+			Expect(float64(7)).To(Equal(entry["size"]))
+		})
+
+		It("Records the status and size of Function URL responses", func() {
+			var buf bytes.Buffer
+			proxy := core.NewFunctionURLProxyHandler(&core.RequestAccessorFnURL{}, core.AccessLogMiddleware(log.New(&buf, "", 0))(created))
+			_, err := proxy.ProxyWithContext(context.Background(), getFunctionURLRequest("/items", "POST"))
+			Expect(err).To(BeNil())
+
+			entry := logLine(&buf)
+			Expect(float64(http.StatusCreated)).To(Equal(entry["status"]))
+			Expect(float64(7)).To(Equal(entry["size"]))
+		})
+
+		It("Records the status and size behind a Compressor", func() {
+			var buf bytes.Buffer
+			body := bytes.Repeat([]byte("a"), 4096)
+			handler := core.NewCompressor().Handler(core.AccessLogMiddleware(log.New(&buf, "", 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusAccepted)
+				w.Write(body)
+			})))
+			proxy := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, handler)
+
+			req := getProxyRequest("/items", "GET")
+			req.Headers = map[string]string{"Accept-Encoding": "gzip"}
+			resp, err := proxy.ProxyWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect([]string{"gzip"}).To(Equal(resp.MultiValueHeaders["Content-Encoding"]))
+
+			entry := logLine(&buf)
+			Expect(float64(http.StatusAccepted)).To(Equal(entry["status"]))
+			Expect(float64(len(body))).To(Equal(entry["size"]))
+		})
+	})
+})
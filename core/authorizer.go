@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNoAuthorizerContext is returned by GetCustomAuthorizerFields when the
+// request was not authorized by a Lambda authorizer, or the context does not
+// hold an API Gateway request.
+var ErrNoAuthorizerContext = errors.New("Request has no authorizer context")
+
+// GetJWTClaims returns the claims of the token validated by the authorizer
+// of the route: the JWT authorizer of HTTP API requests, or the Cognito user
+// pool authorizer of REST API requests, whose claims API Gateway passes in
+// the "claims" field of the authorizer map. Claims that are not strings are
+// returned in their JSON encoding, such as 1704164645 for a timestamp.
+// Returns false if the request was not
+// authorized by either.
+func GetJWTClaims(ctx context.Context) (map[string]string, bool) {
+	switch v := ctx.Value(ctxKey{}).(type) {
+	case requestContextV2:
+		if v.gatewayProxyContext.Authorizer == nil || v.gatewayProxyContext.Authorizer.JWT == nil {
+			return nil, false
+		}
+		return v.gatewayProxyContext.Authorizer.JWT.Claims, true
+	case requestContext:
+		raw, ok := v.gatewayProxyContext.Authorizer["claims"].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		claims := make(map[string]string, len(raw))
+		for key, value := range raw {
+			if s, ok := value.(string); ok {
+				claims[key] = s
+			} else if encoded, err := json.Marshal(value); err == nil {
+				claims[key] = string(encoded)
+			}
+		}
+		return claims, true
+	}
+	return nil, false
+}
+
+// GetCustomAuthorizerFields decodes the context returned by the Lambda
+// authorizer of the route into target, a pointer to a struct or map, through
+// its JSON encoding so the fields can be mapped with json tags. REST API
+// events carry the context fields next to the principalId at the top of the
+// authorizer map, HTTP API events in its lambda field. REST API authorizers
+// can only return strings, numbers and booleans, which API Gateway passes
+// to the function as strings, so use the ",string" tag option for numeric
+// fields. Returns ErrNoAuthorizerContext when the request has no authorizer
+// context and an error naming the field when it does not match target.
+func GetCustomAuthorizerFields(ctx context.Context, target interface{}) error {
+	var fields map[string]interface{}
+	switch v := ctx.Value(ctxKey{}).(type) {
+	case requestContextV2:
+		if v.gatewayProxyContext.Authorizer != nil {
+			fields = v.gatewayProxyContext.Authorizer.Lambda
+		}
+	case requestContext:
+		fields = v.gatewayProxyContext.Authorizer
+	}
+	if len(fields) == 0 {
+		return ErrNoAuthorizerContext
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("Could not encode authorizer context: %v", err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return fmt.Errorf("Could not decode authorizer field %s: %s is not a %v", typeErr.Field, typeErr.Value, typeErr.Type)
+		}
+		return fmt.Errorf("Could not decode authorizer context: %v", err)
+	}
+	return nil
+}
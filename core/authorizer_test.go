@@ -0,0 +1,154 @@
+package core_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Authorizer accessor tests", func() {
+	v1Context := func(authorizer map[string]interface{}) context.Context {
+		event := getProxyRequest("/orders", "GET")
+		event.RequestContext = getRequestContext()
+		event.RequestContext.Authorizer = authorizer
+		accessor := core.RequestAccessor{}
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), event)
+		Expect(err).To(BeNil())
+		return httpReq.Context()
+	}
+	v2Context := func(authorizer *events.APIGatewayV2HTTPRequestContextAuthorizerDescription) context.Context {
+		event := getProxyRequestV2("/orders", "GET")
+		event.RequestContext = getRequestContextV2()
+		event.RequestContext.Authorizer = authorizer
+		accessor := core.RequestAccessorV2{}
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), event)
+		Expect(err).To(BeNil())
+		return httpReq.Context()
+	}
+
+	Context("JWT claims", func() {
+		It("Returns the claims of a JWT authorizer", func() {
+			claims, ok := core.GetJWTClaims(v2Context(&events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+				JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+					Claims: map[string]string{"sub": "user-1", "email": "user@example.com"},
+				},
+			}))
+			Expect(ok).To(BeTrue())
+			Expect("user-1").To(Equal(claims["sub"]))
+			Expect("user@example.com").To(Equal(claims["email"]))
+		})
+
+		It("Returns the claims of a Cognito user pool authorizer", func() {
+			claims, ok := core.GetJWTClaims(v1Context(map[string]interface{}{
+				"claims": map[string]interface{}{"sub": "user-1", "auth_time": float64(1704164645)},
+			}))
+			Expect(ok).To(BeTrue())
+			Expect("user-1").To(Equal(claims["sub"]))
+			Expect("1704164645").To(Equal(claims["auth_time"]))
+		})
+
+		It("Reports requests without claims", func() {
+			_, ok := core.GetJWTClaims(v1Context(map[string]interface{}{"principalId": "user-1"}))
+			Expect(ok).To(BeFalse())
+			_, ok = core.GetJWTClaims(v2Context(nil))
+			Expect(ok).To(BeFalse())
+			_, ok = core.GetJWTClaims(context.Background())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("IAM identity", func() {
+		It("Returns the identity of signed REST API requests", func() {
+			event := getProxyRequest("/orders", "GET")
+			event.RequestContext = getRequestContext()
+			event.RequestContext.Identity.UserArn = "arn:aws:iam::123456789012:user/caller"
+			event.RequestContext.Identity.AccessKey = "AKIAEXAMPLE"
+			event.RequestContext.Identity.Caller = "AIDAEXAMPLE"
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), event)
+			Expect(err).To(BeNil())
+
+			identity, ok := core.GetIAMIdentity(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("arn:aws:iam::123456789012:user/caller").To(Equal(identity.UserARN))
+			Expect("AKIAEXAMPLE").To(Equal(identity.AccessKey))
+			Expect("AIDAEXAMPLE").To(Equal(identity.CallerID))
+
+			_, ok = core.GetIAMIdentity(v1Context(nil))
+			Expect(ok).To(BeFalse())
+		})
+
+		It("Returns the identity of signed Function URL requests", func() {
+			event := getFunctionURLRequest("/orders", "GET")
+			event.RequestContext.Authorizer = &events.LambdaFunctionURLRequestContextAuthorizerDescription{
+				IAM: &events.LambdaFunctionURLRequestContextAuthorizerIAMDescription{
+					AccountID: "123456789012",
+					UserARN:   "arn:aws:iam::123456789012:role/caller",
+				},
+			}
+			accessor := core.RequestAccessorFnURL{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), event)
+			Expect(err).To(BeNil())
+
+			identity, ok := core.GetIAMIdentity(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("123456789012").To(Equal(identity.AccountID))
+			Expect("arn:aws:iam::123456789012:role/caller").To(Equal(identity.UserARN))
+		})
+	})
+
+	Context("Lambda authorizer context", func() {
+		type tenant struct {
+			PrincipalID string `json:"principalId"`
+			TenantID    string `json:"tenantId"`
+			Seats       int    `json:"seats,string"`
+		}
+
+		It("Decodes the context of a REST API authorizer", func() {
+			var fields tenant
+			err := core.GetCustomAuthorizerFields(v1Context(map[string]interface{}{
+				"principalId": "user-1",
+				"tenantId":    "acme",
+				"seats":       "25",
+			}), &fields)
+			Expect(err).To(BeNil())
+			Expect(tenant{PrincipalID: "user-1", TenantID: "acme", Seats: 25}).To(Equal(fields))
+		})
+
+		It("Decodes the context of an HTTP API authorizer", func() {
+			var fields struct {
+				TenantID string   `json:"tenantId"`
+				Roles    []string `json:"roles"`
+			}
+			err := core.GetCustomAuthorizerFields(v2Context(&events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+				Lambda: map[string]interface{}{"tenantId": "acme", "roles": []interface{}{"admin"}},
+			}), &fields)
+			Expect(err).To(BeNil())
+			Expect("acme").To(Equal(fields.TenantID))
+			Expect([]string{"admin"}).To(Equal(fields.Roles))
+		})
+
+		It("Names the field that does not match", func() {
+			var fields struct {
+				Admin bool `json:"admin"`
+			}
+			err := core.GetCustomAuthorizerFields(v1Context(map[string]interface{}{"admin": "yes"}), &fields)
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("authorizer field admin"))
+		})
+
+		It("Reports requests without an authorizer context", func() {
+			var fields tenant
+			Expect(core.ErrNoAuthorizerContext).To(Equal(core.GetCustomAuthorizerFields(v1Context(nil), &fields)))
+			Expect(core.ErrNoAuthorizerContext).To(Equal(core.GetCustomAuthorizerFields(v2Context(nil), &fields)))
+
+			req, _ := http.NewRequest("GET", "/orders", nil)
+			Expect(core.ErrNoAuthorizerContext).To(Equal(core.GetCustomAuthorizerFields(req.Context(), &fields)))
+		})
+	})
+})
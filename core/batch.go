@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"log"
+	"net/http"
+)
+
+// BatchRunner sends the records of a batch event, such as an SQS, SNS or
+// Kinesis event, to an http.Handler one at a time. The function given to
+// NewBatchRunner maps each record to the request sent to the handler, so
+// the same runner serves any event source.
+type BatchRunner[RecordT any] struct {
+	newRequest func(ctx context.Context, record RecordT) (*http.Request, error)
+}
+
+// NewBatchRunner returns a BatchRunner that builds the request for each
+// record with newRequest.
+func NewBatchRunner[RecordT any](newRequest func(ctx context.Context, record RecordT) (*http.Request, error)) *BatchRunner[RecordT] {
+	return &BatchRunner[RecordT]{newRequest: newRequest}
+}
+
+// BatchItemResult is the outcome of sending a single record to the handler.
+// Err is set if the record could not be converted to a request or the
+// handler did not produce a valid response.
+type BatchItemResult[RecordT any] struct {
+	Record     RecordT
+	StatusCode int
+	Err        error
+}
+
+// Succeeded reports whether the record was processed without error and the
+// handler responded with a status in the 2xx range.
+func (r BatchItemResult[RecordT]) Succeeded() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// BatchResults holds the results of a batch in the order of its records.
+type BatchResults[RecordT any] []BatchItemResult[RecordT]
+
+// Failed returns the records that were not processed successfully, to be
+// reported as batch item failures in the event source's response type.
+func (results BatchResults[RecordT]) Failed() []RecordT {
+	failed := []RecordT{}
+	for _, result := range results {
+		if !result.Succeeded() {
+			failed = append(failed, result.Record)
+		}
+	}
+	return failed
+}
+
+// Run sends every record to the handler in order and returns the result for
+// each of them.
+func (b *BatchRunner[RecordT]) Run(ctx context.Context, records []RecordT, handler http.Handler) BatchResults[RecordT] {
+	results := make(BatchResults[RecordT], 0, len(records))
+	for i, record := range records {
+		result := BatchItemResult[RecordT]{Record: record}
+		result.StatusCode, result.Err = b.runRecord(ctx, record, handler)
+		if result.Err != nil {
+			log.Printf("Error while processing batch record %d: %v\n", i, result.Err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (b *BatchRunner[RecordT]) runRecord(ctx context.Context, record RecordT, handler http.Handler) (int, error) {
+	req, err := b.newRequest(ctx, record)
+	if err != nil {
+		return 0, err
+	}
+
+	w := NewProxyResponseWriter()
+	handler.ServeHTTP(w, req)
+	proxyResponse, err := w.GetProxyResponse()
+	if err != nil {
+		return 0, err
+	}
+	return proxyResponse.StatusCode, nil
+}
@@ -0,0 +1,73 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type stubRecord struct {
+	ID   string
+	Path string
+}
+
+var _ = Describe("BatchRunner tests", func() {
+	newRequest := func(ctx context.Context, record stubRecord) (*http.Request, error) {
+		if record.Path == "" {
+			return nil, errors.New("Record has no path")
+		}
+		return http.NewRequestWithContext(ctx, http.MethodPost, "https://example.com"+record.Path, strings.NewReader(record.ID))
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusNoContent)
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+
+	It("Collects the result of each record", func() {
+		records := []stubRecord{
+			{ID: "1", Path: "/ok"},
+			{ID: "2", Path: "/fail"},
+			{ID: "3"},
+			{ID: "4", Path: "/ok"},
+			{ID: "5", Path: "/silent"},
+		}
+
+		results := core.NewBatchRunner(newRequest).Run(context.Background(), records, handler)
+		Expect(5).To(Equal(len(results)))
+
+		Expect(results[0].Succeeded()).To(BeTrue())
+		Expect(http.StatusNoContent).To(Equal(results[0].StatusCode))
+
+		Expect(results[1].Succeeded()).To(BeFalse())
+		Expect(results[1].Err).To(BeNil())
+		Expect(http.StatusInternalServerError).To(Equal(results[1].StatusCode))
+
+		Expect(results[2].Succeeded()).To(BeFalse())
+		Expect(results[2].Err).ToNot(BeNil())
+
+		Expect(results[3].Succeeded()).To(BeTrue())
+
+		// the handler never set a status, GetProxyResponse fails
+		Expect(results[4].Succeeded()).To(BeFalse())
+		Expect(results[4].Err).ToNot(BeNil())
+
+		Expect([]stubRecord{records[1], records[2], records[4]}).To(Equal(results.Failed()))
+	})
+
+	It("Returns an empty list of failures when all records succeed", func() {
+		results := core.NewBatchRunner(newRequest).Run(context.Background(), []stubRecord{{ID: "1", Path: "/ok"}}, handler)
+		Expect(results.Failed()).ToNot(BeNil())
+		Expect(results.Failed()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// ErrInvalidBindTarget is returned by BindQuery when the destination is not
+// a non-nil pointer to a struct.
+var ErrInvalidBindTarget = errors.New("Bind target must be a non-nil pointer to a struct")
+
+// BindQuery decodes the query parameters of the request into the fields of
+// the struct dst points to. Fields are matched by their query tag, for
+// example `query:"page"`, fields without the tag are left untouched. Strings,
+// booleans, integers and floats are supported, as well as slices of them,
+// which receive every value of a repeated parameter. Scalar fields receive
+// the first value. Returns an error naming the parameter when a value can't
+// be parsed into its field.
+func BindQuery(r *http.Request, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrInvalidBindTarget
+	}
+	v = v.Elem()
+	query := r.URL.Query()
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := field.Tag.Get("query")
+		if name == "" || name == "-" || field.PkgPath != "" {
+			continue
+		}
+		values, ok := query[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Slice {
+			slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+			for j, value := range values {
+				if err := setQueryValue(slice.Index(j), value); err != nil {
+					return fmt.Errorf("Invalid value %q for query parameter %s: %v", value, name, err)
+				}
+			}
+			fv.Set(slice)
+			continue
+		}
+		if err := setQueryValue(fv, values[0]); err != nil {
+			return fmt.Errorf("Invalid value %q for query parameter %s: %v", values[0], name, err)
+		}
+	}
+	return nil
+}
+
+func setQueryValue(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
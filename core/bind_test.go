@@ -0,0 +1,57 @@
+package core_test
+
+import (
+	"context"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BindQuery tests", func() {
+	type orderQuery struct {
+		Status   string   `query:"status"`
+		Page     int      `query:"page"`
+		Archived bool     `query:"archived"`
+		Tags     []string `query:"tag"`
+		Limit    int
+	}
+
+	It("Binds scalars and repeated parameters", func() {
+		req := getProxyRequest("/orders", "GET")
+		req.MultiValueQueryStringParameters = map[string][]string{
+			"status":   {"open"},
+			"page":     {"3"},
+			"archived": {"true"},
+			"tag":      {"urgent", "gift"},
+			"Limit":    {"10"},
+		}
+		accessor := core.RequestAccessor{}
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+
+		var query orderQuery
+		Expect(core.BindQuery(httpReq, &query)).To(BeNil())
+		Expect("open").To(Equal(query.Status))
+		Expect(3).To(Equal(query.Page))
+		Expect(true).To(Equal(query.Archived))
+		Expect([]string{"urgent", "gift"}).To(Equal(query.Tags))
+		Expect(0).To(Equal(query.Limit))
+	})
+
+	It("Returns an error for invalid values and targets", func() {
+		req := getProxyRequest("/orders", "GET")
+		req.QueryStringParameters = map[string]string{"page": "two"}
+		accessor := core.RequestAccessor{}
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+
+		var query orderQuery
+		err = core.BindQuery(httpReq, &query)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("page"))
+
+		Expect(core.ErrInvalidBindTarget).To(Equal(core.BindQuery(httpReq, query)))
+	})
+})
@@ -0,0 +1,25 @@
+package core
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// GetRequestCharset returns the charset parameter of the Content-Type of the
+// request in lowercase, for example "iso-8859-1" for
+// text/html; charset=ISO-8859-1. The accessors pass bodies through as they
+// were received, handlers use the charset to decode text that is not UTF-8.
+// Returns an empty string when the request declares no charset or the
+// Content-Type can't be parsed.
+func GetRequestCharset(r *http.Request) string {
+	contentType := r.Header.Get(contentTypeHeaderKey)
+	if contentType == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(params["charset"])
+}
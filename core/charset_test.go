@@ -0,0 +1,38 @@
+package core_test
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetRequestCharset tests", func() {
+	It("Returns the declared charset and leaves the body untouched", func() {
+		req := getProxyRequest("/pages", "POST")
+		req.Headers = map[string]string{"Content-Type": "text/html; charset=ISO-8859-1"}
+		req.Body = "caf\xe9"
+		accessor := core.RequestAccessor{}
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect("iso-8859-1").To(Equal(core.GetRequestCharset(httpReq)))
+
+		body, err := ioutil.ReadAll(httpReq.Body)
+		Expect(err).To(BeNil())
+		Expect([]byte("caf\xe9")).To(Equal(body))
+	})
+
+	It("Returns an empty string without a charset", func() {
+		accessor := core.RequestAccessor{}
+		for _, contentType := range []string{"application/json", "", "not a ; type"} {
+			req := getProxyRequest("/pages", "POST")
+			req.Headers = map[string]string{"Content-Type": contentType}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect("").To(Equal(core.GetRequestCharset(httpReq)))
+		}
+	})
+})
@@ -0,0 +1,25 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+const coldStartCtxKey = contextKey("coldStart")
+
+// warm is set to 1 once the first event of the process has been converted.
+var warm int32
+
+// IsColdStart reports whether the request was built from the first event
+// handled by this process. The flag is stored by the EventToRequestWithContext
+// methods of the request accessors.
+func IsColdStart(ctx context.Context) bool {
+	coldStart, _ := ctx.Value(coldStartCtxKey).(bool)
+	return coldStart
+}
+
+// withColdStart stores the cold start flag for the current event in ctx.
+func withColdStart(ctx context.Context) context.Context {
+	coldStart := atomic.CompareAndSwapInt32(&warm, 0, 1)
+	return context.WithValue(ctx, coldStartCtxKey, coldStart)
+}
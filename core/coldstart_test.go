@@ -0,0 +1,29 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cold start tests", func() {
+	It("Only marks the first invocation as a cold start", func() {
+		atomic.StoreInt32(&warm, 0)
+		accessor := RequestAccessor{}
+		event := events.APIGatewayProxyRequest{Path: "/hello", HTTPMethod: "GET"}
+
+		firstReq, err := accessor.EventToRequestWithContext(context.Background(), event)
+		Expect(err).To(BeNil())
+		Expect(IsColdStart(firstReq.Context())).To(BeTrue())
+
+		secondReq, err := accessor.EventToRequestWithContext(context.Background(), event)
+		Expect(err).To(BeNil())
+		Expect(IsColdStart(secondReq.Context())).To(BeFalse())
+
+		Expect(IsColdStart(context.Background())).To(BeFalse())
+	})
+})
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultCompressionMinSize = 1024
+
+// defaultCompressionSkipTypes are the content types that are already
+// compressed and gain nothing from another pass.
+var defaultCompressionSkipTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"application/zip",
+	"application/gzip",
+}
+
+// Compressor is a net/http middleware that compresses response bodies with
+// the content coding the client prefers among the ones it supports, gzip
+// and those added with SetEncoding. API Gateway does not compress Lambda
+// proxy responses for REST APIs, so large text responses are compressed
+// here instead. Compressed bodies are not valid UTF-8 and are always
+// returned base64 encoded by the proxy response writers.
+type Compressor struct {
+	minSize   int
+	skipTypes []string
+	encodings []encoding
+}
+
+// encoding is a content coding and the function creating its writers.
+type encoding struct {
+	coding    string
+	newWriter func(w io.Writer) io.WriteCloser
+}
+
+// NewCompressor returns a Compressor that gzip compresses bodies of at
+// least 1024 bytes and skips images, video, audio and archives.
+func NewCompressor() *Compressor {
+	return &Compressor{
+		minSize:   defaultCompressionMinSize,
+		skipTypes: defaultCompressionSkipTypes,
+		encodings: []encoding{{coding: "gzip", newWriter: func(w io.Writer) io.WriteCloser {
+			return gzip.NewWriter(w)
+		}}},
+	}
+}
+
+// SetEncoding adds a content coding, or replaces the writers of one the
+// Compressor already supports. The package only implements gzip, other
+// codings come from their own libraries, for example brotli with
+//
+//	c.SetEncoding("br", func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) })
+//
+// Codings added later are preferred when the client accepts several with
+// the same quality.
+func (c *Compressor) SetEncoding(coding string, newWriter func(w io.Writer) io.WriteCloser) {
+	coding = strings.ToLower(coding)
+	for i, e := range c.encodings {
+		if e.coding == coding {
+			c.encodings[i].newWriter = newWriter
+			return
+		}
+	}
+	c.encodings = append([]encoding{{coding: coding, newWriter: newWriter}}, c.encodings...)
+}
+
+// SetMinSize sets the size in bytes a body must reach to be compressed.
+func (c *Compressor) SetMinSize(size int) {
+	c.minSize = size
+}
+
+// SetCompressionSkipTypes replaces the list of content type prefixes that
+// are never compressed.
+func (c *Compressor) SetCompressionSkipTypes(prefixes []string) {
+	c.skipTypes = prefixes
+}
+
+// Handler wraps the handler so its responses are compressed.
+func (c *Compressor) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc, ok := c.negotiate(r.Header.Get("Accept-Encoding"))
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedWriter{ResponseWriter: w}
+		next.ServeHTTP(bw, r)
+		if bw.status == 0 {
+			return
+		}
+
+		body := bw.body.Bytes()
+		if len(body) > 0 && w.Header().Get(contentTypeHeaderKey) == "" {
+			w.Header().Set(contentTypeHeaderKey, http.DetectContentType(body))
+		}
+
+		if c.shouldCompress(w.Header(), bw.status, len(body)) {
+			var compressed bytes.Buffer
+			cw := enc.newWriter(&compressed)
+			if _, err := cw.Write(body); err == nil && cw.Close() == nil {
+				body = compressed.Bytes()
+				w.Header().Set("Content-Encoding", enc.coding)
+				w.Header().Del("Content-Length")
+				w.Header().Add("Vary", "Accept-Encoding")
+			}
+		}
+
+		w.WriteHeader(bw.status)
+		if len(body) > 0 {
+			w.Write(body)
+		}
+	})
+}
+
+func (c *Compressor) shouldCompress(headers http.Header, status int, size int) bool {
+	if status == http.StatusNoContent || status == http.StatusNotModified || size < c.minSize {
+		return false
+	}
+	if headers.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := strings.ToLower(headers.Get(contentTypeHeaderKey))
+	for _, prefix := range c.skipTypes {
+		if strings.HasPrefix(contentType, strings.ToLower(prefix)) {
+			return false
+		}
+	}
+	return true
+}
+
+// negotiate returns the encoding with the highest quality in the
+// Accept-Encoding header, the first one in the preference order of the
+// Compressor on ties. The * coding sets the quality of the codings the
+// header does not list.
+func (c *Compressor) negotiate(acceptEncoding string) (encoding, bool) {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		if coding == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		qualities[coding] = quality
+	}
+
+	var best encoding
+	bestQuality := 0.0
+	for _, e := range c.encodings {
+		quality, ok := qualities[e.coding]
+		if !ok {
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = e, quality
+		}
+	}
+	return best, bestQuality > 0
+}
+
+// bufferedWriter holds back the status and body written by a handler so a
+// middleware can transform them before they reach the underlying writer.
+// Headers are written to the underlying writer directly.
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	if w.status == 0 && !isInformational(status) {
+		w.status = status
+	}
+}
+
+func (w *bufferedWriter) Write(body []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(body)
+}
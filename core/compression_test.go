@@ -0,0 +1,136 @@
+package core_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compressor tests", func() {
+	jsonBody := "{\"items\": [" + strings.Repeat("\"item\",", 500) + "\"item\"]}"
+	pngBody := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 2048)...)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/items", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(jsonBody))
+	})
+	mux.HandleFunc("/small", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/logo.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pngBody)
+	})
+
+	handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, core.NewCompressor().Handler(mux))
+
+	It("Compresses large text responses for clients that accept gzip", func() {
+		req := getProxyRequest("/items", "GET")
+		req.MultiValueHeaders = map[string][]string{"Accept-Encoding": {"br;q=1.0, gzip;q=0.8"}}
+		resp, err := handler.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect(http.StatusOK).To(Equal(resp.StatusCode))
+		Expect(true).To(Equal(resp.IsBase64Encoded))
+		Expect([]string{"gzip"}).To(Equal(resp.MultiValueHeaders["Content-Encoding"]))
+		Expect([]string{"Accept-Encoding"}).To(Equal(resp.MultiValueHeaders["Vary"]))
+		Expect([]string{"application/json"}).To(Equal(resp.MultiValueHeaders["Content-Type"]))
+
+		compressed, err := base64.StdEncoding.DecodeString(resp.Body)
+		Expect(err).To(BeNil())
+		gz, err := gzip.NewReader(bytes.NewReader(compressed))
+		Expect(err).To(BeNil())
+		body, err := ioutil.ReadAll(gz)
+		Expect(err).To(BeNil())
+		Expect(jsonBody).To(Equal(string(body)))
+	})
+
+	It("Does not compress for other clients or small bodies", func() {
+		resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/items", "GET"))
+		Expect(err).To(BeNil())
+		Expect(jsonBody).To(Equal(resp.Body))
+		Expect(resp.MultiValueHeaders).ToNot(HaveKey("Content-Encoding"))
+
+		req := getProxyRequest("/items", "GET")
+		req.MultiValueHeaders = map[string][]string{"Accept-Encoding": {"gzip;q=0"}}
+		resp, err = handler.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect(resp.MultiValueHeaders).ToNot(HaveKey("Content-Encoding"))
+
+		req = getProxyRequest("/small", "GET")
+		req.MultiValueHeaders = map[string][]string{"Accept-Encoding": {"gzip"}}
+		resp, err = handler.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect("ok").To(Equal(resp.Body))
+		Expect(resp.MultiValueHeaders).ToNot(HaveKey("Content-Encoding"))
+	})
+
+	It("Skips already compressed content types", func() {
+		req := getProxyRequest("/logo.png", "GET")
+		req.MultiValueHeaders = map[string][]string{"Accept-Encoding": {"gzip, deflate"}}
+		resp, err := handler.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect([]string{"image/png"}).To(Equal(resp.MultiValueHeaders["Content-Type"]))
+		Expect(resp.MultiValueHeaders).ToNot(HaveKey("Content-Encoding"))
+		Expect(base64.StdEncoding.EncodeToString(pngBody)).To(Equal(resp.Body))
+	})
+
+	It("Honors a custom skip list", func() {
+		compressor := core.NewCompressor()
+		compressor.SetCompressionSkipTypes([]string{"application/json"})
+		custom := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, compressor.Handler(mux))
+
+		req := getProxyRequest("/items", "GET")
+		req.MultiValueHeaders = map[string][]string{"Accept-Encoding": {"gzip"}}
+		resp, err := custom.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect(resp.MultiValueHeaders).ToNot(HaveKey("Content-Encoding"))
+
+		req = getProxyRequest("/logo.png", "GET")
+		req.MultiValueHeaders = map[string][]string{"Accept-Encoding": {"gzip"}}
+		resp, err = custom.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect([]string{"gzip"}).To(Equal(resp.MultiValueHeaders["Content-Encoding"]))
+	})
+
+	It("Negotiates the added encodings", func() {
+		compressor := core.NewCompressor()
+		compressor.SetEncoding("deflate", func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.BestSpeed)
+			return fw
+		})
+		custom := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, compressor.Handler(mux))
+		encodingFor := func(acceptEncoding string) []string {
+			req := getProxyRequest("/items", "GET")
+			req.MultiValueHeaders = map[string][]string{"Accept-Encoding": {acceptEncoding}}
+			resp, err := custom.ProxyWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			return resp.MultiValueHeaders["Content-Encoding"]
+		}
+
+		Expect([]string{"deflate"}).To(Equal(encodingFor("gzip, deflate")))
+		Expect([]string{"gzip"}).To(Equal(encodingFor("gzip, deflate;q=0.5")))
+		Expect([]string{"deflate"}).To(Equal(encodingFor("*")))
+		Expect([]string{"gzip"}).To(Equal(encodingFor("deflate;q=0, *")))
+
+		req := getProxyRequest("/items", "GET")
+		req.MultiValueHeaders = map[string][]string{"Accept-Encoding": {"deflate"}}
+		resp, err := custom.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		compressed, err := base64.StdEncoding.DecodeString(resp.Body)
+		Expect(err).To(BeNil())
+		body, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+		Expect(err).To(BeNil())
+		Expect(jsonBody).To(Equal(string(body)))
+	})
+})
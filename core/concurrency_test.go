@@ -0,0 +1,141 @@
+package core_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// proxyConcurrently calls proxy from many goroutines at once and returns
+// the errors of the calls whose response does not match their request.
+func proxyConcurrently(calls int, proxy func(i int) error) []error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := proxy(i); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+var _ = Describe("Concurrency tests", func() {
+	echoPath := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		http.SetCookie(w, &http.Cookie{Name: "id", Value: r.URL.Query().Get("id")})
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/login?id="+r.URL.Query().Get("id"), http.StatusFound)
+			return
+		}
+		fmt.Fprintf(w, "%s %s", r.URL.Path, r.URL.Query().Get("id"))
+	})
+
+	It("Keeps the state of simultaneous REST API events apart", func() {
+		accessor := &core.RequestAccessor{}
+		accessor.StripBasePath("/prod")
+		handler := core.NewAPIGatewayProxyHandler(accessor, echoPath)
+		handler.SetRewriteRedirects(true)
+		handler.SetResponseWriterPooling(true)
+		handler.SetMetricsRecorder(&lockedMetrics{})
+
+		errs := proxyConcurrently(200, func(i int) error {
+			path := fmt.Sprintf("/items/%d", i)
+			if i%10 == 0 {
+				path = "/redirect"
+			}
+			req := getProxyRequest("/prod"+path, "GET")
+			req.QueryStringParameters = map[string]string{"id": fmt.Sprint(i)}
+			resp, err := handler.ProxyWithContext(context.Background(), req)
+			if err != nil {
+				return err
+			}
+			if path == "/redirect" {
+				if location := resp.MultiValueHeaders["Location"]; len(location) != 1 || location[0] != fmt.Sprintf("/prod/login?id=%d", i) {
+					return fmt.Errorf("call %d got Location %v", i, location)
+				}
+				return nil
+			}
+			if resp.Body != fmt.Sprintf("%s %d", path, i) || resp.MultiValueHeaders["X-Path"][0] != path {
+				return fmt.Errorf("call %d got %q", i, resp.Body)
+			}
+			return nil
+		})
+		Expect(errs).To(BeEmpty())
+	})
+
+	It("Keeps the state of simultaneous HTTP API events apart", func() {
+		handler := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, echoPath)
+		handler.SetDeadlineMargin(1)
+
+		errs := proxyConcurrently(200, func(i int) error {
+			req := getProxyRequestV2(fmt.Sprintf("/items/%d", i), "GET")
+			req.RawQueryString = fmt.Sprintf("id=%d", i)
+			resp, err := handler.ProxyWithContext(context.Background(), req)
+			if err != nil {
+				return err
+			}
+			if resp.Body != fmt.Sprintf("/items/%d %d", i, i) || len(resp.Cookies) != 1 || resp.Cookies[0] != fmt.Sprintf("id=%d", i) {
+				return fmt.Errorf("call %d got %q and cookies %v", i, resp.Body, resp.Cookies)
+			}
+			return nil
+		})
+		Expect(errs).To(BeEmpty())
+	})
+
+	It("Keeps the state of simultaneous ALB and Function URL events apart", func() {
+		alb := core.NewALBProxyHandler(&core.RequestAccessorALB{}, echoPath)
+		fnURL := core.NewFunctionURLProxyHandler(&core.RequestAccessorFnURL{}, echoPath)
+
+		errs := proxyConcurrently(200, func(i int) error {
+			path := fmt.Sprintf("/items/%d", i)
+			if i%2 == 0 {
+				req := getALBRequest(path, "GET")
+				req.QueryStringParameters = map[string]string{"id": fmt.Sprint(i)}
+				resp, err := alb.ProxyWithContext(context.Background(), req)
+				if err != nil {
+					return err
+				}
+				if resp.Body != fmt.Sprintf("%s %d", path, i) {
+					return fmt.Errorf("call %d got %q", i, resp.Body)
+				}
+				return nil
+			}
+			req := getFunctionURLRequest(path, "GET")
+			req.RawQueryString = fmt.Sprintf("id=%d", i)
+			resp, err := fnURL.ProxyWithContext(context.Background(), req)
+			if err != nil {
+				return err
+			}
+			if resp.Body != fmt.Sprintf("%s %d", path, i) {
+				return fmt.Errorf("call %d got %q", i, resp.Body)
+			}
+			return nil
+		})
+		Expect(errs).To(BeEmpty())
+	})
+})
+
+type lockedMetrics struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (m *lockedMetrics) RecordInvocation(ctx context.Context, metrics core.InvocationMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.count++
+}
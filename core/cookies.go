@@ -0,0 +1,49 @@
+package core
+
+import (
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// AddResponseCookie adds the cookie to the Set-Cookie header of a REST API
+// response built without a response writer, such as a fast path or an
+// internal error response. The cookies are kept in MultiValueHeaders, a
+// Set-Cookie value of the single-value Headers map is moved there first: API
+// Gateway only sends one cookie when the header is in both maps.
+func AddResponseCookie(resp *events.APIGatewayProxyResponse, cookie *http.Cookie) {
+	if resp.MultiValueHeaders == nil {
+		resp.MultiValueHeaders = make(map[string][]string)
+	}
+	for key, value := range resp.Headers {
+		if http.CanonicalHeaderKey(key) == "Set-Cookie" {
+			resp.MultiValueHeaders["Set-Cookie"] = append(resp.MultiValueHeaders["Set-Cookie"], value)
+			delete(resp.Headers, key)
+		}
+	}
+	if v := cookie.String(); v != "" {
+		resp.MultiValueHeaders["Set-Cookie"] = append(resp.MultiValueHeaders["Set-Cookie"], v)
+	}
+}
+
+// AddResponseCookieV2 adds the cookie to the Cookies array of an HTTP API
+// response built without a response writer. Set-Cookie values of the header
+// maps are moved to the array first, HTTP APIs only return all the cookies
+// of a response from there.
+func AddResponseCookieV2(resp *events.APIGatewayV2HTTPResponse, cookie *http.Cookie) {
+	for key, value := range resp.Headers {
+		if http.CanonicalHeaderKey(key) == "Set-Cookie" {
+			resp.Cookies = append(resp.Cookies, value)
+			delete(resp.Headers, key)
+		}
+	}
+	for key, values := range resp.MultiValueHeaders {
+		if http.CanonicalHeaderKey(key) == "Set-Cookie" {
+			resp.Cookies = append(resp.Cookies, values...)
+			delete(resp.MultiValueHeaders, key)
+		}
+	}
+	if v := cookie.String(); v != "" {
+		resp.Cookies = append(resp.Cookies, v)
+	}
+}
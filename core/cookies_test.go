@@ -0,0 +1,62 @@
+package core_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Response cookie tests", func() {
+	It("Keeps every cookie of a REST API response in MultiValueHeaders", func() {
+		resp := events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"set-cookie": "theme=dark", "Content-Type": "text/plain"},
+		}
+		core.AddResponseCookie(&resp, &http.Cookie{Name: "session", Value: "abc", HttpOnly: true})
+
+		Expect([]string{"theme=dark", "session=abc; HttpOnly"}).To(Equal(resp.MultiValueHeaders["Set-Cookie"]))
+		Expect(resp.Headers).ToNot(HaveKey("set-cookie"))
+		Expect("text/plain").To(Equal(resp.Headers["Content-Type"]))
+	})
+
+	It("Moves the cookies of an HTTP API response to the cookies array", func() {
+		resp := events.APIGatewayV2HTTPResponse{
+			StatusCode:        http.StatusOK,
+			MultiValueHeaders: map[string][]string{"Set-Cookie": {"theme=dark"}},
+		}
+		core.AddResponseCookieV2(&resp, &http.Cookie{Name: "session", Value: "abc"})
+
+		Expect([]string{"theme=dark", "session=abc"}).To(Equal(resp.Cookies))
+		Expect(resp.MultiValueHeaders).ToNot(HaveKey("Set-Cookie"))
+	})
+
+	It("Rotates a session cookie through the v1 and v2 handlers", func() {
+		// a session middleware reads the session cookie of the request and
+		// replaces it, next to a CSRF cookie
+		sessionHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session, err := r.Cookie("session")
+			Expect(err).To(BeNil())
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: session.Value + "-rotated", Path: "/", HttpOnly: true})
+			http.SetCookie(w, &http.Cookie{Name: "csrf", Value: "token", Path: "/"})
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		v1Request := getProxyRequest("/login", "POST")
+		v1Request.MultiValueHeaders = map[string][]string{"Cookie": {"session=abc"}}
+		v1Resp, err := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, sessionHandler).ProxyWithContext(context.Background(), v1Request)
+		Expect(err).To(BeNil())
+		Expect([]string{"session=abc-rotated; Path=/; HttpOnly", "csrf=token; Path=/"}).To(Equal(v1Resp.MultiValueHeaders["Set-Cookie"]))
+
+		v2Request := getProxyRequestV2("/login", "POST")
+		v2Request.Cookies = []string{"session=abc"}
+		v2Resp, err := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, sessionHandler).ProxyWithContext(context.Background(), v2Request)
+		Expect(err).To(BeNil())
+		Expect([]string{"session=abc-rotated; Path=/; HttpOnly", "csrf=token; Path=/"}).To(Equal(v2Resp.Cookies))
+		Expect(v2Resp.MultiValueHeaders).ToNot(HaveKey("Set-Cookie"))
+	})
+})
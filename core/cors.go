@@ -0,0 +1,95 @@
+package core
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORSConfig configures how a ProxyHandler answers CORS preflight requests.
+// Preflight requests that match the configuration are answered with a
+// 204 No Content before they reach the http.Handler, all others are
+// passed through.
+type CORSConfig struct {
+	// AllowedOrigins lists the origins allowed to make cross-origin requests.
+	// "*" allows any origin.
+	AllowedOrigins []string
+	// AllowedMethods lists the methods allowed for cross-origin requests.
+	// Defaults to GET, HEAD and POST when empty.
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers allowed for cross-origin
+	// requests. "*" allows any header the client asks for.
+	AllowedHeaders []string
+	// AllowCredentials sets the Access-Control-Allow-Credentials header.
+	AllowCredentials bool
+	// MaxAge is the number of seconds the client can cache the preflight
+	// response. It is not sent when 0.
+	MaxAge int
+}
+
+// handlePreflight writes the preflight response to w if req is a preflight
+// request matching the configuration. Returns false if the request should
+// be sent to the handler instead.
+func (c *CORSConfig) handlePreflight(w http.ResponseWriter, req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	requestMethod := req.Header.Get("Access-Control-Request-Method")
+	if req.Method != http.MethodOptions || origin == "" || requestMethod == "" {
+		return false
+	}
+
+	allowAnyOrigin := false
+	originAllowed := false
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			allowAnyOrigin = true
+			originAllowed = true
+		} else if strings.EqualFold(allowed, origin) {
+			originAllowed = true
+		}
+	}
+	if !originAllowed {
+		return false
+	}
+
+	methods := c.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	methodAllowed := false
+	for _, method := range methods {
+		if strings.EqualFold(method, requestMethod) {
+			methodAllowed = true
+		}
+	}
+	if !methodAllowed {
+		return false
+	}
+
+	headers := w.Header()
+	if allowAnyOrigin && !c.AllowCredentials {
+		headers.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		headers.Set("Access-Control-Allow-Origin", origin)
+		headers.Add("Vary", "Origin")
+	}
+	headers.Set("Access-Control-Allow-Methods", strings.ToUpper(strings.Join(methods, ", ")))
+
+	allowedHeaders := strings.Join(c.AllowedHeaders, ", ")
+	for _, header := range c.AllowedHeaders {
+		if header == "*" {
+			allowedHeaders = req.Header.Get("Access-Control-Request-Headers")
+		}
+	}
+	if allowedHeaders != "" {
+		headers.Set("Access-Control-Allow-Headers", allowedHeaders)
+	}
+	if c.AllowCredentials {
+		headers.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if c.MaxAge > 0 {
+		headers.Set("Access-Control-Max-Age", strconv.Itoa(c.MaxAge))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
@@ -0,0 +1,74 @@
+package core_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CORS preflight tests", func() {
+	routerHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, routerHandler)
+	handler.SetCORSConfig(core.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "PUT"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAge:         600,
+	})
+
+	preflight := func(origin string, method string) events.APIGatewayProxyRequest {
+		req := getProxyRequest("/orders", "OPTIONS")
+		req.MultiValueHeaders = map[string][]string{
+			"Origin":                         {origin},
+			"Access-Control-Request-Method":  {method},
+			"Access-Control-Request-Headers": {"authorization"},
+		}
+		return req
+	}
+
+	It("Answers matching preflight requests", func() {
+		resp, err := handler.ProxyWithContext(context.Background(), preflight("https://app.example.com", "PUT"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusNoContent).To(Equal(resp.StatusCode))
+		Expect([]string{"https://app.example.com"}).To(Equal(resp.MultiValueHeaders["Access-Control-Allow-Origin"]))
+		Expect([]string{"GET, PUT"}).To(Equal(resp.MultiValueHeaders["Access-Control-Allow-Methods"]))
+		Expect([]string{"Authorization, Content-Type"}).To(Equal(resp.MultiValueHeaders["Access-Control-Allow-Headers"]))
+		Expect([]string{"600"}).To(Equal(resp.MultiValueHeaders["Access-Control-Max-Age"]))
+		Expect([]string{"Origin"}).To(Equal(resp.MultiValueHeaders["Vary"]))
+		Expect("").To(Equal(resp.Body))
+	})
+
+	It("Sends other requests to the handler", func() {
+		resp, err := handler.ProxyWithContext(context.Background(), preflight("https://evil.example.com", "PUT"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusMethodNotAllowed).To(Equal(resp.StatusCode))
+
+		resp, err = handler.ProxyWithContext(context.Background(), preflight("https://app.example.com", "DELETE"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusMethodNotAllowed).To(Equal(resp.StatusCode))
+
+		resp, err = handler.ProxyWithContext(context.Background(), getProxyRequest("/orders", "OPTIONS"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusMethodNotAllowed).To(Equal(resp.StatusCode))
+	})
+
+	It("Allows any origin with a wildcard", func() {
+		wildcard := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, routerHandler)
+		wildcard.SetCORSConfig(core.CORSConfig{
+			AllowedOrigins: []string{"*"},
+			AllowedHeaders: []string{"*"},
+		})
+		resp, err := wildcard.ProxyWithContext(context.Background(), preflight("https://other.example.com", "POST"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusNoContent).To(Equal(resp.StatusCode))
+		Expect([]string{"*"}).To(Equal(resp.MultiValueHeaders["Access-Control-Allow-Origin"]))
+		Expect([]string{"authorization"}).To(Equal(resp.MultiValueHeaders["Access-Control-Allow-Headers"]))
+	})
+})
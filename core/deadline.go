@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// SetDeadlineMargin makes the context of each request expire the given
+// duration before the deadline of the Lambda invocation, so handlers,
+// database drivers and ctx.Done checks can abort and write a response
+// before the function is frozen. It only applies to ProxyWithContext and
+// ProxyRaw, which receive the context with the deadline of the invocation.
+func (p *ProxyHandler[ReqT, RespT]) SetDeadlineMargin(margin time.Duration) {
+	p.deadlineMargin = margin
+}
+
+// withDeadlineMargin returns the request with a context that expires at the
+// deadline margin, and the function that releases the context.
+func (p *ProxyHandler[ReqT, RespT]) withDeadlineMargin(req *http.Request) (*http.Request, context.CancelFunc) {
+	deadline, ok := req.Context().Deadline()
+	if p.deadlineMargin <= 0 || !ok {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithDeadline(req.Context(), deadline.Add(-p.deadlineMargin))
+	return req.WithContext(ctx), cancel
+}
+
+// deadlines implements the SetReadDeadline and SetWriteDeadline methods
+// http.ResponseController calls on the response writers of this package.
+// Once the write deadline has passed Write returns os.ErrDeadlineExceeded,
+// once the read deadline has passed so does reading the request body.
+type deadlines struct {
+	mu    sync.Mutex
+	read  time.Time
+	write time.Time
+}
+
+// SetReadDeadline sets the deadline for reading the request body. A zero
+// value means no deadline.
+func (d *deadlines) SetReadDeadline(deadline time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.read = deadline
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for writing the response. A zero value
+// means no deadline.
+func (d *deadlines) SetWriteDeadline(deadline time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.write = deadline
+	return nil
+}
+
+func (d *deadlines) checkWrite() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return checkExpired(d.write)
+}
+
+func (d *deadlines) checkRead() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return checkExpired(d.read)
+}
+
+func checkExpired(deadline time.Time) error {
+	if !deadline.IsZero() && !time.Now().Before(deadline) {
+		return os.ErrDeadlineExceeded
+	}
+	return nil
+}
+
+// readDeadliner is implemented by response writers that take a read
+// deadline, see deadlines.
+type readDeadliner interface {
+	checkRead() error
+}
+
+// deadlineBody is a request body that fails once the read deadline of the
+// response writer has passed.
+type deadlineBody struct {
+	io.ReadCloser
+	deadlines readDeadliner
+}
+
+func (b deadlineBody) Read(p []byte) (int, error) {
+	if err := b.deadlines.checkRead(); err != nil {
+		return 0, err
+	}
+	return b.ReadCloser.Read(p)
+}
+
+// withReadDeadline makes the body of the request honor the read deadline
+// set on the response writer.
+func withReadDeadline(req *http.Request, w http.ResponseWriter) {
+	if d, ok := w.(readDeadliner); ok && req.Body != nil && req.Body != http.NoBody {
+		req.Body = deadlineBody{ReadCloser: req.Body, deadlines: d}
+	}
+}
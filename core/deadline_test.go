@@ -0,0 +1,111 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deadline tests", func() {
+	Context("Deadline margin", func() {
+		It("Ends the request context before the invocation deadline", func() {
+			var deadline time.Time
+			var hasDeadline bool
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				deadline, hasDeadline = r.Context().Deadline()
+				w.WriteHeader(http.StatusOK)
+			}))
+			handler.SetDeadlineMargin(10 * time.Minute)
+
+			invocationDeadline := time.Now().Add(15 * time.Minute)
+			ctx, cancel := context.WithDeadline(context.Background(), invocationDeadline)
+			defer cancel()
+			_, err := handler.ProxyWithContext(ctx, getProxyRequest("/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect(hasDeadline).To(BeTrue())
+			Expect(invocationDeadline.Add(-10 * time.Minute)).To(BeTemporally("==", deadline))
+		})
+
+		It("Cancels the request context when the margin is reached", func() {
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			handler.SetDeadlineMargin(time.Minute)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute+20*time.Millisecond)
+			defer cancel()
+			resp, err := handler.ProxyWithContext(ctx, getProxyRequest("/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusServiceUnavailable).To(Equal(resp.StatusCode))
+			Expect(ctx.Err()).To(BeNil())
+		})
+
+		It("Keeps the invocation deadline without a margin", func() {
+			var deadline time.Time
+			handler := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				deadline, _ = r.Context().Deadline()
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			invocationDeadline := time.Now().Add(15 * time.Minute)
+			ctx, cancel := context.WithDeadline(context.Background(), invocationDeadline)
+			defer cancel()
+			_, err := handler.ProxyWithContext(ctx, getProxyRequestV2("/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect(invocationDeadline).To(BeTemporally("==", deadline))
+		})
+	})
+
+	Context("http.ResponseController", func() {
+		It("Fails writes after the write deadline", func() {
+			var writeErr error
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				Expect(http.NewResponseController(w).SetWriteDeadline(time.Now().Add(-time.Second))).To(BeNil())
+				_, writeErr = w.Write([]byte("late"))
+			}))
+
+			resp, err := handler.Proxy(getProxyRequest("/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect(errors.Is(writeErr, os.ErrDeadlineExceeded)).To(BeTrue())
+			Expect("").To(Equal(resp.Body))
+		})
+
+		It("Fails body reads after the read deadline", func() {
+			var readErr error
+			handler := core.NewALBProxyHandler(&core.RequestAccessorALB{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(http.NewResponseController(w).SetReadDeadline(time.Now().Add(-time.Second))).To(BeNil())
+				_, readErr = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusRequestTimeout)
+			}))
+
+			req := getALBRequest("/orders", "POST")
+			req.Body = "payload"
+			resp, err := handler.Proxy(req)
+			Expect(err).To(BeNil())
+			Expect(errors.Is(readErr, os.ErrDeadlineExceeded)).To(BeTrue())
+			Expect(http.StatusRequestTimeout).To(Equal(resp.StatusCode))
+		})
+
+		It("Clears the deadlines with a zero time", func() {
+			w := core.NewProxyResponseWriterV2()
+			controller := http.NewResponseController(w)
+			Expect(controller.SetWriteDeadline(time.Now().Add(-time.Second))).To(BeNil())
+			_, err := w.Write([]byte("late"))
+			Expect(err).ToNot(BeNil())
+
+			Expect(controller.SetWriteDeadline(time.Time{})).To(BeNil())
+			_, err = w.Write([]byte("on time"))
+			Expect(err).To(BeNil())
+		})
+	})
+})
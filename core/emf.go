@@ -0,0 +1,79 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// emfMetadata is the _aws member of a CloudWatch Embedded Metric Format
+// log line.
+type emfMetadata struct {
+	Timestamp         int64                `json:"Timestamp"`
+	CloudWatchMetrics []emfMetricDirective `json:"CloudWatchMetrics"`
+}
+
+type emfMetricDirective struct {
+	Namespace  string         `json:"Namespace"`
+	Dimensions [][]string     `json:"Dimensions"`
+	Metrics    []emfMetricDef `json:"Metrics"`
+}
+
+type emfMetricDef struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// EMFMetricsMiddleware returns a net/http middleware that writes one
+// CloudWatch Embedded Metric Format line per request to the given logger.
+// Each line publishes a Requests count and a Latency in milliseconds to the
+// namespace, along with the status code and request ID as properties.
+// Metrics are published with a Route dimension holding the route key of
+// HTTP API requests, see GetRouteKey, and without dimensions otherwise.
+// Lambda sends the lines to CloudWatch Logs, which extracts the metrics.
+func EMFMetricsMiddleware(namespace string, logger Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := newResponseRecorder(w)
+			next.ServeHTTP(rec, r)
+
+			status, _ := rec.stats()
+			line, err := json.Marshal(emfEntry(namespace, r, status, time.Since(start), start))
+			if err != nil {
+				logger.Printf("Could not marshal EMF metrics: %v", err)
+				return
+			}
+			logger.Printf("%s", line)
+		})
+	}
+}
+
+func emfEntry(namespace string, r *http.Request, status int, latency time.Duration, start time.Time) map[string]interface{} {
+	dimensions := []string{}
+	entry := map[string]interface{}{
+		"Requests":   1,
+		"Latency":    float64(latency) / float64(time.Millisecond),
+		"StatusCode": status,
+	}
+	if routeKey, ok := GetRouteKey(r.Context()); ok {
+		dimensions = append(dimensions, "Route")
+		entry["Route"] = routeKey
+	}
+	if requestID, ok := getRequestID(r.Context()); ok {
+		entry["RequestId"] = requestID
+	}
+
+	entry["_aws"] = emfMetadata{
+		Timestamp: start.UnixNano() / int64(time.Millisecond),
+		CloudWatchMetrics: []emfMetricDirective{{
+			Namespace:  namespace,
+			Dimensions: [][]string{dimensions},
+			Metrics: []emfMetricDef{
+				{Name: "Requests", Unit: "Count"},
+				{Name: "Latency", Unit: "Milliseconds"},
+			},
+		}},
+	}
+	return entry
+}
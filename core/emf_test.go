@@ -0,0 +1,96 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EMFMetricsMiddleware tests", func() {
+	It("Emits one EMF line per request", func() {
+		var buf bytes.Buffer
+		logger := log.New(&buf, "", 0)
+
+		handler := core.EMFMetricsMiddleware("OrdersAPI", logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		proxy := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, handler)
+
+		req := getProxyRequestV2("/items/42", "POST")
+		req.RouteKey = "POST /items/{id}"
+		req.RequestContext.RequestID = "req-1"
+		resp, err := proxy.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect(http.StatusAccepted).To(Equal(resp.StatusCode))
+
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		Expect(1).To(Equal(len(lines)))
+
+		entry := struct {
+			AWS struct {
+				Timestamp         int64
+				CloudWatchMetrics []struct {
+					Namespace  string
+					Dimensions [][]string
+					Metrics    []struct{ Name, Unit string }
+				}
+			} `json:"_aws"`
+			Requests   int
+			Latency    *float64
+			StatusCode int
+			Route      string
+			RequestID  string `json:"RequestId"`
+		}{}
+		Expect(json.Unmarshal(lines[0], &entry)).To(BeNil())
+		Expect(entry.AWS.Timestamp).To(BeNumerically(">", 0))
+		Expect(1).To(Equal(len(entry.AWS.CloudWatchMetrics)))
+		directive := entry.AWS.CloudWatchMetrics[0]
+		Expect("OrdersAPI").To(Equal(directive.Namespace))
+		Expect([][]string{{"Route"}}).To(Equal(directive.Dimensions))
+		Expect([]struct{ Name, Unit string }{{"Requests", "Count"}, {"Latency", "Milliseconds"}}).To(Equal(directive.Metrics))
+		Expect(1).To(Equal(entry.Requests))
+		Expect(entry.Latency).ToNot(BeNil())
+		Expect(http.StatusAccepted).To(Equal(entry.StatusCode))
+		Expect("POST /items/{id}").To(Equal(entry.Route))
+		Expect("req-1").To(Equal(entry.RequestID))
+	})
+
+	It("Publishes without dimensions when there is no route key", func() {
+		var buf bytes.Buffer
+		handler := core.EMFMetricsMiddleware("OrdersAPI", log.New(&buf, "", 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		proxy := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, handler)
+		_, err := proxy.ProxyWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+		Expect(err).To(BeNil())
+
+		entry := map[string]interface{}{}
+		Expect(json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry)).To(BeNil())
+		Expect(entry).ToNot(HaveKey("Route"))
+		metadata := entry["_aws"].(map[string]interface{})
+		directive := metadata["CloudWatchMetrics"].([]interface{})[0].(map[string]interface{})
+		Expect([]interface{}{[]interface{}{}}).To(Equal(directive["Dimensions"]))
+	})
+
+	It("Publishes the status of ALB responses behind an ETag middleware", func() {
+		var buf bytes.Buffer
+		handler := core.EMFMetricsMiddleware("OrdersAPI", log.New(&buf, "", 0))(core.ETagMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("accepted"))
+		})))
+		proxy := core.NewALBProxyHandler(&core.RequestAccessorALB{}, handler)
+		_, err := proxy.ProxyWithContext(context.Background(), getALBRequest("/hello", "POST"))
+		Expect(err).To(BeNil())
+
+		entry := map[string]interface{}{}
+		Expect(json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry)).To(BeNil())
+		Expect(float64(http.StatusAccepted)).To(Equal(entry["StatusCode"]))
+	})
+})
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// ETagMiddleware returns a net/http middleware that sets a strong ETag,
+// computed from the SHA-256 hash of the body, on 200 OK responses to GET
+// and HEAD requests that don't already have one. Requests with a matching
+// If-None-Match header receive a 304 Not Modified without a body.
+func ETagMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			bw := &bufferedWriter{ResponseWriter: w}
+			next.ServeHTTP(bw, r)
+			if bw.status == 0 {
+				return
+			}
+
+			body := bw.body.Bytes()
+			if bw.status == http.StatusOK && w.Header().Get("ETag") == "" {
+				sum := sha256.Sum256(body)
+				w.Header().Set("ETag", "\""+hex.EncodeToString(sum[:])+"\"")
+			}
+
+			etag := w.Header().Get("ETag")
+			if bw.status == http.StatusOK && etagMatches(r.Header.Get("If-None-Match"), etag) {
+				w.Header().Del(contentTypeHeaderKey)
+				w.Header().Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			if len(body) > 0 && w.Header().Get(contentTypeHeaderKey) == "" {
+				w.Header().Set(contentTypeHeaderKey, http.DetectContentType(body))
+			}
+			w.WriteHeader(bw.status)
+			if len(body) > 0 {
+				w.Write(body)
+			}
+		})
+	}
+}
+
+// etagMatches reports whether the If-None-Match header matches the ETag,
+// using the weak comparison RFC 7232 requires for If-None-Match.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
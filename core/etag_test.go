@@ -0,0 +1,76 @@
+package core_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ETagMiddleware tests", func() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/items", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{\"items\": []}"))
+	})
+	mux.HandleFunc("/tagged", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", "\"v1\"")
+		w.Write([]byte("tagged"))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, core.ETagMiddleware()(mux))
+
+	It("Sets an ETag computed from the body", func() {
+		resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/items", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusOK).To(Equal(resp.StatusCode))
+		Expect("{\"items\": []}").To(Equal(resp.Body))
+		Expect(1).To(Equal(len(resp.MultiValueHeaders["Etag"])))
+		Expect(resp.MultiValueHeaders["Etag"][0]).To(MatchRegexp("^\"[0-9a-f]{64}\"$"))
+
+		again, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/items", "GET"))
+		Expect(err).To(BeNil())
+		Expect(resp.MultiValueHeaders["Etag"]).To(Equal(again.MultiValueHeaders["Etag"]))
+	})
+
+	It("Returns 304 when If-None-Match matches", func() {
+		resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/items", "GET"))
+		Expect(err).To(BeNil())
+		etag := resp.MultiValueHeaders["Etag"][0]
+
+		req := getProxyRequest("/items", "GET")
+		req.MultiValueHeaders = map[string][]string{"If-None-Match": {"\"other\", W/" + etag}}
+		resp, err = handler.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect(http.StatusNotModified).To(Equal(resp.StatusCode))
+		Expect("").To(Equal(resp.Body))
+		Expect([]string{etag}).To(Equal(resp.MultiValueHeaders["Etag"]))
+	})
+
+	It("Keeps an ETag set by the handler", func() {
+		req := getProxyRequest("/tagged", "GET")
+		req.MultiValueHeaders = map[string][]string{"If-None-Match": {"\"v1\""}}
+		resp, err := handler.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect(http.StatusNotModified).To(Equal(resp.StatusCode))
+		Expect([]string{"\"v1\""}).To(Equal(resp.MultiValueHeaders["Etag"]))
+	})
+
+	It("Skips other statuses and methods", func() {
+		resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/missing", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusNotFound).To(Equal(resp.StatusCode))
+		Expect(resp.MultiValueHeaders).ToNot(HaveKey("Etag"))
+
+		resp, err = handler.ProxyWithContext(context.Background(), getProxyRequest("/items", "POST"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusOK).To(Equal(resp.StatusCode))
+		Expect(resp.MultiValueHeaders).ToNot(HaveKey("Etag"))
+	})
+})
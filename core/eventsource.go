@@ -0,0 +1,75 @@
+package core
+
+import "context"
+
+// EventType identifies the kind of Lambda event a request was built from.
+type EventType int
+
+const (
+	// EventTypeUnknown is returned for contexts that were not created by
+	// one of the request accessors in this package.
+	EventTypeUnknown EventType = iota
+	// EventTypeAPIGatewayV1 is an API Gateway REST API (v1 payload) event.
+	EventTypeAPIGatewayV1
+	// EventTypeAPIGatewayV2 is an API Gateway HTTP API (v2 payload) event.
+	EventTypeAPIGatewayV2
+	// EventTypeALB is an Application Load Balancer target group event.
+	EventTypeALB
+	// EventTypeFunctionURL is a Lambda Function URL event.
+	EventTypeFunctionURL
+	// EventTypeKinesis is a record of a Kinesis stream event.
+	EventTypeKinesis
+	// EventTypeSNS is a record of an SNS event.
+	EventTypeSNS
+	// EventTypeLambdaEdge is a CloudFront event of a Lambda@Edge trigger.
+	EventTypeLambdaEdge
+	// EventTypeWebSocket is an API Gateway WebSocket API event.
+	EventTypeWebSocket
+)
+
+// String returns a short name for the event type, suitable for metric tags.
+func (t EventType) String() string {
+	switch t {
+	case EventTypeAPIGatewayV1:
+		return "apigateway-v1"
+	case EventTypeAPIGatewayV2:
+		return "apigateway-v2"
+	case EventTypeALB:
+		return "alb"
+	case EventTypeFunctionURL:
+		return "function-url"
+	case EventTypeKinesis:
+		return "kinesis"
+	case EventTypeSNS:
+		return "sns"
+	case EventTypeLambdaEdge:
+		return "lambda-edge"
+	case EventTypeWebSocket:
+		return "apigateway-websocket"
+	}
+	return "unknown"
+}
+
+// GetEventSource returns the type of the event the request was built from
+// by the EventToRequestWithContext method of a request accessor.
+func GetEventSource(ctx context.Context) EventType {
+	switch ctx.Value(ctxKey{}).(type) {
+	case requestContext:
+		return EventTypeAPIGatewayV1
+	case requestContextV2:
+		return EventTypeAPIGatewayV2
+	case requestContextALB:
+		return EventTypeALB
+	case requestContextFnURL:
+		return EventTypeFunctionURL
+	case requestContextKinesis:
+		return EventTypeKinesis
+	case requestContextSNS:
+		return EventTypeSNS
+	case requestContextEdge:
+		return EventTypeLambdaEdge
+	case requestContextWebSocket:
+		return EventTypeWebSocket
+	}
+	return EventTypeUnknown
+}
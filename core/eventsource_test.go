@@ -0,0 +1,43 @@
+package core_test
+
+import (
+	"context"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Event source tests", func() {
+	It("Returns the source of each event type", func() {
+		v1Accessor := core.RequestAccessor{}
+		httpReq, err := v1Accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+		Expect(err).To(BeNil())
+		Expect(core.EventTypeAPIGatewayV1).To(Equal(core.GetEventSource(httpReq.Context())))
+
+		v2Accessor := core.RequestAccessorV2{}
+		httpReq, err = v2Accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("/hello", "GET"))
+		Expect(err).To(BeNil())
+		Expect(core.EventTypeAPIGatewayV2).To(Equal(core.GetEventSource(httpReq.Context())))
+
+		albAccessor := core.RequestAccessorALB{}
+		httpReq, err = albAccessor.EventToRequestWithContext(context.Background(), getALBRequest("/hello", "GET"))
+		Expect(err).To(BeNil())
+		Expect(core.EventTypeALB).To(Equal(core.GetEventSource(httpReq.Context())))
+
+		fnURLAccessor := core.RequestAccessorFnURL{}
+		httpReq, err = fnURLAccessor.EventToRequestWithContext(context.Background(), getFunctionURLRequest("/hello", "GET"))
+		Expect(err).To(BeNil())
+		Expect(core.EventTypeFunctionURL).To(Equal(core.GetEventSource(httpReq.Context())))
+		Expect("function-url").To(Equal(core.GetEventSource(httpReq.Context()).String()))
+
+		wsAccessor := core.RequestAccessorWebSocket{}
+		httpReq, err = wsAccessor.EventToRequestWithContext(context.Background(), getWebSocketRequest("sendMessage", "hi"))
+		Expect(err).To(BeNil())
+		Expect(core.EventTypeWebSocket).To(Equal(core.GetEventSource(httpReq.Context())))
+		Expect("apigateway-websocket").To(Equal(core.GetEventSource(httpReq.Context()).String()))
+
+		Expect(core.EventTypeUnknown).To(Equal(core.GetEventSource(context.Background())))
+	})
+})
@@ -0,0 +1,34 @@
+package core
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+const functionMetadataCtxKey = contextKey("functionMetadata")
+
+// FunctionMetadata describes the Lambda function handling the request, for
+// example to enrich log lines.
+type FunctionMetadata struct {
+	FunctionName    string
+	FunctionVersion string
+	MemoryLimitInMB int
+}
+
+// GetFunctionMetadata returns the metadata of the function stored by the
+// EventToRequestWithContext methods of the request accessors. The values are
+// read from the environment of the Lambda runtime.
+func GetFunctionMetadata(ctx context.Context) (FunctionMetadata, bool) {
+	metadata, ok := ctx.Value(functionMetadataCtxKey).(FunctionMetadata)
+	return metadata, ok
+}
+
+// withFunctionMetadata stores the metadata of the function in ctx.
+func withFunctionMetadata(ctx context.Context) context.Context {
+	return context.WithValue(ctx, functionMetadataCtxKey, FunctionMetadata{
+		FunctionName:    lambdacontext.FunctionName,
+		FunctionVersion: lambdacontext.FunctionVersion,
+		MemoryLimitInMB: lambdacontext.MemoryLimitInMB,
+	})
+}
@@ -0,0 +1,34 @@
+package core_test
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Function metadata tests", func() {
+	It("Stores the function metadata in the request context", func() {
+		name, version, memory := lambdacontext.FunctionName, lambdacontext.FunctionVersion, lambdacontext.MemoryLimitInMB
+		defer func() {
+			lambdacontext.FunctionName, lambdacontext.FunctionVersion, lambdacontext.MemoryLimitInMB = name, version, memory
+		}()
+		lambdacontext.FunctionName = "orders-api"
+		lambdacontext.FunctionVersion = "42"
+		lambdacontext.MemoryLimitInMB = 512
+
+		accessor := core.RequestAccessorV2{}
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("/orders", "GET"))
+		Expect(err).To(BeNil())
+
+		metadata, ok := core.GetFunctionMetadata(httpReq.Context())
+		Expect(ok).To(BeTrue())
+		Expect(core.FunctionMetadata{FunctionName: "orders-api", FunctionVersion: "42", MemoryLimitInMB: 512}).To(Equal(metadata))
+
+		_, ok = core.GetFunctionMetadata(context.Background())
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,710 @@
+// Package core provides utility methods that help convert proxy events
+// into an http.Request and http.ResponseWriter
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// EventAccessor is implemented by the request accessors in this package and
+// converts a Lambda event of type ReqT into an http.Request.
+type EventAccessor[ReqT any] interface {
+	ProxyEventToHTTPRequest(req ReqT) (*http.Request, error)
+	EventToRequestWithContext(ctx context.Context, req ReqT) (*http.Request, error)
+}
+
+// ProxyResponder is implemented by the response writers in this package and
+// converts the data written by a handler into a Lambda response of type RespT.
+type ProxyResponder[RespT any] interface {
+	http.ResponseWriter
+	GetProxyResponse() (RespT, error)
+}
+
+// binaryTypesSetter is implemented by response writers that take the binary
+// content types set with SetBinaryContentTypes.
+type binaryTypesSetter interface {
+	SetBinaryContentTypes(contentTypes []string)
+}
+
+// requestPreparer is implemented by response writers that configure
+// themselves from the request before the http.Handler runs.
+type requestPreparer interface {
+	prepareForRequest(req *http.Request)
+}
+
+// ProxyHandler sends Lambda events to an http.Handler. It ties together an
+// accessor that builds the request, a factory that creates a fresh response
+// writer for each invocation and the handler itself. Framework adapters use it
+// to implement their Proxy and ProxyWithContext methods.
+type ProxyHandler[ReqT any, RespT any] struct {
+	accessor       EventAccessor[ReqT]
+	newWriter      func() ProxyResponder[RespT]
+	handler        http.Handler
+	errorResponder func(err error) RespT
+	cors           *CORSConfig
+	allowedMethods []string
+	allowedTypes   []string
+	fastPaths      map[string]RespT
+	injectID       bool
+	slowThreshold  time.Duration
+	logger         Logger
+	tooLarge       bool
+	extendedID     bool
+	warmup         *RespT
+	warmupConfig   *WarmupConfig
+	binaryTypes    []string
+	pooled         bool
+	capture        CaptureFunc
+	eventHooks     []func(ctx context.Context, event *ReqT) error
+	responseHooks  []func(resp *RespT)
+
+	maxResponseBytes int
+	responseTooLarge bool
+	offloader        ResponseOffloader
+	rewriteRedirects bool
+	metrics          MetricsRecorder
+	deadlineMargin   time.Duration
+}
+
+// APIGatewayProxyHandler is a ProxyHandler for API Gateway REST API (v1 payload) events.
+type APIGatewayProxyHandler = ProxyHandler[events.APIGatewayProxyRequest, events.APIGatewayProxyResponse]
+
+// APIGatewayV2ProxyHandler is a ProxyHandler for API Gateway HTTP API (v2 payload) events.
+type APIGatewayV2ProxyHandler = ProxyHandler[events.APIGatewayV2HTTPRequest, events.APIGatewayV2HTTPResponse]
+
+// ALBProxyHandler is a ProxyHandler for Application Load Balancer target group events.
+type ALBProxyHandler = ProxyHandler[events.ALBTargetGroupRequest, events.ALBTargetGroupResponse]
+
+// FunctionURLProxyHandler is a ProxyHandler for Lambda Function URL events.
+type FunctionURLProxyHandler = ProxyHandler[events.LambdaFunctionURLRequest, events.LambdaFunctionURLResponse]
+
+// WebSocketProxyHandler is a ProxyHandler for API Gateway WebSocket API events.
+// Route responses use the REST API response format.
+type WebSocketProxyHandler = ProxyHandler[events.APIGatewayWebsocketProxyRequest, events.APIGatewayProxyResponse]
+
+// LambdaEdgeProxyHandler is a ProxyHandler for the CloudFront events of
+// Lambda@Edge viewer request and origin request triggers.
+type LambdaEdgeProxyHandler = ProxyHandler[CloudFrontEvent, CloudFrontResponse]
+
+// NewProxyHandler creates a new ProxyHandler from the given accessor, response
+// writer factory and http.Handler.
+func NewProxyHandler[ReqT any, RespT any](accessor EventAccessor[ReqT], newWriter func() ProxyResponder[RespT], handler http.Handler) *ProxyHandler[ReqT, RespT] {
+	return &ProxyHandler[ReqT, RespT]{
+		accessor:  accessor,
+		newWriter: newWriter,
+		handler:   handler,
+	}
+}
+
+// NewAPIGatewayProxyHandler returns a ProxyHandler that converts events with the
+// given RequestAccessor and collects responses with a ProxyResponseWriter
+// taken from the pool of AcquireProxyResponseWriter.
+func NewAPIGatewayProxyHandler(accessor *RequestAccessor, handler http.Handler) *APIGatewayProxyHandler {
+	return NewProxyHandler[events.APIGatewayProxyRequest, events.APIGatewayProxyResponse](accessor, func() ProxyResponder[events.APIGatewayProxyResponse] {
+		return AcquireProxyResponseWriter()
+	}, handler)
+}
+
+// NewAPIGatewayV2ProxyHandler returns a ProxyHandler that converts events with the
+// given RequestAccessorV2 and collects responses with a ProxyResponseWriterV2.
+func NewAPIGatewayV2ProxyHandler(accessor *RequestAccessorV2, handler http.Handler) *APIGatewayV2ProxyHandler {
+	return NewProxyHandler[events.APIGatewayV2HTTPRequest, events.APIGatewayV2HTTPResponse](accessor, func() ProxyResponder[events.APIGatewayV2HTTPResponse] {
+		return NewProxyResponseWriterV2()
+	}, handler)
+}
+
+// NewALBProxyHandler returns a ProxyHandler that converts events with the
+// given RequestAccessorALB and collects responses with a ProxyResponseWriterALB.
+func NewALBProxyHandler(accessor *RequestAccessorALB, handler http.Handler) *ALBProxyHandler {
+	return NewProxyHandler[events.ALBTargetGroupRequest, events.ALBTargetGroupResponse](accessor, func() ProxyResponder[events.ALBTargetGroupResponse] {
+		return NewProxyResponseWriterALB()
+	}, handler)
+}
+
+// NewFunctionURLProxyHandler returns a ProxyHandler that converts events with the
+// given RequestAccessorFnURL and collects responses with a ProxyResponseWriterFnURL.
+func NewFunctionURLProxyHandler(accessor *RequestAccessorFnURL, handler http.Handler) *FunctionURLProxyHandler {
+	return NewProxyHandler[events.LambdaFunctionURLRequest, events.LambdaFunctionURLResponse](accessor, func() ProxyResponder[events.LambdaFunctionURLResponse] {
+		return NewProxyResponseWriterFnURL()
+	}, handler)
+}
+
+// NewWebSocketProxyHandler returns a ProxyHandler that converts events with the
+// given RequestAccessorWebSocket and collects responses with a ProxyResponseWriter.
+func NewWebSocketProxyHandler(accessor *RequestAccessorWebSocket, handler http.Handler) *WebSocketProxyHandler {
+	return NewProxyHandler[events.APIGatewayWebsocketProxyRequest, events.APIGatewayProxyResponse](accessor, func() ProxyResponder[events.APIGatewayProxyResponse] {
+		return NewProxyResponseWriter()
+	}, handler)
+}
+
+// NewLambdaEdgeProxyHandler returns a ProxyHandler that converts events with the
+// given RequestAccessorEdge and collects responses with a ProxyResponseWriterEdge.
+func NewLambdaEdgeProxyHandler(accessor *RequestAccessorEdge, handler http.Handler) *LambdaEdgeProxyHandler {
+	return NewProxyHandler[CloudFrontEvent, CloudFrontResponse](accessor, func() ProxyResponder[CloudFrontResponse] {
+		return NewProxyResponseWriterEdge()
+	}, handler)
+}
+
+// SetResponseWriterFactory replaces the function used to create the response
+// writer for each invocation. Use it to configure the writers of an adapter,
+// for example to set a default status on a ProxyResponseWriterV2.
+func (p *ProxyHandler[ReqT, RespT]) SetResponseWriterFactory(newWriter func() ProxyResponder[RespT]) {
+	p.newWriter = newWriter
+}
+
+// SetInternalErrorResponder sets a function that turns internal failures,
+// such as an event that can't be converted or a handler that never set a
+// status code, into a response. When set, Proxy and ProxyWithContext return
+// the response it builds and a nil error instead of a Gateway Timeout and the
+// error, which Lambda reports to the client as a 502.
+func (p *ProxyHandler[ReqT, RespT]) SetInternalErrorResponder(responder func(err error) RespT) {
+	p.errorResponder = responder
+}
+
+// SetCORSConfig makes the handler answer CORS preflight requests that match
+// the configuration with a 204 No Content and the Access-Control-* headers,
+// without sending them to the http.Handler.
+func (p *ProxyHandler[ReqT, RespT]) SetCORSConfig(config CORSConfig) {
+	p.cors = &config
+}
+
+// SetAllowedMethods makes the handler answer requests with a method outside
+// of the list with a 405 Method Not Allowed and an Allow header, without
+// sending them to the http.Handler. CORS preflight requests answered by the
+// CORS configuration are not affected. An empty list allows all methods.
+func (p *ProxyHandler[ReqT, RespT]) SetAllowedMethods(methods []string) {
+	p.allowedMethods = make([]string, len(methods))
+	for i, method := range methods {
+		p.allowedMethods[i] = strings.ToUpper(method)
+	}
+}
+
+// SetAllowedRequestContentTypes makes the handler answer POST, PUT and PATCH
+// requests with a 415 Unsupported Media Type, without sending them to the
+// http.Handler, when their Content-Type is not in the list. Types are
+// compared without their parameters and requests without a body and
+// Content-Type are let through. An empty list allows all content types.
+func (p *ProxyHandler[ReqT, RespT]) SetAllowedRequestContentTypes(contentTypes []string) {
+	p.allowedTypes = contentTypes
+}
+
+// SetFastPaths registers canned responses for exact request paths, such as
+// health checks. Requests for those paths are answered with the response
+// right after the event is converted, without running the http.Handler or
+// the CORS and method checks.
+func (p *ProxyHandler[ReqT, RespT]) SetFastPaths(paths map[string]RespT) {
+	p.fastPaths = paths
+}
+
+// SetInjectRequestIDInErrors makes the Gateway Timeout responses returned for
+// internal failures carry the request ID, so a client can quote it in a
+// support ticket. The ID is set in the X-Request-Id header and returned in a
+// JSON body with the error message. Responses built by the internal error
+// responder are left untouched.
+func (p *ProxyHandler[ReqT, RespT]) SetInjectRequestIDInErrors(inject bool) {
+	p.injectID = inject
+}
+
+// SetRequestTooLargeResponse makes the handler answer events the accessor
+// rejects with ErrRequestTooLarge, see RequestAccessor.SetMaxDecodedBodyBytes,
+// with a 413 Request Entity Too Large instead of the internal error, which
+// clients receive as a 502.
+func (p *ProxyHandler[ReqT, RespT]) SetRequestTooLargeResponse(enable bool) {
+	p.tooLarge = enable
+}
+
+// SetMaxResponseBytes makes the handler check the JSON encoded size of its
+// responses, which is what Lambda limits, against limit, for example
+// MaxLambdaResponseBytes or MaxALBResponseBytes. An oversized response is
+// an internal failure with ErrResponseTooLarge, see
+// SetInternalErrorResponder, unless SetResponseTooLargeResponse or
+// SetResponseOffloader configure another answer. Encoding the responses has
+// a cost, a limit of 0 disables the check.
+func (p *ProxyHandler[ReqT, RespT]) SetMaxResponseBytes(limit int) {
+	p.maxResponseBytes = limit
+}
+
+// SetResponseTooLargeResponse makes the handler answer requests whose
+// response exceeds the limit set with SetMaxResponseBytes with a 413
+// Request Entity Too Large.
+func (p *ProxyHandler[ReqT, RespT]) SetResponseTooLargeResponse(enable bool) {
+	p.responseTooLarge = enable
+}
+
+// SetResponseOffloader makes the handler pass the body of responses that
+// exceed the limit set with SetMaxResponseBytes to offload, and answer with
+// a 303 See Other to the URL it returns. When offload fails the handler
+// falls back to the 413 or the error.
+func (p *ProxyHandler[ReqT, RespT]) SetResponseOffloader(offload ResponseOffloader) {
+	p.offloader = offload
+}
+
+// SetRewriteRedirects makes the handler add the base path stripped from the
+// request path back to the Location header of responses, so redirects to
+// paths of the application, such as /login, reach the client as
+// /prod/login. It undoes StripBasePath, the BasePathVariable and the base
+// path mappings of a RequestAccessor; paths changed by SetPathRewrites are
+// not rewritten back. Locations on other hosts are left untouched.
+func (p *ProxyHandler[ReqT, RespT]) SetRewriteRedirects(enable bool) {
+	p.rewriteRedirects = enable
+}
+
+// SetResponseWriterPooling makes the handler release every ProxyResponseWriter
+// with ReleaseProxyResponseWriter once it built the response, so the next
+// invocations reuse the writers and their buffers instead of allocating
+// them. Only enable it when handlers don't use the http.ResponseWriter after
+// returning, a late write would end up in another response.
+func (p *ProxyHandler[ReqT, RespT]) SetResponseWriterPooling(enable bool) {
+	p.pooled = enable
+}
+
+// SetBinaryContentTypes configures every response writer the handler creates
+// with the given binary content types, see
+// ProxyResponseWriter.SetBinaryContentTypes. Writers without a
+// SetBinaryContentTypes method are left unchanged.
+func (p *ProxyHandler[ReqT, RespT]) SetBinaryContentTypes(contentTypes []string) {
+	p.binaryTypes = contentTypes
+}
+
+// SetCaptureFunc makes the handler run the http.Handler in a trace
+// subsegment named after the route of the request, see SetSlowRequestThreshold,
+// opened with capture. The request context carries the subsegment, so calls
+// the handler instruments show up below it. Pass xray.Capture to use the
+// X-Ray SDK without this package depending on it.
+func (p *ProxyHandler[ReqT, RespT]) SetCaptureFunc(capture CaptureFunc) {
+	p.capture = capture
+}
+
+// ExtendedRequestIDHeader is the response header SetExtendedRequestIDHeader
+// sets to the extended request ID of the request.
+const ExtendedRequestIDHeader = "X-Amzn-Extended-Request-Id"
+
+// SetExtendedRequestIDHeader makes the handler add the X-Amzn-Extended-Request-Id
+// header, with the value returned by GetExtendedRequestID, to the responses
+// of requests that have one, unless the http.Handler set it.
+func (p *ProxyHandler[ReqT, RespT]) SetExtendedRequestIDHeader(enable bool) {
+	p.extendedID = enable
+}
+
+// SetWarmupResponse makes ProxyRaw answer keep-warm pings, see IsWarmupEvent,
+// with the given response right away, without converting the payload or
+// running the http.Handler. Typed events don't carry the fields of a ping,
+// so Proxy and ProxyWithContext are not affected unless SetWarmupConfig is
+// used as well, which also sets the response to the pings it detects.
+func (p *ProxyHandler[ReqT, RespT]) SetWarmupResponse(resp RespT) {
+	p.warmup = &resp
+}
+
+// SetSlowRequestThreshold makes the handler log a warning with the route and
+// the duration of requests the http.Handler takes longer than d to serve.
+// The route is the route key of HTTP API requests, the method and resource
+// of REST API requests and the method and path otherwise. A threshold of 0
+// disables the warning.
+func (p *ProxyHandler[ReqT, RespT]) SetSlowRequestThreshold(d time.Duration) {
+	p.slowThreshold = d
+}
+
+// SetLogger sets the logger the handler writes its warnings to. Defaults to
+// the standard logger of the log package.
+func (p *ProxyHandler[ReqT, RespT]) SetLogger(logger Logger) {
+	p.logger = logger
+}
+
+// EventRejection is returned by the event hooks registered with Use to
+// answer an event with the status code and message, for example a 401 for
+// an event with an invalid signature, instead of sending it to the
+// http.Handler.
+type EventRejection struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *EventRejection) Error() string {
+	return fmt.Sprintf("Event rejected with status %d: %s", e.StatusCode, e.Message)
+}
+
+// Use registers a hook that runs on the raw event before it is converted
+// into an http.Request, in the order of registration. Hooks can modify the
+// event. A hook returning an *EventRejection answers the event with its
+// status code and message, any other error is an internal failure, see
+// SetInternalErrorResponder. The remaining hooks and the http.Handler don't
+// run in both cases.
+func (p *ProxyHandler[ReqT, RespT]) Use(hook func(ctx context.Context, event *ReqT) error) {
+	p.eventHooks = append(p.eventHooks, hook)
+}
+
+// UseResponse registers a hook that runs on every response Proxy and
+// ProxyWithContext return without an error, in the order of registration,
+// for example to add headers to the response. The responses set with
+// SetFastPaths are passed as well, their maps are shared between
+// invocations.
+func (p *ProxyHandler[ReqT, RespT]) UseResponse(hook func(resp *RespT)) {
+	p.responseHooks = append(p.responseHooks, hook)
+}
+
+// Proxy receives a Lambda event, transforms it into an http.Request object
+// with the custom context headers, and sends it to the http.Handler.
+// It returns a response object generated from the http.ResponseWriter.
+func (p *ProxyHandler[ReqT, RespT]) Proxy(event ReqT) (RespT, error) {
+	if p.isWarmupEvent(event) {
+		return p.warmupResponse(context.Background())
+	}
+	if err := p.runEventHooks(context.Background(), &event); err != nil {
+		return p.rejectEvent(context.Background(), err)
+	}
+	start := time.Now()
+	req, err := p.accessor.ProxyEventToHTTPRequest(event)
+	metrics := InvocationMetrics{ConversionDuration: time.Since(start)}
+	resp, err := p.runResponseHooks(p.proxyInternal(context.Background(), req, err, &metrics))
+	p.recordMetrics(context.Background(), req, &metrics, resp, err)
+	return resp, err
+}
+
+// ProxyWithContext receives context and a Lambda event, transforms them into
+// an http.Request object, and sends it to the http.Handler.
+// It returns a response object generated from the http.ResponseWriter.
+func (p *ProxyHandler[ReqT, RespT]) ProxyWithContext(ctx context.Context, event ReqT) (RespT, error) {
+	if p.isWarmupEvent(event) {
+		return p.warmupResponse(ctx)
+	}
+	if err := p.runEventHooks(ctx, &event); err != nil {
+		return p.rejectEvent(ctx, err)
+	}
+	start := time.Now()
+	req, err := p.accessor.EventToRequestWithContext(ctx, event)
+	metrics := InvocationMetrics{ConversionDuration: time.Since(start)}
+	resp, err := p.runResponseHooks(p.proxyInternal(ctx, req, err, &metrics))
+	p.recordMetrics(ctx, req, &metrics, resp, err)
+	return resp, err
+}
+
+// ProxyWithWriter receives context and a Lambda event and sends it to the
+// http.Handler like ProxyWithContext, but returns the response writer
+// populated by the handler before GetProxyResponse is called, so callers
+// can inspect the status, headers and body or finalize the response
+// themselves. Event hooks, CORS preflights, the allowed methods and content
+// types, trace capture, the deadline margin, the extended request ID and
+// redirect rewriting apply, and rejected events and oversized requests are
+// written to the returned writer. Warm-up events are not detected, and the
+// fast paths, response hooks, response size guard and metrics recorder,
+// which work on the response object, are left to the caller. Events that
+// can't be converted and failing event hooks return an error.
+func (p *ProxyHandler[ReqT, RespT]) ProxyWithWriter(ctx context.Context, event ReqT) (ProxyResponder[RespT], error) {
+	w := p.newWriter()
+	if err := p.runEventHooks(ctx, &event); err != nil {
+		var rejection *EventRejection
+		if !errors.As(err, &rejection) {
+			return nil, NewLoggedError("Event hook failed: %v", err)
+		}
+		if rejection.Message != "" {
+			w.Header().Set(contentTypeHeaderKey, "text/plain; charset=utf-8")
+		}
+		w.WriteHeader(rejection.StatusCode)
+		w.Write([]byte(rejection.Message))
+		return w, nil
+	}
+
+	req, err := p.accessor.EventToRequestWithContext(ctx, event)
+	if err != nil {
+		if p.tooLarge && errors.Is(err, ErrRequestTooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return w, nil
+		}
+		return nil, NewLoggedError("Could not convert proxy event to request: %v", err)
+	}
+
+	p.serveRequest(w, req, &InvocationMetrics{})
+	return w, nil
+}
+
+func (p *ProxyHandler[ReqT, RespT]) runEventHooks(ctx context.Context, event *ReqT) error {
+	for _, hook := range p.eventHooks {
+		if err := hook(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ProxyHandler[ReqT, RespT]) runResponseHooks(resp RespT, err error) (RespT, error) {
+	if err != nil {
+		return resp, err
+	}
+	for _, hook := range p.responseHooks {
+		hook(&resp)
+	}
+	return resp, nil
+}
+
+// rejectEvent returns the response for an event an event hook failed on.
+// Rejections go through the response hooks like the responses of the
+// http.Handler.
+func (p *ProxyHandler[ReqT, RespT]) rejectEvent(ctx context.Context, err error) (RespT, error) {
+	var rejection *EventRejection
+	if !errors.As(err, &rejection) {
+		return p.internalError(ctx, NewLoggedError("Event hook failed: %v", err))
+	}
+	w := p.newWriter()
+	if rejection.Message != "" {
+		w.Header().Set(contentTypeHeaderKey, "text/plain; charset=utf-8")
+	}
+	w.WriteHeader(rejection.StatusCode)
+	w.Write([]byte(rejection.Message))
+	return p.runResponseHooks(w.GetProxyResponse())
+}
+
+// ProxyRaw receives context and a JSON encoded Lambda event, for example the
+// payload read from the Runtime API by a custom runtime, and sends it to the
+// http.Handler like ProxyWithContext.
+// The payload is stored in the request context, use GetRawEvent to read
+// fields of the event that the typed events structs don't have yet.
+// It returns the JSON encoded response object.
+func (p *ProxyHandler[ReqT, RespT]) ProxyRaw(ctx context.Context, payload []byte) ([]byte, error) {
+	return p.ProxyRawWith(ctx, payload, p.ProxyWithContext)
+}
+
+// ProxyRawWith works like ProxyRaw but sends the decoded event to proxy
+// instead of ProxyWithContext, for adapters that wrap ProxyWithContext with
+// their own options and need raw payloads to go through them as well.
+func (p *ProxyHandler[ReqT, RespT]) ProxyRawWith(ctx context.Context, payload []byte, proxy func(ctx context.Context, event ReqT) (RespT, error)) ([]byte, error) {
+	if p.isWarmupPayload(payload) {
+		resp, err := p.warmupResponse(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(resp)
+	}
+
+	var event ReqT
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, NewLoggedError("Could not unmarshal proxy event: %v", err)
+	}
+
+	ctx = context.WithValue(ctx, rawEventKey{}, json.RawMessage(payload))
+	resp, err := proxy(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(resp)
+}
+
+// StatusResponse returns an empty response with the status, built by the
+// response writer of the handler, for adapters that answer an event without
+// sending it to the http.Handler.
+func (p *ProxyHandler[ReqT, RespT]) StatusResponse(status int) (RespT, error) {
+	w := p.newWriter()
+	w.WriteHeader(status)
+	return w.GetProxyResponse()
+}
+
+type rawEventKey struct{}
+
+// GetRawEvent returns the JSON encoded event a request was built from when
+// the event was sent through ProxyRaw. Returns nil for requests built from
+// typed events.
+func GetRawEvent(ctx context.Context) json.RawMessage {
+	raw, _ := ctx.Value(rawEventKey{}).(json.RawMessage)
+	return raw
+}
+
+func (p *ProxyHandler[ReqT, RespT]) proxyInternal(ctx context.Context, req *http.Request, err error, metrics *InvocationMetrics) (RespT, error) {
+	if err != nil {
+		if p.tooLarge && errors.Is(err, ErrRequestTooLarge) {
+			w := p.newWriter()
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return w.GetProxyResponse()
+		}
+		return p.internalError(ctx, NewLoggedError("Could not convert proxy event to request: %v", err))
+	}
+
+	if resp, ok := p.fastPaths[req.URL.Path]; ok {
+		return resp, nil
+	}
+
+	w := p.newWriter()
+	req = p.serveRequest(w, req, metrics)
+
+	resp, err := w.GetProxyResponse()
+	if pooled, ok := interface{}(w).(*ProxyResponseWriter); ok && p.pooled {
+		ReleaseProxyResponseWriter(pooled)
+	}
+	if err != nil {
+		return p.internalError(req.Context(), NewLoggedError("Error while generating proxy response: %v", err))
+	}
+
+	return p.guardResponseSize(req, resp)
+}
+
+// serveRequest configures the response writer for the request and sends
+// the request to the http.Handler, answering CORS preflights itself. It
+// returns the request the handler received.
+func (p *ProxyHandler[ReqT, RespT]) serveRequest(w ProxyResponder[RespT], req *http.Request, metrics *InvocationMetrics) *http.Request {
+	if setter, ok := w.(binaryTypesSetter); ok && p.binaryTypes != nil {
+		setter.SetBinaryContentTypes(p.binaryTypes)
+	}
+	if preparer, ok := w.(requestPreparer); ok {
+		preparer.prepareForRequest(req)
+	}
+	propagateTraceID(req)
+	req, cancel := p.withDeadlineMargin(req)
+	defer cancel()
+	withReadDeadline(req, w)
+	if p.cors == nil || !p.cors.handlePreflight(w, req) {
+		start := time.Now()
+		p.captureServe(w, req)
+		metrics.HandlerDuration = time.Since(start)
+		if elapsed := metrics.HandlerDuration; p.slowThreshold > 0 && elapsed > p.slowThreshold {
+			p.warnf("Slow request: %s took %v", requestRoute(req), elapsed)
+		}
+	}
+	if p.extendedID && w.Header().Get(ExtendedRequestIDHeader) == "" {
+		if extendedID, ok := GetExtendedRequestID(req.Context()); ok {
+			w.Header().Set(ExtendedRequestIDHeader, extendedID)
+		}
+	}
+
+	if p.rewriteRedirects {
+		rewriteLocation(w.Header(), req)
+	}
+	return req
+}
+
+// warnf logs a warning to the logger set with SetLogger.
+func (p *ProxyHandler[ReqT, RespT]) warnf(format string, v ...interface{}) {
+	if p.logger == nil {
+		log.Printf(format, v...)
+		return
+	}
+	p.logger.Printf(format, v...)
+}
+
+// requestRoute returns the method and route of the request for log
+// messages, in the format of HTTP API route keys.
+func requestRoute(req *http.Request) string {
+	if routeKey, ok := GetRouteKey(req.Context()); ok && routeKey != "$default" {
+		return routeKey
+	}
+	if resource, ok := GetResourceTemplate(req.Context()); ok {
+		return req.Method + " " + resource
+	}
+	return req.Method + " " + req.URL.Path
+}
+
+// captureServe serves the request in the subsegment opened by the function
+// set with SetCaptureFunc, if any. The request is served without a
+// subsegment when the function fails before calling it.
+func (p *ProxyHandler[ReqT, RespT]) captureServe(w http.ResponseWriter, req *http.Request) {
+	if p.capture == nil {
+		p.serve(w, req)
+		return
+	}
+	served := false
+	err := p.capture(req.Context(), requestRoute(req), func(ctx context.Context) error {
+		served = true
+		p.serve(w, req.WithContext(ctx))
+		return nil
+	})
+	if err != nil {
+		p.warnf("Trace subsegment for %s failed: %v", requestRoute(req), err)
+	}
+	if !served {
+		p.serve(w, req)
+	}
+}
+
+// serve sends the request to the http.Handler, or answers it with a 405 if
+// its method is not in the list set with SetAllowedMethods and a 415 if its
+// content type is not in the list set with SetAllowedRequestContentTypes.
+func (p *ProxyHandler[ReqT, RespT]) serve(w http.ResponseWriter, req *http.Request) {
+	if len(p.allowedMethods) > 0 {
+		allowed := false
+		for _, method := range p.allowedMethods {
+			if method == req.Method {
+				allowed = true
+			}
+		}
+		if !allowed {
+			w.Header().Set("Allow", strings.Join(p.allowedMethods, ", "))
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+	}
+	if !p.contentTypeAllowed(req) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	p.handler.ServeHTTP(w, req)
+}
+
+// contentTypeAllowed reports whether the Content-Type of a request with a
+// body is in the list set with SetAllowedRequestContentTypes.
+func (p *ProxyHandler[ReqT, RespT]) contentTypeAllowed(req *http.Request) bool {
+	if len(p.allowedTypes) == 0 {
+		return true
+	}
+	if req.Method != http.MethodPost && req.Method != http.MethodPut && req.Method != http.MethodPatch {
+		return true
+	}
+	contentType := req.Header.Get(contentTypeHeaderKey)
+	if contentType == "" && req.ContentLength == 0 {
+		return true
+	}
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	for _, allowed := range p.allowedTypes {
+		if strings.EqualFold(mediaType, strings.TrimSpace(allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// internalError returns the response for an internal failure, built by the
+// internal error responder if one is set. ctx is the context of the request,
+// or the Lambda context when the event could not be converted.
+func (p *ProxyHandler[ReqT, RespT]) internalError(ctx context.Context, err error) (RespT, error) {
+	if p.errorResponder != nil {
+		return p.errorResponder(err), nil
+	}
+	return p.gatewayTimeout(ctx, err), err
+}
+
+// errorRequestID returns the request ID for an internal error response.
+func errorRequestID(ctx context.Context) (string, bool) {
+	if id, ok := getRequestID(ctx); ok {
+		return id, true
+	}
+	if lc, ok := lambdacontext.FromContext(ctx); ok && lc.AwsRequestID != "" {
+		return lc.AwsRequestID, true
+	}
+	return "", false
+}
+
+// gatewayTimeout returns a Gateway Timeout (504) response of the type
+// produced by the response writer, with the request ID and error message if
+// SetInjectRequestIDInErrors is enabled.
+func (p *ProxyHandler[ReqT, RespT]) gatewayTimeout(ctx context.Context, err error) RespT {
+	w := p.newWriter()
+	id, ok := errorRequestID(ctx)
+	if p.injectID && ok {
+		w.Header().Set(RequestIDHeader, id)
+		w.Header().Set(contentTypeHeaderKey, "application/json")
+		w.WriteHeader(http.StatusGatewayTimeout)
+		body, _ := json.Marshal(map[string]string{"message": err.Error(), "requestId": id})
+		w.Write(body)
+	} else {
+		w.WriteHeader(http.StatusGatewayTimeout)
+	}
+	resp, _ := w.GetProxyResponse()
+	return resp
+}
@@ -0,0 +1,540 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProxyHandler tests", func() {
+	stubHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("X-Method", r.Method)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("path " + r.URL.Path))
+	})
+
+	Context("API Gateway v1 events", func() {
+		accessor := &core.RequestAccessor{}
+		handler := core.NewAPIGatewayProxyHandler(accessor, stubHandler)
+
+		It("Proxies the event through the handler", func() {
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/hello", "POST"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusCreated).To(Equal(resp.StatusCode))
+			Expect("path /hello").To(Equal(resp.Body))
+			Expect("POST").To(Equal(resp.MultiValueHeaders["X-Method"][0]))
+
+			resp, err = handler.Proxy(getProxyRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusCreated).To(Equal(resp.StatusCode))
+		})
+
+		It("Uses the accessor configuration", func() {
+			accessor.StripBasePath("app1")
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/app1/orders", "GET"))
+			accessor.StripBasePath("")
+			Expect(err).To(BeNil())
+			Expect("path /orders").To(Equal(resp.Body))
+		})
+
+		It("Returns a gateway timeout when the handler writes nothing", func() {
+			emptyHandler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			resp, err := emptyHandler.ProxyWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+			Expect(err).ToNot(BeNil())
+			Expect(http.StatusGatewayTimeout).To(Equal(resp.StatusCode))
+		})
+	})
+
+	Context("Allowed methods", func() {
+		It("Answers other methods with a 405", func() {
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, stubHandler)
+			handler.SetAllowedMethods([]string{"get", "POST"})
+
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/hello", "TRACE"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusMethodNotAllowed).To(Equal(resp.StatusCode))
+			Expect([]string{"GET, POST"}).To(Equal(resp.MultiValueHeaders["Allow"]))
+			Expect(resp.MultiValueHeaders).ToNot(HaveKey("X-Method"))
+
+			resp, err = handler.ProxyWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusCreated).To(Equal(resp.StatusCode))
+		})
+
+		It("Still answers CORS preflight requests", func() {
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, stubHandler)
+			handler.SetAllowedMethods([]string{"GET"})
+			handler.SetCORSConfig(core.CORSConfig{AllowedOrigins: []string{"*"}})
+
+			req := getProxyRequest("/hello", "OPTIONS")
+			req.MultiValueHeaders = map[string][]string{
+				"Origin":                        {"https://app.example.com"},
+				"Access-Control-Request-Method": {"GET"},
+			}
+			resp, err := handler.ProxyWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(http.StatusNoContent).To(Equal(resp.StatusCode))
+		})
+	})
+
+	Context("Proxy with writer", func() {
+		It("Returns the writer populated through the pipeline", func() {
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, stubHandler)
+			handler.SetAllowedMethods([]string{"GET"})
+			handler.Use(func(ctx context.Context, event *events.APIGatewayProxyRequest) error {
+				if event.Path == "/blocked" {
+					return &core.EventRejection{StatusCode: http.StatusForbidden, Message: "blocked"}
+				}
+				return nil
+			})
+
+			w, err := handler.ProxyWithWriter(context.Background(), getProxyRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusCreated).To(Equal(resp.StatusCode))
+			Expect("path /hello").To(Equal(resp.Body))
+
+			w, err = handler.ProxyWithWriter(context.Background(), getProxyRequest("/hello", "TRACE"))
+			Expect(err).To(BeNil())
+			Expect("GET").To(Equal(w.Header().Get("Allow")))
+
+			w, err = handler.ProxyWithWriter(context.Background(), getProxyRequest("/blocked", "GET"))
+			Expect(err).To(BeNil())
+			resp, err = w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusForbidden).To(Equal(resp.StatusCode))
+			Expect("blocked").To(Equal(resp.Body))
+		})
+	})
+
+	Context("Allowed request content types", func() {
+		It("Answers other content types with a 415", func() {
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, stubHandler)
+			handler.SetAllowedRequestContentTypes([]string{"application/json"})
+
+			req := getProxyRequest("/hello", "POST")
+			req.Body = "<order/>"
+			req.MultiValueHeaders = map[string][]string{"Content-Type": {"text/xml"}}
+			resp, err := handler.ProxyWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(http.StatusUnsupportedMediaType).To(Equal(resp.StatusCode))
+
+			req.Body = "{}"
+			req.MultiValueHeaders = map[string][]string{"Content-Type": {"application/json; charset=utf-8"}}
+			resp, err = handler.ProxyWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(http.StatusCreated).To(Equal(resp.StatusCode))
+
+			resp, err = handler.ProxyWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusCreated).To(Equal(resp.StatusCode))
+		})
+	})
+
+	Context("Internal error responder", func() {
+		It("Turns internal failures into the responder's response", func() {
+			emptyHandler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			emptyHandler.SetInternalErrorResponder(func(err error) events.APIGatewayProxyResponse {
+				body, _ := json.Marshal(map[string]string{"error": err.Error()})
+				return events.APIGatewayProxyResponse{
+					StatusCode:        http.StatusInternalServerError,
+					MultiValueHeaders: map[string][]string{"Content-Type": {"application/json"}},
+					Body:              string(body),
+				}
+			})
+
+			resp, err := emptyHandler.ProxyWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusInternalServerError).To(Equal(resp.StatusCode))
+			Expect("application/json").To(Equal(resp.MultiValueHeaders["Content-Type"][0]))
+			Expect("{\"error\":\"Error while generating proxy response: Status code not set on response\"}").To(Equal(resp.Body))
+
+			badRequest := getProxyRequest("/hello", "POST")
+			badRequest.Body = "not base64!"
+			badRequest.IsBase64Encoded = true
+			resp, err = emptyHandler.Proxy(badRequest)
+			Expect(err).To(BeNil())
+			Expect(http.StatusInternalServerError).To(Equal(resp.StatusCode))
+		})
+	})
+
+	Context("Oversized request bodies", func() {
+		oversized := getProxyRequest("/upload", "POST")
+		oversized.Body = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("a"), 2048))
+		oversized.IsBase64Encoded = true
+
+		It("Answers with a 413 when enabled", func() {
+			accessor := &core.RequestAccessor{}
+			accessor.SetMaxDecodedBodyBytes(1024)
+			handler := core.NewAPIGatewayProxyHandler(accessor, stubHandler)
+
+			_, err := handler.ProxyWithContext(context.Background(), oversized)
+			Expect(err).ToNot(BeNil())
+
+			handler.SetRequestTooLargeResponse(true)
+			resp, err := handler.ProxyWithContext(context.Background(), oversized)
+			Expect(err).To(BeNil())
+			Expect(http.StatusRequestEntityTooLarge).To(Equal(resp.StatusCode))
+
+			resp, err = handler.ProxyWithContext(context.Background(), getProxyRequest("/upload", "POST"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusCreated).To(Equal(resp.StatusCode))
+		})
+	})
+
+	Context("Request ID in internal errors", func() {
+		emptyHandler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		It("Leaves the Gateway Timeout empty by default", func() {
+			req := getProxyRequest("/hello", "GET")
+			req.RequestContext = getRequestContext()
+			resp, err := emptyHandler.ProxyWithContext(context.Background(), req)
+			Expect(err).ToNot(BeNil())
+			Expect(http.StatusGatewayTimeout).To(Equal(resp.StatusCode))
+			Expect("").To(Equal(resp.Body))
+			Expect(resp.MultiValueHeaders).ToNot(HaveKey("X-Request-Id"))
+		})
+
+		It("Adds the request ID to the header and body", func() {
+			emptyHandler.SetInjectRequestIDInErrors(true)
+			req := getProxyRequest("/hello", "GET")
+			req.RequestContext = getRequestContext()
+			resp, err := emptyHandler.ProxyWithContext(context.Background(), req)
+			Expect(err).ToNot(BeNil())
+			Expect(http.StatusGatewayTimeout).To(Equal(resp.StatusCode))
+			Expect([]string{"x"}).To(Equal(resp.MultiValueHeaders["X-Request-Id"]))
+			Expect([]string{"application/json"}).To(Equal(resp.MultiValueHeaders["Content-Type"]))
+
+			var body map[string]string
+			Expect(json.Unmarshal([]byte(resp.Body), &body)).To(BeNil())
+			Expect("x").To(Equal(body["requestId"]))
+			Expect(err.Error()).To(Equal(body["message"]))
+		})
+
+		It("Uses the Lambda request ID when the event can't be converted", func() {
+			emptyHandler.SetInjectRequestIDInErrors(true)
+			badRequest := getProxyRequest("/hello", "POST")
+			badRequest.Body = "not base64!"
+			badRequest.IsBase64Encoded = true
+			ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "abc123"})
+			resp, err := emptyHandler.ProxyWithContext(ctx, badRequest)
+			Expect(err).ToNot(BeNil())
+			Expect([]string{"abc123"}).To(Equal(resp.MultiValueHeaders["X-Request-Id"]))
+		})
+	})
+
+	Context("Response writer pooling", func() {
+		It("Keeps the responses of reused writers apart", func() {
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, stubHandler)
+			handler.SetResponseWriterPooling(true)
+
+			first, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/first", "GET"))
+			Expect(err).To(BeNil())
+			second, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/second", "POST"))
+			Expect(err).To(BeNil())
+
+			Expect("path /first").To(Equal(first.Body))
+			Expect([]string{"GET"}).To(Equal(first.MultiValueHeaders["X-Method"]))
+			Expect("path /second").To(Equal(second.Body))
+			Expect([]string{"POST"}).To(Equal(second.MultiValueHeaders["X-Method"]))
+		})
+	})
+
+	Context("Binary content types", func() {
+		It("Configures the response writers", func() {
+			imageHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "image/svg+xml")
+				w.Write([]byte("<svg/>"))
+			})
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, imageHandler)
+			handler.SetBinaryContentTypes([]string{"image/*"})
+
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/logo", "GET"))
+			Expect(err).To(BeNil())
+			Expect(true).To(Equal(resp.IsBase64Encoded))
+			Expect(base64.StdEncoding.EncodeToString([]byte("<svg/>"))).To(Equal(resp.Body))
+
+			handlerV2 := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, imageHandler)
+			handlerV2.SetBinaryContentTypes([]string{"*/*"})
+
+			respV2, err := handlerV2.ProxyWithContext(context.Background(), getProxyRequestV2("/logo", "GET"))
+			Expect(err).To(BeNil())
+			Expect(true).To(Equal(respV2.IsBase64Encoded))
+		})
+	})
+
+	Context("Extended request ID header", func() {
+		It("Adds the extended request ID to the response when enabled", func() {
+			req := getProxyRequest("/hello", "GET")
+			req.RequestContext = getRequestContext()
+			req.RequestContext.ExtendedRequestID = "GxXbXFGzoAMFdLA="
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, stubHandler)
+
+			resp, err := handler.ProxyWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(resp.MultiValueHeaders).ToNot(HaveKey(core.ExtendedRequestIDHeader))
+
+			handler.SetExtendedRequestIDHeader(true)
+			resp, err = handler.ProxyWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect([]string{"GxXbXFGzoAMFdLA="}).To(Equal(resp.MultiValueHeaders[core.ExtendedRequestIDHeader]))
+		})
+	})
+
+	Context("Event and response hooks", func() {
+		It("Runs the event hooks before converting the event", func() {
+			var order []string
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, stubHandler)
+			handler.Use(func(ctx context.Context, event *events.APIGatewayProxyRequest) error {
+				order = append(order, "first "+event.Path)
+				event.Path = "/rewritten"
+				return nil
+			})
+			handler.Use(func(ctx context.Context, event *events.APIGatewayProxyRequest) error {
+				order = append(order, "second "+event.Path)
+				return nil
+			})
+
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect([]string{"first /hello", "second /rewritten"}).To(Equal(order))
+			Expect("path /rewritten").To(Equal(resp.Body))
+		})
+
+		It("Answers rejected events without running the handler", func() {
+			served := false
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				served = true
+			}))
+			handler.Use(func(ctx context.Context, event *events.APIGatewayProxyRequest) error {
+				return &core.EventRejection{StatusCode: http.StatusUnauthorized, Message: "Invalid signature"}
+			})
+
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/hello", "POST"))
+			Expect(err).To(BeNil())
+			Expect(served).To(BeFalse())
+			Expect(http.StatusUnauthorized).To(Equal(resp.StatusCode))
+			Expect("Invalid signature").To(Equal(resp.Body))
+		})
+
+		It("Treats other hook errors as internal failures", func() {
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, stubHandler)
+			handler.Use(func(ctx context.Context, event *events.APIGatewayProxyRequest) error {
+				return fmt.Errorf("Could not read the signing key")
+			})
+
+			resp, err := handler.Proxy(getProxyRequest("/hello", "GET"))
+			Expect(err).ToNot(BeNil())
+			Expect(http.StatusGatewayTimeout).To(Equal(resp.StatusCode))
+		})
+
+		It("Runs the response hooks on the outgoing response", func() {
+			handler := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, stubHandler)
+			handler.UseResponse(func(resp *events.APIGatewayV2HTTPResponse) {
+				if resp.Headers == nil {
+					resp.Headers = make(map[string]string)
+				}
+				resp.Headers["Strict-Transport-Security"] = "max-age=63072000"
+			})
+			handler.Use(func(ctx context.Context, event *events.APIGatewayV2HTTPRequest) error {
+				if event.RawPath == "/forbidden" {
+					return &core.EventRejection{StatusCode: http.StatusForbidden}
+				}
+				return nil
+			})
+
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequestV2("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusCreated).To(Equal(resp.StatusCode))
+			Expect("max-age=63072000").To(Equal(resp.Headers["Strict-Transport-Security"]))
+
+			resp, err = handler.ProxyWithContext(context.Background(), getProxyRequestV2("/forbidden", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusForbidden).To(Equal(resp.StatusCode))
+			Expect("max-age=63072000").To(Equal(resp.Headers["Strict-Transport-Security"]))
+		})
+	})
+
+	Context("Slow request warnings", func() {
+		var buf bytes.Buffer
+		sleepy := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/slow" {
+				time.Sleep(20 * time.Millisecond)
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, sleepy)
+		handler.SetSlowRequestThreshold(10 * time.Millisecond)
+		handler.SetLogger(log.New(&buf, "", 0))
+
+		BeforeEach(func() {
+			buf.Reset()
+		})
+
+		It("Logs requests slower than the threshold", func() {
+			req := getProxyRequest("/slow", "GET")
+			req.Resource = "/{page}"
+			resp, err := handler.ProxyWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(http.StatusOK).To(Equal(resp.StatusCode))
+			Expect(buf.String()).To(HavePrefix("Slow request: GET /{page} took "))
+		})
+
+		It("Stays quiet for fast requests", func() {
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/fast", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusOK).To(Equal(resp.StatusCode))
+			Expect("").To(Equal(buf.String()))
+		})
+	})
+
+	Context("Raw JSON events", func() {
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, stubHandler)
+
+		It("Proxies a JSON event and returns a JSON response", func() {
+			payload, err := handler.ProxyRaw(context.Background(), []byte(`{"httpMethod": "DELETE", "path": "/orders/1"}`))
+			Expect(err).To(BeNil())
+
+			resp := events.APIGatewayProxyResponse{}
+			Expect(json.Unmarshal(payload, &resp)).To(BeNil())
+			Expect(http.StatusCreated).To(Equal(resp.StatusCode))
+			Expect("path /orders/1").To(Equal(resp.Body))
+			Expect("DELETE").To(Equal(resp.MultiValueHeaders["X-Method"][0]))
+		})
+
+		It("Exposes the raw event to the handler", func() {
+			var extra string
+			rawHandler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				event := struct {
+					NewField string `json:"newField"`
+				}{}
+				if err := json.Unmarshal(core.GetRawEvent(r.Context()), &event); err == nil {
+					extra = event.NewField
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			_, err := rawHandler.ProxyRaw(context.Background(), []byte(`{"httpMethod": "GET", "path": "/orders", "newField": "preview"}`))
+			Expect(err).To(BeNil())
+			Expect("preview").To(Equal(extra))
+
+			req, err := (&core.RequestAccessor{}).EventToRequestWithContext(context.Background(), getProxyRequest("/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect(core.GetRawEvent(req.Context())).To(BeNil())
+		})
+
+		It("Returns an error for invalid JSON", func() {
+			payload, err := handler.ProxyRaw(context.Background(), []byte(`{"httpMethod": `))
+			Expect(err).ToNot(BeNil())
+			Expect(payload).To(BeNil())
+		})
+		It("Sends the decoded event to a wrapping proxy function", func() {
+			payload, err := handler.ProxyRawWith(context.Background(), []byte(`{"httpMethod": "GET", "path": "/orders"}`), func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				Expect("/orders").To(Equal(req.Path))
+				Expect(core.GetRawEvent(ctx)).ToNot(BeNil())
+				return handler.StatusResponse(http.StatusServiceUnavailable)
+			})
+			Expect(err).To(BeNil())
+
+			resp := events.APIGatewayProxyResponse{}
+			Expect(json.Unmarshal(payload, &resp)).To(BeNil())
+			Expect(http.StatusServiceUnavailable).To(Equal(resp.StatusCode))
+			Expect("").To(Equal(resp.Body))
+		})
+	})
+
+	Context("API Gateway v2 events", func() {
+		handler := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, stubHandler)
+
+		It("Proxies the event through the handler", func() {
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequestV2("/hello", "PUT"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusCreated).To(Equal(resp.StatusCode))
+			Expect("path /hello").To(Equal(resp.Body))
+			Expect("PUT").To(Equal(resp.MultiValueHeaders["X-Method"][0]))
+		})
+
+		It("Returns a gateway timeout when the event can't be converted", func() {
+			badRequest := getProxyRequestV2("/hello", "POST")
+			badRequest.Body = "not base64!"
+			badRequest.IsBase64Encoded = true
+			resp, err := handler.ProxyWithContext(context.Background(), badRequest)
+			Expect(err).ToNot(BeNil())
+			Expect(http.StatusGatewayTimeout).To(Equal(resp.StatusCode))
+		})
+	})
+
+	Context("Range requests", func() {
+		It("Preserves the partial content written by http.ServeContent", func() {
+			content := "0123456789abcdefghij"
+			rangeHandler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(w, r, "data.txt", time.Unix(0, 0), strings.NewReader(content))
+			}))
+
+			req := getProxyRequest("/data.txt", "GET")
+			req.MultiValueHeaders = map[string][]string{"Range": {"bytes=5-9"}}
+			resp, err := rangeHandler.ProxyWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(http.StatusPartialContent).To(Equal(resp.StatusCode))
+			Expect([]string{"bytes 5-9/20"}).To(Equal(resp.MultiValueHeaders["Content-Range"]))
+			Expect([]string{"5"}).To(Equal(resp.MultiValueHeaders["Content-Length"]))
+			Expect("56789").To(Equal(resp.Body))
+
+			resp, err = rangeHandler.ProxyWithContext(context.Background(), getProxyRequest("/data.txt", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusOK).To(Equal(resp.StatusCode))
+			Expect(content).To(Equal(resp.Body))
+		})
+	})
+
+	Context("Custom type parameters", func() {
+		It("Builds a handler from an accessor and a writer factory", func() {
+			handler := core.NewProxyHandler[events.APIGatewayProxyRequest, events.APIGatewayProxyResponse](
+				&core.RequestAccessor{},
+				func() core.ProxyResponder[events.APIGatewayProxyResponse] {
+					return core.NewProxyResponseWriter()
+				},
+				stubHandler,
+			)
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/custom", "GET"))
+			Expect(err).To(BeNil())
+			Expect("path /custom").To(Equal(resp.Body))
+		})
+	})
+})
+
+func BenchmarkAPIGatewayProxyHandler(b *testing.B) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{\"hello\": \"world\"}"))
+	})
+	req := getProxyRequest("/hello", "GET")
+	for _, pooled := range []bool{false, true} {
+		b.Run(fmt.Sprintf("pooled=%v", pooled), func(b *testing.B) {
+			proxy := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, handler)
+			proxy.SetResponseWriterPooling(pooled)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := proxy.ProxyWithContext(context.Background(), req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader is the request header IdempotencyMiddleware reads the
+// idempotency key from.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set to "true" on responses IdempotencyMiddleware
+// replays from the store.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// Defaults of MemoryIdempotencyStore, see SetTTL and SetMaxEntries.
+const (
+	DefaultIdempotencyTTL        = 24 * time.Hour
+	DefaultIdempotencyMaxEntries = 1000
+)
+
+// StoredResponse is a response recorded by IdempotencyMiddleware.
+type StoredResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// RequestHash is the SHA-256 of the body of the request the response
+	// was recorded for, hex encoded.
+	RequestHash string
+	// InFlight marks a key whose first request is still being handled. Its
+	// other fields, RequestHash aside, are empty.
+	InFlight bool
+}
+
+// IdempotencyStore saves the responses recorded by IdempotencyMiddleware by
+// key. Keys combine the method, the path and the idempotency key of the
+// request. Implementations must be safe for concurrent use.
+type IdempotencyStore interface {
+	// Reserve stores resp for the key if the key has no response yet and
+	// returns true. Otherwise it leaves the store unchanged and returns the
+	// response stored for the key and false. It must be atomic, for example a
+	// conditional put in a DynamoDB table, so that only one of simultaneous
+	// requests with the same key runs the handler.
+	Reserve(ctx context.Context, key string, resp *StoredResponse) (*StoredResponse, bool, error)
+	// Put stores the response for the key, replacing its in-flight marker.
+	Put(ctx context.Context, key string, resp *StoredResponse) error
+	// Delete removes the key, so the request can be retried.
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore that keeps the responses in
+// memory, so it only deduplicates requests that reach the same warm
+// container. Lambda runs concurrent requests on separate instances and
+// recycles them at any time; use a shared store, such as a DynamoDB table,
+// for guarantees that hold across instances. Responses expire after the TTL
+// and the oldest ones are dropped once the store holds its maximum number of
+// entries.
+type MemoryIdempotencyStore struct {
+	mu         sync.Mutex
+	entries    map[string]memoryIdempotencyEntry
+	ttl        time.Duration
+	maxEntries int
+}
+
+type memoryIdempotencyEntry struct {
+	resp    *StoredResponse
+	created time.Time
+}
+
+// NewMemoryIdempotencyStore returns an empty MemoryIdempotencyStore that
+// keeps responses for DefaultIdempotencyTTL and holds at most
+// DefaultIdempotencyMaxEntries of them.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		entries:    make(map[string]memoryIdempotencyEntry),
+		ttl:        DefaultIdempotencyTTL,
+		maxEntries: DefaultIdempotencyMaxEntries,
+	}
+}
+
+// SetTTL sets how long responses are kept. A TTL of 0 keeps them until they
+// are dropped for space.
+func (s *MemoryIdempotencyStore) SetTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ttl = ttl
+}
+
+// SetMaxEntries sets the number of keys the store holds before it drops the
+// oldest ones. A limit of 0 disables it.
+func (s *MemoryIdempotencyStore) SetMaxEntries(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxEntries = n
+}
+
+// Reserve implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key string, resp *StoredResponse) (*StoredResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[key]; ok && !s.expired(entry) {
+		return entry.resp, false, nil
+	}
+	s.store(key, resp)
+	return resp, true, nil
+}
+
+// Put implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Put(ctx context.Context, key string, resp *StoredResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(key, resp)
+	return nil
+}
+
+// Delete implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) expired(entry memoryIdempotencyEntry) bool {
+	return s.ttl > 0 && time.Since(entry.created) >= s.ttl
+}
+
+// store saves the response, first dropping expired entries and then the
+// oldest ones when the store is full.
+func (s *MemoryIdempotencyStore) store(key string, resp *StoredResponse) {
+	if _, ok := s.entries[key]; !ok && s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		for k, entry := range s.entries {
+			if s.expired(entry) {
+				delete(s.entries, k)
+			}
+		}
+		for len(s.entries) >= s.maxEntries {
+			oldest, oldestCreated := "", time.Time{}
+			for k, entry := range s.entries {
+				if oldest == "" || entry.created.Before(oldestCreated) {
+					oldest, oldestCreated = k, entry.created
+				}
+			}
+			delete(s.entries, oldest)
+		}
+	}
+	s.entries[key] = memoryIdempotencyEntry{resp: resp, created: time.Now()}
+}
+
+// IdempotencyMiddleware returns a net/http middleware that deduplicates
+// requests carrying an Idempotency-Key header. Keys are scoped to the method
+// and path of the request. The first response for a key is saved in the
+// store, later requests with the same key and body receive the saved
+// response, with an Idempotent-Replayed header, without running the handler.
+// A request that reuses a key with a different body is answered with a 422
+// and one that arrives while the first request with its key is still running
+// with a 409, both as RFC 7807 problem details. Server errors (5xx) are not
+// saved so the client can retry them. Requests without the header and
+// requests the store fails for are sent to the handler unchanged.
+func IdempotencyMiddleware(store IdempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+			if idempotencyKey == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			hash, err := requestBodyHash(r)
+			if err != nil {
+				ProblemResponse(w, http.StatusBadRequest, "", "Could not read the request body")
+				return
+			}
+			key := r.Method + " " + r.URL.Path + " " + idempotencyKey
+			stored, reserved, err := store.Reserve(r.Context(), key, &StoredResponse{RequestHash: hash, InFlight: true})
+			if err != nil {
+				log.Printf("Could not reserve idempotency key %s: %v\n", idempotencyKey, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !reserved {
+				replayStoredResponse(w, stored, hash)
+				return
+			}
+
+			// release the key if the handler panics or the response is not
+			// saved, so the request can be retried
+			saved := false
+			defer func() {
+				if !saved {
+					if err := store.Delete(r.Context(), key); err != nil {
+						log.Printf("Could not release idempotency key %s: %v\n", idempotencyKey, err)
+					}
+				}
+			}()
+
+			bw := &bufferedWriter{ResponseWriter: w}
+			next.ServeHTTP(bw, r)
+			if bw.status == 0 {
+				return
+			}
+
+			body := bw.body.Bytes()
+			if len(body) > 0 && w.Header().Get(contentTypeHeaderKey) == "" {
+				w.Header().Set(contentTypeHeaderKey, http.DetectContentType(body))
+			}
+			if bw.status < http.StatusInternalServerError {
+				resp := &StoredResponse{
+					StatusCode:  bw.status,
+					Header:      w.Header().Clone(),
+					Body:        append([]byte(nil), body...),
+					RequestHash: hash,
+				}
+				if err := store.Put(r.Context(), key, resp); err != nil {
+					log.Printf("Could not store idempotency key %s: %v\n", idempotencyKey, err)
+				} else {
+					saved = true
+				}
+			}
+
+			w.WriteHeader(bw.status)
+			if len(body) > 0 {
+				w.Write(body)
+			}
+		})
+	}
+}
+
+// replayStoredResponse answers a request whose key already has a response or
+// an in-flight marker in the store.
+func replayStoredResponse(w http.ResponseWriter, stored *StoredResponse, hash string) {
+	if stored.RequestHash != hash {
+		ProblemResponse(w, http.StatusUnprocessableEntity, "", "The idempotency key was already used for a different request")
+		return
+	}
+	if stored.InFlight {
+		ProblemResponse(w, http.StatusConflict, "", "A request with the same idempotency key is still being processed")
+		return
+	}
+	for name, values := range stored.Header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(stored.StatusCode)
+	w.Write(stored.Body)
+}
+
+// requestBodyHash returns the hex encoded SHA-256 of the request body and
+// makes the body readable again for the handler.
+func requestBodyHash(r *http.Request) (string, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		sum := sha256.Sum256(nil)
+		return hex.EncodeToString(sum[:]), nil
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,164 @@
+package core_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IdempotencyMiddleware tests", func() {
+	calls := 0
+	payments := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(fmt.Sprintf("{\"payment\": %d}", calls)))
+	})
+
+	newRequest := func(path string, key string) events.APIGatewayProxyRequest {
+		req := getProxyRequest(path, "POST")
+		if key != "" {
+			req.Headers = map[string]string{core.IdempotencyKeyHeader: key}
+		}
+		return req
+	}
+
+	BeforeEach(func() {
+		calls = 0
+	})
+
+	It("Replays the response for a repeated key", func() {
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, core.IdempotencyMiddleware(core.NewMemoryIdempotencyStore())(payments))
+
+		first, err := handler.ProxyWithContext(context.Background(), newRequest("/payments", "key-1"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusCreated).To(Equal(first.StatusCode))
+		Expect("{\"payment\": 1}").To(Equal(first.Body))
+		Expect(first.MultiValueHeaders).ToNot(HaveKey(core.IdempotentReplayedHeader))
+
+		second, err := handler.ProxyWithContext(context.Background(), newRequest("/payments", "key-1"))
+		Expect(err).To(BeNil())
+		Expect(1).To(Equal(calls))
+		Expect(http.StatusCreated).To(Equal(second.StatusCode))
+		Expect(first.Body).To(Equal(second.Body))
+		Expect([]string{"application/json"}).To(Equal(second.MultiValueHeaders["Content-Type"]))
+		Expect([]string{"true"}).To(Equal(second.MultiValueHeaders[core.IdempotentReplayedHeader]))
+
+		other, err := handler.ProxyWithContext(context.Background(), newRequest("/payments", "key-2"))
+		Expect(err).To(BeNil())
+		Expect("{\"payment\": 2}").To(Equal(other.Body))
+	})
+
+	It("Runs the handler for requests without a key and server errors", func() {
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, core.IdempotencyMiddleware(core.NewMemoryIdempotencyStore())(payments))
+
+		for i := 0; i < 2; i++ {
+			_, err := handler.ProxyWithContext(context.Background(), newRequest("/payments", ""))
+			Expect(err).To(BeNil())
+			resp, err := handler.ProxyWithContext(context.Background(), newRequest("/fail", "key-3"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusInternalServerError).To(Equal(resp.StatusCode))
+		}
+		Expect(4).To(Equal(calls))
+	})
+
+	It("Scopes keys to the method and path", func() {
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, core.IdempotencyMiddleware(core.NewMemoryIdempotencyStore())(payments))
+
+		_, err := handler.ProxyWithContext(context.Background(), newRequest("/payments", "key-1"))
+		Expect(err).To(BeNil())
+		resp, err := handler.ProxyWithContext(context.Background(), newRequest("/refunds", "key-1"))
+		Expect(err).To(BeNil())
+		Expect(2).To(Equal(calls))
+		Expect(resp.MultiValueHeaders).ToNot(HaveKey(core.IdempotentReplayedHeader))
+	})
+
+	It("Rejects a key reused with a different body", func() {
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, core.IdempotencyMiddleware(core.NewMemoryIdempotencyStore())(payments))
+
+		req := newRequest("/payments", "key-1")
+		req.Body = `{"amount": 10}`
+		_, err := handler.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+
+		req.Body = `{"amount": 1000}`
+		resp, err := handler.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect(1).To(Equal(calls))
+		Expect(http.StatusUnprocessableEntity).To(Equal(resp.StatusCode))
+		Expect([]string{"application/problem+json"}).To(Equal(resp.MultiValueHeaders["Content-Type"]))
+	})
+
+	It("Answers a duplicate of a request still in flight with a conflict", func() {
+		started, release := make(chan struct{}), make(chan struct{})
+		slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusCreated)
+		})
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, core.IdempotencyMiddleware(core.NewMemoryIdempotencyStore())(slow))
+
+		done := make(chan events.APIGatewayProxyResponse)
+		go func() {
+			resp, _ := handler.ProxyWithContext(context.Background(), newRequest("/payments", "key-1"))
+			done <- resp
+		}()
+		<-started
+
+		duplicate, err := handler.ProxyWithContext(context.Background(), newRequest("/payments", "key-1"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusConflict).To(Equal(duplicate.StatusCode))
+
+		close(release)
+		Expect(http.StatusCreated).To(Equal((<-done).StatusCode))
+	})
+
+	It("Releases the key when the handler writes no response", func() {
+		store := core.NewMemoryIdempotencyStore()
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, core.IdempotencyMiddleware(store)(payments))
+
+		_, err := handler.ProxyWithContext(context.Background(), newRequest("/fail", "key-1"))
+		Expect(err).To(BeNil())
+		_, reserved, err := store.Reserve(context.Background(), "POST /fail key-1", &core.StoredResponse{InFlight: true})
+		Expect(err).To(BeNil())
+		Expect(reserved).To(BeTrue())
+	})
+
+	Context("MemoryIdempotencyStore", func() {
+		It("Expires responses after the TTL", func() {
+			store := core.NewMemoryIdempotencyStore()
+			store.SetTTL(time.Millisecond)
+			_, reserved, _ := store.Reserve(context.Background(), "key", &core.StoredResponse{StatusCode: http.StatusOK})
+			Expect(reserved).To(BeTrue())
+
+			time.Sleep(2 * time.Millisecond)
+			_, reserved, _ = store.Reserve(context.Background(), "key", &core.StoredResponse{StatusCode: http.StatusOK})
+			Expect(reserved).To(BeTrue())
+		})
+
+		It("Drops the oldest responses when full", func() {
+			store := core.NewMemoryIdempotencyStore()
+			store.SetMaxEntries(2)
+			for _, key := range []string{"a", "b", "c"} {
+				Expect(store.Put(context.Background(), key, &core.StoredResponse{StatusCode: http.StatusOK})).To(BeNil())
+				time.Sleep(time.Millisecond)
+			}
+
+			_, reserved, _ := store.Reserve(context.Background(), "a", &core.StoredResponse{})
+			Expect(reserved).To(BeTrue())
+			_, reserved, _ = store.Reserve(context.Background(), "c", &core.StoredResponse{})
+			Expect(reserved).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// setLazyBody makes the request read its body straight from the event body
+// string, decoding base64 while the handler reads, instead of from a decoded
+// copy. ContentLength is the exact decoded size and GetBody returns a new
+// reader over the same string. With a limit greater than 0, bodies that
+// decode to more than limit bytes are rejected with ErrRequestTooLarge
+// without decoding them.
+func setLazyBody(req *http.Request, body string, isBase64 bool, limit int64) error {
+	if body == "" {
+		req.Body = http.NoBody
+		req.GetBody = func() (io.ReadCloser, error) { return http.NoBody, nil }
+		req.ContentLength = 0
+		return nil
+	}
+
+	size := int64(len(body))
+	if isBase64 {
+		size = decodedBase64Len(body)
+	}
+	if limit > 0 && size > limit {
+		return ErrRequestTooLarge
+	}
+
+	newReader := func() (io.ReadCloser, error) {
+		if !isBase64 {
+			return ioutil.NopCloser(strings.NewReader(body)), nil
+		}
+		return ioutil.NopCloser(base64.NewDecoder(base64.StdEncoding, strings.NewReader(body))), nil
+	}
+	req.Body, _ = newReader()
+	req.GetBody = newReader
+	req.ContentLength = size
+	return nil
+}
+
+// decodedBase64Len returns the number of bytes the padded base64 string
+// decodes to, or -1 if its length is not a multiple of 4, which the
+// decoder will reject once it reaches the end.
+func decodedBase64Len(s string) int64 {
+	if len(s)%4 != 0 {
+		return -1
+	}
+	n := int64(len(s) / 4 * 3)
+	switch {
+	case strings.HasSuffix(s, "=="):
+		n -= 2
+	case strings.HasSuffix(s, "="):
+		n--
+	}
+	return n
+}
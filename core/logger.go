@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"log"
+	"strings"
+)
+
+// requestLogger prefixes the messages written to the base logger with the
+// correlation fields of a request.
+type requestLogger struct {
+	base   Logger
+	prefix string
+}
+
+func (l *requestLogger) Printf(format string, v ...interface{}) {
+	l.base.Printf(l.prefix+format, v...)
+}
+
+// RequestLogger returns a Logger that writes to base with the request ID,
+// method and route of the request in ctx in front of every message, as
+// requestId=... method=... route=..., so the lines of a request can be found
+// together. Fields that are not in the context are left out. The route is
+// the route key of HTTP API requests, the resource of REST API requests or
+// the route matched by the framework adapter. A nil base writes to the
+// standard logger of the log package.
+func RequestLogger(ctx context.Context, base Logger) Logger {
+	if base == nil {
+		base = log.Default()
+	}
+
+	var fields []string
+	if requestID, ok := getRequestID(ctx); ok {
+		fields = append(fields, "requestId="+requestID)
+	}
+	if method := requestMethod(ctx); method != "" {
+		fields = append(fields, "method="+method)
+	}
+	if route := contextRoute(ctx); route != "" {
+		fields = append(fields, "route="+route)
+	}
+	if len(fields) == 0 {
+		return base
+	}
+
+	prefix := strings.ReplaceAll(strings.Join(fields, " "), "%", "%%")
+	return &requestLogger{base: base, prefix: prefix + " "}
+}
+
+// requestMethod returns the HTTP method of the event stored in ctx.
+func requestMethod(ctx context.Context) string {
+	switch rc := ctx.Value(ctxKey{}).(type) {
+	case requestContext:
+		return rc.gatewayProxyContext.HTTPMethod
+	case requestContextV2:
+		return rc.gatewayProxyContext.HTTP.Method
+	case requestContextFnURL:
+		return rc.fnURLContext.HTTP.Method
+	}
+	return ""
+}
+
+// contextRoute returns the route template of the request stored in ctx.
+func contextRoute(ctx context.Context) string {
+	if routeKey, ok := GetRouteKey(ctx); ok && routeKey != "$default" {
+		return routeKey
+	}
+	if resource, ok := GetResourceTemplate(ctx); ok {
+		return resource
+	}
+	route, _ := GetMatchedRoute(ctx)
+	return route
+}
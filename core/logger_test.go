@@ -0,0 +1,38 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestLogger tests", func() {
+	It("Prefixes messages with the correlation fields", func() {
+		req := getProxyRequest("/orders/42", "GET")
+		req.Resource = "/orders/{id}"
+		req.RequestContext = getRequestContext()
+		req.RequestContext.RequestID = "req-42"
+		req.RequestContext.HTTPMethod = "GET"
+		accessor := core.RequestAccessor{}
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+
+		var out bytes.Buffer
+		logger := core.RequestLogger(httpReq.Context(), log.New(&out, "", 0))
+		logger.Printf("Loaded order %d at 100%%", 42)
+		Expect("requestId=req-42 method=GET route=/orders/{id} Loaded order 42 at 100%\n").To(Equal(out.String()))
+	})
+
+	It("Returns the base logger without request fields", func() {
+		var out bytes.Buffer
+		base := log.New(&out, "", 0)
+		logger := core.RequestLogger(context.Background(), base)
+		logger.Printf("Starting")
+		Expect("Starting\n").To(Equal(out.String()))
+	})
+})
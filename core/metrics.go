@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// InvocationMetrics are the measurements of a single event sent through a
+// ProxyHandler, reported to the MetricsRecorder set with SetMetricsRecorder.
+type InvocationMetrics struct {
+	// Route is the route of the request, see SetSlowRequestThreshold, or
+	// empty when the event could not be converted.
+	Route string
+	// StatusCode is the status of the response, 0 when none was returned.
+	StatusCode int
+	// ConversionDuration is the time spent converting the event into an
+	// http.Request.
+	ConversionDuration time.Duration
+	// HandlerDuration is the time the http.Handler took to serve the request.
+	HandlerDuration time.Duration
+	// ResponseBytes is the length of the response body as returned to
+	// Lambda, after base64 encoding.
+	ResponseBytes int
+	// Base64Encoded reports whether the response body was base64 encoded.
+	Base64Encoded bool
+	// ColdStart reports whether the event was the first one of the process.
+	ColdStart bool
+	// Err is the error returned with the response, if any.
+	Err error
+}
+
+// MetricsRecorder receives the metrics of every event sent through a
+// ProxyHandler, after the response hooks ran. It is called synchronously,
+// so implementations should not block.
+type MetricsRecorder interface {
+	RecordInvocation(ctx context.Context, metrics InvocationMetrics)
+}
+
+// SetMetricsRecorder sets the recorder that receives the metrics of every
+// event sent through Proxy and ProxyWithContext, to measure the proxy layer
+// separately from the http.Handler. Warm-up pings and rejected events are
+// not recorded.
+func (p *ProxyHandler[ReqT, RespT]) SetMetricsRecorder(recorder MetricsRecorder) {
+	p.metrics = recorder
+}
+
+// recordMetrics completes the metrics with the response and hands them to
+// the recorder.
+func (p *ProxyHandler[ReqT, RespT]) recordMetrics(ctx context.Context, req *http.Request, metrics *InvocationMetrics, resp RespT, err error) {
+	if p.metrics == nil {
+		return
+	}
+	if req != nil {
+		ctx = req.Context()
+		metrics.Route = requestRoute(req)
+		metrics.ColdStart = IsColdStart(ctx)
+	}
+	metrics.StatusCode, metrics.ResponseBytes, metrics.Base64Encoded = responseFields(resp)
+	metrics.Err = err
+	p.metrics.RecordInvocation(ctx, *metrics)
+}
+
+// responseFields returns the status, body length and encoding of the
+// response types of this package.
+func responseFields(resp interface{}) (int, int, bool) {
+	switch r := resp.(type) {
+	case events.APIGatewayProxyResponse:
+		return r.StatusCode, len(r.Body), r.IsBase64Encoded
+	case events.APIGatewayV2HTTPResponse:
+		return r.StatusCode, len(r.Body), r.IsBase64Encoded
+	case events.ALBTargetGroupResponse:
+		return r.StatusCode, len(r.Body), r.IsBase64Encoded
+	case events.LambdaFunctionURLResponse:
+		return r.StatusCode, len(r.Body), r.IsBase64Encoded
+	case CloudFrontResponse:
+		status, _ := strconv.Atoi(r.Status)
+		return status, len(r.Body), r.BodyEncoding == "base64"
+	}
+	return 0, 0, false
+}
+
+// EMFMetricsRecorder is a MetricsRecorder that writes one CloudWatch
+// Embedded Metric Format line per invocation. Each line publishes the
+// Invocations, ColdStarts and Base64Responses counts, the ConversionTime and
+// HandlerTime in milliseconds and the ResponseSize in bytes to the
+// namespace, without dimensions, along with the route, status code and
+// request ID as properties.
+type EMFMetricsRecorder struct {
+	namespace string
+	logger    Logger
+}
+
+// NewEMFMetricsRecorder returns an EMFMetricsRecorder that writes to the
+// logger, or to stdout without a prefix when logger is nil. Lambda sends the
+// lines to CloudWatch Logs, which extracts the metrics.
+func NewEMFMetricsRecorder(namespace string, logger Logger) *EMFMetricsRecorder {
+	if logger == nil {
+		logger = log.New(os.Stdout, "", 0)
+	}
+	return &EMFMetricsRecorder{namespace: namespace, logger: logger}
+}
+
+// RecordInvocation implements MetricsRecorder.
+func (r *EMFMetricsRecorder) RecordInvocation(ctx context.Context, metrics InvocationMetrics) {
+	entry := map[string]interface{}{
+		"Invocations":     1,
+		"ColdStarts":      boolCount(metrics.ColdStart),
+		"Base64Responses": boolCount(metrics.Base64Encoded),
+		"ConversionTime":  float64(metrics.ConversionDuration) / float64(time.Millisecond),
+		"HandlerTime":     float64(metrics.HandlerDuration) / float64(time.Millisecond),
+		"ResponseSize":    metrics.ResponseBytes,
+		"StatusCode":      metrics.StatusCode,
+	}
+	if metrics.Route != "" {
+		entry["Route"] = metrics.Route
+	}
+	if requestID, ok := getRequestID(ctx); ok {
+		entry["RequestId"] = requestID
+	}
+	entry["_aws"] = emfMetadata{
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+		CloudWatchMetrics: []emfMetricDirective{{
+			Namespace:  r.namespace,
+			Dimensions: [][]string{{}},
+			Metrics: []emfMetricDef{
+				{Name: "Invocations", Unit: "Count"},
+				{Name: "ColdStarts", Unit: "Count"},
+				{Name: "Base64Responses", Unit: "Count"},
+				{Name: "ConversionTime", Unit: "Milliseconds"},
+				{Name: "HandlerTime", Unit: "Milliseconds"},
+				{Name: "ResponseSize", Unit: "Bytes"},
+			},
+		}},
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		r.logger.Printf("Could not marshal EMF metrics: %v", err)
+		return
+	}
+	r.logger.Printf("%s", line)
+}
+
+func boolCount(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,128 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type recordedMetrics struct {
+	invocations []core.InvocationMetrics
+}
+
+func (r *recordedMetrics) RecordInvocation(ctx context.Context, metrics core.InvocationMetrics) {
+	r.invocations = append(r.invocations, metrics)
+}
+
+var _ = Describe("MetricsRecorder tests", func() {
+	It("Records the metrics of each invocation", func() {
+		recorder := &recordedMetrics{}
+		proxy := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(5 * time.Millisecond)
+			w.Header().Set("Content-Type", "image/png")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte{0x89, 0x50, 0x4e, 0x47})
+		}))
+		proxy.SetMetricsRecorder(recorder)
+
+		req := getProxyRequestV2("/items", "POST")
+		req.RouteKey = "POST /items"
+		resp, err := proxy.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+
+		Expect(1).To(Equal(len(recorder.invocations)))
+		metrics := recorder.invocations[0]
+		Expect("POST /items").To(Equal(metrics.Route))
+		Expect(http.StatusCreated).To(Equal(metrics.StatusCode))
+		Expect(len(resp.Body)).To(Equal(metrics.ResponseBytes))
+		Expect(metrics.Base64Encoded).To(BeTrue())
+		Expect(metrics.HandlerDuration).To(BeNumerically(">=", 5*time.Millisecond))
+		Expect(metrics.ConversionDuration).To(BeNumerically(">", 0))
+		Expect(metrics.Err).To(BeNil())
+	})
+
+	It("Records the error returned with the response", func() {
+		recorder := &recordedMetrics{}
+		proxy := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		proxy.SetMetricsRecorder(recorder)
+
+		req := getProxyRequest("/items", "GET")
+		req.Resource = "/items"
+		_, err := proxy.Proxy(req)
+		Expect(err).ToNot(BeNil())
+
+		Expect(1).To(Equal(len(recorder.invocations)))
+		Expect("GET /items").To(Equal(recorder.invocations[0].Route))
+		Expect(http.StatusGatewayTimeout).To(Equal(recorder.invocations[0].StatusCode))
+		Expect(err).To(Equal(recorder.invocations[0].Err))
+	})
+
+	It("Does not record warm-up pings", func() {
+		recorder := &recordedMetrics{}
+		proxy := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		proxy.SetWarmupConfig(core.WarmupConfig{})
+		proxy.SetMetricsRecorder(recorder)
+
+		_, err := proxy.Proxy(events.APIGatewayProxyRequest{})
+		Expect(err).To(BeNil())
+		Expect(0).To(Equal(len(recorder.invocations)))
+	})
+
+	It("Writes EMF lines", func() {
+		var buf bytes.Buffer
+		proxy := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hello"))
+		}))
+		proxy.SetMetricsRecorder(core.NewEMFMetricsRecorder("OrdersAPI", log.New(&buf, "", 0)))
+
+		req := getProxyRequestV2("/items/42", "GET")
+		req.RouteKey = "GET /items/{id}"
+		req.RequestContext.RequestID = "req-1"
+		_, err := proxy.ProxyWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		Expect(1).To(Equal(len(lines)))
+
+		entry := struct {
+			AWS struct {
+				CloudWatchMetrics []struct {
+					Namespace  string
+					Dimensions [][]string
+					Metrics    []struct{ Name, Unit string }
+				}
+			} `json:"_aws"`
+			Invocations     int
+			Base64Responses int
+			ConversionTime  *float64
+			HandlerTime     *float64
+			ResponseSize    int
+			StatusCode      int
+			Route           string
+			RequestID       string `json:"RequestId"`
+		}{}
+		Expect(json.Unmarshal(lines[0], &entry)).To(BeNil())
+		Expect(1).To(Equal(len(entry.AWS.CloudWatchMetrics)))
+		directive := entry.AWS.CloudWatchMetrics[0]
+		Expect("OrdersAPI").To(Equal(directive.Namespace))
+		Expect([][]string{{}}).To(Equal(directive.Dimensions))
+		Expect(6).To(Equal(len(directive.Metrics)))
+		Expect(1).To(Equal(entry.Invocations))
+		Expect(0).To(Equal(entry.Base64Responses))
+		Expect(entry.ConversionTime).ToNot(BeNil())
+		Expect(entry.HandlerTime).ToNot(BeNil())
+		Expect(5).To(Equal(entry.ResponseSize))
+		Expect(http.StatusOK).To(Equal(entry.StatusCode))
+		Expect("GET /items/{id}").To(Equal(entry.Route))
+		Expect("req-1").To(Equal(entry.RequestID))
+	})
+})
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NegotiateContentType picks the content type to respond with from the
+// offered types based on the Accept header of the request and its quality
+// values. Exact matches take precedence over type/* and */* ranges, and
+// offers with the same quality are picked in the order they are listed.
+// Returns the first offer when the request has no Accept header, and
+// defaultType when none of the offers is acceptable.
+func NegotiateContentType(r *http.Request, offered []string, defaultType string) string {
+	accept := strings.Join(r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(accept) == "" {
+		if len(offered) > 0 {
+			return offered[0]
+		}
+		return defaultType
+	}
+
+	ranges := parseAccept(accept)
+	best := defaultType
+	bestQuality := 0.0
+	for _, offer := range offered {
+		if q := acceptQuality(ranges, offer); q > bestQuality {
+			best = offer
+			bestQuality = q
+		}
+	}
+	return best
+}
+
+// notAcceptableProblem is the problem details body written by NotAcceptable.
+type notAcceptableProblem struct {
+	problem
+	Offered []string `json:"offered"`
+}
+
+// NotAcceptable writes a 406 Not Acceptable response with an
+// application/problem+json body listing the offered content types, for
+// handlers that could not negotiate a content type the client accepts.
+func NotAcceptable(w http.ResponseWriter, offered ...string) {
+	if offered == nil {
+		offered = []string{}
+	}
+	writeProblem(w, http.StatusNotAcceptable, notAcceptableProblem{
+		problem: newProblem(http.StatusNotAcceptable, "", "None of the offered content types is acceptable"),
+		Offered: offered,
+	})
+}
+
+// acceptRange is a media range of an Accept header with its quality.
+type acceptRange struct {
+	mediaType string
+	subType   string
+	quality   float64
+}
+
+func parseAccept(accept string) []acceptRange {
+	ranges := make([]acceptRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+		slash := strings.Index(mediaRange, "/")
+		if slash < 0 {
+			continue
+		}
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		ranges = append(ranges, acceptRange{
+			mediaType: mediaRange[:slash],
+			subType:   mediaRange[slash+1:],
+			quality:   quality,
+		})
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific range matching the
+// content type, or 0 if no range matches.
+func acceptQuality(ranges []acceptRange, contentType string) float64 {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	slash := strings.Index(contentType, "/")
+	if slash < 0 {
+		return 0
+	}
+	mediaType, subType := contentType[:slash], contentType[slash+1:]
+
+	quality := 0.0
+	specificity := -1
+	for _, ar := range ranges {
+		s := -1
+		switch {
+		case ar.mediaType == mediaType && ar.subType == subType:
+			s = 2
+		case ar.mediaType == mediaType && ar.subType == "*":
+			s = 1
+		case ar.mediaType == "*" && ar.subType == "*":
+			s = 0
+		}
+		if s > specificity {
+			specificity = s
+			quality = ar.quality
+		}
+	}
+	return quality
+}
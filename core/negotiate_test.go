@@ -0,0 +1,54 @@
+package core_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NegotiateContentType tests", func() {
+	offered := []string{"application/json", "text/html"}
+
+	newRequest := func(accept string) *http.Request {
+		req, _ := http.NewRequest("GET", "/orders", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		return req
+	}
+
+	It("Selects the offer with the highest quality", func() {
+		Expect("text/html").To(Equal(core.NegotiateContentType(newRequest("application/json;q=0.9, text/html"), offered, "text/plain")))
+		Expect("application/json").To(Equal(core.NegotiateContentType(newRequest("application/json, text/html;q=0.5"), offered, "text/plain")))
+	})
+
+	It("Prefers the most specific range", func() {
+		Expect("text/html").To(Equal(core.NegotiateContentType(newRequest("text/*, application/*;q=0.2"), offered, "text/plain")))
+		Expect("text/html").To(Equal(core.NegotiateContentType(newRequest("*/*;q=0.8, application/json;q=0.1"), offered, "text/plain")))
+	})
+
+	It("Falls back to the first offer and the default type", func() {
+		Expect("application/json").To(Equal(core.NegotiateContentType(newRequest(""), offered, "text/plain")))
+		Expect("application/json").To(Equal(core.NegotiateContentType(newRequest("*/*"), offered, "text/plain")))
+		Expect("text/plain").To(Equal(core.NegotiateContentType(newRequest("image/png"), offered, "text/plain")))
+		Expect("text/plain").To(Equal(core.NegotiateContentType(newRequest("application/json;q=0, text/html;q=0"), offered, "text/plain")))
+	})
+
+	It("Writes a problem response listing the offers", func() {
+		w := httptest.NewRecorder()
+		core.NotAcceptable(w, offered...)
+		Expect(http.StatusNotAcceptable).To(Equal(w.Code))
+		Expect("application/problem+json").To(Equal(w.Header().Get("Content-Type")))
+
+		var problem map[string]interface{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &problem)).To(BeNil())
+		Expect("Not Acceptable").To(Equal(problem["title"]))
+		Expect(float64(http.StatusNotAcceptable)).To(Equal(problem["status"]))
+		Expect([]interface{}{"application/json", "text/html"}).To(Equal(problem["offered"]))
+	})
+})
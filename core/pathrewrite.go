@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// BasePathVariable is the name of the environment variable with the base
+// path the request accessors strip from the request path when none was set
+// with StripBasePath, for example the stage or the base path mapping of a
+// custom domain name: /prod.
+const BasePathVariable = "GO_API_BASE_PATH"
+
+// PathRewrite is a rule that rewrites the request path before routing. When
+// the pattern matches the path, the path is replaced with the result of
+// Pattern.ReplaceAllString, so the replacement can reference capture groups
+// as $1 or ${name}.
+type PathRewrite struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// NewPathRewrite compiles the pattern into a PathRewrite. It panics if the
+// pattern is invalid, like regexp.MustCompile.
+func NewPathRewrite(pattern string, replacement string) PathRewrite {
+	return PathRewrite{Pattern: regexp.MustCompile(pattern), Replacement: replacement}
+}
+
+// basePathRewrite is the prefix replaced at the start of the request path:
+// from in the path of the event and to in the path the handler routes on.
+type basePathRewrite struct {
+	from string
+	to   string
+}
+
+type basePathKey struct{}
+
+// configuredBasePath returns the base path set with StripBasePath, or the
+// one in the BasePathVariable environment variable.
+func configuredBasePath(basePath string) string {
+	if basePath != "" {
+		return basePath
+	}
+	if envBasePath, ok := os.LookupEnv(BasePathVariable); ok {
+		return normalizeBasePath(envBasePath)
+	}
+	return ""
+}
+
+// stripPathPrefix removes the base path from the start of the path.
+func stripPathPrefix(path string, basePath string) (string, basePathRewrite) {
+	if len(basePath) > 1 && strings.HasPrefix(path, basePath) {
+		return strings.Replace(path, basePath, "", 1), basePathRewrite{from: basePath}
+	}
+	return path, basePathRewrite{}
+}
+
+// applyPathRewrites rewrites the path with the first rule that matches it.
+func applyPathRewrites(path string, rules []PathRewrite) string {
+	for _, rule := range rules {
+		if rule.Pattern != nil && rule.Pattern.MatchString(path) {
+			return rule.Pattern.ReplaceAllString(path, rule.Replacement)
+		}
+	}
+	return path
+}
+
+// withBasePathRewrite stores the base path replaced in the request path in
+// the context of the request, for the Location rewrite of the ProxyHandler.
+func withBasePathRewrite(req *http.Request, rewrite basePathRewrite) *http.Request {
+	if rewrite.from == "" {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), basePathKey{}, rewrite))
+}
+
+// GetStrippedBasePath returns the base path that was removed from the start
+// of the request path, such as the path set with StripBasePath or a base
+// path mapping key. Returns false when the path was routed unchanged.
+func GetStrippedBasePath(ctx context.Context) (string, bool) {
+	rewrite, ok := ctx.Value(basePathKey{}).(basePathRewrite)
+	return rewrite.from, ok
+}
+
+// rewriteLocation adds the base path stripped from the request path back to
+// the Location header of a response, when it points to an absolute path on
+// the host of the request.
+func rewriteLocation(headers http.Header, req *http.Request) {
+	location := headers.Get("Location")
+	rewrite, ok := req.Context().Value(basePathKey{}).(basePathRewrite)
+	if location == "" || !ok {
+		return
+	}
+	u, err := url.Parse(location)
+	if err != nil || (u.Host != "" && u.Host != req.Host) || !strings.HasPrefix(u.Path, "/") {
+		return
+	}
+	if rewrite.to != "" {
+		if u.Path != rewrite.to && !strings.HasPrefix(u.Path, rewrite.to+"/") {
+			return
+		}
+		u.Path = strings.TrimPrefix(u.Path, rewrite.to)
+		u.RawPath = ""
+	}
+	u.Path = rewrite.from + u.Path
+	if u.RawPath != "" {
+		u.RawPath = rewrite.from + u.RawPath
+	}
+	headers.Set("Location", u.String())
+}
@@ -0,0 +1,112 @@
+package core_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Path rewrite tests", func() {
+	Context("Base path from the environment", func() {
+		AfterEach(func() {
+			os.Unsetenv(core.BasePathVariable)
+		})
+
+		It("Strips the base path set in the environment", func() {
+			os.Setenv(core.BasePathVariable, "prod/")
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("/prod/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/orders").To(Equal(httpReq.URL.Path))
+
+			basePath, ok := core.GetStrippedBasePath(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("/prod").To(Equal(basePath))
+		})
+
+		It("Prefers the base path set with StripBasePath", func() {
+			os.Setenv(core.BasePathVariable, "/prod")
+			accessor := core.RequestAccessor{}
+			accessor.StripBasePath("/api")
+			httpReq, err := accessor.ProxyEventToHTTPRequest(getProxyRequest("/api/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/orders").To(Equal(httpReq.URL.Path))
+		})
+	})
+
+	Context("Rewrite rules", func() {
+		It("Applies the first matching rule after stripping the base path", func() {
+			accessor := core.RequestAccessorFnURL{}
+			accessor.StripBasePath("/prod")
+			accessor.SetPathRewrites(
+				core.NewPathRewrite("^/v1/(.*)$", "/api/$1"),
+				core.NewPathRewrite("^/v1/orders$", "/never"),
+			)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getFunctionURLRequest("/prod/v1/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/api/orders").To(Equal(httpReq.URL.Path))
+		})
+
+		It("Leaves paths no rule matches unchanged", func() {
+			accessor := core.RequestAccessorALB{}
+			accessor.SetPathRewrites(core.NewPathRewrite("^/v1/(.*)$", "/api/$1"))
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getALBRequest("/health", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/health").To(Equal(httpReq.URL.Path))
+			_, ok := core.GetStrippedBasePath(httpReq.Context())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("Location rewrite", func() {
+		redirect := func(location string) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, location, http.StatusFound)
+			})
+		}
+
+		It("Adds the stripped base path back to redirects", func() {
+			accessor := &core.RequestAccessor{}
+			accessor.StripBasePath("/prod")
+			handler := core.NewAPIGatewayProxyHandler(accessor, redirect("/login?next=%2Forders"))
+			handler.SetRewriteRedirects(true)
+
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/prod/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.StatusFound).To(Equal(resp.StatusCode))
+			Expect([]string{"/prod/login?next=%2Forders"}).To(Equal(resp.MultiValueHeaders["Location"]))
+		})
+
+		It("Undoes base path mappings", func() {
+			accessor := &core.RequestAccessor{}
+			accessor.SetBasePathMappings(map[string]string{"/shop": "/store"})
+			handler := core.NewAPIGatewayProxyHandler(accessor, redirect("/store/cart"))
+			handler.SetRewriteRedirects(true)
+
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/shop/items", "GET"))
+			Expect(err).To(BeNil())
+			Expect([]string{"/shop/cart"}).To(Equal(resp.MultiValueHeaders["Location"]))
+		})
+
+		It("Leaves redirects to other hosts and disabled handlers untouched", func() {
+			accessor := &core.RequestAccessorV2{}
+			accessor.StripBasePath("/prod")
+			handler := core.NewAPIGatewayV2ProxyHandler(accessor, redirect("https://example.com/login"))
+			handler.SetRewriteRedirects(true)
+
+			resp, err := handler.ProxyWithContext(context.Background(), getProxyRequestV2("/prod/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect([]string{"https://example.com/login"}).To(Equal(resp.MultiValueHeaders["Location"]))
+
+			handler = core.NewAPIGatewayV2ProxyHandler(accessor, redirect("/login"))
+			resp, err = handler.ProxyWithContext(context.Background(), getProxyRequestV2("/prod/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect([]string{"/login"}).To(Equal(resp.MultiValueHeaders["Location"]))
+		})
+	})
+})
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+)
+
+const (
+	// maxPresizedBodyBytes caps the body buffer allocated up front for the
+	// writers the pool creates.
+	maxPresizedBodyBytes = 64 << 10
+
+	// maxPooledBodyBytes is the capacity above which Reset drops the body
+	// buffer instead of keeping it, so one large response doesn't pin its
+	// memory in the pool.
+	maxPooledBodyBytes = 1 << 20
+)
+
+var responseWriterPool = sync.Pool{
+	New: func() interface{} {
+		w := NewProxyResponseWriter()
+		size := MaxObservedResponseBytes()
+		if size > maxPresizedBodyBytes {
+			size = maxPresizedBodyBytes
+		}
+		w.body.Grow(size)
+		return w
+	},
+}
+
+// AcquireProxyResponseWriter returns a ProxyResponseWriter from a pool of
+// writers released with ReleaseProxyResponseWriter, or a new one whose body
+// buffer is sized for the largest response seen so far, up to 64KB. The
+// writer behaves like one returned by NewProxyResponseWriter.
+func AcquireProxyResponseWriter() *ProxyResponseWriter {
+	return responseWriterPool.Get().(*ProxyResponseWriter)
+}
+
+// ReleaseProxyResponseWriter resets the writer and returns it to the pool of
+// AcquireProxyResponseWriter. Responses already returned by GetProxyResponse
+// remain valid, but the writer must not be used again, neither by the caller
+// nor by goroutines the handler left running.
+func ReleaseProxyResponseWriter(w *ProxyResponseWriter) {
+	w.Reset()
+	responseWriterPool.Put(w)
+}
+
+// Reset discards the response written so far and every option set on the
+// writer, leaving it like a writer returned by NewProxyResponseWriter. The
+// body buffer is kept for the next response, the header map is replaced
+// because the responses returned by GetProxyResponse share it.
+func (r *ProxyResponseWriter) Reset() {
+	body := r.body
+	body.Reset()
+	if body.Cap() > maxPooledBodyBytes {
+		body = bytes.Buffer{}
+	}
+	*r = ProxyResponseWriter{
+		headers: make(http.Header),
+		body:    body,
+		status:  defaultStatusCode,
+	}
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"bytes"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Response writer pool tests", func() {
+	It("Resets the writer to the state of a new one", func() {
+		response := NewProxyResponseWriter()
+		response.SetLowercaseHeaderKeys(true)
+		response.Header().Set("X-Order", "42")
+		response.WriteHeader(http.StatusCreated)
+		response.Write([]byte("first"))
+		first, err := response.GetProxyResponse()
+		Expect(err).To(BeNil())
+
+		response.Reset()
+		Expect(0).To(Equal(len(response.Header())))
+		response.Write([]byte("second"))
+		second, err := response.GetProxyResponse()
+		Expect(err).To(BeNil())
+
+		Expect(http.StatusOK).To(Equal(second.StatusCode))
+		Expect("second").To(Equal(second.Body))
+		Expect(second.MultiValueHeaders).To(HaveKey("Content-Type"))
+		Expect(second.MultiValueHeaders).ToNot(HaveKey("X-Order"))
+		Expect("first").To(Equal(first.Body))
+		Expect([]string{"42"}).To(Equal(first.MultiValueHeaders["x-order"]))
+	})
+
+	It("Keeps the body buffer up to the pooling limit", func() {
+		response := NewProxyResponseWriter()
+		response.Write(bytes.Repeat([]byte("a"), 1024))
+		response.Reset()
+		Expect(response.body.Cap()).To(BeNumerically(">=", 1024))
+
+		response.Write(bytes.Repeat([]byte("a"), maxPooledBodyBytes+1))
+		response.Reset()
+		Expect(0).To(Equal(response.body.Cap()))
+	})
+
+	It("Acquires writers that behave like new ones", func() {
+		response := AcquireProxyResponseWriter()
+		response.Header().Set("X-Order", "42")
+		response.Write([]byte("first"))
+		_, err := response.GetProxyResponse()
+		Expect(err).To(BeNil())
+		ReleaseProxyResponseWriter(response)
+
+		response = AcquireProxyResponseWriter()
+		Expect(0).To(Equal(len(response.Header())))
+		response.Write([]byte("second"))
+		proxyResponse, err := response.GetProxyResponse()
+		Expect(err).To(BeNil())
+		Expect("second").To(Equal(proxyResponse.Body))
+	})
+})
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// problemContentType is the media type of RFC 7807 problem details.
+const problemContentType = "application/problem+json"
+
+// problem holds the members of an RFC 7807 problem details object.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func newProblem(status int, title string, detail string) problem {
+	if title == "" {
+		title = http.StatusText(status)
+	}
+	return problem{Type: "about:blank", Title: title, Status: status, Detail: detail}
+}
+
+// ProblemResponse writes an error response with the status and an RFC 7807
+// application/problem+json body holding the title and detail. The title
+// defaults to the status text when empty and the detail is omitted when
+// empty. The problem type is about:blank.
+func ProblemResponse(w http.ResponseWriter, status int, title, detail string) {
+	writeProblem(w, status, newProblem(status, title, detail))
+}
+
+// writeProblem writes the JSON encoded problem body with the status.
+func writeProblem(w http.ResponseWriter, status int, body interface{}) {
+	encoded, _ := json.Marshal(body)
+	w.Header().Set(contentTypeHeaderKey, problemContentType)
+	w.WriteHeader(status)
+	w.Write(encoded)
+}
@@ -0,0 +1,47 @@
+package core_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProblemResponse tests", func() {
+	It("Writes an RFC 7807 problem body", func() {
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			core.ProblemResponse(w, http.StatusConflict, "Order already paid", "Order 42 was paid on 2024-01-02")
+		}))
+
+		resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/orders/42/pay", "POST"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusConflict).To(Equal(resp.StatusCode))
+		Expect([]string{"application/problem+json"}).To(Equal(resp.MultiValueHeaders["Content-Type"]))
+
+		var problem map[string]interface{}
+		Expect(json.Unmarshal([]byte(resp.Body), &problem)).To(BeNil())
+		Expect("about:blank").To(Equal(problem["type"]))
+		Expect("Order already paid").To(Equal(problem["title"]))
+		Expect(float64(http.StatusConflict)).To(Equal(problem["status"]))
+		Expect("Order 42 was paid on 2024-01-02").To(Equal(problem["detail"]))
+	})
+
+	It("Defaults the title to the status text", func() {
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			core.ProblemResponse(w, http.StatusServiceUnavailable, "", "")
+		}))
+
+		resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/orders", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusServiceUnavailable).To(Equal(resp.StatusCode))
+
+		var problem map[string]interface{}
+		Expect(json.Unmarshal([]byte(resp.Body), &problem)).To(BeNil())
+		Expect("Service Unavailable").To(Equal(problem["title"]))
+		Expect(problem).ToNot(HaveKey("detail"))
+	})
+})
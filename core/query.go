@@ -0,0 +1,69 @@
+package core
+
+import (
+	"sort"
+	"strings"
+)
+
+// exactQueryString rebuilds a query string from the query string parameters
+// of an event in a stable order: keys in alphabetical order, the values of
+// each key in the order of the event. Keys and values are passed through
+// escape, see SetExactQueryString.
+func exactQueryString(params map[string][]string, escape func(string) string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		for _, value := range params[key] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(escape(key))
+			b.WriteByte('=')
+			b.WriteString(escape(value))
+		}
+	}
+	return b.String()
+}
+
+// singleValueParams returns the single-value query string parameters of an
+// event as multi-value parameters.
+func singleValueParams(params map[string]string) map[string][]string {
+	multiValue := make(map[string][]string, len(params))
+	for key, value := range params {
+		multiValue[key] = []string{value}
+	}
+	return multiValue
+}
+
+// escapeQueryComponent percent-encodes every byte of s except the unreserved
+// characters of RFC 3986, letters, digits, "-", ".", "_" and "~". Unlike
+// url.QueryEscape it encodes a space as "%20" rather than "+", which matches
+// the escaping of encodeURIComponent and of the SigV4 and OAuth signature
+// base strings, and keeps reserved characters such as "/", ";" and "&" of a
+// decoded value encoded so they remain part of the value.
+func escapeQueryComponent(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&15])
+	}
+	return b.String()
+}
+
+// verbatimQueryComponent returns s unchanged, for the query string
+// parameters of Application Load Balancer events, which are not decoded.
+func verbatimQueryComponent(s string) string {
+	return s
+}
@@ -0,0 +1,88 @@
+package core_test
+
+import (
+	"context"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Query string tests", func() {
+	Context("REST API exact query strings", func() {
+		accessor := core.RequestAccessor{}
+		accessor.SetExactQueryString(true)
+
+		It("Keeps repeated keys in order and sorts the keys", func() {
+			req := getProxyRequest("/search", "GET")
+			req.MultiValueQueryStringParameters = map[string][]string{
+				"tag": {"b", "a", "b"},
+				"q":   {"go lang"},
+			}
+			for i := 0; i < 10; i++ {
+				httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+				Expect(err).To(BeNil())
+				Expect("q=go%20lang&tag=b&tag=a&tag=b").To(Equal(httpReq.URL.RawQuery))
+				Expect([]string{"b", "a", "b"}).To(Equal(httpReq.URL.Query()["tag"]))
+			}
+		})
+
+		It("Keeps slashes and semicolons of values encoded", func() {
+			req := getProxyRequest("/oauth/callback", "GET")
+			req.MultiValueQueryStringParameters = map[string][]string{
+				"redirect_uri": {"https://example.com/cb?x=1"},
+				"filter":       {"a;b"},
+				"a+b":          {"c&d=e"},
+			}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect("a%2Bb=c%26d%3De&filter=a%3Bb&redirect_uri=https%3A%2F%2Fexample.com%2Fcb%3Fx%3D1").To(Equal(httpReq.URL.RawQuery))
+
+			query := httpReq.URL.Query()
+			Expect("https://example.com/cb?x=1").To(Equal(query.Get("redirect_uri")))
+			Expect("a;b").To(Equal(query.Get("filter")))
+			Expect("c&d=e").To(Equal(query.Get("a+b")))
+		})
+
+		It("Rebuilds single value parameters", func() {
+			req := getProxyRequest("/search", "GET")
+			req.QueryStringParameters = map[string]string{"z": "1", "path": "a/b"}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect("path=a%2Fb&z=1").To(Equal(httpReq.URL.RawQuery))
+		})
+	})
+
+	Context("ALB exact query strings", func() {
+		It("Copies the parameters without encoding them again", func() {
+			accessor := core.RequestAccessorALB{}
+			accessor.SetExactQueryString(true)
+
+			req := getALBRequest("/files", "GET")
+			req.MultiValueQueryStringParameters = map[string][]string{
+				"path":      {"docs%2Freadme.md"},
+				"Signature": {"abc%2Bdef%3D"},
+				"id":        {"2", "1"},
+			}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect("Signature=abc%2Bdef%3D&id=2&id=1&path=docs%2Freadme.md").To(Equal(httpReq.URL.RawQuery))
+			Expect("docs/readme.md").To(Equal(httpReq.URL.Query().Get("path")))
+			Expect("abc+def=").To(Equal(httpReq.URL.Query().Get("Signature")))
+		})
+	})
+
+	Context("HTTP API raw query strings", func() {
+		It("Uses the raw query string of the event", func() {
+			accessor := core.RequestAccessorV2{}
+			req := getProxyRequestV2("/files", "GET")
+			req.RawQueryString = "z=1&path=docs%2Freadme.md&a=x;y&z=0"
+			req.QueryStringParameters = map[string]string{"z": "1,0", "path": "docs/readme.md", "a": "x;y"}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect("z=1&path=docs%2Freadme.md&a=x;y&z=0").To(Equal(httpReq.URL.RawQuery))
+			Expect([]string{"1", "0"}).To(Equal(httpReq.URL.Query()["z"]))
+		})
+	})
+})
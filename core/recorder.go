@@ -0,0 +1,72 @@
+package core
+
+import (
+	"net/http"
+	"sync"
+)
+
+// responseRecorder wraps the http.ResponseWriter passed to a middleware and
+// records the status and the number of body bytes the handler writes
+// through it. It works with any writer, the proxy response writers of every
+// event type as well as the buffering writers of other middlewares.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	// mu guards status and size against handlers that write from another
+	// goroutine
+	mu     sync.Mutex
+	status int
+	size   int
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w}
+}
+
+// WriteHeader records the first final status and passes it on.
+func (r *responseRecorder) WriteHeader(status int) {
+	r.mu.Lock()
+	if r.status == 0 && !isInformational(status) {
+		r.status = status
+	}
+	r.mu.Unlock()
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write counts the bytes written, setting the status to 200 OK if none was
+// written before.
+func (r *responseRecorder) Write(body []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(body)
+	r.mu.Lock()
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.size += n
+	r.mu.Unlock()
+	return n, err
+}
+
+// Flush implements http.Flusher when the wrapped writer does.
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		r.mu.Lock()
+		if r.status == 0 {
+			r.status = http.StatusOK
+		}
+		r.mu.Unlock()
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// stats returns the recorded status, 0 when the handler wrote nothing, and
+// the number of body bytes written.
+func (r *responseRecorder) stats() (int, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status, r.size
+}
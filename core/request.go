@@ -4,15 +4,21 @@ package core
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -35,12 +41,57 @@ const (
 	// API Gateway stage variables. To access the stage variable values
 	// use the GetAPIGatewayStageVars method of the RequestAccessor object.
 	APIGwStageVarsHeader = "X-GoLambdaProxy-ApiGw-StageVars"
+
+	// ContextKeyStageVars is the context key under which the
+	// EventToRequestWithContext methods store the API Gateway stage
+	// variables of the event. Use GetStageVariables to read them.
+	ContextKeyStageVars = contextKey("stageVariables")
 )
 
+// ErrTooManyParams is returned by the request accessors when an event has
+// more query string parameters than the limit set with SetMaxQueryParams.
+var ErrTooManyParams = errors.New("Too many query string parameters in request")
+
+// ErrTooManyHeaders is returned by the RequestAccessor when an event has more
+// headers than the limit set with SetMaxHeaderCount.
+var ErrTooManyHeaders = errors.New("Too many headers in request")
+
+// ErrRequestTooLarge is returned by the RequestAccessor when the decoded body
+// of an event is larger than the limit set with SetMaxDecodedBodyBytes.
+var ErrRequestTooLarge = errors.New("Request body too large")
+
+// DefaultMaxDecompressedBodyBytes caps the decompressed size of request
+// bodies when SetAutoDecompressRequest is enabled and no limit was set with
+// SetMaxDecodedBodyBytes.
+const DefaultMaxDecompressedBodyBytes = 32 << 20
+
 // RequestAccessor objects give access to custom API Gateway properties
 // in the request.
 type RequestAccessor struct {
-	stripBasePath string
+	stripBasePath    string
+	protocol         string
+	basePathMappings map[string]string
+	useProxyPath     bool
+	methodOverride   bool
+	maxQueryParams   int
+	truncateQuery    bool
+	decompress       bool
+	generateID       bool
+	newRequestID     func() string
+	splitHeaders     map[string]bool
+	strictHeaders    bool
+	captureEvent     func(events.APIGatewayProxyRequest)
+	maxHeaders       int
+	decodePlus       bool
+	maxBodyBytes     int64
+	collapseSlashes  bool
+	rewindableBody   bool
+	stripAuth        bool
+	defaultHeaders   http.Header
+	exactQuery       bool
+	lazyBody         bool
+	pathRewrites     []PathRewrite
+	preserveMethod   bool
 }
 
 // GetAPIGatewayContext extracts the API Gateway context object from a
@@ -103,6 +154,207 @@ func (r *RequestAccessor) StripBasePath(basePath string) string {
 	return newBasePath
 }
 
+// SetBasePathMappings configures the accessor for a custom domain name that
+// maps several base paths to the same function. Keys are the incoming path
+// prefixes and values the prefix they are replaced with, often an empty string.
+// A mapping matches when the request path equals the key or continues with a
+// "/" after it; the longest matching key wins. The matched key is available to
+// handlers through GetMatchedBasePath. When a mapping matches, the path set
+// with StripBasePath is not removed.
+func (r *RequestAccessor) SetBasePathMappings(mappings map[string]string) {
+	r.basePathMappings = make(map[string]string, len(mappings))
+	for prefix, target := range mappings {
+		r.basePathMappings[normalizeBasePath(prefix)] = normalizeBasePath(target)
+	}
+}
+
+// SetPathRewrites sets rules that rewrite the request path before routing,
+// after the base path was stripped. The first rule whose pattern matches the
+// path is applied, for example
+// NewPathRewrite("^/v1/(.*)$", "/api/$1").
+func (r *RequestAccessor) SetPathRewrites(rules ...PathRewrite) {
+	r.pathRewrites = rules
+}
+
+// SetUseProxyPathForRouting makes the accessor route on the path captured by
+// a {proxy+} resource instead of the full event path. When enabled and the
+// event has a "proxy" path parameter, the request path becomes "/" followed
+// by its value. Events without the parameter keep the full path.
+func (r *RequestAccessor) SetUseProxyPathForRouting(useProxyPath bool) {
+	r.useProxyPath = useProxyPath
+}
+
+// SetHonorMethodOverride makes the accessor route POST requests with an
+// X-HTTP-Method-Override header as the method in the header, for clients
+// behind proxies that only let GET and POST through. Only PUT, PATCH and
+// DELETE overrides are honored, all other values are ignored.
+func (r *RequestAccessor) SetHonorMethodOverride(honor bool) {
+	r.methodOverride = honor
+}
+
+// SetMaxQueryParams limits the number of distinct query string parameters
+// an event can have. Events with more parameters are rejected with
+// ErrTooManyParams, or truncated to the first n parameters in alphabetical
+// order if SetTruncateQueryParams was enabled. A limit of 0 disables the check.
+func (r *RequestAccessor) SetMaxQueryParams(n int) {
+	r.maxQueryParams = n
+}
+
+// SetExactQueryString makes the accessor rebuild the query string in a
+// stable order, keys in alphabetical order and the values of each key in
+// the order of the event, with every reserved character of the keys and
+// values percent-encoded and spaces encoded as %20. API Gateway only sends
+// the decoded parameters of REST API events, so the original order of the
+// keys and the encoding chosen by the client cannot be recovered, but the
+// query string is the same for every invocation with the same parameters,
+// which signature checks of signed URLs and OAuth callbacks rely on. By
+// default the keys are in random order and encoded with url.QueryEscape.
+func (r *RequestAccessor) SetExactQueryString(exact bool) {
+	r.exactQuery = exact
+}
+
+// SetDecodePlusInPath makes the accessor decode "+" in the request path to a
+// space, for integrations that encode spaces in paths like a query string.
+// By default "+" is literal in paths, as RFC 3986 specifies.
+func (r *RequestAccessor) SetDecodePlusInPath(decode bool) {
+	r.decodePlus = decode
+}
+
+// SetStripAuthorizationHeader makes the accessor remove the Authorization
+// header from the requests, for APIs where an authorizer already validated
+// it and the handler, its logs and the services it calls must not see the
+// credentials. The claims of the authorizer stay available in the API
+// Gateway context.
+func (r *RequestAccessor) SetStripAuthorizationHeader(strip bool) {
+	r.stripAuth = strip
+}
+
+// SetDefaultRequestHeaders sets headers added to every request, such as an
+// internal X-Service-Name. A header the client sent keeps its values, the
+// default is only added when the request doesn't have it.
+func (r *RequestAccessor) SetDefaultRequestHeaders(headers http.Header) {
+	r.defaultHeaders = headers.Clone()
+}
+
+// SetRewindableBody makes the accessor back the body of every request with
+// a buffer and set GetBody, so a middleware that consumed the body can call
+// GetBody to read it again. Bodies decompressed with SetAutoDecompressRequest
+// are read into the buffer up front.
+func (r *RequestAccessor) SetRewindableBody(rewindable bool) {
+	r.rewindableBody = rewindable
+}
+
+// SetCollapseSlashes makes the accessor collapse repeated slashes in the
+// request path, so /api//users is routed as /api/users. The query
+// string is left unchanged.
+func (r *RequestAccessor) SetCollapseSlashes(collapse bool) {
+	r.collapseSlashes = collapse
+}
+
+// SetMaxDecodedBodyBytes limits the size of the decoded body of base64
+// encoded events. Decoding stops as soon as the limit is passed and the
+// accessor returns ErrRequestTooLarge, so an event can't make it allocate
+// more than n bytes for the body. A limit of 0 disables the check. The limit
+// also caps bodies decompressed with SetAutoDecompressRequest, reading past
+// it fails with ErrRequestTooLarge.
+func (r *RequestAccessor) SetMaxDecodedBodyBytes(n int64) {
+	r.maxBodyBytes = n
+}
+
+// SetLazyBody makes the request body read the event body in place, decoding
+// base64 as the handler reads it, instead of decoding it into a copy up
+// front. This keeps a single copy of large uploads in memory. ContentLength
+// is still set and GetBody returns a fresh reader. Invalid base64 is only
+// reported when the handler reads that part of the body, and GetRawBody
+// returns nil for these requests.
+func (r *RequestAccessor) SetLazyBody(lazy bool) {
+	r.lazyBody = lazy
+}
+
+// SetMaxHeaderCount limits the number of distinct headers an event can have.
+// Events with more headers, in either the Headers or the MultiValueHeaders
+// map, are rejected with ErrTooManyHeaders before the request is built.
+// Header names are compared case-insensitively. A limit of 0 disables the
+// check.
+func (r *RequestAccessor) SetMaxHeaderCount(n int) {
+	r.maxHeaders = n
+}
+
+// SetTruncateQueryParams makes the accessor drop the query string parameters
+// over the limit set with SetMaxQueryParams instead of rejecting the event.
+func (r *RequestAccessor) SetTruncateQueryParams(truncate bool) {
+	r.truncateQuery = truncate
+}
+
+// SetAutoDecompressRequest makes the accessor decompress request bodies sent
+// with a Content-Encoding: gzip header. The handler reads the decompressed
+// body, the Content-Encoding and Content-Length headers are removed and the
+// request ContentLength is set to -1 since the decompressed size is unknown.
+// GetRawBody still returns the compressed body. The decompressed body is
+// capped at the limit of SetMaxDecodedBodyBytes, or
+// DefaultMaxDecompressedBodyBytes when none is set.
+func (r *RequestAccessor) SetAutoDecompressRequest(decompress bool) {
+	r.decompress = decompress
+}
+
+// SetRequestIDGenerator makes the accessor generate an ID for events that
+// don't carry a request ID, such as hand-written test events, with the given
+// function. Pass nil to generate random UUIDs. The generated ID is set in the
+// RequestIDHeader header, unless the request already has one, and returned
+// by GetRequestID.
+func (r *RequestAccessor) SetRequestIDGenerator(generator func() string) {
+	r.generateID = true
+	r.newRequestID = generator
+}
+
+// SetSplitCommaHeaders lists headers whose comma joined values are split
+// into multiple header values when the event only carries the single value
+// Headers map, for example a joined Accept header. Headers from the
+// MultiValueHeaders map are never split.
+func (r *RequestAccessor) SetSplitCommaHeaders(headers []string) {
+	r.splitHeaders = make(map[string]bool, len(headers))
+	for _, header := range headers {
+		r.splitHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+}
+
+// SetStrictHeaderMerge makes the accessor reject events where a header is in
+// both the Headers and MultiValueHeaders maps with conflicting values, to
+// debug integrations that build events by hand. API Gateway sets the last
+// value of a multi-value header in Headers, a value that matches the last
+// value or all values joined with commas is not a conflict. By default the
+// MultiValueHeaders map is used and Headers is ignored.
+func (r *RequestAccessor) SetStrictHeaderMerge(strict bool) {
+	r.strictHeaders = strict
+}
+
+// SetEventCapture registers a function that EventToRequestWithContext calls
+// with every event, as it was received and before it is converted, so the
+// event can be logged or persisted to replay it while debugging. Events
+// that fail to convert are captured as well.
+func (r *RequestAccessor) SetEventCapture(capture func(events.APIGatewayProxyRequest)) {
+	r.captureEvent = capture
+}
+
+// SetProtocol overrides the protocol version set on the requests built by
+// the accessor. Requests default to HTTP/1.1, which is what API Gateway uses
+// to talk to the integration. Returns an error if the version can't be parsed.
+func (r *RequestAccessor) SetProtocol(proto string) error {
+	if _, _, ok := http.ParseHTTPVersion(proto); !ok {
+		return fmt.Errorf("Invalid protocol version: %s", proto)
+	}
+	r.protocol = proto
+	return nil
+}
+
+// SetPreserveMethodCase makes the accessor pass the method of the event
+// through verbatim. By default it is uppercased, so an event with a "get"
+// method reaches the handler as GET. Methods are not validated either way,
+// extension methods such as PROPFIND pass through unchanged.
+func (r *RequestAccessor) SetPreserveMethodCase(preserve bool) {
+	r.preserveMethod = preserve
+}
+
 // ProxyEventToHTTPRequest converts an API Gateway proxy event into a http.Request object.
 // Returns the populated http request with additional two custom headers for the stage variables and API Gateway context.
 // To access these properties use the GetAPIGatewayStageVars and GetAPIGatewayContext method of the RequestAccessor object.
@@ -119,44 +371,76 @@ func (r *RequestAccessor) ProxyEventToHTTPRequest(req events.APIGatewayProxyRequ
 // Returns the populated http request with lambda context, stage variables and APIGatewayProxyRequestContext as part of its context.
 // Access those using GetAPIGatewayContextFromContext, GetStageVarsFromContext and GetRuntimeContextFromContext functions in this package.
 func (r *RequestAccessor) EventToRequestWithContext(ctx context.Context, req events.APIGatewayProxyRequest) (*http.Request, error) {
-	httpRequest, err := r.EventToRequest(req)
+	if r.captureEvent != nil {
+		r.captureEvent(req)
+	}
+	httpRequest, body, err := r.eventToRequest(req)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
-	return addToContext(ctx, httpRequest, req), nil
+	_, basePath := r.mapBasePath(req.Path)
+	_, rewrite := r.routingPath(req)
+	return withBasePathRewrite(addToContext(ctx, httpRequest, req, body, basePath), rewrite), nil
 }
 
 // EventToRequest converts an API Gateway proxy event into an http.Request object.
 // Returns the populated request maintaining headers
 func (r *RequestAccessor) EventToRequest(req events.APIGatewayProxyRequest) (*http.Request, error) {
-	decodedBody := []byte(req.Body)
-	if req.IsBase64Encoded {
-		base64Body, err := base64.StdEncoding.DecodeString(req.Body)
+	httpRequest, _, err := r.eventToRequest(req)
+	return httpRequest, err
+}
+
+// eventToRequest builds the http.Request for the event and also returns the
+// decoded body bytes it is backed by.
+func (r *RequestAccessor) eventToRequest(req events.APIGatewayProxyRequest) (*http.Request, []byte, error) {
+	if r.maxHeaders > 0 && (countHeaders(req.Headers) > r.maxHeaders || countHeaders(req.MultiValueHeaders) > r.maxHeaders) {
+		return nil, nil, ErrTooManyHeaders
+	}
+
+	var decodedBody []byte
+	if !r.lazyBody {
+		decodedBody = []byte(req.Body)
+	}
+	if req.IsBase64Encoded && !r.lazyBody {
+		base64Body, err := decodeBase64Body(req.Body, r.maxBodyBytes)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		decodedBody = base64Body
 	}
 
-	path := req.Path
-	if r.stripBasePath != "" && len(r.stripBasePath) > 1 {
-		if strings.HasPrefix(path, r.stripBasePath) {
-			path = strings.Replace(path, r.stripBasePath, "", 1)
-		}
+	path, _ := r.routingPath(req)
+	if r.decodePlus {
+		path = strings.ReplaceAll(path, "+", " ")
 	}
-	if !strings.HasPrefix(path, "/") {
-		path = "/" + path
+	if r.collapseSlashes {
+		path = collapseSlashes(path)
 	}
 	serverAddress := "https://" + req.RequestContext.DomainName
 	if customAddress, ok := os.LookupEnv(CustomHostVariable); ok {
 		serverAddress = customAddress
 	}
-	path = serverAddress + path
+	path = serverAddress + escapeFragment(path)
 
-	if len(req.MultiValueQueryStringParameters) > 0 {
+	multiValueQuery := req.MultiValueQueryStringParameters
+	singleValueQuery := req.QueryStringParameters
+	if r.maxQueryParams > 0 && (len(multiValueQuery) > r.maxQueryParams || len(singleValueQuery) > r.maxQueryParams) {
+		if !r.truncateQuery {
+			return nil, nil, ErrTooManyParams
+		}
+		log.Printf("Request has more than %d query string parameters, dropping the rest\n", r.maxQueryParams)
+		multiValueQuery = firstKeys(multiValueQuery, r.maxQueryParams)
+		singleValueQuery = firstKeys(singleValueQuery, r.maxQueryParams)
+	}
+
+	if r.exactQuery && len(multiValueQuery) > 0 {
+		path += "?" + exactQueryString(multiValueQuery, escapeQueryComponent)
+	} else if r.exactQuery && len(singleValueQuery) > 0 {
+		path += "?" + exactQueryString(singleValueParams(singleValueQuery), escapeQueryComponent)
+	} else if len(multiValueQuery) > 0 {
 		queryString := ""
-		for q, l := range req.MultiValueQueryStringParameters {
+		for q, l := range multiValueQuery {
 			for _, v := range l {
 				if queryString != "" {
 					queryString += "&"
@@ -165,21 +449,21 @@ func (r *RequestAccessor) EventToRequest(req events.APIGatewayProxyRequest) (*ht
 			}
 		}
 		path += "?" + queryString
-	} else if len(req.QueryStringParameters) > 0 {
+	} else if len(singleValueQuery) > 0 {
 		// Support `QueryStringParameters` for backward compatibility.
 		// https://github.com/awslabs/aws-lambda-go-api-proxy/issues/37
 		queryString := ""
-		for q := range req.QueryStringParameters {
+		for q := range singleValueQuery {
 			if queryString != "" {
 				queryString += "&"
 			}
-			queryString += url.QueryEscape(q) + "=" + url.QueryEscape(req.QueryStringParameters[q])
+			queryString += url.QueryEscape(q) + "=" + url.QueryEscape(singleValueQuery[q])
 		}
 		path += "?" + queryString
 	}
 
 	httpRequest, err := http.NewRequest(
-		strings.ToUpper(req.HTTPMethod),
+		eventMethod(req.HTTPMethod, r.preserveMethod),
 		path,
 		bytes.NewReader(decodedBody),
 	)
@@ -187,7 +471,18 @@ func (r *RequestAccessor) EventToRequest(req events.APIGatewayProxyRequest) (*ht
 	if err != nil {
 		fmt.Printf("Could not convert request %s:%s to http.Request\n", req.HTTPMethod, req.Path)
 		log.Println(err)
-		return nil, err
+		return nil, nil, err
+	}
+	if r.lazyBody {
+		if err := setLazyBody(httpRequest, req.Body, req.IsBase64Encoded, r.maxBodyBytes); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if r.strictHeaders && req.MultiValueHeaders != nil {
+		if err := checkHeaderConflicts(req.Headers, req.MultiValueHeaders); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	if req.MultiValueHeaders != nil {
@@ -198,13 +493,332 @@ func (r *RequestAccessor) EventToRequest(req events.APIGatewayProxyRequest) (*ht
 		}
 	} else {
 		for h := range req.Headers {
-			httpRequest.Header.Add(h, req.Headers[h])
+			if !r.splitHeaders[http.CanonicalHeaderKey(h)] {
+				httpRequest.Header.Add(h, req.Headers[h])
+				continue
+			}
+			for _, value := range strings.Split(req.Headers[h], ",") {
+				if value = strings.TrimSpace(value); value != "" {
+					httpRequest.Header.Add(h, value)
+				}
+			}
 		}
 	}
 
+	// Lambda receives the whole body up front, handlers must not wait to
+	// send a 100 Continue
+	httpRequest.Header.Del("Expect")
+	if r.stripAuth {
+		httpRequest.Header.Del("Authorization")
+	}
+	for k, values := range r.defaultHeaders {
+		if _, ok := httpRequest.Header[http.CanonicalHeaderKey(k)]; !ok {
+			for _, value := range values {
+				httpRequest.Header.Add(k, value)
+			}
+		}
+	}
+	if r.generateID && req.RequestContext.RequestID == "" && httpRequest.Header.Get(RequestIDHeader) == "" {
+		generator := r.newRequestID
+		if generator == nil {
+			generator = newRequestID
+		}
+		httpRequest.Header.Set(RequestIDHeader, generator())
+	}
+	bodyBytes := decodedBody
+	if r.decompress && strings.EqualFold(strings.TrimSpace(httpRequest.Header.Get("Content-Encoding")), "gzip") {
+		if err := decompressBody(httpRequest, r.maxBodyBytes); err != nil {
+			return nil, nil, err
+		}
+		bodyBytes = nil
+	}
+	if r.rewindableBody {
+		if err := rewindableBody(httpRequest, bodyBytes); err != nil {
+			return nil, nil, err
+		}
+	}
+	if r.methodOverride {
+		applyMethodOverride(httpRequest)
+	}
+	setProtocol(httpRequest, r.protocol)
+	setHostFromHeader(httpRequest)
+	setForwardedPort(httpRequest)
 	httpRequest.RequestURI = httpRequest.URL.RequestURI()
 
-	return httpRequest, nil
+	return httpRequest, decodedBody, nil
+}
+
+// decompressBody replaces the body of the request with a reader that
+// decompresses the gzip encoded body. Reading more than limit decompressed
+// bytes, or DefaultMaxDecompressedBodyBytes when limit is 0, fails with
+// ErrRequestTooLarge, so a small compressed body can't inflate without bound.
+func decompressBody(req *http.Request, limit int64) error {
+	gz, err := gzip.NewReader(req.Body)
+	if err != nil {
+		return fmt.Errorf("Could not decompress request body: %v", err)
+	}
+	if limit <= 0 {
+		limit = DefaultMaxDecompressedBodyBytes
+	}
+	req.Body = &limitedBody{ReadCloser: gz, remaining: limit}
+	req.GetBody = nil
+	req.ContentLength = -1
+	req.Header.Del("Content-Encoding")
+	req.Header.Del("Content-Length")
+	return nil
+}
+
+// limitedBody is a request body that returns ErrRequestTooLarge once more
+// than the remaining bytes are available from it.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		var probe [1]byte
+		if _, err := io.ReadFull(b.ReadCloser, probe[:]); err != nil {
+			return 0, err
+		}
+		return 0, ErrRequestTooLarge
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// rewindableBody backs the body of the request with body and sets GetBody to
+// return a new reader of it. A nil body is read from the request first.
+func rewindableBody(req *http.Request, body []byte) error {
+	if body == nil {
+		read, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return fmt.Errorf("Could not read request body: %w", err)
+		}
+		body = read
+		req.ContentLength = int64(len(body))
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return nil
+}
+
+// methodOverrides lists the methods a POST request can be overridden to.
+var methodOverrides = map[string]bool{
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// applyMethodOverride replaces the method of a POST request with the one in
+// its X-HTTP-Method-Override header, if that method can be overridden to.
+func applyMethodOverride(req *http.Request) {
+	if req.Method != http.MethodPost {
+		return
+	}
+	override := strings.ToUpper(strings.TrimSpace(req.Header.Get("X-HTTP-Method-Override")))
+	if methodOverrides[override] {
+		req.Method = override
+	}
+}
+
+// firstKeys returns a copy of m with only the first n keys in alphabetical
+// order.
+func firstKeys[V any](m map[string]V, n int) map[string]V {
+	if len(m) <= n {
+		return m
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	limited := make(map[string]V, n)
+	for _, key := range keys[:n] {
+		limited[key] = m[key]
+	}
+	return limited
+}
+
+// routingPath returns the path the request is routed on, after the base path
+// mappings, the proxy path, StripBasePath and the path rewrites were applied,
+// and the base path that was replaced at its start.
+func (r *RequestAccessor) routingPath(req events.APIGatewayProxyRequest) (string, basePathRewrite) {
+	var rewrite basePathRewrite
+	path, basePath := r.mapBasePath(req.Path)
+	if proxyPath, ok := req.PathParameters["proxy"]; r.useProxyPath && ok {
+		path = "/" + strings.TrimPrefix(proxyPath, "/")
+	} else if basePath != "" {
+		rewrite = basePathRewrite{from: basePath, to: r.basePathMappings[basePath]}
+	} else {
+		path, rewrite = stripPathPrefix(path, configuredBasePath(r.stripBasePath))
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return applyPathRewrites(path, r.pathRewrites), rewrite
+}
+
+// mapBasePath applies the longest matching base path mapping to the path.
+// Returns the mapped path and the matched prefix, or the unchanged path and
+// an empty string when no mapping matches.
+func (r *RequestAccessor) mapBasePath(path string) (string, string) {
+	matched := ""
+	for prefix := range r.basePathMappings {
+		if len(prefix) <= len(matched) {
+			continue
+		}
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			matched = prefix
+		}
+	}
+	if matched == "" {
+		return path, ""
+	}
+	return r.basePathMappings[matched] + strings.TrimPrefix(path, matched), matched
+}
+
+// normalizeBasePath adds a leading slash to the base path and removes the
+// trailing one. Blank base paths become an empty string.
+func normalizeBasePath(basePath string) string {
+	basePath = strings.TrimSpace(basePath)
+	if basePath == "" || basePath == "/" {
+		return ""
+	}
+	if !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+	return strings.TrimSuffix(basePath, "/")
+}
+
+// escapeFragment escapes "#" characters in a decoded path so that they stay
+// part of the path when the URL is parsed instead of starting a fragment.
+func escapeFragment(path string) string {
+	return strings.ReplaceAll(path, "#", "%23")
+}
+
+// setProtocol sets the Proto fields of the request. Requests keep the
+// HTTP/1.1 default of http.NewRequest when proto is empty.
+func setProtocol(req *http.Request, proto string) {
+	if proto == "" {
+		return
+	}
+	major, minor, _ := http.ParseHTTPVersion(proto)
+	req.Proto = proto
+	req.ProtoMajor = major
+	req.ProtoMinor = minor
+}
+
+// eventMethod returns the method of an event for the http.Request,
+// uppercased unless preserve is set.
+func eventMethod(method string, preserve bool) string {
+	if preserve {
+		return method
+	}
+	return strings.ToUpper(method)
+}
+
+// countHeaders returns the number of distinct header names in the map.
+func countHeaders[V any](headers map[string]V) int {
+	names := make(map[string]bool, len(headers))
+	for k := range headers {
+		names[http.CanonicalHeaderKey(k)] = true
+	}
+	return len(names)
+}
+
+// checkHeaderConflicts returns an error if a header of the single value map
+// has a value that doesn't match the multi-value map.
+func checkHeaderConflicts(headers map[string]string, multiValueHeaders map[string][]string) error {
+	multi := make(http.Header, len(multiValueHeaders))
+	for k, values := range multiValueHeaders {
+		for _, value := range values {
+			multi.Add(k, value)
+		}
+	}
+	for k, value := range headers {
+		values := multi.Values(k)
+		if len(values) == 0 {
+			continue
+		}
+		if value != values[len(values)-1] && value != strings.Join(values, ",") {
+			return fmt.Errorf("Header %s has conflicting values in Headers and MultiValueHeaders", http.CanonicalHeaderKey(k))
+		}
+	}
+	return nil
+}
+
+// collapseSlashes replaces every run of slashes in the path with a single
+// slash.
+func collapseSlashes(path string) string {
+	var b strings.Builder
+	b.Grow(len(path))
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && i > 0 && path[i-1] == '/' {
+			continue
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
+
+// decodeBase64Body decodes a base64 encoded event body. With a limit greater
+// than 0 the body is decoded through a limited reader and ErrRequestTooLarge
+// is returned once more than limit bytes come out of it.
+func decodeBase64Body(body string, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	decoder := base64.NewDecoder(base64.StdEncoding, strings.NewReader(body))
+	decoded, err := ioutil.ReadAll(io.LimitReader(decoder, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(decoded)) > limit {
+		return nil, ErrRequestTooLarge
+	}
+	return decoded, nil
+}
+
+// setHostFromHeader sets the host of a request built from an event without a
+// domain name, such as a locally generated test event, to its Host header.
+// Without it the request has an empty host and middlewares comparing the
+// Origin or Referer header with the host reject same-origin requests.
+func setHostFromHeader(req *http.Request) {
+	if req.URL.Host != "" {
+		return
+	}
+	host := strings.TrimSpace(req.Header.Get("Host"))
+	if host == "" {
+		return
+	}
+	req.URL.Host = host
+	req.Host = host
+}
+
+// setForwardedPort adds the port from the X-Forwarded-Port header to the
+// host of the request, so handlers can build absolute URLs for clients on a
+// non-standard port. Hosts that already carry a port and the default port of
+// the scheme are left alone.
+func setForwardedPort(req *http.Request) {
+	port := strings.TrimSpace(req.Header.Get(forwardedPortHeaderKey))
+	if port == "" || req.URL.Host == "" || req.URL.Port() != "" {
+		return
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return
+	}
+	if (req.URL.Scheme == "https" && port == "443") || (req.URL.Scheme == "http" && port == "80") {
+		return
+	}
+	req.URL.Host = net.JoinHostPort(req.URL.Hostname(), port)
+	req.Host = req.URL.Host
 }
 
 func addToHeader(req *http.Request, apiGwRequest events.APIGatewayProxyRequest) (*http.Request, error) {
@@ -223,10 +837,17 @@ func addToHeader(req *http.Request, apiGwRequest events.APIGatewayProxyRequest)
 	return req, nil
 }
 
-func addToContext(ctx context.Context, req *http.Request, apiGwRequest events.APIGatewayProxyRequest) *http.Request {
+func addToContext(ctx context.Context, req *http.Request, apiGwRequest events.APIGatewayProxyRequest, body []byte, basePath string) *http.Request {
 	lc, _ := lambdacontext.FromContext(ctx)
-	rc := requestContext{lambdaContext: lc, gatewayProxyContext: apiGwRequest.RequestContext, stageVars: apiGwRequest.StageVariables}
+	rc := requestContext{lambdaContext: lc, gatewayProxyContext: apiGwRequest.RequestContext, stageVars: apiGwRequest.StageVariables, rawBody: body, basePath: basePath, proxyPath: apiGwRequest.PathParameters["proxy"], pathParams: apiGwRequest.PathParameters, resource: apiGwRequest.Resource}
+	if apiGwRequest.RequestContext.RequestID == "" {
+		rc.requestID = req.Header.Get(RequestIDHeader)
+	}
 	ctx = context.WithValue(ctx, ctxKey{}, rc)
+	ctx = withColdStart(ctx)
+	ctx = withFunctionMetadata(ctx)
+	ctx = withResponseMetaIfMissing(ctx)
+	ctx = context.WithValue(ctx, ContextKeyStageVars, apiGwRequest.StageVariables)
 	return req.WithContext(ctx)
 }
 
@@ -248,10 +869,104 @@ func GetStageVarsFromContext(ctx context.Context) (map[string]string, bool) {
 	return v.stageVars, ok
 }
 
+// GetStageVariables retrieves the API Gateway stage variables stored under
+// ContextKeyStageVars for both v1 and v2 events. Returns nil if the context
+// holds no stage variables.
+func GetStageVariables(ctx context.Context) map[string]string {
+	stageVars, _ := ctx.Value(ContextKeyStageVars).(map[string]string)
+	return stageVars
+}
+
+// GetProxyPath returns the path captured by a {proxy+} resource, the value of
+// the "proxy" path parameter of the event. Returns an empty string when the
+// resource has no greedy path parameter.
+func GetProxyPath(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKey{}).(requestContext)
+	return v.proxyPath
+}
+
+// GetPathParameters returns the path parameters API Gateway resolved for the
+// resource of the event, or nil if the resource has none.
+func GetPathParameters(ctx context.Context) map[string]string {
+	v, _ := ctx.Value(ctxKey{}).(requestContext)
+	return v.pathParams
+}
+
+// GetAPIKeyID returns the ID of the API key the client sent with the
+// request, for example to rate limit per key. Returns false if the context
+// does not hold a v1 request or the method does not require an API key.
+func GetAPIKeyID(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContext)
+	if !ok || v.gatewayProxyContext.Identity.APIKeyID == "" {
+		return "", false
+	}
+	return v.gatewayProxyContext.Identity.APIKeyID, true
+}
+
+// GetExtendedRequestID returns the extended request ID API Gateway assigned
+// to the request, which AWS support asks for when investigating a request.
+// Returns false if the context does not hold a v1 request or the event has
+// no extended request ID.
+func GetExtendedRequestID(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContext)
+	if !ok || v.gatewayProxyContext.ExtendedRequestID == "" {
+		return "", false
+	}
+	return v.gatewayProxyContext.ExtendedRequestID, true
+}
+
+// GetResourceTemplate returns the API Gateway resource the request matched,
+// such as /users/{id}, which unlike the path is the same for every request
+// to the resource. Returns false if the context does not hold a v1 request
+// or the event has no resource.
+func GetResourceTemplate(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContext)
+	if !ok || v.resource == "" {
+		return "", false
+	}
+	return v.resource, true
+}
+
+// GetRawBody retrieves the request body exactly as it was received in the
+// event, after base64 decoding but before any other processing. The bytes are
+// stored by EventToRequestWithContext and remain available after the handler
+// or a middleware consumed req.Body. The returned slice must not be modified.
+func GetRawBody(ctx context.Context) []byte {
+	switch v := ctx.Value(ctxKey{}).(type) {
+	case requestContext:
+		return v.rawBody
+	case requestContextV2:
+		return v.rawBody
+	case requestContextALB:
+		return v.rawBody
+	case requestContextFnURL:
+		return v.rawBody
+	case requestContextEdge:
+		return v.rawBody
+	}
+	return nil
+}
+
+// GetMatchedBasePath returns the base path mapping, as configured with
+// SetBasePathMappings, that matched the request. Returns an empty string when
+// no mapping matched.
+func GetMatchedBasePath(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKey{}).(requestContext)
+	return v.basePath
+}
+
 type ctxKey struct{}
 
+type contextKey string
+
 type requestContext struct {
 	lambdaContext       *lambdacontext.LambdaContext
 	gatewayProxyContext events.APIGatewayProxyRequestContext
 	stageVars           map[string]string
+	rawBody             []byte
+	basePath            string
+	proxyPath           string
+	requestID           string
+	pathParams          map[string]string
+	resource            string
 }
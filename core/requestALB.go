@@ -0,0 +1,366 @@
+// Package core provides utility methods that help convert proxy events
+// into an http.Request and http.ResponseWriter
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+const (
+	// ALBContextHeader is the custom header key used to store the
+	// ALB target group context. To access the Context properties use the
+	// GetALBContext method of the RequestAccessorALB object.
+	ALBContextHeader = "X-GoLambdaProxy-Alb-Context"
+
+	forwardedForHeaderKey   = "X-Forwarded-For"
+	forwardedProtoHeaderKey = "X-Forwarded-Proto"
+	forwardedPortHeaderKey  = "X-Forwarded-Port"
+)
+
+// RequestAccessorALB objects give access to custom ALB target group properties
+// in the request.
+type RequestAccessorALB struct {
+	stripBasePath     string
+	trustedProxyCount int
+	protocol          string
+	lazyBody          bool
+	pathRewrites      []PathRewrite
+	exactQuery        bool
+	generateID        bool
+	newRequestID      func() string
+	preserveMethod    bool
+}
+
+// GetALBContext extracts the ALB target group context object from a
+// request's custom header.
+// Returns a populated events.ALBTargetGroupRequestContext object from
+// the request.
+func (r *RequestAccessorALB) GetALBContext(req *http.Request) (events.ALBTargetGroupRequestContext, error) {
+	if req.Header.Get(ALBContextHeader) == "" {
+		return events.ALBTargetGroupRequestContext{}, errors.New("No context header in request")
+	}
+	context := events.ALBTargetGroupRequestContext{}
+	err := json.Unmarshal([]byte(req.Header.Get(ALBContextHeader)), &context)
+	if err != nil {
+		log.Println("Error while unmarshalling context")
+		log.Println(err)
+		return events.ALBTargetGroupRequestContext{}, err
+	}
+	return context, nil
+}
+
+// StripBasePath instructs the RequestAccessorALB object that the given base
+// path should be removed from the request path before sending it to the
+// framework for routing.
+func (r *RequestAccessorALB) StripBasePath(basePath string) string {
+	if strings.Trim(basePath, " ") == "" {
+		r.stripBasePath = ""
+		return ""
+	}
+
+	newBasePath := basePath
+	if !strings.HasPrefix(newBasePath, "/") {
+		newBasePath = "/" + newBasePath
+	}
+
+	if strings.HasSuffix(newBasePath, "/") {
+		newBasePath = newBasePath[:len(newBasePath)-1]
+	}
+
+	r.stripBasePath = newBasePath
+
+	return newBasePath
+}
+
+// SetProtocol overrides the protocol version set on the requests built by
+// the accessor. Requests default to HTTP/1.1, which is what the load balancer
+// uses to talk to Lambda targets. Returns an error if the version can't be parsed.
+func (r *RequestAccessorALB) SetProtocol(proto string) error {
+	if _, _, ok := http.ParseHTTPVersion(proto); !ok {
+		return fmt.Errorf("Invalid protocol version: %s", proto)
+	}
+	r.protocol = proto
+	return nil
+}
+
+// SetPreserveMethodCase makes the accessor pass the method of the event
+// through verbatim instead of uppercasing it, see
+// RequestAccessor.SetPreserveMethodCase.
+func (r *RequestAccessorALB) SetPreserveMethodCase(preserve bool) {
+	r.preserveMethod = preserve
+}
+
+// SetTrustedProxyCount sets the number of trusted proxies, including the load
+// balancer itself, that sit in front of the function. The client address used
+// for the request's RemoteAddr is the n-th entry of X-Forwarded-For counting
+// from the rightmost one. When n is 0 or larger than the number of entries the
+// leftmost entry is used.
+func (r *RequestAccessorALB) SetTrustedProxyCount(n int) {
+	if n < 0 {
+		n = 0
+	}
+	r.trustedProxyCount = n
+}
+
+// SetPathRewrites sets rules that rewrite the request path before routing,
+// after the base path was stripped. See RequestAccessor.SetPathRewrites.
+func (r *RequestAccessorALB) SetPathRewrites(rules ...PathRewrite) {
+	r.pathRewrites = rules
+}
+
+// SetExactQueryString makes the accessor rebuild the query string in a
+// stable order, keys in alphabetical order and the values of each key in
+// the order of the event, like RequestAccessor.SetExactQueryString. The load
+// balancer sends the parameters as the client encoded them, so they are
+// copied verbatim instead of being encoded a second time.
+func (r *RequestAccessorALB) SetExactQueryString(exact bool) {
+	r.exactQuery = exact
+}
+
+// SetRequestIDGenerator makes the accessor generate a request ID with the
+// given function, like RequestAccessor.SetRequestIDGenerator. Load balancer
+// events never carry one, so an ID is generated for every request that
+// doesn't have an X-Request-Id header. Pass nil to generate random UUIDs.
+func (r *RequestAccessorALB) SetRequestIDGenerator(generator func() string) {
+	r.generateID = true
+	r.newRequestID = generator
+}
+
+// SetLazyBody makes the request body read the event body in place, like
+// RequestAccessor.SetLazyBody.
+func (r *RequestAccessorALB) SetLazyBody(lazy bool) {
+	r.lazyBody = lazy
+}
+
+// ProxyEventToHTTPRequest converts an ALB target group event into a http.Request object.
+// Returns the populated http request with an additional custom header for the ALB context.
+// To access this property use the GetALBContext method of the RequestAccessorALB object.
+func (r *RequestAccessorALB) ProxyEventToHTTPRequest(req events.ALBTargetGroupRequest) (*http.Request, error) {
+	httpRequest, err := r.EventToRequest(req)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return addToHeaderALB(httpRequest, req)
+}
+
+// EventToRequestWithContext converts an ALB target group event and context into an http.Request object.
+// Returns the populated http request with lambda context and ALBTargetGroupRequestContext as part of its context.
+// Access those using GetALBContextFromContext and GetRuntimeContextFromContextALB functions in this package.
+func (r *RequestAccessorALB) EventToRequestWithContext(ctx context.Context, req events.ALBTargetGroupRequest) (*http.Request, error) {
+	httpRequest, body, err := r.eventToRequest(req)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	requestID := ""
+	if r.generateID {
+		requestID = httpRequest.Header.Get(RequestIDHeader)
+	}
+	_, rewrite := r.routingPath(req)
+	return withBasePathRewrite(addToContextALB(ctx, httpRequest, req, body, requestID), rewrite), nil
+}
+
+// EventToRequest converts an ALB target group event into an http.Request object.
+// Returns the populated request maintaining headers
+func (r *RequestAccessorALB) EventToRequest(req events.ALBTargetGroupRequest) (*http.Request, error) {
+	httpRequest, _, err := r.eventToRequest(req)
+	return httpRequest, err
+}
+
+// eventToRequest builds the http.Request for the event and also returns the
+// decoded body bytes it is backed by.
+func (r *RequestAccessorALB) eventToRequest(req events.ALBTargetGroupRequest) (*http.Request, []byte, error) {
+	var decodedBody []byte
+	if !r.lazyBody {
+		decodedBody = []byte(req.Body)
+	}
+	if req.IsBase64Encoded && !r.lazyBody {
+		base64Body, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+		decodedBody = base64Body
+	}
+
+	headers := make(http.Header)
+	if req.MultiValueHeaders != nil {
+		for k, values := range req.MultiValueHeaders {
+			for _, value := range values {
+				headers.Add(k, value)
+			}
+		}
+	} else {
+		for h := range req.Headers {
+			headers.Add(h, req.Headers[h])
+		}
+	}
+	// With multi-value headers enabled the ALB sends every Cookie header it
+	// received, join them into the single header clients are expected to send
+	if cookies := headers.Values("Cookie"); len(cookies) > 1 {
+		headers.Set("Cookie", mergeCookies(cookies, nil))
+	}
+
+	path, _ := r.routingPath(req)
+	scheme := "https"
+	if proto := headers.Get(forwardedProtoHeaderKey); proto != "" {
+		scheme = proto
+	}
+	serverAddress := scheme + "://" + headers.Get("Host")
+	if customAddress, ok := os.LookupEnv(CustomHostVariable); ok {
+		serverAddress = customAddress
+	}
+	path = serverAddress + escapeFragment(path)
+
+	if r.exactQuery && len(req.MultiValueQueryStringParameters) > 0 {
+		path += "?" + exactQueryString(req.MultiValueQueryStringParameters, verbatimQueryComponent)
+	} else if r.exactQuery && len(req.QueryStringParameters) > 0 {
+		path += "?" + exactQueryString(singleValueParams(req.QueryStringParameters), verbatimQueryComponent)
+	} else if len(req.MultiValueQueryStringParameters) > 0 {
+		queryString := ""
+		for q, l := range req.MultiValueQueryStringParameters {
+			for _, v := range l {
+				if queryString != "" {
+					queryString += "&"
+				}
+				queryString += url.QueryEscape(q) + "=" + url.QueryEscape(v)
+			}
+		}
+		path += "?" + queryString
+	} else if len(req.QueryStringParameters) > 0 {
+		queryString := ""
+		for q := range req.QueryStringParameters {
+			if queryString != "" {
+				queryString += "&"
+			}
+			queryString += url.QueryEscape(q) + "=" + url.QueryEscape(req.QueryStringParameters[q])
+		}
+		path += "?" + queryString
+	}
+
+	httpRequest, err := http.NewRequest(
+		eventMethod(req.HTTPMethod, r.preserveMethod),
+		path,
+		bytes.NewReader(decodedBody),
+	)
+
+	if err != nil {
+		fmt.Printf("Could not convert request %s:%s to http.Request\n", req.HTTPMethod, req.Path)
+		log.Println(err)
+		return nil, nil, err
+	}
+	if r.lazyBody {
+		if err := setLazyBody(httpRequest, req.Body, req.IsBase64Encoded, 0); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	httpRequest.Header = headers
+	httpRequest.RemoteAddr = r.clientAddress(headers)
+	// Lambda receives the whole body up front, handlers must not wait to
+	// send a 100 Continue
+	httpRequest.Header.Del("Expect")
+	if r.generateID && httpRequest.Header.Get(RequestIDHeader) == "" {
+		generator := r.newRequestID
+		if generator == nil {
+			generator = newRequestID
+		}
+		httpRequest.Header.Set(RequestIDHeader, generator())
+	}
+	setProtocol(httpRequest, r.protocol)
+	setForwardedPort(httpRequest)
+	httpRequest.RequestURI = httpRequest.URL.RequestURI()
+
+	return httpRequest, decodedBody, nil
+}
+
+// clientAddress picks the client IP from the X-Forwarded-For header based on
+// the configured number of trusted proxies.
+func (r *RequestAccessorALB) clientAddress(headers http.Header) string {
+	hops := make([]string, 0)
+	for _, value := range headers.Values(forwardedForHeaderKey) {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	if len(hops) == 0 {
+		return ""
+	}
+	if r.trustedProxyCount == 0 || r.trustedProxyCount > len(hops) {
+		return hops[0]
+	}
+	return hops[len(hops)-r.trustedProxyCount]
+}
+
+func addToHeaderALB(req *http.Request, albRequest events.ALBTargetGroupRequest) (*http.Request, error) {
+	albContext, err := json.Marshal(albRequest.RequestContext)
+	if err != nil {
+		log.Println("Could not Marshal ALB context for custom header")
+		return req, err
+	}
+	req.Header.Add(ALBContextHeader, string(albContext))
+	return req, nil
+}
+
+func addToContextALB(ctx context.Context, req *http.Request, albRequest events.ALBTargetGroupRequest, body []byte, requestID string) *http.Request {
+	lc, _ := lambdacontext.FromContext(ctx)
+	rc := requestContextALB{
+		lambdaContext: lc,
+		albContext:    albRequest.RequestContext,
+		rawBody:       body,
+		multiValue:    albRequest.MultiValueHeaders != nil || albRequest.MultiValueQueryStringParameters != nil,
+		requestID:     requestID,
+	}
+	ctx = context.WithValue(ctx, ctxKey{}, rc)
+	ctx = withColdStart(ctx)
+	ctx = withFunctionMetadata(ctx)
+	ctx = withResponseMetaIfMissing(ctx)
+	return req.WithContext(ctx)
+}
+
+// GetALBContextFromContext retrieve ALBTargetGroupRequestContext from context.Context
+func GetALBContextFromContext(ctx context.Context) (events.ALBTargetGroupRequestContext, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextALB)
+	return v.albContext, ok
+}
+
+// GetRuntimeContextFromContextALB retrieve Lambda Runtime Context from context.Context
+func GetRuntimeContextFromContextALB(ctx context.Context) (*lambdacontext.LambdaContext, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextALB)
+	return v.lambdaContext, ok
+}
+
+type requestContextALB struct {
+	lambdaContext *lambdacontext.LambdaContext
+	albContext    events.ALBTargetGroupRequestContext
+	rawBody       []byte
+	multiValue    bool
+	requestID     string
+}
+
+// routingPath returns the path the request is routed on, after StripBasePath
+// and the path rewrites were applied, and the base path that was stripped.
+func (r *RequestAccessorALB) routingPath(req events.ALBTargetGroupRequest) (string, basePathRewrite) {
+	path := req.Path
+	path, rewrite := stripPathPrefix(path, configuredBasePath(r.stripBasePath))
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return applyPathRewrites(path, r.pathRewrites), rewrite
+}
@@ -0,0 +1,220 @@
+package core_test
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestAccessorALB tests", func() {
+	Context("event conversion", func() {
+		accessor := core.RequestAccessorALB{}
+		basicRequest := getALBRequest("/hello", "GET")
+		It("Correctly converts a basic event", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), basicRequest)
+			Expect(err).To(BeNil())
+			Expect("/hello").To(Equal(httpReq.URL.Path))
+			Expect("/hello").To(Equal(httpReq.RequestURI))
+			Expect("GET").To(Equal(httpReq.Method))
+			Expect("lambda-test.elb.amazonaws.com").To(Equal(httpReq.Host))
+		})
+
+		It("Populates the protocol version", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getALBRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect("HTTP/1.1").To(Equal(httpReq.Proto))
+			Expect(1).To(Equal(httpReq.ProtoMajor))
+			Expect(1).To(Equal(httpReq.ProtoMinor))
+
+			protoAccessor := core.RequestAccessorALB{}
+			Expect(protoAccessor.SetProtocol("HTTP/2.0")).To(BeNil())
+			httpReq, err = protoAccessor.EventToRequestWithContext(context.Background(), getALBRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect("HTTP/2.0").To(Equal(httpReq.Proto))
+			Expect(2).To(Equal(httpReq.ProtoMajor))
+		})
+
+		binaryBody := make([]byte, 256)
+		_, err := rand.Read(binaryBody)
+		if err != nil {
+			Fail("Could not generate random binary body")
+		}
+
+		binaryRequest := getALBRequest("/hello", "POST")
+		binaryRequest.Body = base64.StdEncoding.EncodeToString(binaryBody)
+		binaryRequest.IsBase64Encoded = true
+
+		It("Decodes a base64 encoded body", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), binaryRequest)
+			Expect(err).To(BeNil())
+			Expect("POST").To(Equal(httpReq.Method))
+
+			bodyBytes, err := ioutil.ReadAll(httpReq.Body)
+
+			Expect(err).To(BeNil())
+			Expect(binaryBody).To(Equal(bodyBytes))
+		})
+
+		It("Passes the decoded body to the handler", func() {
+			var handlerBody []byte
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerBody, _ = ioutil.ReadAll(r.Body)
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), binaryRequest)
+			Expect(err).To(BeNil())
+			Expect(int64(len(binaryBody))).To(Equal(httpReq.ContentLength))
+
+			handler.ServeHTTP(httptest.NewRecorder(), httpReq)
+			Expect(binaryBody).To(Equal(handlerBody))
+		})
+
+		It("Populates the ALB context", func() {
+			lambdaContext := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "abc123"})
+			httpReq, err := accessor.EventToRequestWithContext(lambdaContext, basicRequest)
+			Expect(err).To(BeNil())
+
+			albContext, ok := core.GetALBContextFromContext(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("arn:aws:elasticloadbalancing:us-east-2:123456789012:targetgroup/lambda-target/abc").To(Equal(albContext.ELB.TargetGroupArn))
+			runtimeContext, ok := core.GetRuntimeContextFromContextALB(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("abc123").To(Equal(runtimeContext.AwsRequestID))
+
+			// calling old method to verify the header is populated
+			httpReq, err = accessor.ProxyEventToHTTPRequest(basicRequest)
+			Expect(err).To(BeNil())
+			headerContext, err := accessor.GetALBContext(httpReq)
+			Expect(err).To(BeNil())
+			Expect(albContext).To(Equal(headerContext))
+		})
+	})
+
+	Context("X-Forwarded-For handling", func() {
+		xffRequest := getALBRequest("/hello", "GET")
+		xffRequest.Headers["x-forwarded-for"] = "203.0.113.10, 198.51.100.20, 192.0.2.30"
+
+		It("Defaults to the leftmost entry", func() {
+			accessor := core.RequestAccessorALB{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), xffRequest)
+			Expect(err).To(BeNil())
+			Expect("203.0.113.10").To(Equal(httpReq.RemoteAddr))
+		})
+
+		It("Selects the entry based on the trusted proxy count", func() {
+			accessor := core.RequestAccessorALB{}
+			expected := map[int]string{
+				1: "192.0.2.30",
+				2: "198.51.100.20",
+				3: "203.0.113.10",
+				5: "203.0.113.10",
+			}
+			for count, ip := range expected {
+				accessor.SetTrustedProxyCount(count)
+				httpReq, err := accessor.EventToRequestWithContext(context.Background(), xffRequest)
+				Expect(err).To(BeNil())
+				Expect(ip).To(Equal(httpReq.RemoteAddr))
+			}
+		})
+
+		It("Reads repeated multi-value headers", func() {
+			mvRequest := getALBRequest("/hello", "GET")
+			mvRequest.Headers = nil
+			mvRequest.MultiValueHeaders = map[string][]string{
+				"host":            {"lambda-test.elb.amazonaws.com"},
+				"x-forwarded-for": {"203.0.113.10, 198.51.100.20", "192.0.2.30"},
+			}
+			accessor := core.RequestAccessorALB{}
+			accessor.SetTrustedProxyCount(2)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), mvRequest)
+			Expect(err).To(BeNil())
+			Expect("198.51.100.20").To(Equal(httpReq.RemoteAddr))
+		})
+
+		It("Leaves RemoteAddr empty without the header", func() {
+			accessor := core.RequestAccessorALB{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getALBRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect("").To(Equal(httpReq.RemoteAddr))
+		})
+	})
+
+	Context("Cookie handling", func() {
+		It("Joins repeated Cookie headers", func() {
+			cookieRequest := getALBRequest("/hello", "GET")
+			cookieRequest.Headers = nil
+			cookieRequest.MultiValueHeaders = map[string][]string{
+				"host":   {"lambda-test.elb.amazonaws.com"},
+				"cookie": {"session=abc; theme=dark", "csrftoken=xyz"},
+			}
+			accessor := core.RequestAccessorALB{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), cookieRequest)
+			Expect(err).To(BeNil())
+			Expect([]string{"session=abc; theme=dark; csrftoken=xyz"}).To(Equal(httpReq.Header.Values("Cookie")))
+
+			cookies := httpReq.Cookies()
+			Expect(3).To(Equal(len(cookies)))
+			session, err := httpReq.Cookie("session")
+			Expect(err).To(BeNil())
+			Expect("abc").To(Equal(session.Value))
+			csrf, err := httpReq.Cookie("csrftoken")
+			Expect(err).To(BeNil())
+			Expect("xyz").To(Equal(csrf.Value))
+		})
+	})
+
+	Context("X-Forwarded-Port handling", func() {
+		It("Adds a non-standard port to the host", func() {
+			portRequest := getALBRequest("/hello", "GET")
+			portRequest.Headers["x-forwarded-port"] = "8443"
+			accessor := core.RequestAccessorALB{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), portRequest)
+			Expect(err).To(BeNil())
+			Expect("lambda-test.elb.amazonaws.com:8443").To(Equal(httpReq.URL.Host))
+			Expect("lambda-test.elb.amazonaws.com:8443").To(Equal(httpReq.Host))
+			Expect("8443").To(Equal(httpReq.URL.Port()))
+		})
+
+		It("Leaves the host alone for the default port or a host with a port", func() {
+			accessor := core.RequestAccessorALB{}
+			portRequest := getALBRequest("/hello", "GET")
+			portRequest.Headers["x-forwarded-port"] = "443"
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), portRequest)
+			Expect(err).To(BeNil())
+			Expect("lambda-test.elb.amazonaws.com").To(Equal(httpReq.Host))
+
+			portRequest = getALBRequest("/hello", "GET")
+			portRequest.Headers["host"] = "lambda-test.elb.amazonaws.com:9000"
+			portRequest.Headers["x-forwarded-port"] = "8443"
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), portRequest)
+			Expect(err).To(BeNil())
+			Expect("lambda-test.elb.amazonaws.com:9000").To(Equal(httpReq.Host))
+		})
+	})
+})
+
+func getALBRequest(path string, method string) events.ALBTargetGroupRequest {
+	return events.ALBTargetGroupRequest{
+		Path:       path,
+		HTTPMethod: method,
+		Headers: map[string]string{
+			"host": "lambda-test.elb.amazonaws.com",
+		},
+		RequestContext: events.ALBTargetGroupRequestContext{
+			ELB: events.ELBContext{
+				TargetGroupArn: "arn:aws:elasticloadbalancing:us-east-2:123456789012:targetgroup/lambda-target/abc",
+			},
+		},
+	}
+}
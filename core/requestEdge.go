@@ -0,0 +1,159 @@
+// Package core provides utility methods that help convert proxy events
+// into an http.Request and http.ResponseWriter
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// ErrNoCloudFrontRecord is returned for CloudFront events without a record.
+var ErrNoCloudFrontRecord = errors.New("CloudFront event has no record")
+
+// RequestAccessorEdge objects convert the CloudFront events of Lambda@Edge
+// viewer request and origin request triggers into requests. The request is
+// sent to the Host header of the viewer request, or the domain name of the
+// distribution when it has none.
+type RequestAccessorEdge struct {
+	protocol string
+}
+
+// SetProtocol overrides the protocol version set on the requests built by
+// the accessor. Requests default to HTTP/1.1. Returns an error if the version
+// can't be parsed.
+func (r *RequestAccessorEdge) SetProtocol(proto string) error {
+	if _, _, ok := http.ParseHTTPVersion(proto); !ok {
+		return fmt.Errorf("Invalid protocol version: %s", proto)
+	}
+	r.protocol = proto
+	return nil
+}
+
+// ProxyEventToHTTPRequest converts a CloudFront event into a http.Request object.
+// CloudFront events have no context headers, the distribution configuration
+// is stored in the context of the request.
+func (r *RequestAccessorEdge) ProxyEventToHTTPRequest(req CloudFrontEvent) (*http.Request, error) {
+	return r.EventToRequestWithContext(context.Background(), req)
+}
+
+// EventToRequestWithContext converts a CloudFront event and context into an http.Request object.
+// Returns the populated http request with lambda context and the CloudFront record as part of its context.
+// Access those using GetCloudFrontContextFromContext, GetCloudFrontRequestFromContext and
+// GetRuntimeContextFromContextEdge functions in this package.
+func (r *RequestAccessorEdge) EventToRequestWithContext(ctx context.Context, req CloudFrontEvent) (*http.Request, error) {
+	httpRequest, body, err := r.eventToRequest(req)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return addToContextEdge(ctx, httpRequest, req.Records[0].CF, body), nil
+}
+
+// EventToRequest converts a CloudFront event into an http.Request object.
+// Returns the populated request maintaining headers
+func (r *RequestAccessorEdge) EventToRequest(req CloudFrontEvent) (*http.Request, error) {
+	httpRequest, _, err := r.eventToRequest(req)
+	return httpRequest, err
+}
+
+func (r *RequestAccessorEdge) eventToRequest(req CloudFrontEvent) (*http.Request, []byte, error) {
+	if len(req.Records) == 0 {
+		return nil, nil, ErrNoCloudFrontRecord
+	}
+	cf := req.Records[0].CF
+	switch cf.Config.EventType {
+	case CloudFrontViewerResponse, CloudFrontOriginResponse:
+		return nil, nil, fmt.Errorf("Unsupported CloudFront event type %s, only request triggers can be proxied", cf.Config.EventType)
+	}
+
+	var decodedBody []byte
+	if cf.Request.Body != nil {
+		decodedBody = []byte(cf.Request.Body.Data)
+		if cf.Request.Body.Encoding == "base64" {
+			base64Body, err := base64.StdEncoding.DecodeString(cf.Request.Body.Data)
+			if err != nil {
+				return nil, nil, err
+			}
+			decodedBody = base64Body
+		}
+	}
+
+	host := cf.Config.DistributionDomainName
+	if values := cf.Request.Headers["host"]; len(values) > 0 && values[0].Value != "" {
+		host = values[0].Value
+	}
+	serverAddress := "https://" + host
+	if customAddress, ok := os.LookupEnv(CustomHostVariable); ok {
+		serverAddress = customAddress
+	}
+	path := serverAddress + cf.Request.URI
+	if cf.Request.QueryString != "" {
+		path += "?" + cf.Request.QueryString
+	}
+
+	httpRequest, err := http.NewRequest(cf.Request.Method, path, bytes.NewReader(decodedBody))
+	if err != nil {
+		log.Printf("Could not convert CloudFront request %s %s to http.Request\n", cf.Request.Method, cf.Request.URI)
+		return nil, nil, err
+	}
+
+	for name, values := range cf.Request.Headers {
+		for _, value := range values {
+			key := value.Key
+			if key == "" {
+				key = name
+			}
+			httpRequest.Header.Add(key, value.Value)
+		}
+	}
+
+	httpRequest.RemoteAddr = cf.Request.ClientIP
+	setProtocol(httpRequest, r.protocol)
+	httpRequest.RequestURI = httpRequest.URL.RequestURI()
+
+	return httpRequest, decodedBody, nil
+}
+
+func addToContextEdge(ctx context.Context, req *http.Request, cf CloudFrontRecordCF, rawBody []byte) *http.Request {
+	lc, _ := lambdacontext.FromContext(ctx)
+	rc := requestContextEdge{lambdaContext: lc, cf: cf, rawBody: rawBody}
+	ctx = context.WithValue(ctx, ctxKey{}, rc)
+	ctx = withColdStart(ctx)
+	ctx = withFunctionMetadata(ctx)
+	ctx = withResponseMetaIfMissing(ctx)
+	return req.WithContext(ctx)
+}
+
+// GetCloudFrontContextFromContext retrieve the CloudFrontConfig of the event from context.Context
+func GetCloudFrontContextFromContext(ctx context.Context) (CloudFrontConfig, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextEdge)
+	return v.cf.Config, ok
+}
+
+// GetCloudFrontRequestFromContext retrieve the CloudFrontRequest of the event
+// from context.Context. Its body reports whether CloudFront truncated the
+// request body and its origin is the origin the request is forwarded to.
+func GetCloudFrontRequestFromContext(ctx context.Context) (CloudFrontRequest, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextEdge)
+	return v.cf.Request, ok
+}
+
+// GetRuntimeContextFromContextEdge retrieve Lambda Runtime Context from context.Context
+func GetRuntimeContextFromContextEdge(ctx context.Context) (*lambdacontext.LambdaContext, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextEdge)
+	return v.lambdaContext, ok
+}
+
+type requestContextEdge struct {
+	lambdaContext *lambdacontext.LambdaContext
+	cf            CloudFrontRecordCF
+	rawBody       []byte
+}
@@ -0,0 +1,130 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestAccessorEdge tests", func() {
+	Context("event conversion", func() {
+		It("Correctly converts a viewer request", func() {
+			accessor := core.RequestAccessorEdge{}
+			lambdaContext := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "abc123"})
+			httpReq, err := accessor.EventToRequestWithContext(lambdaContext, getEdgeRequest(core.CloudFrontViewerRequest, "/hello%20world", "name=go&tag=a"))
+			Expect(err).To(BeNil())
+			Expect("GET").To(Equal(httpReq.Method))
+			Expect("/hello world").To(Equal(httpReq.URL.Path))
+			Expect("/hello%20world?name=go&tag=a").To(Equal(httpReq.RequestURI))
+			Expect("www.example.com").To(Equal(httpReq.Host))
+			Expect("go").To(Equal(httpReq.URL.Query().Get("name")))
+			Expect([]string{"gzip", "br"}).To(Equal(httpReq.Header.Values("Accept-Encoding")))
+			Expect("203.0.113.178").To(Equal(httpReq.RemoteAddr))
+
+			config, ok := core.GetCloudFrontContextFromContext(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("EDFDVBD6EXAMPLE").To(Equal(config.DistributionID))
+			Expect(core.CloudFrontViewerRequest).To(Equal(config.EventType))
+			runtimeContext, ok := core.GetRuntimeContextFromContextEdge(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("abc123").To(Equal(runtimeContext.AwsRequestID))
+			Expect(core.EventTypeLambdaEdge).To(Equal(core.GetEventSource(httpReq.Context())))
+		})
+
+		It("Uses the distribution domain name without a Host header", func() {
+			event := getEdgeRequest(core.CloudFrontOriginRequest, "/", "")
+			delete(event.Records[0].CF.Request.Headers, "host")
+			accessor := core.RequestAccessorEdge{}
+			httpReq, err := accessor.ProxyEventToHTTPRequest(event)
+			Expect(err).To(BeNil())
+			Expect("d111111abcdef8.cloudfront.net").To(Equal(httpReq.Host))
+			Expect("").To(Equal(httpReq.URL.RawQuery))
+		})
+
+		It("Overrides the protocol version", func() {
+			accessor := core.RequestAccessorEdge{}
+			Expect(accessor.SetProtocol("HTTP/2.0")).To(BeNil())
+			Expect(accessor.SetProtocol("HTTP/x")).ToNot(BeNil())
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getEdgeRequest(core.CloudFrontViewerRequest, "/", ""))
+			Expect(err).To(BeNil())
+			Expect("HTTP/2.0").To(Equal(httpReq.Proto))
+			Expect(2).To(Equal(httpReq.ProtoMajor))
+		})
+
+		It("Decodes base64 bodies", func() {
+			event := getEdgeRequest(core.CloudFrontOriginRequest, "/submit", "")
+			event.Records[0].CF.Request.Method = "POST"
+			event.Records[0].CF.Request.Body = &core.CloudFrontRequestBody{Encoding: "base64", Data: "aGVsbG8=", InputTruncated: true}
+			accessor := core.RequestAccessorEdge{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), event)
+			Expect(err).To(BeNil())
+
+			body, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect("hello").To(Equal(string(body)))
+			Expect("hello").To(Equal(string(core.GetRawBody(httpReq.Context()))))
+			cfRequest, ok := core.GetCloudFrontRequestFromContext(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect(true).To(Equal(cfRequest.Body.InputTruncated))
+		})
+
+		It("Rejects events without records and response triggers", func() {
+			accessor := core.RequestAccessorEdge{}
+			_, err := accessor.EventToRequest(core.CloudFrontEvent{})
+			Expect(errors.Is(err, core.ErrNoCloudFrontRecord)).To(BeTrue())
+
+			_, err = accessor.EventToRequest(getEdgeRequest(core.CloudFrontViewerResponse, "/", ""))
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Context("proxy handler", func() {
+		It("Answers the viewer with the response of the handler", func() {
+			handler := core.NewLambdaEdgeProxyHandler(&core.RequestAccessorEdge{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.WriteHeader(http.StatusTeapot)
+				w.Write([]byte("short and stout"))
+			}))
+
+			resp, err := handler.ProxyWithContext(context.Background(), getEdgeRequest(core.CloudFrontViewerRequest, "/", ""))
+			Expect(err).To(BeNil())
+			Expect("418").To(Equal(resp.Status))
+			Expect("I'm a teapot").To(Equal(resp.StatusDescription))
+			Expect([]core.CloudFrontHeader{{Key: "Cache-Control", Value: "max-age=60"}}).To(Equal(resp.Headers["cache-control"]))
+			Expect("short and stout").To(Equal(resp.Body))
+			Expect("text").To(Equal(resp.BodyEncoding))
+		})
+	})
+})
+
+func getEdgeRequest(eventType string, uri string, queryString string) core.CloudFrontEvent {
+	return core.CloudFrontEvent{
+		Records: []core.CloudFrontRecord{{
+			CF: core.CloudFrontRecordCF{
+				Config: core.CloudFrontConfig{
+					DistributionDomainName: "d111111abcdef8.cloudfront.net",
+					DistributionID:         "EDFDVBD6EXAMPLE",
+					EventType:              eventType,
+					RequestID:              "4TyzHTaYWb1GX1qTfsHhEqV6HUDd_BzoBZnwfnvQc_1oF26ClkoUSEQ==",
+				},
+				Request: core.CloudFrontRequest{
+					ClientIP:    "203.0.113.178",
+					Method:      "GET",
+					URI:         uri,
+					QueryString: queryString,
+					Headers: core.CloudFrontHeaders{
+						"host":            {{Key: "Host", Value: "www.example.com"}},
+						"accept-encoding": {{Key: "Accept-Encoding", Value: "gzip"}, {Key: "Accept-Encoding", Value: "br"}},
+					},
+				},
+			},
+		}},
+	}
+}
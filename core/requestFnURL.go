@@ -0,0 +1,260 @@
+// Package core provides utility methods that help convert proxy events
+// into an http.Request and http.ResponseWriter
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// FnURLContextHeader is the custom header key used to store the
+// Function URL request context. To access the Context properties use the
+// GetFunctionURLContext method of the RequestAccessorFnURL object.
+const FnURLContextHeader = "X-GoLambdaProxy-FnUrl-Context"
+
+// RequestAccessorFnURL objects give access to custom Lambda Function URL
+// properties in the request.
+type RequestAccessorFnURL struct {
+	stripBasePath  string
+	protocol       string
+	lazyBody       bool
+	pathRewrites   []PathRewrite
+	preserveMethod bool
+}
+
+// GetFunctionURLContext extracts the Function URL context object from a
+// request's custom header.
+// Returns a populated events.LambdaFunctionURLRequestContext object from
+// the request.
+func (r *RequestAccessorFnURL) GetFunctionURLContext(req *http.Request) (events.LambdaFunctionURLRequestContext, error) {
+	if req.Header.Get(FnURLContextHeader) == "" {
+		return events.LambdaFunctionURLRequestContext{}, errors.New("No context header in request")
+	}
+	context := events.LambdaFunctionURLRequestContext{}
+	err := json.Unmarshal([]byte(req.Header.Get(FnURLContextHeader)), &context)
+	if err != nil {
+		log.Println("Error while unmarshalling context")
+		log.Println(err)
+		return events.LambdaFunctionURLRequestContext{}, err
+	}
+	return context, nil
+}
+
+// StripBasePath instructs the RequestAccessorFnURL object that the given base
+// path should be removed from the request path before sending it to the
+// framework for routing.
+func (r *RequestAccessorFnURL) StripBasePath(basePath string) string {
+	if strings.Trim(basePath, " ") == "" {
+		r.stripBasePath = ""
+		return ""
+	}
+
+	newBasePath := basePath
+	if !strings.HasPrefix(newBasePath, "/") {
+		newBasePath = "/" + newBasePath
+	}
+
+	if strings.HasSuffix(newBasePath, "/") {
+		newBasePath = newBasePath[:len(newBasePath)-1]
+	}
+
+	r.stripBasePath = newBasePath
+
+	return newBasePath
+}
+
+// SetProtocol overrides the protocol version set on the requests built by
+// the accessor. Requests default to HTTP/1.1. Returns an error if the version
+// can't be parsed.
+func (r *RequestAccessorFnURL) SetProtocol(proto string) error {
+	if _, _, ok := http.ParseHTTPVersion(proto); !ok {
+		return fmt.Errorf("Invalid protocol version: %s", proto)
+	}
+	r.protocol = proto
+	return nil
+}
+
+// SetPreserveMethodCase makes the accessor pass the method of the event
+// through verbatim instead of uppercasing it, see
+// RequestAccessor.SetPreserveMethodCase.
+func (r *RequestAccessorFnURL) SetPreserveMethodCase(preserve bool) {
+	r.preserveMethod = preserve
+}
+
+// SetPathRewrites sets rules that rewrite the request path before routing,
+// after the base path was stripped. See RequestAccessor.SetPathRewrites.
+func (r *RequestAccessorFnURL) SetPathRewrites(rules ...PathRewrite) {
+	r.pathRewrites = rules
+}
+
+// SetLazyBody makes the request body read the event body in place, like
+// RequestAccessor.SetLazyBody.
+func (r *RequestAccessorFnURL) SetLazyBody(lazy bool) {
+	r.lazyBody = lazy
+}
+
+// ProxyEventToHTTPRequest converts a Function URL event into a http.Request object.
+// Returns the populated http request with an additional custom header for the Function URL context.
+// To access this property use the GetFunctionURLContext method of the RequestAccessorFnURL object.
+func (r *RequestAccessorFnURL) ProxyEventToHTTPRequest(req events.LambdaFunctionURLRequest) (*http.Request, error) {
+	httpRequest, err := r.EventToRequest(req)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return addToHeaderFnURL(httpRequest, req)
+}
+
+// EventToRequestWithContext converts a Function URL event and context into an http.Request object.
+// Returns the populated http request with lambda context and LambdaFunctionURLRequestContext as part of its context.
+// Access those using GetFunctionURLContextFromContext and GetRuntimeContextFromContextFnURL functions in this package.
+func (r *RequestAccessorFnURL) EventToRequestWithContext(ctx context.Context, req events.LambdaFunctionURLRequest) (*http.Request, error) {
+	httpRequest, body, err := r.eventToRequest(req)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	_, rewrite := r.routingPath(req)
+	return withBasePathRewrite(addToContextFnURL(ctx, httpRequest, req, body), rewrite), nil
+}
+
+// EventToRequest converts a Function URL event into an http.Request object.
+// Returns the populated request maintaining headers
+func (r *RequestAccessorFnURL) EventToRequest(req events.LambdaFunctionURLRequest) (*http.Request, error) {
+	httpRequest, _, err := r.eventToRequest(req)
+	return httpRequest, err
+}
+
+// eventToRequest builds the http.Request for the event and also returns the
+// decoded body bytes it is backed by.
+func (r *RequestAccessorFnURL) eventToRequest(req events.LambdaFunctionURLRequest) (*http.Request, []byte, error) {
+	var decodedBody []byte
+	if !r.lazyBody {
+		decodedBody = []byte(req.Body)
+	}
+	if req.IsBase64Encoded && !r.lazyBody {
+		base64Body, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+		decodedBody = base64Body
+	}
+
+	path, _ := r.routingPath(req)
+	serverAddress := "https://" + req.RequestContext.DomainName
+	if customAddress, ok := os.LookupEnv(CustomHostVariable); ok {
+		serverAddress = customAddress
+	}
+	path = serverAddress + escapeFragment(path)
+
+	if len(req.RawQueryString) > 0 {
+		path += "?" + req.RawQueryString
+	} else if len(req.QueryStringParameters) > 0 {
+		values := url.Values{}
+		for key, value := range req.QueryStringParameters {
+			values.Add(key, value)
+		}
+		path += "?" + values.Encode()
+	}
+
+	httpRequest, err := http.NewRequest(
+		eventMethod(req.RequestContext.HTTP.Method, r.preserveMethod),
+		path,
+		bytes.NewReader(decodedBody),
+	)
+
+	if err != nil {
+		fmt.Printf("Could not convert request %s:%s to http.Request\n", req.RequestContext.HTTP.Method, req.RequestContext.HTTP.Path)
+		log.Println(err)
+		return nil, nil, err
+	}
+	if r.lazyBody {
+		if err := setLazyBody(httpRequest, req.Body, req.IsBase64Encoded, 0); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for headerKey, headerValue := range req.Headers {
+		addJoinedHeader(httpRequest.Header, headerKey, headerValue)
+	}
+
+	// Function URLs move the Cookie header into the cookies array
+	if len(req.Cookies) > 0 {
+		httpRequest.Header.Set("Cookie", mergeCookies(httpRequest.Header.Values("Cookie"), req.Cookies))
+	}
+
+	httpRequest.RemoteAddr = req.RequestContext.HTTP.SourceIP
+	// Lambda receives the whole body up front, handlers must not wait to
+	// send a 100 Continue
+	httpRequest.Header.Del("Expect")
+	setProtocol(httpRequest, r.protocol)
+	setForwardedPort(httpRequest)
+	httpRequest.RequestURI = httpRequest.URL.RequestURI()
+
+	return httpRequest, decodedBody, nil
+}
+
+func addToHeaderFnURL(req *http.Request, fnURLRequest events.LambdaFunctionURLRequest) (*http.Request, error) {
+	fnURLContext, err := json.Marshal(fnURLRequest.RequestContext)
+	if err != nil {
+		log.Println("Could not Marshal Function URL context for custom header")
+		return req, err
+	}
+	req.Header.Add(FnURLContextHeader, string(fnURLContext))
+	return req, nil
+}
+
+func addToContextFnURL(ctx context.Context, req *http.Request, fnURLRequest events.LambdaFunctionURLRequest, body []byte) *http.Request {
+	lc, _ := lambdacontext.FromContext(ctx)
+	rc := requestContextFnURL{lambdaContext: lc, fnURLContext: fnURLRequest.RequestContext, rawBody: body}
+	ctx = context.WithValue(ctx, ctxKey{}, rc)
+	ctx = withColdStart(ctx)
+	ctx = withFunctionMetadata(ctx)
+	ctx = withResponseMetaIfMissing(ctx)
+	return req.WithContext(ctx)
+}
+
+// GetFunctionURLContextFromContext retrieve LambdaFunctionURLRequestContext from context.Context
+func GetFunctionURLContextFromContext(ctx context.Context) (events.LambdaFunctionURLRequestContext, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextFnURL)
+	return v.fnURLContext, ok
+}
+
+// GetRuntimeContextFromContextFnURL retrieve Lambda Runtime Context from context.Context
+func GetRuntimeContextFromContextFnURL(ctx context.Context) (*lambdacontext.LambdaContext, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextFnURL)
+	return v.lambdaContext, ok
+}
+
+type requestContextFnURL struct {
+	lambdaContext *lambdacontext.LambdaContext
+	fnURLContext  events.LambdaFunctionURLRequestContext
+	rawBody       []byte
+}
+
+// routingPath returns the path the request is routed on, after StripBasePath
+// and the path rewrites were applied, and the base path that was stripped.
+func (r *RequestAccessorFnURL) routingPath(req events.LambdaFunctionURLRequest) (string, basePathRewrite) {
+	path := req.RawPath
+	if len(path) == 0 {
+		path = req.RequestContext.HTTP.Path
+	}
+
+	path, rewrite := stripPathPrefix(path, configuredBasePath(r.stripBasePath))
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return applyPathRewrites(path, r.pathRewrites), rewrite
+}
@@ -0,0 +1,116 @@
+package core_test
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"math/rand"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestAccessorFnURL tests", func() {
+	Context("event conversion", func() {
+		accessor := core.RequestAccessorFnURL{}
+		basicRequest := getFunctionURLRequest("/hello", "GET")
+		It("Correctly converts a basic event", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), basicRequest)
+			Expect(err).To(BeNil())
+			Expect("/hello").To(Equal(httpReq.URL.Path))
+			Expect("/hello").To(Equal(httpReq.RequestURI))
+			Expect("GET").To(Equal(httpReq.Method))
+			Expect("abcdefg.lambda-url.us-east-2.on.aws").To(Equal(httpReq.Host))
+			Expect("203.0.113.10").To(Equal(httpReq.RemoteAddr))
+		})
+
+		It("Uses the raw query string", func() {
+			qsRequest := getFunctionURLRequest("/hello", "GET")
+			qsRequest.RawQueryString = "hello=1&world=2&world=3"
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), qsRequest)
+			Expect(err).To(BeNil())
+			Expect([]string{"2", "3"}).To(Equal(httpReq.URL.Query()["world"]))
+		})
+
+		It("Keeps an encoded # in the path", func() {
+			fragmentRequest := getFunctionURLRequest("/docs/a%23b", "GET")
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), fragmentRequest)
+			Expect(err).To(BeNil())
+			Expect("/docs/a#b").To(Equal(httpReq.URL.Path))
+			Expect("/docs/a%23b").To(Equal(httpReq.URL.EscapedPath()))
+			Expect("").To(Equal(httpReq.URL.Fragment))
+			Expect("/docs/a%23b").To(Equal(httpReq.RequestURI))
+		})
+
+		It("Joins the cookies into the Cookie header", func() {
+			cookieRequest := getFunctionURLRequest("/hello", "GET")
+			cookieRequest.Cookies = []string{"session=abc", "theme=dark"}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), cookieRequest)
+			Expect(err).To(BeNil())
+			Expect("session=abc; theme=dark").To(Equal(httpReq.Header.Get("Cookie")))
+			theme, err := httpReq.Cookie("theme")
+			Expect(err).To(BeNil())
+			Expect("dark").To(Equal(theme.Value))
+		})
+
+		binaryBody := make([]byte, 256)
+		_, err := rand.Read(binaryBody)
+		if err != nil {
+			Fail("Could not generate random binary body")
+		}
+
+		binaryRequest := getFunctionURLRequest("/hello", "POST")
+		binaryRequest.Body = base64.StdEncoding.EncodeToString(binaryBody)
+		binaryRequest.IsBase64Encoded = true
+
+		It("Decodes a base64 encoded body", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), binaryRequest)
+			Expect(err).To(BeNil())
+			Expect("POST").To(Equal(httpReq.Method))
+
+			bodyBytes, err := ioutil.ReadAll(httpReq.Body)
+
+			Expect(err).To(BeNil())
+			Expect(binaryBody).To(Equal(bodyBytes))
+		})
+
+		It("Populates the Function URL context", func() {
+			lambdaContext := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "abc123"})
+			httpReq, err := accessor.EventToRequestWithContext(lambdaContext, basicRequest)
+			Expect(err).To(BeNil())
+
+			fnURLContext, ok := core.GetFunctionURLContextFromContext(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("x").To(Equal(fnURLContext.RequestID))
+			runtimeContext, ok := core.GetRuntimeContextFromContextFnURL(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("abc123").To(Equal(runtimeContext.AwsRequestID))
+
+			// calling old method to verify the header is populated
+			httpReq, err = accessor.ProxyEventToHTTPRequest(basicRequest)
+			Expect(err).To(BeNil())
+			headerContext, err := accessor.GetFunctionURLContext(httpReq)
+			Expect(err).To(BeNil())
+			Expect(fnURLContext).To(Equal(headerContext))
+		})
+	})
+})
+
+func getFunctionURLRequest(path string, method string) events.LambdaFunctionURLRequest {
+	return events.LambdaFunctionURLRequest{
+		RawPath: path,
+		RequestContext: events.LambdaFunctionURLRequestContext{
+			RequestID:  "x",
+			DomainName: "abcdefg.lambda-url.us-east-2.on.aws",
+			HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{
+				Path:     path,
+				Method:   method,
+				SourceIP: "203.0.113.10",
+			},
+		},
+	}
+}
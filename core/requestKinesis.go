@@ -0,0 +1,124 @@
+// Package core provides utility methods that help convert proxy events
+// into an http.Request and http.ResponseWriter
+package core
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+const (
+	// KinesisPartitionKeyHeader is the header that holds the partition key of
+	// the Kinesis record a request was built from.
+	KinesisPartitionKeyHeader = "X-Kinesis-Partition-Key"
+	// KinesisSequenceNumberHeader is the header that holds the sequence number
+	// of the Kinesis record a request was built from.
+	KinesisSequenceNumberHeader = "X-Kinesis-Sequence-Number"
+)
+
+// RequestAccessorKinesis objects convert Kinesis stream records into
+// requests, so records can be processed by the same http.Handler that serves
+// API traffic. Requests are sent as a POST to "/" unless a route is set with
+// SetRoute.
+type RequestAccessorKinesis struct {
+	method string
+	path   string
+}
+
+// SetRoute sets the method and path of the requests built from the records.
+func (r *RequestAccessorKinesis) SetRoute(method string, path string) {
+	r.method = strings.ToUpper(method)
+	r.path = path
+}
+
+// EventToRequestWithContext converts a Kinesis record and context into an http.Request object.
+// Returns the populated http request with lambda context and the record as part of its context.
+// Access those using GetKinesisRecordFromContext and GetRuntimeContextFromContextKinesis functions in this package.
+func (r *RequestAccessorKinesis) EventToRequestWithContext(ctx context.Context, record events.KinesisEventRecord) (*http.Request, error) {
+	httpRequest, err := r.EventToRequest(record)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return addToContextKinesis(ctx, httpRequest, record), nil
+}
+
+// EventToRequest converts a Kinesis record into an http.Request object.
+// The body is the record data and the partition key and sequence number are
+// set in the KinesisPartitionKeyHeader and KinesisSequenceNumberHeader headers.
+func (r *RequestAccessorKinesis) EventToRequest(record events.KinesisEventRecord) (*http.Request, error) {
+	method := r.method
+	if method == "" {
+		method = http.MethodPost
+	}
+	path := r.path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	serverAddress := "https://kinesis." + record.AwsRegion + ".amazonaws.com"
+	if customAddress, ok := os.LookupEnv(CustomHostVariable); ok {
+		serverAddress = customAddress
+	}
+
+	httpRequest, err := http.NewRequest(method, serverAddress+path, bytes.NewReader(record.Kinesis.Data))
+	if err != nil {
+		log.Printf("Could not convert Kinesis record %s to http.Request\n", record.EventID)
+		return nil, err
+	}
+
+	httpRequest.Header.Set(KinesisPartitionKeyHeader, record.Kinesis.PartitionKey)
+	httpRequest.Header.Set(KinesisSequenceNumberHeader, record.Kinesis.SequenceNumber)
+	httpRequest.RequestURI = httpRequest.URL.RequestURI()
+
+	return httpRequest, nil
+}
+
+// ProxyBatch sends every record of the event to the handler in order and
+// reports the records that could not be converted or got a response with a
+// status outside of the 2xx range as batch item failures, so Lambda only
+// retries those. Enable ReportBatchItemFailures on the event source mapping
+// to use the response.
+func (r *RequestAccessorKinesis) ProxyBatch(ctx context.Context, event events.KinesisEvent, handler http.Handler) events.KinesisEventResponse {
+	results := NewBatchRunner(r.EventToRequestWithContext).Run(ctx, event.Records, handler)
+	resp := events.KinesisEventResponse{BatchItemFailures: []events.KinesisBatchItemFailure{}}
+	for _, record := range results.Failed() {
+		resp.BatchItemFailures = append(resp.BatchItemFailures, events.KinesisBatchItemFailure{
+			ItemIdentifier: record.Kinesis.SequenceNumber,
+		})
+	}
+	return resp
+}
+
+func addToContextKinesis(ctx context.Context, req *http.Request, record events.KinesisEventRecord) *http.Request {
+	lc, _ := lambdacontext.FromContext(ctx)
+	rc := requestContextKinesis{lambdaContext: lc, record: record}
+	ctx = context.WithValue(ctx, ctxKey{}, rc)
+	ctx = withColdStart(ctx)
+	ctx = withFunctionMetadata(ctx)
+	ctx = withResponseMetaIfMissing(ctx)
+	return req.WithContext(ctx)
+}
+
+// GetKinesisRecordFromContext retrieve the Kinesis record from context.Context
+func GetKinesisRecordFromContext(ctx context.Context) (events.KinesisEventRecord, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextKinesis)
+	return v.record, ok
+}
+
+// GetRuntimeContextFromContextKinesis retrieve Lambda Runtime Context from context.Context
+func GetRuntimeContextFromContextKinesis(ctx context.Context) (*lambdacontext.LambdaContext, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextKinesis)
+	return v.lambdaContext, ok
+}
+
+type requestContextKinesis struct {
+	lambdaContext *lambdacontext.LambdaContext
+	record        events.KinesisEventRecord
+}
@@ -0,0 +1,103 @@
+package core_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestAccessorKinesis tests", func() {
+	Context("record conversion", func() {
+		It("Correctly converts a record", func() {
+			accessor := core.RequestAccessorKinesis{}
+			lambdaContext := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "abc123"})
+			httpReq, err := accessor.EventToRequestWithContext(lambdaContext, getKinesisRecord("1", "{\"order\": 1}"))
+			Expect(err).To(BeNil())
+			Expect("POST").To(Equal(httpReq.Method))
+			Expect("/").To(Equal(httpReq.URL.Path))
+			Expect("partition-1").To(Equal(httpReq.Header.Get(core.KinesisPartitionKeyHeader)))
+			Expect("1").To(Equal(httpReq.Header.Get(core.KinesisSequenceNumberHeader)))
+
+			body, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect("{\"order\": 1}").To(Equal(string(body)))
+
+			record, ok := core.GetKinesisRecordFromContext(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("shardId-000000000000:1").To(Equal(record.EventID))
+			runtimeContext, ok := core.GetRuntimeContextFromContextKinesis(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("abc123").To(Equal(runtimeContext.AwsRequestID))
+			Expect(core.EventTypeKinesis).To(Equal(core.GetEventSource(httpReq.Context())))
+		})
+
+		It("Uses the configured route", func() {
+			accessor := core.RequestAccessorKinesis{}
+			accessor.SetRoute("put", "/orders")
+			httpReq, err := accessor.EventToRequest(getKinesisRecord("1", "{}"))
+			Expect(err).To(BeNil())
+			Expect("PUT").To(Equal(httpReq.Method))
+			Expect("/orders").To(Equal(httpReq.URL.Path))
+			Expect("/orders").To(Equal(httpReq.RequestURI))
+		})
+	})
+
+	Context("batch processing", func() {
+		It("Reports the records that failed", func() {
+			var processed []string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				processed = append(processed, string(body))
+				if string(body) == "bad" {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			event := events.KinesisEvent{Records: []events.KinesisEventRecord{
+				getKinesisRecord("1", "good"),
+				getKinesisRecord("2", "bad"),
+				getKinesisRecord("3", "good"),
+			}}
+
+			accessor := core.RequestAccessorKinesis{}
+			resp := accessor.ProxyBatch(context.Background(), event, handler)
+			Expect([]string{"good", "bad", "good"}).To(Equal(processed))
+			Expect([]events.KinesisBatchItemFailure{{ItemIdentifier: "2"}}).To(Equal(resp.BatchItemFailures))
+		})
+
+		It("Returns an empty list of failures when all records succeed", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			accessor := core.RequestAccessorKinesis{}
+			resp := accessor.ProxyBatch(context.Background(), events.KinesisEvent{Records: []events.KinesisEventRecord{getKinesisRecord("1", "good")}}, handler)
+			Expect(resp.BatchItemFailures).ToNot(BeNil())
+			Expect(resp.BatchItemFailures).To(BeEmpty())
+		})
+	})
+})
+
+func getKinesisRecord(sequenceNumber string, data string) events.KinesisEventRecord {
+	return events.KinesisEventRecord{
+		AwsRegion:      "us-east-1",
+		EventID:        "shardId-000000000000:" + sequenceNumber,
+		EventName:      "aws:kinesis:record",
+		EventSource:    "aws:kinesis",
+		EventSourceArn: "arn:aws:kinesis:us-east-1:123456789012:stream/orders",
+		Kinesis: events.KinesisRecord{
+			Data:           []byte(data),
+			PartitionKey:   "partition-1",
+			SequenceNumber: sequenceNumber,
+		},
+	}
+}
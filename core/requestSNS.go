@@ -0,0 +1,166 @@
+// Package core provides utility methods that help convert proxy events
+// into an http.Request and http.ResponseWriter
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+const (
+	// SNSTopicArnHeader is the header that holds the ARN of the topic an SNS
+	// message was published to.
+	SNSTopicArnHeader = "X-Sns-Topic-Arn"
+	// SNSMessageIDHeader is the header that holds the ID of the SNS message.
+	SNSMessageIDHeader = "X-Sns-Message-Id"
+	// SNSSubjectHeader is the header that holds the subject of the SNS message.
+	SNSSubjectHeader = "X-Sns-Subject"
+	// SNSMessageAttributeHeaderPrefix prefixes the name of each message
+	// attribute to build the header that holds its value.
+	SNSMessageAttributeHeaderPrefix = "X-Sns-Attribute-"
+)
+
+// RequestAccessorSNS objects convert SNS messages delivered to the function
+// into requests, so messages can be processed by the same http.Handler that
+// serves API traffic. Requests are sent as a POST to "/" unless a route is
+// set with SetRoute.
+type RequestAccessorSNS struct {
+	method string
+	path   string
+}
+
+// SetRoute sets the method and path of the requests built from the records.
+func (r *RequestAccessorSNS) SetRoute(method string, path string) {
+	r.method = strings.ToUpper(method)
+	r.path = path
+}
+
+// EventToRequestWithContext converts an SNS record and context into an http.Request object.
+// Returns the populated http request with lambda context and the record as part of its context.
+// Access those using GetSNSRecordFromContext and GetRuntimeContextFromContextSNS functions in this package.
+func (r *RequestAccessorSNS) EventToRequestWithContext(ctx context.Context, record events.SNSEventRecord) (*http.Request, error) {
+	httpRequest, err := r.EventToRequest(record)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return addToContextSNS(ctx, httpRequest, record), nil
+}
+
+// EventToRequest converts an SNS record into an http.Request object.
+// The body is the message, the topic ARN, message ID and subject are set in
+// the SNSTopicArnHeader, SNSMessageIDHeader and SNSSubjectHeader headers and
+// each message attribute is set in a header named after the attribute with
+// the SNSMessageAttributeHeaderPrefix.
+func (r *RequestAccessorSNS) EventToRequest(record events.SNSEventRecord) (*http.Request, error) {
+	method := r.method
+	if method == "" {
+		method = http.MethodPost
+	}
+	path := r.path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	serverAddress := "https://sns." + snsRegion(record.SNS.TopicArn) + ".amazonaws.com"
+	if customAddress, ok := os.LookupEnv(CustomHostVariable); ok {
+		serverAddress = customAddress
+	}
+
+	httpRequest, err := http.NewRequest(method, serverAddress+path, strings.NewReader(record.SNS.Message))
+	if err != nil {
+		log.Printf("Could not convert SNS message %s to http.Request\n", record.SNS.MessageID)
+		return nil, err
+	}
+
+	httpRequest.Header.Set(SNSTopicArnHeader, record.SNS.TopicArn)
+	httpRequest.Header.Set(SNSMessageIDHeader, record.SNS.MessageID)
+	if record.SNS.Subject != "" {
+		httpRequest.Header.Set(SNSSubjectHeader, record.SNS.Subject)
+	}
+	for name, attribute := range record.SNS.MessageAttributes {
+		if value, ok := snsAttributeValue(attribute); ok {
+			httpRequest.Header.Set(SNSMessageAttributeHeaderPrefix+name, value)
+		}
+	}
+	httpRequest.RequestURI = httpRequest.URL.RequestURI()
+
+	return httpRequest, nil
+}
+
+// ProxyBatch sends every record of the event to the handler in order.
+// SNS has no partial batch failure response: if any record could not be
+// converted or got a response with a status outside of the 2xx range an
+// error is returned and Lambda retries the whole event, including the
+// records that were processed successfully. Lambda currently delivers a
+// single message per SNS event.
+func (r *RequestAccessorSNS) ProxyBatch(ctx context.Context, event events.SNSEvent, handler http.Handler) error {
+	results := NewBatchRunner(r.EventToRequestWithContext).Run(ctx, event.Records, handler)
+	failed := results.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(failed))
+	for i, record := range failed {
+		ids[i] = record.SNS.MessageID
+	}
+	return fmt.Errorf("Could not process SNS messages: %s", strings.Join(ids, ", "))
+}
+
+// snsRegion returns the region of a topic ARN such as
+// arn:aws:sns:us-east-1:123456789012:orders.
+func snsRegion(topicArn string) string {
+	parts := strings.Split(topicArn, ":")
+	if len(parts) < 4 {
+		return ""
+	}
+	return parts[3]
+}
+
+// snsAttributeValue returns the value of a message attribute, which the
+// event holds as an object with a Type and a Value.
+func snsAttributeValue(attribute interface{}) (string, bool) {
+	fields, ok := attribute.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	value, ok := fields["Value"]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+func addToContextSNS(ctx context.Context, req *http.Request, record events.SNSEventRecord) *http.Request {
+	lc, _ := lambdacontext.FromContext(ctx)
+	rc := requestContextSNS{lambdaContext: lc, record: record}
+	ctx = context.WithValue(ctx, ctxKey{}, rc)
+	ctx = withColdStart(ctx)
+	ctx = withFunctionMetadata(ctx)
+	ctx = withResponseMetaIfMissing(ctx)
+	return req.WithContext(ctx)
+}
+
+// GetSNSRecordFromContext retrieve the SNS record from context.Context
+func GetSNSRecordFromContext(ctx context.Context) (events.SNSEventRecord, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextSNS)
+	return v.record, ok
+}
+
+// GetRuntimeContextFromContextSNS retrieve Lambda Runtime Context from context.Context
+func GetRuntimeContextFromContextSNS(ctx context.Context) (*lambdacontext.LambdaContext, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextSNS)
+	return v.lambdaContext, ok
+}
+
+type requestContextSNS struct {
+	lambdaContext *lambdacontext.LambdaContext
+	record        events.SNSEventRecord
+}
@@ -0,0 +1,84 @@
+package core_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestAccessorSNS tests", func() {
+	Context("record conversion", func() {
+		It("Correctly converts a record", func() {
+			accessor := core.RequestAccessorSNS{}
+			lambdaContext := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "abc123"})
+			httpReq, err := accessor.EventToRequestWithContext(lambdaContext, getSNSRecord("msg-1", "{\"order\": 1}"))
+			Expect(err).To(BeNil())
+			Expect("POST").To(Equal(httpReq.Method))
+			Expect("/").To(Equal(httpReq.URL.Path))
+			Expect("sns.us-east-1.amazonaws.com").To(Equal(httpReq.Host))
+			Expect("arn:aws:sns:us-east-1:123456789012:orders").To(Equal(httpReq.Header.Get(core.SNSTopicArnHeader)))
+			Expect("msg-1").To(Equal(httpReq.Header.Get(core.SNSMessageIDHeader)))
+			Expect("Order created").To(Equal(httpReq.Header.Get(core.SNSSubjectHeader)))
+			Expect("eu").To(Equal(httpReq.Header.Get(core.SNSMessageAttributeHeaderPrefix + "region")))
+
+			body, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect("{\"order\": 1}").To(Equal(string(body)))
+
+			record, ok := core.GetSNSRecordFromContext(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("msg-1").To(Equal(record.SNS.MessageID))
+			runtimeContext, ok := core.GetRuntimeContextFromContextSNS(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("abc123").To(Equal(runtimeContext.AwsRequestID))
+			Expect(core.EventTypeSNS).To(Equal(core.GetEventSource(httpReq.Context())))
+		})
+	})
+
+	Context("batch processing", func() {
+		It("Routes the records to the handler", func() {
+			mux := http.NewServeMux()
+			var orders []string
+			mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				orders = append(orders, string(body))
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			accessor := core.RequestAccessorSNS{}
+			accessor.SetRoute("post", "/orders")
+			event := events.SNSEvent{Records: []events.SNSEventRecord{getSNSRecord("msg-1", "first")}}
+			Expect(accessor.ProxyBatch(context.Background(), event, mux)).To(BeNil())
+			Expect([]string{"first"}).To(Equal(orders))
+
+			accessor.SetRoute("post", "/unknown")
+			err := accessor.ProxyBatch(context.Background(), event, mux)
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("msg-1"))
+		})
+	})
+})
+
+func getSNSRecord(messageID string, message string) events.SNSEventRecord {
+	return events.SNSEventRecord{
+		EventSource:  "aws:sns",
+		EventVersion: "1.0",
+		SNS: events.SNSEntity{
+			MessageID: messageID,
+			Type:      "Notification",
+			TopicArn:  "arn:aws:sns:us-east-1:123456789012:orders",
+			Subject:   "Order created",
+			Message:   message,
+			MessageAttributes: map[string]interface{}{
+				"region": map[string]interface{}{"Type": "String", "Value": "eu"},
+			},
+		},
+	}
+}
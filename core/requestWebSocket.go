@@ -0,0 +1,167 @@
+// Package core provides utility methods that help convert proxy events
+// into an http.Request and http.ResponseWriter
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// RequestAccessorWebSocket objects convert API Gateway WebSocket API events
+// into requests. Each event is sent to the path of its route key, for
+// example /$connect or /sendMessage, with the method of the event or POST
+// for messages, which have none.
+type RequestAccessorWebSocket struct {
+	protocol       string
+	preserveMethod bool
+}
+
+// SetProtocol overrides the protocol version set on the requests built by
+// the accessor. Requests default to HTTP/1.1. Returns an error if the version
+// can't be parsed.
+func (r *RequestAccessorWebSocket) SetProtocol(proto string) error {
+	if _, _, ok := http.ParseHTTPVersion(proto); !ok {
+		return fmt.Errorf("Invalid protocol version: %s", proto)
+	}
+	r.protocol = proto
+	return nil
+}
+
+// SetPreserveMethodCase makes the accessor pass the method of the event
+// through verbatim instead of uppercasing it, see
+// RequestAccessor.SetPreserveMethodCase.
+func (r *RequestAccessorWebSocket) SetPreserveMethodCase(preserve bool) {
+	r.preserveMethod = preserve
+}
+
+// ProxyEventToHTTPRequest converts a WebSocket event into a http.Request object.
+// WebSocket events have no context headers, the WebSocket request context is
+// stored in the context of the request.
+func (r *RequestAccessorWebSocket) ProxyEventToHTTPRequest(req events.APIGatewayWebsocketProxyRequest) (*http.Request, error) {
+	return r.EventToRequestWithContext(context.Background(), req)
+}
+
+// EventToRequestWithContext converts a WebSocket event and context into an http.Request object.
+// Returns the populated http request with lambda context and the WebSocket request context as part of its context.
+// Access those using GetWebSocketContextFromContext and GetRuntimeContextFromContextWebSocket functions in this package.
+func (r *RequestAccessorWebSocket) EventToRequestWithContext(ctx context.Context, req events.APIGatewayWebsocketProxyRequest) (*http.Request, error) {
+	httpRequest, err := r.EventToRequest(req)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return addToContextWebSocket(ctx, httpRequest, req), nil
+}
+
+// EventToRequest converts a WebSocket event into an http.Request object.
+// Returns the populated request maintaining headers
+func (r *RequestAccessorWebSocket) EventToRequest(req events.APIGatewayWebsocketProxyRequest) (*http.Request, error) {
+	decodedBody := []byte(req.Body)
+	if req.IsBase64Encoded {
+		base64Body, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		decodedBody = base64Body
+	}
+
+	method := eventMethod(req.HTTPMethod, r.preserveMethod)
+	if method == "" {
+		method = http.MethodPost
+	}
+	serverAddress := "https://" + req.RequestContext.DomainName
+	if customAddress, ok := os.LookupEnv(CustomHostVariable); ok {
+		serverAddress = customAddress
+	}
+	path := serverAddress + "/" + url.PathEscape(req.RequestContext.RouteKey)
+
+	if len(req.MultiValueQueryStringParameters) > 0 {
+		path += "?" + url.Values(req.MultiValueQueryStringParameters).Encode()
+	} else if len(req.QueryStringParameters) > 0 {
+		values := url.Values{}
+		for key, value := range req.QueryStringParameters {
+			values.Add(key, value)
+		}
+		path += "?" + values.Encode()
+	}
+
+	httpRequest, err := http.NewRequest(method, path, bytes.NewReader(decodedBody))
+	if err != nil {
+		log.Printf("Could not convert WebSocket event %s for connection %s to http.Request\n", req.RequestContext.RouteKey, req.RequestContext.ConnectionID)
+		return nil, err
+	}
+
+	if req.MultiValueHeaders != nil {
+		for k, values := range req.MultiValueHeaders {
+			for _, value := range values {
+				httpRequest.Header.Add(k, value)
+			}
+		}
+	} else {
+		for h := range req.Headers {
+			httpRequest.Header.Add(h, req.Headers[h])
+		}
+	}
+
+	httpRequest.RemoteAddr = req.RequestContext.Identity.SourceIP
+	setProtocol(httpRequest, r.protocol)
+	httpRequest.RequestURI = httpRequest.URL.RequestURI()
+
+	return httpRequest, nil
+}
+
+func addToContextWebSocket(ctx context.Context, req *http.Request, wsRequest events.APIGatewayWebsocketProxyRequest) *http.Request {
+	lc, _ := lambdacontext.FromContext(ctx)
+	rc := requestContextWebSocket{lambdaContext: lc, wsContext: wsRequest.RequestContext}
+	ctx = context.WithValue(ctx, ctxKey{}, rc)
+	ctx = withColdStart(ctx)
+	ctx = withFunctionMetadata(ctx)
+	ctx = withResponseMetaIfMissing(ctx)
+	return req.WithContext(ctx)
+}
+
+// GetWebSocketContextFromContext retrieve APIGatewayWebsocketProxyRequestContext from context.Context
+func GetWebSocketContextFromContext(ctx context.Context) (events.APIGatewayWebsocketProxyRequestContext, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextWebSocket)
+	return v.wsContext, ok
+}
+
+// GetRuntimeContextFromContextWebSocket retrieve Lambda Runtime Context from context.Context
+func GetRuntimeContextFromContextWebSocket(ctx context.Context) (*lambdacontext.LambdaContext, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextWebSocket)
+	return v.lambdaContext, ok
+}
+
+// GetWebSocketCallbackURL returns the URL of the connection of a WebSocket
+// request in the API Gateway management API, such as
+// https://abc123.execute-api.us-east-1.amazonaws.com/prod/@connections/L0SM9cOFvHcCIhw=.
+// Handlers POST to it to send a message to the client, GET it for the
+// connection status and DELETE it to disconnect the client. The URL is built
+// from the domain name and stage of the event, events received through a
+// custom domain name need the execute-api domain of the API instead.
+func GetWebSocketCallbackURL(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextWebSocket)
+	if !ok || v.wsContext.ConnectionID == "" || v.wsContext.DomainName == "" {
+		return "", false
+	}
+	callbackURL := url.URL{
+		Scheme: "https",
+		Host:   v.wsContext.DomainName,
+		Path:   "/" + v.wsContext.Stage + "/@connections/" + v.wsContext.ConnectionID,
+	}
+	return callbackURL.String(), true
+}
+
+type requestContextWebSocket struct {
+	lambdaContext *lambdacontext.LambdaContext
+	wsContext     events.APIGatewayWebsocketProxyRequestContext
+}
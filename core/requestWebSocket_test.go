@@ -0,0 +1,88 @@
+package core_test
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestAccessorWebSocket tests", func() {
+	Context("event conversion", func() {
+		It("Correctly converts a message event", func() {
+			accessor := core.RequestAccessorWebSocket{}
+			lambdaContext := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "abc123"})
+			httpReq, err := accessor.EventToRequestWithContext(lambdaContext, getWebSocketRequest("sendMessage", "{\"text\": \"hi\"}"))
+			Expect(err).To(BeNil())
+			Expect("POST").To(Equal(httpReq.Method))
+			Expect("/sendMessage").To(Equal(httpReq.URL.Path))
+			Expect("abc123.execute-api.us-east-1.amazonaws.com").To(Equal(httpReq.Host))
+
+			body, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect("{\"text\": \"hi\"}").To(Equal(string(body)))
+
+			wsContext, ok := core.GetWebSocketContextFromContext(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("L0SM9cOFvHcCIhw=").To(Equal(wsContext.ConnectionID))
+			runtimeContext, ok := core.GetRuntimeContextFromContextWebSocket(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("abc123").To(Equal(runtimeContext.AwsRequestID))
+		})
+
+		It("Routes connect events with their method", func() {
+			connect := getWebSocketRequest("$connect", "")
+			connect.HTTPMethod = "GET"
+			connect.Headers = map[string]string{"Sec-WebSocket-Protocol": "chat"}
+			accessor := core.RequestAccessorWebSocket{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), connect)
+			Expect(err).To(BeNil())
+			Expect("GET").To(Equal(httpReq.Method))
+			Expect("/$connect").To(Equal(httpReq.URL.Path))
+			Expect("chat").To(Equal(httpReq.Header.Get("Sec-WebSocket-Protocol")))
+		})
+
+		It("Overrides the protocol version", func() {
+			accessor := core.RequestAccessorWebSocket{}
+			Expect(accessor.SetProtocol("HTTP/2.0")).To(BeNil())
+			Expect(accessor.SetProtocol("HTTP/x")).ToNot(BeNil())
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getWebSocketRequest("sendMessage", "hi"))
+			Expect(err).To(BeNil())
+			Expect("HTTP/2.0").To(Equal(httpReq.Proto))
+			Expect(2).To(Equal(httpReq.ProtoMajor))
+		})
+	})
+
+	Context("callback URL", func() {
+		It("Composes the management API URL of the connection", func() {
+			accessor := core.RequestAccessorWebSocket{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getWebSocketRequest("sendMessage", ""))
+			Expect(err).To(BeNil())
+			callbackURL, ok := core.GetWebSocketCallbackURL(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("https://abc123.execute-api.us-east-1.amazonaws.com/prod/@connections/L0SM9cOFvHcCIhw=").To(Equal(callbackURL))
+
+			_, ok = core.GetWebSocketCallbackURL(context.Background())
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
+
+func getWebSocketRequest(routeKey string, body string) events.APIGatewayWebsocketProxyRequest {
+	return events.APIGatewayWebsocketProxyRequest{
+		Body: body,
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{
+			RouteKey:     routeKey,
+			Stage:        "prod",
+			RequestID:    "req-1",
+			ConnectionID: "L0SM9cOFvHcCIhw=",
+			DomainName:   "abc123.execute-api.us-east-1.amazonaws.com",
+			EventType:    "MESSAGE",
+		},
+	}
+}
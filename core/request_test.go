@@ -1,11 +1,19 @@
 package core_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/base64"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -38,6 +46,70 @@ var _ = Describe("RequestAccessor tests", func() {
 			Expect("GET").To(Equal(httpReq.Method))
 		})
 
+		It("Passes nonstandard methods through", func() {
+			for _, method := range []string{"PROPFIND", "MKCALENDAR", "REPORT"} {
+				httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/calendars/home", method))
+				Expect(err).To(BeNil())
+				Expect(method).To(Equal(httpReq.Method))
+			}
+		})
+
+		It("Preserves the method case when enabled", func() {
+			preserving := core.RequestAccessor{}
+			preserving.SetPreserveMethodCase(true)
+			httpReq, err := preserving.EventToRequestWithContext(context.Background(), getProxyRequest("/calendars/home", "PropFind"))
+			Expect(err).To(BeNil())
+			Expect("PropFind").To(Equal(httpReq.Method))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/calendars/home", "PropFind"))
+			Expect(err).To(BeNil())
+			Expect("PROPFIND").To(Equal(httpReq.Method))
+
+			v2Accessor := core.RequestAccessorV2{}
+			v2Accessor.SetPreserveMethodCase(true)
+			httpReq, err = v2Accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("/calendars/home", "PropFind"))
+			Expect(err).To(BeNil())
+			Expect("PropFind").To(Equal(httpReq.Method))
+
+			albAccessor := core.RequestAccessorALB{}
+			albAccessor.SetPreserveMethodCase(true)
+			httpReq, err = albAccessor.EventToRequestWithContext(context.Background(), getALBRequest("/calendars/home", "PropFind"))
+			Expect(err).To(BeNil())
+			Expect("PropFind").To(Equal(httpReq.Method))
+
+			fnURLAccessor := core.RequestAccessorFnURL{}
+			fnURLAccessor.SetPreserveMethodCase(true)
+			httpReq, err = fnURLAccessor.EventToRequestWithContext(context.Background(), getFunctionURLRequest("/calendars/home", "PropFind"))
+			Expect(err).To(BeNil())
+			Expect("PropFind").To(Equal(httpReq.Method))
+
+			wsAccessor := core.RequestAccessorWebSocket{}
+			wsAccessor.SetPreserveMethodCase(true)
+			wsRequest := getWebSocketRequest("sendMessage", "")
+			wsRequest.HTTPMethod = "PropFind"
+			httpReq, err = wsAccessor.EventToRequestWithContext(context.Background(), wsRequest)
+			Expect(err).To(BeNil())
+			Expect("PropFind").To(Equal(httpReq.Method))
+		})
+
+		It("Populates the protocol version", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), basicRequest)
+			Expect(err).To(BeNil())
+			Expect("HTTP/1.1").To(Equal(httpReq.Proto))
+			Expect(1).To(Equal(httpReq.ProtoMajor))
+			Expect(1).To(Equal(httpReq.ProtoMinor))
+
+			protoAccessor := core.RequestAccessor{}
+			Expect(protoAccessor.SetProtocol("HTTP/2.0")).To(BeNil())
+			httpReq, err = protoAccessor.EventToRequestWithContext(context.Background(), basicRequest)
+			Expect(err).To(BeNil())
+			Expect("HTTP/2.0").To(Equal(httpReq.Proto))
+			Expect(2).To(Equal(httpReq.ProtoMajor))
+			Expect(0).To(Equal(httpReq.ProtoMinor))
+
+			Expect(protoAccessor.SetProtocol("HTTP/x")).ToNot(BeNil())
+		})
+
 		binaryBody := make([]byte, 256)
 		_, err := rand.Read(binaryBody)
 		if err != nil {
@@ -64,6 +136,25 @@ var _ = Describe("RequestAccessor tests", func() {
 			Expect(binaryBody).To(Equal(bodyBytes))
 		})
 
+		It("Keeps the raw body in the context after req.Body is consumed", func() {
+			webhookRequest := getProxyRequest("/webhook", "POST")
+			webhookRequest.Body = "{\"event\":  \"push\"}\n"
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), webhookRequest)
+			Expect(err).To(BeNil())
+
+			bodyBytes, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect(webhookRequest.Body).To(Equal(string(bodyBytes)))
+
+			Expect([]byte(webhookRequest.Body)).To(Equal(core.GetRawBody(httpReq.Context())))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), binaryRequest)
+			Expect(err).To(BeNil())
+			ioutil.ReadAll(httpReq.Body)
+			Expect(binaryBody).To(Equal(core.GetRawBody(httpReq.Context())))
+			Expect(core.GetRawBody(context.Background())).To(BeNil())
+		})
+
 		mqsRequest := getProxyRequest("/hello", "GET")
 		mqsRequest.MultiValueQueryStringParameters = map[string][]string{
 			"hello": {"1"},
@@ -142,6 +233,18 @@ var _ = Describe("RequestAccessor tests", func() {
 			"hello": "1",
 			"world": "2",
 		}
+		It("Strips the Expect header", func() {
+			expectRequest := getProxyRequest("/upload", "PUT")
+			expectRequest.MultiValueHeaders = map[string][]string{
+				"Expect":       {"100-continue"},
+				"Content-Type": {"application/octet-stream"},
+			}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), expectRequest)
+			Expect(err).To(BeNil())
+			Expect("").To(Equal(httpReq.Header.Get("Expect")))
+			Expect("application/octet-stream").To(Equal(httpReq.Header.Get("Content-Type")))
+		})
+
 		It("Populates single value headers correctly", func() {
 			httpReq, err := accessor.EventToRequestWithContext(context.Background(), svhRequest)
 			Expect(err).To(BeNil())
@@ -156,6 +259,67 @@ var _ = Describe("RequestAccessor tests", func() {
 			}
 		})
 
+		It("Uses http.NoBody for empty bodies", func() {
+			emptyAccessor := core.RequestAccessor{}
+			httpReq, err := emptyAccessor.EventToRequestWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.NoBody).To(Equal(httpReq.Body))
+			Expect(int64(0)).To(Equal(httpReq.ContentLength))
+
+			v2Accessor := core.RequestAccessorV2{}
+			httpReq, err = v2Accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.NoBody).To(Equal(httpReq.Body))
+
+			albAccessor := core.RequestAccessorALB{}
+			httpReq, err = albAccessor.EventToRequestWithContext(context.Background(), getALBRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.NoBody).To(Equal(httpReq.Body))
+
+			fnURLAccessor := core.RequestAccessorFnURL{}
+			httpReq, err = fnURLAccessor.EventToRequestWithContext(context.Background(), getFunctionURLRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect(http.NoBody).To(Equal(httpReq.Body))
+		})
+
+		It("Preserves the case of the path", func() {
+			caseAccessor := core.RequestAccessor{}
+			httpReq, err := caseAccessor.EventToRequestWithContext(context.Background(), getProxyRequest("/Users/MixedCase", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/Users/MixedCase").To(Equal(httpReq.URL.Path))
+			Expect("/Users/MixedCase").To(Equal(httpReq.RequestURI))
+
+			v2Accessor := core.RequestAccessorV2{}
+			httpReq, err = v2Accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("/Users/MixedCase", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/Users/MixedCase").To(Equal(httpReq.URL.Path))
+
+			albAccessor := core.RequestAccessorALB{}
+			httpReq, err = albAccessor.EventToRequestWithContext(context.Background(), getALBRequest("/Users/MixedCase", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/Users/MixedCase").To(Equal(httpReq.URL.Path))
+
+			fnURLAccessor := core.RequestAccessorFnURL{}
+			httpReq, err = fnURLAccessor.EventToRequestWithContext(context.Background(), getFunctionURLRequest("/Users/MixedCase", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/Users/MixedCase").To(Equal(httpReq.URL.Path))
+
+			mappingAccessor := core.RequestAccessor{}
+			mappingAccessor.SetBasePathMappings(map[string]string{"/api": "/Internal"})
+			httpReq, err = mappingAccessor.EventToRequestWithContext(context.Background(), getProxyRequest("/api/Users/MixedCase", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/Internal/Users/MixedCase").To(Equal(httpReq.URL.Path))
+		})
+
+		It("Does not treat a # in the path as a fragment", func() {
+			fragmentAccessor := core.RequestAccessor{}
+			httpReq, err := fragmentAccessor.EventToRequestWithContext(context.Background(), getProxyRequest("/docs/a#b", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/docs/a#b").To(Equal(httpReq.URL.Path))
+			Expect("").To(Equal(httpReq.URL.Fragment))
+			Expect("/docs/a%23b").To(Equal(httpReq.RequestURI))
+		})
+
 		basePathRequest := getProxyRequest("/app1/orders", "GET")
 
 		It("Stips the base path correct", func() {
@@ -197,6 +361,699 @@ var _ = Describe("RequestAccessor tests", func() {
 		})
 	})
 
+	Context("SetBasePathMappings tests", func() {
+		accessor := core.RequestAccessor{}
+		accessor.SetBasePathMappings(map[string]string{
+			"svc-a/":     "",
+			"/svc-b":     "/api",
+			"/svc-a/v2/": "/v2",
+		})
+
+		It("Maps each base path to its target", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/svc-a/users", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/users").To(Equal(httpReq.URL.Path))
+			Expect("/svc-a").To(Equal(core.GetMatchedBasePath(httpReq.Context())))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/svc-b/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/api/orders").To(Equal(httpReq.URL.Path))
+			Expect("/svc-b").To(Equal(core.GetMatchedBasePath(httpReq.Context())))
+		})
+
+		It("Prefers the longest mapping", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/svc-a/v2/users", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/v2/users").To(Equal(httpReq.URL.Path))
+			Expect("/svc-a/v2").To(Equal(core.GetMatchedBasePath(httpReq.Context())))
+		})
+
+		It("Only matches whole path segments", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/svc-abc/users", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/svc-abc/users").To(Equal(httpReq.URL.Path))
+			Expect("").To(Equal(core.GetMatchedBasePath(httpReq.Context())))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/svc-a", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/").To(Equal(httpReq.URL.Path))
+		})
+	})
+
+	Context("Proxy path tests", func() {
+		proxyRequest := getProxyRequest("/api/v1/users/42", "GET")
+		proxyRequest.Resource = "/api/{proxy+}"
+		proxyRequest.PathParameters = map[string]string{"proxy": "v1/users/42"}
+
+		It("Returns the greedy path parameter", func() {
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), proxyRequest)
+			Expect(err).To(BeNil())
+			Expect("/api/v1/users/42").To(Equal(httpReq.URL.Path))
+			Expect("v1/users/42").To(Equal(core.GetProxyPath(httpReq.Context())))
+			Expect(proxyRequest.PathParameters).To(Equal(core.GetPathParameters(httpReq.Context())))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect("").To(Equal(core.GetProxyPath(httpReq.Context())))
+			Expect(core.GetPathParameters(httpReq.Context())).To(BeNil())
+		})
+
+		It("Routes on the proxy path when enabled", func() {
+			accessor := core.RequestAccessor{}
+			accessor.SetUseProxyPathForRouting(true)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), proxyRequest)
+			Expect(err).To(BeNil())
+			Expect("/v1/users/42").To(Equal(httpReq.URL.Path))
+			Expect("/v1/users/42").To(Equal(httpReq.RequestURI))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/hello").To(Equal(httpReq.URL.Path))
+		})
+	})
+
+	Context("Query parameter limit tests", func() {
+		manyParamsRequest := getProxyRequest("/search", "GET")
+		manyParamsRequest.MultiValueQueryStringParameters = map[string][]string{}
+		for i := 0; i < 100; i++ {
+			manyParamsRequest.MultiValueQueryStringParameters[fmt.Sprintf("p%03d", i)] = []string{"x"}
+		}
+
+		It("Rejects events over the limit", func() {
+			accessor := core.RequestAccessor{}
+			accessor.SetMaxQueryParams(10)
+			_, err := accessor.EventToRequestWithContext(context.Background(), manyParamsRequest)
+			Expect(err).To(Equal(core.ErrTooManyParams))
+
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/search", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/search").To(Equal(httpReq.URL.Path))
+		})
+
+		It("Truncates events over the limit when configured", func() {
+			accessor := core.RequestAccessor{}
+			accessor.SetMaxQueryParams(10)
+			accessor.SetTruncateQueryParams(true)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), manyParamsRequest)
+			Expect(err).To(BeNil())
+
+			query := httpReq.URL.Query()
+			Expect(10).To(Equal(len(query)))
+			Expect("x").To(Equal(query.Get("p000")))
+			Expect("x").To(Equal(query.Get("p009")))
+			Expect("").To(Equal(query.Get("p010")))
+		})
+	})
+
+	Context("Plus sign in path tests", func() {
+		It("Keeps plus signs literal by default", func() {
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/files/c++ guide", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/files/c++ guide").To(Equal(httpReq.URL.Path))
+		})
+
+		It("Decodes plus signs to spaces when enabled", func() {
+			accessor := core.RequestAccessor{}
+			accessor.SetDecodePlusInPath(true)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/files/annual+report", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/files/annual report").To(Equal(httpReq.URL.Path))
+			Expect("/files/annual%20report").To(Equal(httpReq.RequestURI))
+		})
+	})
+
+	Context("Double slash tests", func() {
+		It("Collapses repeated slashes in the path", func() {
+			req := getProxyRequest("/api//users///x", "GET")
+			req.MultiValueQueryStringParameters = map[string][]string{"next": {"//example"}}
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect("/api//users///x").To(Equal(httpReq.URL.Path))
+
+			accessor.SetCollapseSlashes(true)
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect("/api/users/x").To(Equal(httpReq.URL.Path))
+			Expect("//example").To(Equal(httpReq.URL.Query().Get("next")))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequest("//", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/").To(Equal(httpReq.URL.Path))
+		})
+	})
+
+	Context("Header count limit tests", func() {
+		It("Rejects events with too many headers", func() {
+			req := getProxyRequest("/hello", "GET")
+			req.Headers = map[string]string{"a": "1", "b": "2", "c": "3"}
+			accessor := core.RequestAccessor{}
+			accessor.SetMaxHeaderCount(2)
+			_, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(Equal(core.ErrTooManyHeaders))
+
+			req.Headers = nil
+			req.MultiValueHeaders = map[string][]string{"a": {"1"}, "A": {"2"}, "b": {"3", "4"}}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect([]string{"1", "2"}).To(ConsistOf(httpReq.Header.Values("A")))
+		})
+	})
+
+	Context("Decoded body limit tests", func() {
+		It("Rejects base64 bodies that decode past the limit", func() {
+			req := getProxyRequest("/hello", "POST")
+			req.Body = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("a"), 1024))
+			req.IsBase64Encoded = true
+			accessor := core.RequestAccessor{}
+			accessor.SetMaxDecodedBodyBytes(1023)
+			_, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(Equal(core.ErrRequestTooLarge))
+
+			accessor.SetMaxDecodedBodyBytes(1024)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			body, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect(1024).To(Equal(len(body)))
+		})
+
+		It("Still reports invalid base64", func() {
+			req := getProxyRequest("/hello", "POST")
+			req.Body = "not base64!"
+			req.IsBase64Encoded = true
+			accessor := core.RequestAccessor{}
+			accessor.SetMaxDecodedBodyBytes(1024)
+			_, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).ToNot(BeNil())
+			Expect(err).ToNot(Equal(core.ErrRequestTooLarge))
+		})
+	})
+
+	Context("Lazy body tests", func() {
+		It("Decodes base64 bodies while they are read", func() {
+			payload := bytes.Repeat([]byte{0, 1, 2, 0xff}, 1000)
+			req := getProxyRequest("/upload", "POST")
+			req.Body = base64.StdEncoding.EncodeToString(payload[:3999])
+			req.IsBase64Encoded = true
+
+			accessor := core.RequestAccessor{}
+			accessor.SetLazyBody(true)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(int64(3999)).To(Equal(httpReq.ContentLength))
+
+			body, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect(payload[:3999]).To(Equal(body))
+
+			again, err := httpReq.GetBody()
+			Expect(err).To(BeNil())
+			body, err = ioutil.ReadAll(again)
+			Expect(err).To(BeNil())
+			Expect(payload[:3999]).To(Equal(body))
+			Expect(core.GetRawBody(httpReq.Context())).To(BeNil())
+		})
+
+		It("Reads plain bodies in place", func() {
+			req := getProxyRequest("/upload", "POST")
+			req.Body = "{\"hello\": \"world\"}"
+
+			accessor := core.RequestAccessor{}
+			accessor.SetLazyBody(true)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(int64(len(req.Body))).To(Equal(httpReq.ContentLength))
+			body, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect(req.Body).To(Equal(string(body)))
+
+			req.Body = ""
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(http.NoBody).To(Equal(httpReq.Body))
+		})
+
+		It("Rejects oversized bodies without decoding them", func() {
+			req := getProxyRequest("/upload", "POST")
+			req.Body = base64.StdEncoding.EncodeToString(make([]byte, 1024))
+			req.IsBase64Encoded = true
+
+			accessor := core.RequestAccessor{}
+			accessor.SetLazyBody(true)
+			accessor.SetMaxDecodedBodyBytes(1023)
+			_, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(Equal(core.ErrRequestTooLarge))
+		})
+
+		It("Reports invalid base64 on read", func() {
+			req := getProxyRequest("/upload", "POST")
+			req.Body = "not base64!"
+			req.IsBase64Encoded = true
+
+			accessor := core.RequestAccessor{}
+			accessor.SetLazyBody(true)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(int64(-1)).To(Equal(httpReq.ContentLength))
+			_, err = ioutil.ReadAll(httpReq.Body)
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Context("Request decompression tests", func() {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		gz.Write([]byte("{\"hello\": \"world\"}"))
+		gz.Close()
+
+		gzipRequest := getProxyRequest("/upload", "POST")
+		gzipRequest.Body = base64.StdEncoding.EncodeToString(compressed.Bytes())
+		gzipRequest.IsBase64Encoded = true
+		gzipRequest.Headers = map[string]string{
+			"Content-Encoding": "gzip",
+			"Content-Length":   strconv.Itoa(compressed.Len()),
+		}
+
+		It("Passes the compressed body by default", func() {
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), gzipRequest)
+			Expect(err).To(BeNil())
+			body, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect(compressed.Bytes()).To(Equal(body))
+			Expect("gzip").To(Equal(httpReq.Header.Get("Content-Encoding")))
+		})
+
+		It("Decompresses the body when enabled", func() {
+			accessor := core.RequestAccessor{}
+			accessor.SetAutoDecompressRequest(true)
+
+			var handlerBody []byte
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerBody, _ = ioutil.ReadAll(r.Body)
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), gzipRequest)
+			Expect(err).To(BeNil())
+			Expect("").To(Equal(httpReq.Header.Get("Content-Encoding")))
+			Expect("").To(Equal(httpReq.Header.Get("Content-Length")))
+			Expect(int64(-1)).To(Equal(httpReq.ContentLength))
+
+			handler.ServeHTTP(httptest.NewRecorder(), httpReq)
+			Expect("{\"hello\": \"world\"}").To(Equal(string(handlerBody)))
+			Expect(compressed.Bytes()).To(Equal(core.GetRawBody(httpReq.Context())))
+		})
+
+		It("Caps the decompressed size", func() {
+			var bomb bytes.Buffer
+			gz := gzip.NewWriter(&bomb)
+			gz.Write(bytes.Repeat([]byte{0}, 10<<20))
+			gz.Close()
+			Expect(bomb.Len()).To(BeNumerically("<", 64<<10))
+
+			bombRequest := getProxyRequest("/upload", "POST")
+			bombRequest.Body = base64.StdEncoding.EncodeToString(bomb.Bytes())
+			bombRequest.IsBase64Encoded = true
+			bombRequest.Headers = map[string]string{"Content-Encoding": "gzip"}
+
+			accessor := core.RequestAccessor{}
+			accessor.SetAutoDecompressRequest(true)
+			accessor.SetMaxDecodedBodyBytes(1 << 20)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), bombRequest)
+			Expect(err).To(BeNil())
+			body, err := ioutil.ReadAll(httpReq.Body)
+			Expect(errors.Is(err, core.ErrRequestTooLarge)).To(BeTrue())
+			Expect(len(body)).To(Equal(1 << 20))
+
+			accessor.SetRewindableBody(true)
+			_, err = accessor.EventToRequestWithContext(context.Background(), bombRequest)
+			Expect(errors.Is(err, core.ErrRequestTooLarge)).To(BeTrue())
+		})
+
+		It("Returns an error for a corrupt body", func() {
+			accessor := core.RequestAccessor{}
+			accessor.SetAutoDecompressRequest(true)
+			corruptRequest := getProxyRequest("/upload", "POST")
+			corruptRequest.Body = "not gzip"
+			corruptRequest.Headers = map[string]string{"Content-Encoding": "gzip"}
+			_, err := accessor.EventToRequestWithContext(context.Background(), corruptRequest)
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Context("Rewindable body tests", func() {
+		It("Lets the handler re-read a body consumed by a middleware", func() {
+			var middlewareBody, handlerBody []byte
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := r.GetBody()
+				Expect(err).To(BeNil())
+				handlerBody, _ = ioutil.ReadAll(body)
+			})
+			consume := func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					middlewareBody, _ = ioutil.ReadAll(r.Body)
+					next.ServeHTTP(w, r)
+				})
+			}
+
+			req := getProxyRequest("/orders", "POST")
+			req.Body = "{\"order\": 1}"
+			accessor := core.RequestAccessor{}
+			accessor.SetRewindableBody(true)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			consume(handler).ServeHTTP(httptest.NewRecorder(), httpReq)
+			Expect([]byte("{\"order\": 1}")).To(Equal(middlewareBody))
+			Expect(middlewareBody).To(Equal(handlerBody))
+		})
+
+		It("Buffers decompressed bodies", func() {
+			var compressed bytes.Buffer
+			gz := gzip.NewWriter(&compressed)
+			gz.Write([]byte("{\"hello\": \"world\"}"))
+			gz.Close()
+
+			req := getProxyRequest("/upload", "POST")
+			req.Body = base64.StdEncoding.EncodeToString(compressed.Bytes())
+			req.IsBase64Encoded = true
+			req.Headers = map[string]string{"Content-Encoding": "gzip"}
+			accessor := core.RequestAccessor{}
+			accessor.SetAutoDecompressRequest(true)
+			accessor.SetRewindableBody(true)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(int64(18)).To(Equal(httpReq.ContentLength))
+
+			for i := 0; i < 2; i++ {
+				body, err := httpReq.GetBody()
+				Expect(err).To(BeNil())
+				decompressed, _ := ioutil.ReadAll(body)
+				Expect("{\"hello\": \"world\"}").To(Equal(string(decompressed)))
+			}
+		})
+	})
+
+	Context("Method override tests", func() {
+		overrideRequest := getProxyRequest("/items/1", "POST")
+		overrideRequest.Headers = map[string]string{
+			"X-HTTP-Method-Override": "delete",
+		}
+
+		It("Ignores the header by default", func() {
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), overrideRequest)
+			Expect(err).To(BeNil())
+			Expect("POST").To(Equal(httpReq.Method))
+		})
+
+		It("Overrides POST requests when enabled", func() {
+			accessor := core.RequestAccessor{}
+			accessor.SetHonorMethodOverride(true)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), overrideRequest)
+			Expect(err).To(BeNil())
+			Expect("DELETE").To(Equal(httpReq.Method))
+		})
+
+		It("Only overrides POST to safe-listed methods", func() {
+			accessor := core.RequestAccessor{}
+			accessor.SetHonorMethodOverride(true)
+
+			getRequest := getProxyRequest("/items/1", "GET")
+			getRequest.Headers = map[string]string{"X-HTTP-Method-Override": "DELETE"}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getRequest)
+			Expect(err).To(BeNil())
+			Expect("GET").To(Equal(httpReq.Method))
+
+			connectRequest := getProxyRequest("/items/1", "POST")
+			connectRequest.Headers = map[string]string{"X-HTTP-Method-Override": "CONNECT"}
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), connectRequest)
+			Expect(err).To(BeNil())
+			Expect("POST").To(Equal(httpReq.Method))
+		})
+	})
+
+	Context("Comma separated header tests", func() {
+		It("Splits the configured headers", func() {
+			req := getProxyRequest("/hello", "GET")
+			req.Headers = map[string]string{
+				"accept":     "application/json, text/html",
+				"user-agent": "Mozilla/5.0 (X11; Linux x86_64), Gecko",
+			}
+
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect([]string{"application/json, text/html"}).To(Equal(httpReq.Header.Values("Accept")))
+
+			accessor.SetSplitCommaHeaders([]string{"accept"})
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect([]string{"application/json", "text/html"}).To(Equal(httpReq.Header.Values("Accept")))
+			Expect([]string{"Mozilla/5.0 (X11; Linux x86_64), Gecko"}).To(Equal(httpReq.Header.Values("User-Agent")))
+		})
+
+		It("Does not split multi-value headers", func() {
+			req := getProxyRequest("/hello", "GET")
+			req.MultiValueHeaders = map[string][]string{"Accept": {"application/json, text/html"}}
+
+			accessor := core.RequestAccessor{}
+			accessor.SetSplitCommaHeaders([]string{"Accept"})
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect([]string{"application/json, text/html"}).To(Equal(httpReq.Header.Values("Accept")))
+		})
+	})
+
+	Context("Strict header merge tests", func() {
+		conflictRequest := getProxyRequest("/hello", "GET")
+		conflictRequest.Headers = map[string]string{"x-tenant": "a"}
+		conflictRequest.MultiValueHeaders = map[string][]string{"X-Tenant": {"b"}}
+
+		It("Uses the multi-value headers in lenient mode", func() {
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), conflictRequest)
+			Expect(err).To(BeNil())
+			Expect([]string{"b"}).To(Equal(httpReq.Header.Values("X-Tenant")))
+		})
+
+		It("Rejects conflicting values in strict mode", func() {
+			accessor := core.RequestAccessor{}
+			accessor.SetStrictHeaderMerge(true)
+			_, err := accessor.EventToRequestWithContext(context.Background(), conflictRequest)
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("X-Tenant"))
+
+			matchingRequest := getProxyRequest("/hello", "GET")
+			matchingRequest.Headers = map[string]string{"accept": "text/html", "x-tenant": "a"}
+			matchingRequest.MultiValueHeaders = map[string][]string{"Accept": {"application/json", "text/html"}, "X-Tenant": {"a"}}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), matchingRequest)
+			Expect(err).To(BeNil())
+			Expect([]string{"application/json", "text/html"}).To(Equal(httpReq.Header.Values("Accept")))
+		})
+	})
+
+	Context("API key tests", func() {
+		It("Returns the API key ID", func() {
+			keyRequest := getProxyRequest("/orders", "GET")
+			keyRequest.RequestContext = getRequestContext()
+			keyRequest.RequestContext.Identity.APIKey = "secret-key-value"
+			keyRequest.RequestContext.Identity.APIKeyID = "abcd1234"
+
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), keyRequest)
+			Expect(err).To(BeNil())
+			keyID, ok := core.GetAPIKeyID(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("abcd1234").To(Equal(keyID))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/orders", "GET"))
+			Expect(err).To(BeNil())
+			_, ok = core.GetAPIKeyID(httpReq.Context())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("Extended request ID tests", func() {
+		It("Returns the extended request ID of the event", func() {
+			req := getProxyRequest("/orders", "GET")
+			req.RequestContext = getRequestContext()
+			req.RequestContext.ExtendedRequestID = "GxXbXFGzoAMFdLA="
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			extendedID, ok := core.GetExtendedRequestID(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("GxXbXFGzoAMFdLA=").To(Equal(extendedID))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/orders", "GET"))
+			Expect(err).To(BeNil())
+			_, ok = core.GetExtendedRequestID(httpReq.Context())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("Resource template tests", func() {
+		It("Returns the resource of the event", func() {
+			req := getProxyRequest("/users/42", "GET")
+			req.Resource = "/users/{id}"
+			req.PathParameters = map[string]string{"id": "42"}
+
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			resource, ok := core.GetResourceTemplate(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("/users/{id}").To(Equal(resource))
+			Expect("/users/42").To(Equal(httpReq.URL.Path))
+
+			_, ok = core.GetResourceTemplate(context.Background())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("Authorization header tests", func() {
+		authRequest := getProxyRequest("/orders", "GET")
+		authRequest.RequestContext = getRequestContext()
+		authRequest.RequestContext.Authorizer = map[string]interface{}{
+			"claims": map[string]interface{}{"sub": "user-1"},
+		}
+		authRequest.MultiValueHeaders = map[string][]string{
+			"Authorization": {"Bearer secret-token"},
+			"Accept":        {"application/json"},
+		}
+
+		It("Keeps the header by default", func() {
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), authRequest)
+			Expect(err).To(BeNil())
+			Expect("Bearer secret-token").To(Equal(httpReq.Header.Get("Authorization")))
+		})
+
+		It("Strips the header and keeps the authorizer claims", func() {
+			accessor := core.RequestAccessor{}
+			accessor.SetStripAuthorizationHeader(true)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), authRequest)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Header).ToNot(HaveKey("Authorization"))
+			Expect("application/json").To(Equal(httpReq.Header.Get("Accept")))
+
+			apiGwContext, ok := core.GetAPIGatewayContextFromContext(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			claims := apiGwContext.Authorizer["claims"].(map[string]interface{})
+			Expect("user-1").To(Equal(claims["sub"]))
+		})
+	})
+
+	Context("Default request header tests", func() {
+		defaults := http.Header{}
+		defaults.Set("X-Service-Name", "orders")
+		defaults.Set("X-Tenant", "default")
+
+		It("Adds the default headers without overriding client values", func() {
+			req := getProxyRequest("/orders", "GET")
+			req.MultiValueHeaders = map[string][]string{"X-Tenant": {"acme"}}
+			accessor := core.RequestAccessor{}
+			accessor.SetDefaultRequestHeaders(defaults)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect("orders").To(Equal(httpReq.Header.Get("X-Service-Name")))
+			Expect([]string{"acme"}).To(Equal(httpReq.Header.Values("X-Tenant")))
+		})
+
+		It("Adds the default headers to requests with single-value headers", func() {
+			req := getProxyRequest("/orders", "GET")
+			req.Headers = map[string]string{"x-service-name": "billing"}
+			accessor := core.RequestAccessor{}
+			accessor.SetDefaultRequestHeaders(defaults)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect([]string{"billing"}).To(Equal(httpReq.Header.Values("X-Service-Name")))
+			Expect("default").To(Equal(httpReq.Header.Get("X-Tenant")))
+		})
+	})
+
+	Context("Event capture tests", func() {
+		It("Passes the received event to the capture function", func() {
+			var captured []events.APIGatewayProxyRequest
+			accessor := core.RequestAccessor{}
+			accessor.StripBasePath("app1")
+			accessor.SetEventCapture(func(event events.APIGatewayProxyRequest) {
+				captured = append(captured, event)
+			})
+
+			event := getProxyRequest("/app1/orders", "POST")
+			event.Body = "{}"
+			event.QueryStringParameters = map[string]string{"page": "2"}
+			_, err := accessor.EventToRequestWithContext(context.Background(), event)
+			Expect(err).To(BeNil())
+
+			badEvent := getProxyRequest("/app1/orders", "POST")
+			badEvent.Body = "not base64!"
+			badEvent.IsBase64Encoded = true
+			_, err = accessor.EventToRequestWithContext(context.Background(), badEvent)
+			Expect(err).ToNot(BeNil())
+
+			Expect([]events.APIGatewayProxyRequest{event, badEvent}).To(Equal(captured))
+		})
+	})
+
+	Context("Origin and Referer headers", func() {
+		// sameOrigin mirrors the check CSRF middlewares such as gorilla/csrf
+		// run on unsafe methods
+		sameOrigin := func(r *http.Request) bool {
+			for _, header := range []string{"Origin", "Referer"} {
+				u, err := url.Parse(r.Header.Get(header))
+				if err != nil || u.Host != r.Host || u.Host != r.URL.Host {
+					return false
+				}
+			}
+			return true
+		}
+		origin := "https://12abcdefgh.execute-api.us-east-2.amazonaws.com"
+
+		It("Keeps the headers consistent with the host", func() {
+			req := getProxyRequest("/orders", "POST")
+			req.RequestContext = getRequestContext()
+			req.MultiValueHeaders = map[string][]string{
+				"Host":    {"12abcdefgh.execute-api.us-east-2.amazonaws.com"},
+				"Origin":  {origin},
+				"Referer": {origin + "/checkout"},
+			}
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(origin).To(Equal(httpReq.Header.Get("Origin")))
+			Expect(origin + "/checkout").To(Equal(httpReq.Header.Get("Referer")))
+			Expect(sameOrigin(httpReq)).To(BeTrue())
+		})
+
+		It("Takes the host from the Host header without a domain name", func() {
+			req := getProxyRequest("/orders", "POST")
+			req.Headers = map[string]string{
+				"Host":    "12abcdefgh.execute-api.us-east-2.amazonaws.com",
+				"Origin":  origin,
+				"Referer": origin + "/checkout",
+			}
+			accessor := core.RequestAccessor{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect("12abcdefgh.execute-api.us-east-2.amazonaws.com").To(Equal(httpReq.Host))
+			Expect(sameOrigin(httpReq)).To(BeTrue())
+
+			v2Req := getProxyRequestV2("/orders", "POST")
+			v2Req.RequestContext.DomainName = ""
+			v2Req.Headers = req.Headers
+			v2Accessor := core.RequestAccessorV2{}
+			httpReq, err = v2Accessor.EventToRequestWithContext(context.Background(), v2Req)
+			Expect(err).To(BeNil())
+			Expect(sameOrigin(httpReq)).To(BeTrue())
+		})
+	})
+
 	Context("Retrieves API Gateway context", func() {
 		It("Returns a correctly unmarshalled object", func() {
 			contextRequest := getProxyRequest("orders", "GET")
@@ -282,6 +1139,10 @@ var _ = Describe("RequestAccessor tests", func() {
 			Expect(stageVars["var2"]).ToNot(BeNil())
 			Expect("value1").To(Equal(stageVars["var1"]))
 			Expect("value2").To(Equal(stageVars["var2"]))
+
+			Expect(stageVars).To(Equal(core.GetStageVariables(httpReq.Context())))
+			Expect(stageVars).To(Equal(httpReq.Context().Value(core.ContextKeyStageVars)))
+			Expect(core.GetStageVariables(context.Background())).To(BeNil())
 		})
 
 		It("Populates the default hostname correctly", func() {
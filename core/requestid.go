@@ -0,0 +1,29 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+// RequestIDHeader is the header the RequestAccessor sets to the generated
+// request ID of events that don't carry one, see SetRequestIDGenerator.
+const RequestIDHeader = "X-Request-Id"
+
+// GetRequestID returns the ID of the request: the request ID of the event,
+// the ID generated by the accessor when the event had none, or the AWS
+// request ID of the Lambda runtime context, in that order.
+func GetRequestID(ctx context.Context) (string, bool) {
+	return getRequestID(ctx)
+}
+
+// newRequestID returns a random version 4 UUID.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
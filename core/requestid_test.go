@@ -0,0 +1,93 @@
+package core_test
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Request ID tests", func() {
+	It("Generates an ID for events without one", func() {
+		accessor := core.RequestAccessor{}
+		accessor.SetRequestIDGenerator(nil)
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+		Expect(err).To(BeNil())
+
+		requestID := httpReq.Header.Get(core.RequestIDHeader)
+		Expect(requestID).To(MatchRegexp("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"))
+		contextID, ok := core.GetRequestID(httpReq.Context())
+		Expect(ok).To(BeTrue())
+		Expect(requestID).To(Equal(contextID))
+	})
+
+	It("Uses the configured generator", func() {
+		accessor := core.RequestAccessor{}
+		accessor.SetRequestIDGenerator(func() string { return "generated-1" })
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+		Expect(err).To(BeNil())
+		Expect("generated-1").To(Equal(httpReq.Header.Get(core.RequestIDHeader)))
+		contextID, _ := core.GetRequestID(httpReq.Context())
+		Expect("generated-1").To(Equal(contextID))
+	})
+
+	It("Keeps the request ID of the event", func() {
+		accessor := core.RequestAccessor{}
+		accessor.SetRequestIDGenerator(func() string { return "generated-1" })
+		req := getProxyRequest("/hello", "GET")
+		req.RequestContext = getRequestContext()
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect("").To(Equal(httpReq.Header.Get(core.RequestIDHeader)))
+		contextID, _ := core.GetRequestID(httpReq.Context())
+		Expect("x").To(Equal(contextID))
+	})
+
+	It("Does not generate IDs by default", func() {
+		accessor := core.RequestAccessor{}
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequest("/hello", "GET"))
+		Expect(err).To(BeNil())
+		Expect("").To(Equal(httpReq.Header.Get(core.RequestIDHeader)))
+		_, ok := core.GetRequestID(httpReq.Context())
+		Expect(ok).To(BeFalse())
+	})
+	Context("ALB events", func() {
+		It("Generates an ID for every request", func() {
+			accessor := core.RequestAccessorALB{}
+			accessor.SetRequestIDGenerator(func() string { return "generated-1" })
+			lambdaContext := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "abc123"})
+			httpReq, err := accessor.EventToRequestWithContext(lambdaContext, getALBRequest("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect("generated-1").To(Equal(httpReq.Header.Get(core.RequestIDHeader)))
+			contextID, ok := core.GetRequestID(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("generated-1").To(Equal(contextID))
+		})
+
+		It("Keeps the X-Request-Id header of the request", func() {
+			accessor := core.RequestAccessorALB{}
+			accessor.SetRequestIDGenerator(nil)
+			req := getALBRequest("/hello", "GET")
+			req.Headers["X-Request-Id"] = "client-1"
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect("client-1").To(Equal(httpReq.Header.Get(core.RequestIDHeader)))
+			contextID, _ := core.GetRequestID(httpReq.Context())
+			Expect("client-1").To(Equal(contextID))
+		})
+
+		It("Falls back to the Lambda request ID by default", func() {
+			accessor := core.RequestAccessorALB{}
+			req := getALBRequest("/hello", "GET")
+			req.Headers["X-Request-Id"] = "client-1"
+			lambdaContext := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "abc123"})
+			httpReq, err := accessor.EventToRequestWithContext(lambdaContext, req)
+			Expect(err).To(BeNil())
+			contextID, _ := core.GetRequestID(httpReq.Context())
+			Expect("abc123").To(Equal(contextID))
+		})
+	})
+})
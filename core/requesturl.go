@@ -0,0 +1,35 @@
+package core
+
+import (
+	"net/http"
+	"strings"
+)
+
+// FullRequestURL returns the URL the client requested, as
+// scheme://host/path?query, for example to log it or to build redirects.
+// The scheme and host come from the request URL built by the accessors,
+// falling back to the X-Forwarded-Proto header, the TLS state and the Host
+// of the request when the URL is relative.
+func FullRequestURL(r *http.Request) string {
+	scheme := r.URL.Scheme
+	if scheme == "" {
+		scheme = strings.TrimSpace(strings.Split(r.Header.Get(forwardedProtoHeaderKey), ",")[0])
+	}
+	if scheme == "" {
+		scheme = "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+	}
+
+	host := r.URL.Host
+	if host == "" {
+		host = r.Host
+	}
+
+	url := scheme + "://" + host + r.URL.EscapedPath()
+	if r.URL.RawQuery != "" {
+		url += "?" + r.URL.RawQuery
+	}
+	return url
+}
@@ -5,8 +5,11 @@ package core
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"log"
@@ -14,6 +17,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambdacontext"
@@ -22,7 +26,12 @@ import (
 // RequestAccessorV2 objects give access to custom API Gateway properties
 // in the request.
 type RequestAccessorV2 struct {
-	stripBasePath string
+	stripBasePath  string
+	protocol       string
+	useRawPath     bool
+	lazyBody       bool
+	pathRewrites   []PathRewrite
+	preserveMethod bool
 }
 
 // GetAPIGatewayContextV2 extracts the API Gateway context object from a
@@ -85,6 +94,45 @@ func (r *RequestAccessorV2) StripBasePath(basePath string) string {
 	return newBasePath
 }
 
+// SetPathRewrites sets rules that rewrite the request path before routing,
+// after the base path was stripped. See RequestAccessor.SetPathRewrites.
+func (r *RequestAccessorV2) SetPathRewrites(rules ...PathRewrite) {
+	r.pathRewrites = rules
+}
+
+// SetUseRawPathForRouting chooses the path the request is routed on. By
+// default the accessor uses RequestContext.HTTP.Path and falls back to RawPath
+// when it is empty. When the default endpoint is invoked with a stage prefix,
+// RawPath can include the /{stage} segment; pass true to route on RawPath,
+// falling back to RequestContext.HTTP.Path, instead.
+func (r *RequestAccessorV2) SetUseRawPathForRouting(useRawPath bool) {
+	r.useRawPath = useRawPath
+}
+
+// SetProtocol overrides the protocol version set on the requests built by
+// the accessor. Requests default to HTTP/1.1, which is what API Gateway uses
+// to talk to the integration. Returns an error if the version can't be parsed.
+func (r *RequestAccessorV2) SetProtocol(proto string) error {
+	if _, _, ok := http.ParseHTTPVersion(proto); !ok {
+		return fmt.Errorf("Invalid protocol version: %s", proto)
+	}
+	r.protocol = proto
+	return nil
+}
+
+// SetPreserveMethodCase makes the accessor pass the method of the event
+// through verbatim instead of uppercasing it, see
+// RequestAccessor.SetPreserveMethodCase.
+func (r *RequestAccessorV2) SetPreserveMethodCase(preserve bool) {
+	r.preserveMethod = preserve
+}
+
+// SetLazyBody makes the request body read the event body in place, like
+// RequestAccessor.SetLazyBody.
+func (r *RequestAccessorV2) SetLazyBody(lazy bool) {
+	r.lazyBody = lazy
+}
+
 // ProxyEventToHTTPRequest converts an API Gateway proxy event into a http.Request object.
 // Returns the populated http request with additional two custom headers for the stage variables and API Gateway context.
 // To access these properties use the GetAPIGatewayStageVars and GetAPIGatewayContext method of the RequestAccessor object.
@@ -101,46 +149,43 @@ func (r *RequestAccessorV2) ProxyEventToHTTPRequest(req events.APIGatewayV2HTTPR
 // Returns the populated http request with lambda context, stage variables and APIGatewayProxyRequestContext as part of its context.
 // Access those using GetAPIGatewayContextFromContext, GetStageVarsFromContext and GetRuntimeContextFromContext functions in this package.
 func (r *RequestAccessorV2) EventToRequestWithContext(ctx context.Context, req events.APIGatewayV2HTTPRequest) (*http.Request, error) {
-	httpRequest, err := r.EventToRequest(req)
+	httpRequest, body, err := r.eventToRequest(req)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
-	return addToContextV2(ctx, httpRequest, req), nil
+	_, rewrite := r.routingPath(req)
+	return withBasePathRewrite(addToContextV2(ctx, httpRequest, req, body), rewrite), nil
 }
 
 // EventToRequest converts an API Gateway proxy event into an http.Request object.
 // Returns the populated request maintaining headers
 func (r *RequestAccessorV2) EventToRequest(req events.APIGatewayV2HTTPRequest) (*http.Request, error) {
-	decodedBody := []byte(req.Body)
-	if req.IsBase64Encoded {
+	httpRequest, _, err := r.eventToRequest(req)
+	return httpRequest, err
+}
+
+// eventToRequest builds the http.Request for the event and also returns the
+// decoded body bytes it is backed by.
+func (r *RequestAccessorV2) eventToRequest(req events.APIGatewayV2HTTPRequest) (*http.Request, []byte, error) {
+	var decodedBody []byte
+	if !r.lazyBody {
+		decodedBody = []byte(req.Body)
+	}
+	if req.IsBase64Encoded && !r.lazyBody {
 		base64Body, err := base64.StdEncoding.DecodeString(req.Body)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		decodedBody = base64Body
 	}
 
-	path := req.RawPath
-
-	//if RawPath empty is, populate from request context
-	if len(path) == 0 {
-		path = req.RequestContext.HTTP.Path
-	}
-
-	if r.stripBasePath != "" && len(r.stripBasePath) > 1 {
-		if strings.HasPrefix(path, r.stripBasePath) {
-			path = strings.Replace(path, r.stripBasePath, "", 1)
-		}
-	}
-	if !strings.HasPrefix(path, "/") {
-		path = "/" + path
-	}
+	path, _ := r.routingPath(req)
 	serverAddress := "https://" + req.RequestContext.DomainName
 	if customAddress, ok := os.LookupEnv(CustomHostVariable); ok {
 		serverAddress = customAddress
 	}
-	path = serverAddress + path
+	path = serverAddress + escapeFragment(path)
 
 	if len(req.RawQueryString) > 0 {
 		path += "?" + req.RawQueryString
@@ -153,7 +198,7 @@ func (r *RequestAccessorV2) EventToRequest(req events.APIGatewayV2HTTPRequest) (
 	}
 
 	httpRequest, err := http.NewRequest(
-		strings.ToUpper(req.RequestContext.HTTP.Method),
+		eventMethod(req.RequestContext.HTTP.Method, r.preserveMethod),
 		path,
 		bytes.NewReader(decodedBody),
 	)
@@ -161,18 +206,133 @@ func (r *RequestAccessorV2) EventToRequest(req events.APIGatewayV2HTTPRequest) (
 	if err != nil {
 		fmt.Printf("Could not convert request %s:%s to http.Request\n", req.RequestContext.HTTP.Method, req.RequestContext.HTTP.Path)
 		log.Println(err)
-		return nil, err
+		return nil, nil, err
+	}
+	if r.lazyBody {
+		if err := setLazyBody(httpRequest, req.Body, req.IsBase64Encoded, 0); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	for headerKey, headerValue := range req.Headers {
-		for _, val := range strings.Split(headerValue, ",") {
-			httpRequest.Header.Add(headerKey, strings.Trim(val, " "))
-		}
+		addJoinedHeader(httpRequest.Header, headerKey, headerValue)
 	}
 
+	// The v2 payload moves cookies to the cookies array, merge them with
+	// a Cookie header the event may still carry
+	if len(req.Cookies) > 0 {
+		httpRequest.Header.Set("Cookie", mergeCookies(httpRequest.Header.Values("Cookie"), req.Cookies))
+	}
+
+	// Lambda receives the whole body up front, handlers must not wait to
+	// send a 100 Continue
+	httpRequest.Header.Del("Expect")
+	httpRequest.TLS = connectionStateV2(req.RequestContext)
+	setProtocol(httpRequest, r.protocol)
+	setHostFromHeader(httpRequest)
+	setForwardedPort(httpRequest)
 	httpRequest.RequestURI = httpRequest.URL.RequestURI()
 
-	return httpRequest, nil
+	return httpRequest, decodedBody, nil
+}
+
+// connectionStateV2 builds the TLS state of the request. HTTP APIs only
+// accept HTTPS and terminate TLS themselves. The event does not include the
+// negotiated version or cipher suite, so only the server name and, with
+// mutual TLS, the client certificate are populated.
+func connectionStateV2(rc events.APIGatewayV2HTTPRequestContext) *tls.ConnectionState {
+	state := &tls.ConnectionState{
+		HandshakeComplete: true,
+		ServerName:        rc.DomainName,
+	}
+	if clientCert := rc.Authentication.ClientCert.ClientCertPem; clientCert != "" {
+		block, _ := pem.Decode([]byte(clientCert))
+		if block == nil {
+			log.Println("Could not decode the client certificate of the request")
+			return state
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			log.Println("Could not parse the client certificate of the request")
+			log.Println(err)
+			return state
+		}
+		state.PeerCertificates = []*x509.Certificate{cert}
+	}
+	return state
+}
+
+// listHeaders are the request headers whose values are comma separated lists,
+// so the values API Gateway joined with commas can be split safely.
+var listHeaders = map[string]bool{
+	"Accept":                         true,
+	"Accept-Charset":                 true,
+	"Accept-Encoding":                true,
+	"Accept-Language":                true,
+	"Access-Control-Request-Headers": true,
+	"Cache-Control":                  true,
+	"Connection":                     true,
+	"Forwarded":                      true,
+	"If-Match":                       true,
+	"If-None-Match":                  true,
+	"Pragma":                         true,
+	"Te":                             true,
+	"Trailer":                        true,
+	"Transfer-Encoding":              true,
+	"Upgrade":                        true,
+	"Via":                            true,
+	"X-Forwarded-For":                true,
+	"X-Forwarded-Port":               true,
+	"X-Forwarded-Proto":              true,
+}
+
+// addJoinedHeader adds a header from a payload that joins multiple values
+// with commas. Values of known list headers are split into multiple values,
+// all other headers, such as Cookie or headers with a comma in their value,
+// are kept intact.
+func addJoinedHeader(headers http.Header, key string, value string) {
+	if !listHeaders[http.CanonicalHeaderKey(key)] {
+		headers.Add(key, value)
+		return
+	}
+	for _, val := range strings.Split(value, ",") {
+		headers.Add(key, strings.Trim(val, " "))
+	}
+}
+
+// mergeCookies builds a single Cookie header value from the cookies array of
+// the event and the values of a Cookie header, which API Gateway may have
+// joined with commas. Cookies are deduplicated by name and the cookies array
+// takes precedence over the header.
+func mergeCookies(headerValues []string, cookies []string) string {
+	merged := make([]string, 0, len(cookies))
+	names := make(map[string]bool)
+	addCookie := func(cookie string) {
+		cookie = strings.TrimSpace(cookie)
+		if cookie == "" {
+			return
+		}
+		name := cookie
+		if i := strings.Index(cookie, "="); i >= 0 {
+			name = cookie[:i]
+		}
+		name = strings.TrimSpace(name)
+		if names[name] {
+			return
+		}
+		names[name] = true
+		merged = append(merged, cookie)
+	}
+
+	for _, cookie := range cookies {
+		addCookie(cookie)
+	}
+	for _, value := range headerValues {
+		for _, cookie := range strings.FieldsFunc(value, func(c rune) bool { return c == ';' || c == ',' }) {
+			addCookie(cookie)
+		}
+	}
+	return strings.Join(merged, "; ")
 }
 
 func addToHeaderV2(req *http.Request, apiGwRequest events.APIGatewayV2HTTPRequest) (*http.Request, error) {
@@ -191,10 +351,24 @@ func addToHeaderV2(req *http.Request, apiGwRequest events.APIGatewayV2HTTPReques
 	return req, nil
 }
 
-func addToContextV2(ctx context.Context, req *http.Request, apiGwRequest events.APIGatewayV2HTTPRequest) *http.Request {
+func addToContextV2(ctx context.Context, req *http.Request, apiGwRequest events.APIGatewayV2HTTPRequest, body []byte) *http.Request {
 	lc, _ := lambdacontext.FromContext(ctx)
-	rc := requestContextV2{lambdaContext: lc, gatewayProxyContext: apiGwRequest.RequestContext, stageVars: apiGwRequest.StageVariables}
+	rc := requestContextV2{lambdaContext: lc, gatewayProxyContext: apiGwRequest.RequestContext, stageVars: apiGwRequest.StageVariables, rawBody: body, routeKey: apiGwRequest.RouteKey}
+	if rc.routeKey == "" {
+		rc.routeKey = apiGwRequest.RequestContext.RouteKey
+	}
+	if epoch := apiGwRequest.RequestContext.TimeEpoch; epoch > 0 {
+		rc.latency, rc.hasLatency = time.Since(time.UnixMilli(epoch)), true
+		// Clocks of API Gateway and the function can drift slightly apart
+		if rc.latency < 0 {
+			rc.latency = 0
+		}
+	}
 	ctx = context.WithValue(ctx, ctxKey{}, rc)
+	ctx = withColdStart(ctx)
+	ctx = withFunctionMetadata(ctx)
+	ctx = withResponseMetaIfMissing(ctx)
+	ctx = context.WithValue(ctx, ContextKeyStageVars, apiGwRequest.StageVariables)
 	return req.WithContext(ctx)
 }
 
@@ -216,8 +390,131 @@ func GetStageVarsFromContextV2(ctx context.Context) (map[string]string, bool) {
 	return v.stageVars, ok
 }
 
+// GetIAMIdentity retrieves the identity of the IAM principal that signed the
+// request when the route uses IAM authorization. REST API and Function URL
+// identities are returned in the HTTP API format, REST API requests count as
+// IAM authorized when their identity has a user ARN. Returns false if the
+// context does not hold an API Gateway or Function URL request or the
+// request was not IAM authorized.
+func GetIAMIdentity(ctx context.Context) (events.APIGatewayV2HTTPRequestContextAuthorizerIAMDescription, bool) {
+	switch v := ctx.Value(ctxKey{}).(type) {
+	case requestContextV2:
+		if v.gatewayProxyContext.Authorizer != nil && v.gatewayProxyContext.Authorizer.IAM != nil {
+			return *v.gatewayProxyContext.Authorizer.IAM, true
+		}
+	case requestContext:
+		if identity := v.gatewayProxyContext.Identity; identity.UserArn != "" {
+			return events.APIGatewayV2HTTPRequestContextAuthorizerIAMDescription{
+				AccessKey: identity.AccessKey,
+				AccountID: identity.AccountID,
+				CallerID:  identity.Caller,
+				CognitoIdentity: events.APIGatewayV2HTTPRequestContextAuthorizerCognitoIdentity{
+					IdentityID:     identity.CognitoIdentityID,
+					IdentityPoolID: identity.CognitoIdentityPoolID,
+				},
+				UserARN: identity.UserArn,
+				UserID:  identity.User,
+			}, true
+		}
+	case requestContextFnURL:
+		if v.fnURLContext.Authorizer != nil && v.fnURLContext.Authorizer.IAM != nil {
+			iam := v.fnURLContext.Authorizer.IAM
+			return events.APIGatewayV2HTTPRequestContextAuthorizerIAMDescription{
+				AccessKey: iam.AccessKey,
+				AccountID: iam.AccountID,
+				CallerID:  iam.CallerID,
+				UserARN:   iam.UserARN,
+				UserID:    iam.UserID,
+			}, true
+		}
+	}
+	return events.APIGatewayV2HTTPRequestContextAuthorizerIAMDescription{}, false
+}
+
+// GetJWTScopes retrieves the scopes of the token validated by a JWT
+// authorizer on the HTTP API route. Returns false if the context does not
+// hold a v2 request or the request was not JWT authorized.
+func GetJWTScopes(ctx context.Context) ([]string, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextV2)
+	if !ok || v.gatewayProxyContext.Authorizer == nil || v.gatewayProxyContext.Authorizer.JWT == nil {
+		return nil, false
+	}
+	return v.gatewayProxyContext.Authorizer.JWT.Scopes, true
+}
+
+// HasScope reports whether the JWT authorizer granted the given scope to the
+// request.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, _ := GetJWTScopes(ctx)
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDomainPrefix returns the first label of the custom domain name the
+// request was sent to, such as "tenant1" for tenant1.api.example.com, so
+// handlers can resolve the tenant without parsing the Host header. Returns
+// false if the context does not hold an API Gateway request or the event
+// has no domain prefix.
+func GetDomainPrefix(ctx context.Context) (string, bool) {
+	prefix := ""
+	switch rc := ctx.Value(ctxKey{}).(type) {
+	case requestContextV2:
+		prefix = rc.gatewayProxyContext.DomainPrefix
+	case requestContext:
+		prefix = rc.gatewayProxyContext.DomainPrefix
+	}
+	return prefix, prefix != ""
+}
+
+// GetRouteKey returns the route key API Gateway matched for the request,
+// such as "GET /items/{id}", to group metrics by route template instead of
+// concrete path. Returns false if the context does not hold a v2 request or
+// the event has no route key.
+func GetRouteKey(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextV2)
+	return v.routeKey, ok && v.routeKey != ""
+}
+
+// GetIntegrationLatency returns the time between API Gateway receiving the
+// request, according to the TimeEpoch of the event, and the accessor
+// building the http.Request, which includes the cold start of the function.
+// Returns false if the context does not hold a v2 request or the event has
+// no TimeEpoch.
+func GetIntegrationLatency(ctx context.Context) (time.Duration, bool) {
+	v, ok := ctx.Value(ctxKey{}).(requestContextV2)
+	return v.latency, ok && v.hasLatency
+}
+
 type requestContextV2 struct {
 	lambdaContext       *lambdacontext.LambdaContext
 	gatewayProxyContext events.APIGatewayV2HTTPRequestContext
 	stageVars           map[string]string
+	rawBody             []byte
+	routeKey            string
+	latency             time.Duration
+	hasLatency          bool
+}
+
+// routingPath returns the path the request is routed on, after StripBasePath
+// and the path rewrites were applied, and the base path that was stripped.
+func (r *RequestAccessorV2) routingPath(req events.APIGatewayV2HTTPRequest) (string, basePathRewrite) {
+	path := req.RequestContext.HTTP.Path
+	fallbackPath := req.RawPath
+	if r.useRawPath {
+		path, fallbackPath = fallbackPath, path
+	}
+
+	if len(path) == 0 {
+		path = fallbackPath
+	}
+
+	path, rewrite := stripPathPrefix(path, configuredBasePath(r.stripBasePath))
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return applyPathRewrites(path, r.pathRewrites), rewrite
 }
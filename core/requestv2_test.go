@@ -2,11 +2,19 @@ package core_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	cryptorand "crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/pem"
 	"io/ioutil"
+	"math/big"
 	"math/rand"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambdacontext"
@@ -38,6 +46,21 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 			Expect("GET").To(Equal(httpReq.Method))
 		})
 
+		It("Populates the protocol version", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect("HTTP/1.1").To(Equal(httpReq.Proto))
+			Expect(1).To(Equal(httpReq.ProtoMajor))
+			Expect(1).To(Equal(httpReq.ProtoMinor))
+
+			protoAccessor := core.RequestAccessorV2{}
+			Expect(protoAccessor.SetProtocol("HTTP/2.0")).To(BeNil())
+			httpReq, err = protoAccessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("/hello", "GET"))
+			Expect(err).To(BeNil())
+			Expect("HTTP/2.0").To(Equal(httpReq.Proto))
+			Expect(2).To(Equal(httpReq.ProtoMajor))
+		})
+
 		binaryBody := make([]byte, 256)
 		_, err := rand.Read(binaryBody)
 		if err != nil {
@@ -135,6 +158,46 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 			}
 		})
 
+		It("Only splits list headers on commas", func() {
+			listRequest := getProxyRequestV2("/hello", "GET")
+			listRequest.Headers = map[string]string{
+				"accept":        "text/html, application/json",
+				"cookie":        "session=abc; theme=dark",
+				"x-signature":   "keyId=\"k1\",signature=\"c2lnbmF0dXJl\"",
+				"if-none-match": "\"a\",\"b\"",
+			}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), listRequest)
+			Expect(err).To(BeNil())
+
+			Expect([]string{"text/html", "application/json"}).To(Equal(httpReq.Header.Values("Accept")))
+			Expect([]string{"\"a\"", "\"b\""}).To(Equal(httpReq.Header.Values("If-None-Match")))
+			Expect([]string{"session=abc; theme=dark"}).To(Equal(httpReq.Header.Values("Cookie")))
+			Expect(2).To(Equal(len(httpReq.Cookies())))
+			Expect([]string{"keyId=\"k1\",signature=\"c2lnbmF0dXJl\""}).To(Equal(httpReq.Header.Values("X-Signature")))
+		})
+
+		It("Merges the cookies array with the Cookie header", func() {
+			cookieRequest := getProxyRequestV2("/hello", "GET")
+			cookieRequest.Headers = map[string]string{
+				"cookie": "session=old; theme=dark,lang=en",
+			}
+			cookieRequest.Cookies = []string{"session=new", "cart=42"}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), cookieRequest)
+			Expect(err).To(BeNil())
+
+			Expect([]string{"session=new; cart=42; theme=dark; lang=en"}).To(Equal(httpReq.Header.Values("Cookie")))
+			cookies := map[string]string{}
+			for _, cookie := range httpReq.Cookies() {
+				cookies[cookie.Name] = cookie.Value
+			}
+			Expect(map[string]string{"session": "new", "cart": "42", "theme": "dark", "lang": "en"}).To(Equal(cookies))
+
+			cookieRequest.Headers = nil
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), cookieRequest)
+			Expect(err).To(BeNil())
+			Expect("session=new; cart=42").To(Equal(httpReq.Header.Get("Cookie")))
+		})
+
 		svhRequest := getProxyRequestV2("/hello", "GET")
 		svhRequest.Headers = map[string]string{
 			"hello": "1",
@@ -177,6 +240,85 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 		})
 	})
 
+	Context("Routing path tests", func() {
+		stageRequest := getProxyRequestV2("/users", "GET")
+		stageRequest.RawPath = "/prod/users"
+
+		It("Routes on the request context path by default", func() {
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), stageRequest)
+			Expect(err).To(BeNil())
+			Expect("/users").To(Equal(httpReq.URL.Path))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("/users", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/users").To(Equal(httpReq.URL.Path))
+		})
+
+		It("Routes on the raw path when requested", func() {
+			accessor := core.RequestAccessorV2{}
+			accessor.SetUseRawPathForRouting(true)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), stageRequest)
+			Expect(err).To(BeNil())
+			Expect("/prod/users").To(Equal(httpReq.URL.Path))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("/users", "GET"))
+			Expect(err).To(BeNil())
+			Expect("/users").To(Equal(httpReq.URL.Path))
+		})
+
+		It("Falls back to the other path when one is empty", func() {
+			rawOnly := getProxyRequestV2("/users", "GET")
+			rawOnly.RequestContext.HTTP.Path = ""
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), rawOnly)
+			Expect(err).To(BeNil())
+			Expect("/users").To(Equal(httpReq.URL.Path))
+
+			contextOnly := getProxyRequestV2("/users", "GET")
+			contextOnly.RawPath = ""
+			accessor.SetUseRawPathForRouting(true)
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), contextOnly)
+			Expect(err).To(BeNil())
+			Expect("/users").To(Equal(httpReq.URL.Path))
+		})
+	})
+
+	Context("TLS connection state tests", func() {
+		It("Populates the server name without mutual TLS", func() {
+			accessor := core.RequestAccessorV2{}
+			req := getProxyRequestV2("/hello", "GET")
+			req.RequestContext.DomainName = "api.example.com"
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.TLS).ToNot(BeNil())
+			Expect(true).To(Equal(httpReq.TLS.HandshakeComplete))
+			Expect("api.example.com").To(Equal(httpReq.TLS.ServerName))
+			Expect(httpReq.TLS.PeerCertificates).To(BeEmpty())
+		})
+
+		It("Populates the client certificate with mutual TLS", func() {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), cryptorand.Reader)
+			Expect(err).To(BeNil())
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(42),
+				Subject:      pkix.Name{CommonName: "client.example.com"},
+				NotBefore:    time.Now().Add(-time.Hour),
+				NotAfter:     time.Now().Add(time.Hour),
+			}
+			der, err := x509.CreateCertificate(cryptorand.Reader, template, template, &key.PublicKey, key)
+			Expect(err).To(BeNil())
+
+			accessor := core.RequestAccessorV2{}
+			req := getProxyRequestV2("/hello", "GET")
+			req.RequestContext.Authentication.ClientCert.ClientCertPem = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(1).To(Equal(len(httpReq.TLS.PeerCertificates)))
+			Expect("client.example.com").To(Equal(httpReq.TLS.PeerCertificates[0].Subject.CommonName))
+		})
+	})
+
 	Context("StripBasePath tests", func() {
 		accessor := core.RequestAccessorV2{}
 		It("Adds prefix slash", func() {
@@ -195,6 +337,53 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 		})
 	})
 
+	Context("Lazy body tests", func() {
+		It("Decodes base64 bodies while they are read", func() {
+			req := getProxyRequestV2("/upload", "POST")
+			req.Body = base64.StdEncoding.EncodeToString([]byte("binary\x00upload"))
+			req.IsBase64Encoded = true
+
+			accessor := core.RequestAccessorV2{}
+			accessor.SetLazyBody(true)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(int64(len("binary\x00upload"))).To(Equal(httpReq.ContentLength))
+			body, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect("binary\x00upload").To(Equal(string(body)))
+		})
+	})
+
+	Context("Integration latency tests", func() {
+		It("Measures the time since API Gateway received the request", func() {
+			req := getProxyRequestV2("/orders", "GET")
+			req.RequestContext.TimeEpoch = time.Now().Add(-50 * time.Millisecond).UnixMilli()
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			latency, ok := core.GetIntegrationLatency(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect(latency).To(BeNumerically(">=", 50*time.Millisecond))
+
+			req.RequestContext.TimeEpoch = time.Now().Add(time.Second).UnixMilli()
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			latency, ok = core.GetIntegrationLatency(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect(latency).To(BeNumerically(">=", 0))
+		})
+
+		It("Is not available without a TimeEpoch", func() {
+			req := getProxyRequestV2("/orders", "GET")
+			req.RequestContext.TimeEpoch = 0
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			_, ok := core.GetIntegrationLatency(httpReq.Context())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
 	Context("Retrieves API Gateway context", func() {
 		It("Returns a correctly unmarshalled object", func() {
 			contextRequest := getProxyRequestV2("orders", "GET")
@@ -246,6 +435,107 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 			Expect("abc123").To(Equal(runtimeContext.AwsRequestID))
 		})
 
+		It("Returns the IAM authorizer identity", func() {
+			iamRequest := getProxyRequestV2("orders", "GET")
+			iamRequest.RequestContext = getRequestContextV2()
+			iamRequest.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+				IAM: &events.APIGatewayV2HTTPRequestContextAuthorizerIAMDescription{
+					AccessKey: "AKIAEXAMPLE",
+					AccountID: "123456789012",
+					UserARN:   "arn:aws:iam::123456789012:user/caller",
+				},
+			}
+
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), iamRequest)
+			Expect(err).To(BeNil())
+			identity, ok := core.GetIAMIdentity(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("AKIAEXAMPLE").To(Equal(identity.AccessKey))
+			Expect("123456789012").To(Equal(identity.AccountID))
+			Expect("arn:aws:iam::123456789012:user/caller").To(Equal(identity.UserARN))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("orders", "GET"))
+			Expect(err).To(BeNil())
+			_, ok = core.GetIAMIdentity(httpReq.Context())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("Reconstructs the full request URL", func() {
+			urlRequest := getProxyRequestV2("/orders/a b", "GET")
+			urlRequest.RawPath = "/orders/a%20b"
+			urlRequest.RawQueryString = "page=2&sort=desc"
+			urlRequest.RequestContext = getRequestContextV2()
+			urlRequest.RequestContext.HTTP = events.APIGatewayV2HTTPRequestContextHTTPDescription{Path: "/orders/a b", Method: "GET"}
+
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), urlRequest)
+			Expect(err).To(BeNil())
+			Expect("https://12abcdefgh.execute-api.us-east-2.amazonaws.com/orders/a%20b?page=2&sort=desc").To(Equal(core.FullRequestURL(httpReq)))
+		})
+
+		It("Returns the domain prefix", func() {
+			tenantRequest := getProxyRequestV2("orders", "GET")
+			tenantRequest.RequestContext = getRequestContextV2()
+			tenantRequest.RequestContext.DomainName = "tenant1.api.example.com"
+			tenantRequest.RequestContext.DomainPrefix = "tenant1"
+
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), tenantRequest)
+			Expect(err).To(BeNil())
+			prefix, ok := core.GetDomainPrefix(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("tenant1").To(Equal(prefix))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("orders", "GET"))
+			Expect(err).To(BeNil())
+			_, ok = core.GetDomainPrefix(httpReq.Context())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("Returns the route key", func() {
+			routeRequest := getProxyRequestV2("/items/42", "GET")
+			routeRequest.RouteKey = "GET /items/{id}"
+
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), routeRequest)
+			Expect(err).To(BeNil())
+			routeKey, ok := core.GetRouteKey(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect("GET /items/{id}").To(Equal(routeKey))
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("/items/42", "GET"))
+			Expect(err).To(BeNil())
+			_, ok = core.GetRouteKey(httpReq.Context())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("Returns the JWT authorizer scopes", func() {
+			jwtRequest := getProxyRequestV2("orders", "GET")
+			jwtRequest.RequestContext = getRequestContextV2()
+			jwtRequest.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+				JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+					Claims: map[string]string{"sub": "user-1"},
+					Scopes: []string{"orders:read", "orders:write"},
+				},
+			}
+
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), jwtRequest)
+			Expect(err).To(BeNil())
+			scopes, ok := core.GetJWTScopes(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect([]string{"orders:read", "orders:write"}).To(Equal(scopes))
+			Expect(core.HasScope(httpReq.Context(), "orders:write")).To(BeTrue())
+			Expect(core.HasScope(httpReq.Context(), "orders:delete")).To(BeFalse())
+
+			httpReq, err = accessor.EventToRequestWithContext(context.Background(), getProxyRequestV2("orders", "GET"))
+			Expect(err).To(BeNil())
+			_, ok = core.GetJWTScopes(httpReq.Context())
+			Expect(ok).To(BeFalse())
+			Expect(core.HasScope(httpReq.Context(), "orders:read")).To(BeFalse())
+		})
+
 		It("Populates stage variables correctly", func() {
 			varsRequest := getProxyRequest("orders", "GET")
 			varsRequest.StageVariables = getStageVariables()
@@ -282,6 +572,16 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 			Expect("value2").To(Equal(stageVars["var2"]))
 		})
 
+		It("Stores stage variables under ContextKeyStageVars", func() {
+			varsRequest := getProxyRequestV2("orders", "GET")
+			varsRequest.StageVariables = getStageVariables()
+
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), varsRequest)
+			Expect(err).To(BeNil())
+			Expect(getStageVariables()).To(Equal(core.GetStageVariables(httpReq.Context())))
+		})
+
 		It("Populates the default hostname correctly", func() {
 
 			basicRequest := getProxyRequest("orders", "GET")
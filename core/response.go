@@ -5,8 +5,17 @@ package core
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"unicode/utf8"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -17,13 +26,91 @@ const (
 	contentTypeHeaderKey = "Content-Type"
 )
 
+// ErrResponseFinalized is returned by the Write method of a
+// ProxyResponseWriter called after GetProxyResponse, for example from a
+// goroutine the handler left running.
+var ErrResponseFinalized = errors.New("Response already finalized by GetProxyResponse")
+
+// maxResponseBytes is the size of the largest body returned by a
+// ProxyResponseWriter in this process.
+var maxResponseBytes int64
+
+// MaxObservedResponseBytes returns the size in bytes of the largest response
+// body buffered by a ProxyResponseWriter since the process started. Use it
+// to tune the memory of the function.
+func MaxObservedResponseBytes() int {
+	return int(atomic.LoadInt64(&maxResponseBytes))
+}
+
+// observeResponseBytes raises the high-water mark to n if it is larger.
+func observeResponseBytes(n int) {
+	for {
+		current := atomic.LoadInt64(&maxResponseBytes)
+		if int64(n) <= current || atomic.CompareAndSwapInt64(&maxResponseBytes, current, int64(n)) {
+			return
+		}
+	}
+}
+
+// HeaderSanitization selects how GetProxyResponse handles header values that
+// contain control characters, such as the CR and LF used for response
+// splitting, which API Gateway rejects.
+type HeaderSanitization int
+
+const (
+	// HeaderSanitizationOff returns header values unchanged.
+	HeaderSanitizationOff HeaderSanitization = iota
+	// HeaderSanitizationReject makes GetProxyResponse return an
+	// *InvalidHeaderError.
+	HeaderSanitizationReject
+	// HeaderSanitizationStrip removes the control characters from the values.
+	HeaderSanitizationStrip
+)
+
+// InvalidHeaderError is returned by GetProxyResponse when a header value
+// contains control characters and HeaderSanitizationReject is enabled.
+type InvalidHeaderError struct {
+	Header string
+	Value  string
+}
+
+func (e *InvalidHeaderError) Error() string {
+	return fmt.Sprintf("Header %s has a value with control characters: %q", e.Header, e.Value)
+}
+
 // ProxyResponseWriter implements http.ResponseWriter and adds the method
-// necessary to return an events.APIGatewayProxyResponse object
+// necessary to return an events.APIGatewayProxyResponse object. Like the
+// other writers of this package it supports the read and write deadlines of
+// http.ResponseController.
 type ProxyResponseWriter struct {
-	headers   http.Header
-	body      bytes.Buffer
-	status    int
-	observers []chan<- bool
+	headers          http.Header
+	body             bytes.Buffer
+	status           int
+	observers        []chan<- bool
+	deferContentType bool
+	noContentType    bool
+	alreadyEncoded   bool
+	finalizeHooks    []func(http.Header)
+	maxHeaderValues  int
+	failHeaderLimit  bool
+	lowercaseKeys    bool
+	sanitization     HeaderSanitization
+	statusRewriter   func(status int, headers http.Header) int
+	base64Types      []string
+	binaryTypes      []string
+	charset          string
+	validateJSON     bool
+	bodyTransformer  func(status int, body []byte, headers http.Header) []byte
+	singleValue      bool
+	canonicalJSON    bool
+	wasBase64        bool
+
+	// mu guards the status, body and finalized flag against handlers that
+	// write from another goroutine
+	mu        sync.Mutex
+	finalized bool
+
+	deadlines
 }
 
 // NewProxyResponseWriter returns a new ProxyResponseWriter object.
@@ -31,13 +118,14 @@ type ProxyResponseWriter struct {
 // status code of -1
 func NewProxyResponseWriter() *ProxyResponseWriter {
 	return &ProxyResponseWriter{
-		headers:   make(http.Header),
-		status:    defaultStatusCode,
-		observers: make([]chan<- bool, 0),
+		headers: make(http.Header),
+		status:  defaultStatusCode,
 	}
 
 }
 
+// CloseNotify returns a channel that receives a value when GetProxyResponse
+// is called. The observers are only allocated once CloseNotify is used.
 func (r *ProxyResponseWriter) CloseNotify() <-chan bool {
 	ch := make(chan bool, 1)
 
@@ -48,8 +136,179 @@ func (r *ProxyResponseWriter) CloseNotify() <-chan bool {
 
 func (r *ProxyResponseWriter) notifyClosed() {
 	for _, v := range r.observers {
-		v <- true
+		// a full channel already holds a notification that was never read,
+		// don't block finalizing the response on it
+		select {
+		case v <- true:
+		default:
+		}
+	}
+}
+
+// SetDeferContentTypeDetection moves the content type detection from the
+// first call to Write to GetProxyResponse. Handlers can then set the
+// Content-Type header after writing the body, for example from a deferred
+// middleware, and the detection only runs on the full body if they did not.
+func (r *ProxyResponseWriter) SetDeferContentTypeDetection(deferDetection bool) {
+	r.deferContentType = deferDetection
+}
+
+// SetDisableContentTypeDetection turns off the content type detection of
+// Write and GetProxyResponse, for handlers that always set the Content-Type
+// header. Responses the handler did not set a Content-Type for are returned
+// without one.
+func (r *ProxyResponseWriter) SetDisableContentTypeDetection(disable bool) {
+	r.noContentType = disable
+}
+
+// SetBodyAlreadyEncoded tells the writer that the handler writes a body that
+// is already base64 encoded. GetProxyResponse then returns the body as it was
+// written with IsBase64Encoded set to true, instead of checking it is valid
+// UTF-8 and encoding it again.
+func (r *ProxyResponseWriter) SetBodyAlreadyEncoded(alreadyEncoded bool) {
+	r.alreadyEncoded = alreadyEncoded
+}
+
+// ForceBase64ForContentTypes lists content types whose bodies are always
+// returned base64 encoded, even when they are valid UTF-8, for example a
+// text/csv body with embedded null bytes. The Content-Type header is not
+// changed. Types are compared without their parameters, so "text/csv"
+// matches "text/csv; charset=utf-8".
+func (r *ProxyResponseWriter) ForceBase64ForContentTypes(contentTypes []string) {
+	r.base64Types = contentTypes
+}
+
+// SetBinaryContentTypes lists content types whose bodies are always returned
+// base64 encoded, mirroring the binary media types configured on the API so
+// API Gateway decodes them. Besides exact types, entries can be wildcards
+// such as "image/*", or "*/*" to encode every body. Bodies of other types
+// keep being encoded only when they are not valid UTF-8. The list is kept
+// apart from the one set with ForceBase64ForContentTypes, bodies matching
+// either are encoded.
+func (r *ProxyResponseWriter) SetBinaryContentTypes(contentTypes []string) {
+	r.binaryTypes = contentTypes
+}
+
+// SetAppendCharset makes GetProxyResponse append "; charset=" and the given
+// charset to text/*, application/json and application/xml content types
+// that don't declare a charset. An empty charset disables it.
+func (r *ProxyResponseWriter) SetAppendCharset(charset string) {
+	r.charset = charset
+}
+
+// SetValidateJSON makes GetProxyResponse return an error when the response
+// has an application/json Content-Type and a body that is not valid JSON.
+// Empty bodies are not checked.
+func (r *ProxyResponseWriter) SetValidateJSON(validate bool) {
+	r.validateJSON = validate
+}
+
+// maxCanonicalJSONBytes is the size of the largest body SetCanonicalizeJSON
+// re-encodes.
+const maxCanonicalJSONBytes = 1 << 20
+
+// SetCanonicalizeJSON makes GetProxyResponse re-encode application/json
+// bodies with the keys of every object sorted, for clients that compare or
+// sign canonical JSON. The body is fully decoded and encoded again, which
+// costs time and memory in proportion to its size, so bodies larger than
+// 1 MB, and bodies that are not valid JSON, are returned unchanged. Numbers
+// keep their original representation.
+func (r *ProxyResponseWriter) SetCanonicalizeJSON(canonicalize bool) {
+	r.canonicalJSON = canonicalize
+}
+
+// SetBodyTransformer registers a function that GetProxyResponse calls with
+// the status, body and headers of the response to replace the body, for
+// example to wrap JSON bodies in a standard envelope. The Content-Length
+// header, if the handler set one, is updated to the size of the new body.
+func (r *ProxyResponseWriter) SetBodyTransformer(transformer func(status int, body []byte, headers http.Header) []byte) {
+	r.bodyTransformer = transformer
+}
+
+// FinalizeHook registers a function that is called with the response headers
+// at the start of GetProxyResponse, after the handler returned. Hooks can add
+// or remove headers, for example security headers, on the buffered response.
+// Hooks run in the order they were registered.
+func (r *ProxyResponseWriter) FinalizeHook(hook func(http.Header)) {
+	r.finalizeHooks = append(r.finalizeHooks, hook)
+}
+
+// SetMaxHeaderValuesPerKey limits the number of values a single header can
+// have in the response. GetProxyResponse logs a warning and keeps the first
+// n values of a header that has more, or returns an error if
+// SetFailOnHeaderLimit was enabled. A limit of 0 disables the check.
+func (r *ProxyResponseWriter) SetMaxHeaderValuesPerKey(n int) {
+	r.maxHeaderValues = n
+}
+
+// SetFailOnHeaderLimit makes GetProxyResponse return an error instead of
+// truncating a header that exceeds the limit set with
+// SetMaxHeaderValuesPerKey.
+func (r *ProxyResponseWriter) SetFailOnHeaderLimit(fail bool) {
+	r.failHeaderLimit = fail
+}
+
+// SetStatusRewriter registers a function that computes the final status of
+// the response from the status and headers set by the handler. It is called
+// by GetProxyResponse after the finalize hooks, for example to turn a 200
+// with a Location header into a 201 Created.
+func (r *ProxyResponseWriter) SetStatusRewriter(rewriter func(status int, headers http.Header) int) {
+	r.statusRewriter = rewriter
+}
+
+// SetLowercaseHeaderKeys makes GetProxyResponse emit the response header
+// keys in lowercase, HTTP/2 style, for consumers that can't match header
+// names case-insensitively.
+func (r *ProxyResponseWriter) SetLowercaseHeaderKeys(lowercase bool) {
+	r.lowercaseKeys = lowercase
+}
+
+// SetSingleValueHeaders makes GetProxyResponse emit the headers that have a
+// single value in the single-value Headers map of the response, for tools
+// that only read that map. Headers with several values stay in
+// MultiValueHeaders, so a header still never appears in both maps.
+// Set-Cookie is always kept in MultiValueHeaders: API Gateway would only
+// send one cookie if another one was added to the Headers map.
+func (r *ProxyResponseWriter) SetSingleValueHeaders(single bool) {
+	r.singleValue = single
+}
+
+// SetHeaderSanitization sets how GetProxyResponse handles header values
+// with control characters other than horizontal tabs. Defaults to
+// HeaderSanitizationOff.
+func (r *ProxyResponseWriter) SetHeaderSanitization(mode HeaderSanitization) {
+	r.sanitization = mode
+}
+
+// SetCacheControl sets the Cache-Control header of the response to a
+// max-age of maxAge, rounded down to seconds, followed by the directives, for
+// example "max-age=3600, public". The max-age is omitted when maxAge is
+// negative or the directives include no-store, which forbids caching.
+func (r *ProxyResponseWriter) SetCacheControl(maxAge time.Duration, directives ...string) {
+	values := make([]string, 0, len(directives)+1)
+	noStore := false
+	for _, directive := range directives {
+		if strings.EqualFold(directive, "no-store") {
+			noStore = true
+		}
+	}
+	if maxAge >= 0 && !noStore {
+		values = append(values, "max-age="+strconv.FormatInt(int64(maxAge/time.Second), 10))
 	}
+	values = append(values, directives...)
+	r.headers.Set("Cache-Control", strings.Join(values, ", "))
+}
+
+// SetRetryAfter sets the Retry-After header of the response to d in
+// delta-seconds, rounded up so clients never retry too early. It does not
+// change the status code, handlers shedding load still write the 503
+// Service Unavailable or 429 Too Many Requests status themselves.
+func (r *ProxyResponseWriter) SetRetryAfter(d time.Duration) {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+	r.headers.Set("Retry-After", strconv.FormatInt(seconds, 10))
 }
 
 // Header implementation from the http.ResponseWriter interface.
@@ -59,8 +318,18 @@ func (r *ProxyResponseWriter) Header() http.Header {
 
 // Write sets the response body in the object. If no status code
 // was set before with the WriteHeader method it sets the status
-// for the response to 200 OK.
+// for the response to 200 OK. Writes after GetProxyResponse are dropped
+// and return ErrResponseFinalized.
 func (r *ProxyResponseWriter) Write(body []byte) (int, error) {
+	if err := r.checkWrite(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.finalized {
+		return 0, ErrResponseFinalized
+	}
+
 	if r.status == defaultStatusCode {
 		r.status = http.StatusOK
 	}
@@ -69,46 +338,374 @@ func (r *ProxyResponseWriter) Write(body []byte) (int, error) {
 	// detect one and set it by default. If the content type cannot be detected
 	// it is automatically set to "application/octet-stream" by the
 	// DetectContentType method
-	if r.Header().Get(contentTypeHeaderKey) == "" {
+	if !r.deferContentType && !r.noContentType && r.Header().Get(contentTypeHeaderKey) == "" {
 		r.Header().Add(contentTypeHeaderKey, http.DetectContentType(body))
 	}
 
 	return (&r.body).Write(body)
 }
 
+// PeekBody returns a copy of up to the first n bytes of the body written so
+// far, for example to log a truncated response. It does not consume the
+// body and has no effect on GetProxyResponse.
+func (r *ProxyResponseWriter) PeekBody(n int) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bb := (&r.body).Bytes()
+	if n < 0 {
+		n = 0
+	}
+	if n > len(bb) {
+		n = len(bb)
+	}
+	peek := make([]byte, n)
+	copy(peek, bb)
+	return peek
+}
+
+// BodyReader returns a reader over a copy of the body written so far. It does
+// not consume the body, so a finalize hook or middleware can read the body,
+// transform it and install the result with SetBodyTransformer.
+func (r *ProxyResponseWriter) BodyReader() io.Reader {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return bytes.NewReader(append([]byte(nil), r.body.Bytes()...))
+}
+
+// BodyLen returns the number of body bytes buffered so far.
+func (r *ProxyResponseWriter) BodyLen() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.body.Len()
+}
+
+// WasBase64Encoded reports whether GetProxyResponse returned the body base64
+// encoded, for example for an access log to record how binary responses were
+// sent. Returns false until GetProxyResponse has run.
+func (r *ProxyResponseWriter) WasBase64Encoded() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.wasBase64
+}
+
+// Status returns the status code of the response, or -1 if the handler has
+// not set one yet.
+func (r *ProxyResponseWriter) Status() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
 // WriteHeader sets a status code for the response. This method is used
-// for error responses.
+// for error responses. Informational (1xx) status codes, such as a 100
+// Continue written for an Expect header, can't be returned through the
+// proxy and are ignored, as are calls after GetProxyResponse.
 func (r *ProxyResponseWriter) WriteHeader(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if isInformational(status) || r.finalized {
+		return
+	}
 	r.status = status
 }
 
+// Flush implements http.Flusher so handlers that flush as they write, such as
+// server-sent event streams, work unchanged. API Gateway receives the
+// response as a whole once the handler returns, so Flush sends nothing: it
+// only commits the status like a Write would, 200 OK unless the handler set
+// one. Use the streaming handler of a Function URL to send the data as it
+// is flushed.
+func (r *ProxyResponseWriter) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status == defaultStatusCode && !r.finalized {
+		r.status = http.StatusOK
+	}
+}
+
 // GetProxyResponse converts the data passed to the response writer into
 // an events.APIGatewayProxyResponse object.
-// Returns a populated proxy response object. If the response is invalid, for example
-// has no headers or an invalid status code returns an error.
+// Returns a populated proxy response object. If the response is invalid, for
+// example has no headers or an invalid status code returns an error.
+// All response headers are emitted in MultiValueHeaders and the single-value
+// Headers map is left empty, so a header never appears in both maps. With
+// SetSingleValueHeaders enabled, most headers with a single value are emitted
+// in Headers instead.
+// API Gateway can't send HTTP trailers, so the values of declared trailers are
+// returned as regular headers.
+// The writer is finalized, later calls to Write and WriteHeader have no
+// effect.
 func (r *ProxyResponseWriter) GetProxyResponse() (events.APIGatewayProxyResponse, error) {
+	r.mu.Lock()
+	r.finalized = true
+	r.mu.Unlock()
+
+	// the finalized writer ignores writes, the hooks run without the lock so
+	// they can read the body with PeekBody or BodyReader
 	r.notifyClosed()
+	foldTrailers(r.headers)
+	for _, hook := range r.finalizeHooks {
+		hook(r.headers)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
 	if r.status == defaultStatusCode {
 		return events.APIGatewayProxyResponse{}, errors.New("Status code not set on response")
 	}
 
+	if r.statusRewriter != nil {
+		r.status = r.statusRewriter(r.status, r.headers)
+	}
+
+	if err := r.limitHeaderValues(); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	if err := r.sanitizeHeaders(); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
 	var output string
 	isBase64 := false
 
 	bb := (&r.body).Bytes()
 
-	if utf8.Valid(bb) {
+	if r.deferContentType && !r.noContentType && len(bb) > 0 && r.headers.Get(contentTypeHeaderKey) == "" {
+		r.headers.Add(contentTypeHeaderKey, http.DetectContentType(bb))
+	}
+
+	if r.bodyTransformer != nil {
+		bb = r.bodyTransformer(r.status, bb, r.headers)
+		if r.headers.Get("Content-Length") != "" {
+			r.headers.Set("Content-Length", strconv.Itoa(len(bb)))
+		}
+	}
+	observeResponseBytes(len(bb))
+
+	if r.validateJSON && len(bb) > 0 && isJSONContentType(r.headers.Get(contentTypeHeaderKey)) && !json.Valid(bb) {
+		return events.APIGatewayProxyResponse{}, errors.New("Response body is not valid JSON")
+	}
+
+	if r.canonicalJSON && len(bb) > 0 && len(bb) <= maxCanonicalJSONBytes && isJSONContentType(r.headers.Get(contentTypeHeaderKey)) {
+		if canonical, ok := canonicalizeJSON(bb); ok {
+			bb = canonical
+			if r.headers.Get("Content-Length") != "" {
+				r.headers.Set("Content-Length", strconv.Itoa(len(bb)))
+			}
+		}
+	}
+
+	if r.charset != "" {
+		if contentType := r.headers.Get(contentTypeHeaderKey); isTextContentType(contentType) && !strings.Contains(strings.ToLower(contentType), "charset=") {
+			r.headers.Set(contentTypeHeaderKey, contentType+"; charset="+r.charset)
+		}
+	}
+
+	if r.alreadyEncoded {
+		output = string(bb)
+		isBase64 = true
+	} else if utf8.Valid(bb) && !isBinaryContentType(r.headers.Get(contentTypeHeaderKey)) && !matchesMediaType(r.headers.Get(contentTypeHeaderKey), r.base64Types) && !matchesMediaType(r.headers.Get(contentTypeHeaderKey), r.binaryTypes) {
 		output = string(bb)
 	} else {
 		output = base64.StdEncoding.EncodeToString(bb)
 		isBase64 = true
 	}
 
+	r.wasBase64 = isBase64
+
+	headers := r.headers
+	if r.lowercaseKeys {
+		headers = lowercaseHeaderKeys(headers)
+	}
+
+	var singleHeaders map[string]string
+	if r.singleValue {
+		headers, singleHeaders = splitSingleValueHeaders(headers)
+	}
+
 	return events.APIGatewayProxyResponse{
 		StatusCode:        r.status,
-		MultiValueHeaders: http.Header(r.headers),
+		Headers:           singleHeaders,
+		MultiValueHeaders: http.Header(headers),
 		Body:              output,
-		IsBase64Encoded:   false,
+		IsBase64Encoded:   isBase64,
 	}, nil
 }
+
+// GetRawResponse finalizes the response like GetProxyResponse and returns
+// its components instead of an events.APIGatewayProxyResponse, for custom
+// runtimes that serialize the response into their own envelope. The body is
+// base64 encoded when isBase64 is true. The headers include those emitted in
+// the single-value map when SetSingleValueHeaders is enabled. Returns the
+// error of GetProxyResponse if the response is invalid.
+func (r *ProxyResponseWriter) GetRawResponse() (statusCode int, headers http.Header, body []byte, isBase64 bool, err error) {
+	resp, err := r.GetProxyResponse()
+	if err != nil {
+		return 0, nil, nil, false, err
+	}
+	headers = make(http.Header, len(resp.MultiValueHeaders)+len(resp.Headers))
+	for key, values := range resp.MultiValueHeaders {
+		headers[key] = values
+	}
+	for key, value := range resp.Headers {
+		headers[key] = []string{value}
+	}
+	return resp.StatusCode, headers, []byte(resp.Body), resp.IsBase64Encoded, nil
+}
+
+// splitSingleValueHeaders moves the headers with a single value, other than
+// Set-Cookie, to a single-value map. Returns the remaining multi-value
+// headers and the single-value map.
+func splitSingleValueHeaders(headers http.Header) (http.Header, map[string]string) {
+	multi := make(http.Header)
+	single := make(map[string]string)
+	for key, values := range headers {
+		if len(values) == 1 && http.CanonicalHeaderKey(key) != "Set-Cookie" {
+			single[key] = values[0]
+			continue
+		}
+		multi[key] = values
+	}
+	return multi, single
+}
+
+// foldTrailers turns trailers into regular headers. The Trailer header that
+// announces them is removed and values set with the http.TrailerPrefix are
+// moved to their header key.
+func foldTrailers(headers http.Header) {
+	headers.Del("Trailer")
+	for key, values := range headers {
+		if !strings.HasPrefix(key, http.TrailerPrefix) {
+			continue
+		}
+		delete(headers, key)
+		name := http.CanonicalHeaderKey(strings.TrimPrefix(key, http.TrailerPrefix))
+		headers[name] = append(headers[name], values...)
+	}
+}
+
+// lowercaseHeaderKeys returns a copy of the headers with lowercase keys.
+// Values of keys that only differ in case are merged.
+func lowercaseHeaderKeys(headers http.Header) http.Header {
+	lowercase := make(http.Header, len(headers))
+	for key, values := range headers {
+		key = strings.ToLower(key)
+		lowercase[key] = append(lowercase[key], values...)
+	}
+	return lowercase
+}
+
+// isInformational reports whether the status is a 1xx code that net/http
+// would send as an interim response rather than the final one.
+func isInformational(status int) bool {
+	return status >= 100 && status < 200 && status != http.StatusSwitchingProtocols
+}
+
+// isBinaryContentType reports whether bodies of the content type must always
+// be base64 encoded, even when they happen to be valid UTF-8. gRPC-Web
+// messages are length-prefixed frames whose header bytes don't survive a
+// text body. The application/grpc-web-text types are base64 encoded by the
+// handler already and are returned as text.
+func isBinaryContentType(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return mediaType == "application/grpc-web" || strings.HasPrefix(mediaType, "application/grpc-web+")
+}
+
+// isTextContentType reports whether the content type is a text type that
+// takes a charset parameter.
+func isTextContentType(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" || mediaType == "application/xml"
+}
+
+// isJSONContentType reports whether the media type of the content type is
+// application/json.
+func isJSONContentType(contentType string) bool {
+	return strings.EqualFold(strings.TrimSpace(strings.Split(contentType, ";")[0]), "application/json")
+}
+
+// canonicalizeJSON decodes the JSON body and encodes it again, which sorts
+// the keys of the objects. Returns false if the body is not valid JSON.
+func canonicalizeJSON(body []byte) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return nil, false
+	}
+
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, false
+	}
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n")), true
+}
+
+// matchesMediaType reports whether the media type of the content type, without
+// its parameters, matches one of the patterns: an exact media type, a
+// "type/*" wildcard or "*/*".
+func matchesMediaType(contentType string, patterns []string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "*/*" || pattern == mediaType:
+			return true
+		case strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+// limitHeaderValues enforces the limit set with SetMaxHeaderValuesPerKey.
+func (r *ProxyResponseWriter) limitHeaderValues() error {
+	if r.maxHeaderValues <= 0 {
+		return nil
+	}
+	for key, values := range r.headers {
+		if len(values) <= r.maxHeaderValues {
+			continue
+		}
+		if r.failHeaderLimit {
+			return fmt.Errorf("Header %s has %d values, the limit is %d", key, len(values), r.maxHeaderValues)
+		}
+		log.Printf("Header %s has %d values, keeping the first %d\n", key, len(values), r.maxHeaderValues)
+		r.headers[key] = values[:r.maxHeaderValues]
+	}
+	return nil
+}
+
+// sanitizeHeaders applies the mode set with SetHeaderSanitization.
+func (r *ProxyResponseWriter) sanitizeHeaders() error {
+	if r.sanitization == HeaderSanitizationOff {
+		return nil
+	}
+	for key, values := range r.headers {
+		for i, value := range values {
+			if strings.IndexFunc(value, isHeaderControl) < 0 {
+				continue
+			}
+			if r.sanitization == HeaderSanitizationReject {
+				return &InvalidHeaderError{Header: key, Value: value}
+			}
+			values[i] = strings.Map(func(c rune) rune {
+				if isHeaderControl(c) {
+					return -1
+				}
+				return c
+			}, value)
+		}
+	}
+	return nil
+}
+
+// isHeaderControl reports whether c is a control character that is not
+// allowed in a header value.
+func isHeaderControl(c rune) bool {
+	return (c < ' ' && c != '\t') || c == 0x7f
+}
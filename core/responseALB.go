@@ -0,0 +1,213 @@
+// Package core provides utility methods that help convert proxy events
+// into an http.Request and http.ResponseWriter
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ProxyResponseWriterALB implements http.ResponseWriter and adds the method
+// necessary to return an events.ALBTargetGroupResponse object
+type ProxyResponseWriterALB struct {
+	headers     http.Header
+	body        bytes.Buffer
+	status      int
+	observers   []chan<- bool
+	multiValue  bool
+	reasons     map[int]string
+	binaryTypes []string
+	lowercase   bool
+
+	deadlines
+}
+
+// NewProxyResponseWriterALB returns a new ProxyResponseWriterALB object.
+// The object is initialized with an empty map of headers and a
+// status code of -1
+func NewProxyResponseWriterALB() *ProxyResponseWriterALB {
+	return &ProxyResponseWriterALB{
+		headers: make(http.Header),
+		status:  defaultStatusCode,
+	}
+
+}
+
+// SetMultiValueHeaders makes GetProxyResponse return the headers in
+// MultiValueHeaders instead of Headers. Enable it for target groups with
+// multi-value headers turned on, the load balancer ignores the Headers map
+// for them. The ProxyHandler returned by NewALBProxyHandler enables it for
+// every event that carries multi-value headers, which the load balancer only
+// sends when the setting is on.
+func (r *ProxyResponseWriterALB) SetMultiValueHeaders(multiValue bool) {
+	r.multiValue = multiValue
+}
+
+// SetLowercaseHeaderKeys makes GetProxyResponse emit the response header
+// keys in lowercase, in Headers and MultiValueHeaders alike. See
+// ProxyResponseWriter.SetLowercaseHeaderKeys.
+func (r *ProxyResponseWriterALB) SetLowercaseHeaderKeys(lowercase bool) {
+	r.lowercase = lowercase
+}
+
+// prepareForRequest matches the header mode of the response to the event
+// the request was built from.
+func (r *ProxyResponseWriterALB) prepareForRequest(req *http.Request) {
+	if v, ok := req.Context().Value(ctxKey{}).(requestContextALB); ok && v.multiValue {
+		r.multiValue = true
+	}
+}
+
+// SetReasonPhrase sets the reason phrase returned in the StatusDescription
+// of responses with the given status, in place of http.StatusText. Handlers
+// reach it by asserting their http.ResponseWriter to a
+// *ProxyResponseWriterALB.
+func (r *ProxyResponseWriterALB) SetReasonPhrase(status int, phrase string) {
+	if r.reasons == nil {
+		r.reasons = make(map[int]string)
+	}
+	r.reasons[status] = phrase
+}
+
+// SetBinaryContentTypes lists content types whose bodies are always
+// returned base64 encoded, like the binary media types of an API. See
+// ProxyResponseWriter.SetBinaryContentTypes for the patterns.
+func (r *ProxyResponseWriterALB) SetBinaryContentTypes(contentTypes []string) {
+	r.binaryTypes = contentTypes
+}
+
+// CloseNotify returns a channel that receives a value when GetProxyResponse
+// is called. The observers are only allocated once CloseNotify is used.
+func (r *ProxyResponseWriterALB) CloseNotify() <-chan bool {
+	ch := make(chan bool, 1)
+
+	r.observers = append(r.observers, ch)
+
+	return ch
+}
+
+func (r *ProxyResponseWriterALB) notifyClosed() {
+	for _, v := range r.observers {
+		select {
+		case v <- true:
+		default:
+		}
+	}
+}
+
+// Header implementation from the http.ResponseWriter interface.
+func (r *ProxyResponseWriterALB) Header() http.Header {
+	return r.headers
+}
+
+// Write sets the response body in the object. If no status code
+// was set before with the WriteHeader method it sets the status
+// for the response to 200 OK.
+func (r *ProxyResponseWriterALB) Write(body []byte) (int, error) {
+	if err := r.checkWrite(); err != nil {
+		return 0, err
+	}
+	if r.status == defaultStatusCode {
+		r.status = http.StatusOK
+	}
+
+	// if the content type header is not set when we write the body we try to
+	// detect one and set it by default. If the content type cannot be detected
+	// it is automatically set to "application/octet-stream" by the
+	// DetectContentType method
+	if r.Header().Get(contentTypeHeaderKey) == "" {
+		r.Header().Add(contentTypeHeaderKey, http.DetectContentType(body))
+	}
+
+	return (&r.body).Write(body)
+}
+
+// WriteHeader sets a status code for the response. This method is used
+// for error responses. Informational (1xx) status codes, such as a 100
+// Continue written for an Expect header, can't be returned through the
+// proxy and are ignored.
+func (r *ProxyResponseWriterALB) WriteHeader(status int) {
+	if isInformational(status) {
+		return
+	}
+	r.status = status
+}
+
+// Flush implements http.Flusher. The response is buffered until the handler
+// returns, so it only sets the status to 200 OK if none was written, see
+// ProxyResponseWriter.Flush.
+func (r *ProxyResponseWriterALB) Flush() {
+	if r.status == defaultStatusCode {
+		r.status = http.StatusOK
+	}
+}
+
+// GetProxyResponse converts the data passed to the response writer into
+// an events.ALBTargetGroupResponse object.
+// Returns a populated proxy response object. If the response is invalid, for example
+// has no headers or an invalid status code returns an error.
+// Without multi-value headers, the values of a header are joined with a comma,
+// except for Set-Cookie where only the last cookie is kept. Load balancers
+// can't send HTTP trailers, so the values of declared trailers are returned as
+// regular headers.
+func (r *ProxyResponseWriterALB) GetProxyResponse() (events.ALBTargetGroupResponse, error) {
+	r.notifyClosed()
+	foldTrailers(r.headers)
+
+	if r.status == defaultStatusCode {
+		return events.ALBTargetGroupResponse{}, errors.New("Status code not set on response")
+	}
+
+	var output string
+	isBase64 := false
+
+	bb := (&r.body).Bytes()
+
+	if contentType := r.headers.Get(contentTypeHeaderKey); utf8.Valid(bb) && !isBinaryContentType(contentType) && !matchesMediaType(contentType, r.binaryTypes) {
+		output = string(bb)
+	} else {
+		output = base64.StdEncoding.EncodeToString(bb)
+		isBase64 = true
+	}
+
+	reason, ok := r.reasons[r.status]
+	if !ok {
+		reason = http.StatusText(r.status)
+	}
+	resp := events.ALBTargetGroupResponse{
+		StatusCode:        r.status,
+		StatusDescription: fmt.Sprintf("%d %s", r.status, reason),
+		Body:              output,
+		IsBase64Encoded:   isBase64,
+	}
+
+	headers := r.headers
+	if r.lowercase {
+		headers = lowercaseHeaderKeys(headers)
+	}
+
+	if r.multiValue {
+		resp.MultiValueHeaders = http.Header(headers)
+		return resp, nil
+	}
+
+	resp.Headers = make(map[string]string, len(headers))
+	for key, values := range headers {
+		if len(values) == 0 {
+			continue
+		}
+		if http.CanonicalHeaderKey(key) == "Set-Cookie" {
+			resp.Headers[key] = values[len(values)-1]
+			continue
+		}
+		resp.Headers[key] = strings.Join(values, ",")
+	}
+	return resp, nil
+}
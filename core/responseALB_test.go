@@ -0,0 +1,162 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"math/rand"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResponseWriterALB tests", func() {
+	Context("writing to response object", func() {
+		It("Sets the status description", func() {
+			response := NewProxyResponseWriterALB()
+			response.WriteHeader(http.StatusNotFound)
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusNotFound).To(Equal(proxyResponse.StatusCode))
+			Expect("404 Not Found").To(Equal(proxyResponse.StatusDescription))
+		})
+
+		It("Sets a custom reason phrase", func() {
+			handler := NewALBProxyHandler(&RequestAccessorALB{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.(*ProxyResponseWriterALB).SetReasonPhrase(http.StatusOK, "Order Accepted")
+				w.Write([]byte("ok"))
+			}))
+			proxyResponse, err := handler.Proxy(events.ALBTargetGroupRequest{HTTPMethod: "GET", Path: "/orders"})
+			Expect(err).To(BeNil())
+			Expect(http.StatusOK).To(Equal(proxyResponse.StatusCode))
+			Expect("200 Order Accepted").To(Equal(proxyResponse.StatusDescription))
+
+			response := NewProxyResponseWriterALB()
+			response.SetReasonPhrase(http.StatusOK, "Order Accepted")
+			response.WriteHeader(http.StatusNotFound)
+			proxyResponse, err = response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect("404 Not Found").To(Equal(proxyResponse.StatusDescription))
+		})
+
+		It("Encodes binary bodies", func() {
+			binaryBody := make([]byte, 256)
+			_, err := rand.Read(binaryBody)
+			Expect(err).To(BeNil())
+
+			response := NewProxyResponseWriterALB()
+			response.Write(binaryBody)
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(true).To(Equal(proxyResponse.IsBase64Encoded))
+			decoded, err := base64.StdEncoding.DecodeString(proxyResponse.Body)
+			Expect(err).To(BeNil())
+			Expect(binaryBody).To(Equal(decoded))
+		})
+
+		It("Encodes binary bodies returned through the handler", func() {
+			gif := []byte("GIF89a\x01\x00\x01\x00\x80\x00\x00\xff\xff\xff")
+			handler := NewALBProxyHandler(&RequestAccessorALB{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "image/gif")
+				w.Write(gif)
+			}))
+
+			proxyResponse, err := handler.Proxy(events.ALBTargetGroupRequest{Path: "/pixel.gif", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(true).To(Equal(proxyResponse.IsBase64Encoded))
+			decoded, err := base64.StdEncoding.DecodeString(proxyResponse.Body)
+			Expect(err).To(BeNil())
+			Expect(gif).To(Equal(decoded))
+		})
+
+		It("Returns an error when the status is not set", func() {
+			response := NewProxyResponseWriterALB()
+			_, err := response.GetProxyResponse()
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Context("Handle headers", func() {
+		It("Joins the values of single-value headers", func() {
+			response := NewProxyResponseWriterALB()
+			response.Header().Add("Vary", "Origin")
+			response.Header().Add("Vary", "Accept-Encoding")
+			response.Header().Add("Set-Cookie", "a=1")
+			response.Header().Add("Set-Cookie", "b=2")
+			response.Write([]byte("hello"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			Expect(proxyResponse.MultiValueHeaders).To(BeNil())
+			Expect("Origin,Accept-Encoding").To(Equal(proxyResponse.Headers["Vary"]))
+			Expect("b=2").To(Equal(proxyResponse.Headers["Set-Cookie"]))
+			Expect("text/plain; charset=utf-8").To(Equal(proxyResponse.Headers["Content-Type"]))
+		})
+
+		It("Matches the header mode of the event", func() {
+			handler := NewALBProxyHandler(&RequestAccessorALB{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("Set-Cookie", "a=1")
+				w.Header().Add("Set-Cookie", "b=2")
+				w.Write([]byte("ok"))
+			}))
+
+			proxyResponse, err := handler.Proxy(events.ALBTargetGroupRequest{HTTPMethod: "GET", Path: "/orders"})
+			Expect(err).To(BeNil())
+			Expect("b=2").To(Equal(proxyResponse.Headers["Set-Cookie"]))
+			Expect(proxyResponse.MultiValueHeaders).To(BeNil())
+
+			proxyResponse, err = handler.ProxyWithContext(context.Background(), events.ALBTargetGroupRequest{
+				HTTPMethod:        "GET",
+				Path:              "/orders",
+				MultiValueHeaders: map[string][]string{"Accept": {"text/plain"}},
+			})
+			Expect(err).To(BeNil())
+			Expect("200 OK").To(Equal(proxyResponse.StatusDescription))
+			Expect([]string{"a=1", "b=2"}).To(Equal(proxyResponse.MultiValueHeaders["Set-Cookie"]))
+			Expect(proxyResponse.Headers).To(BeNil())
+		})
+
+		It("Writes multi-value headers when enabled", func() {
+			response := NewProxyResponseWriterALB()
+			response.SetMultiValueHeaders(true)
+			response.Header().Add("Set-Cookie", "a=1")
+			response.Header().Add("Set-Cookie", "b=2")
+			response.Write([]byte("hello"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			Expect(proxyResponse.Headers).To(BeNil())
+			Expect([]string{"a=1", "b=2"}).To(Equal(proxyResponse.MultiValueHeaders["Set-Cookie"]))
+		})
+
+		It("Lowercases header keys when enabled", func() {
+			response := NewProxyResponseWriterALB()
+			response.SetLowercaseHeaderKeys(true)
+			response.Header().Add("X-Request-Id", "abc")
+			response.Header().Add("Set-Cookie", "a=1")
+			response.Header().Add("Set-Cookie", "b=2")
+			response.Write([]byte("hello"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			Expect("abc").To(Equal(proxyResponse.Headers["x-request-id"]))
+			Expect("b=2").To(Equal(proxyResponse.Headers["set-cookie"]))
+			Expect(proxyResponse.Headers).ToNot(HaveKey("X-Request-Id"))
+
+			response = NewProxyResponseWriterALB()
+			response.SetLowercaseHeaderKeys(true)
+			response.SetMultiValueHeaders(true)
+			response.Header().Add("Set-Cookie", "a=1")
+			response.Header().Add("Set-Cookie", "b=2")
+			response.Write([]byte("hello"))
+			proxyResponse, err = response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			Expect([]string{"a=1", "b=2"}).To(Equal(proxyResponse.MultiValueHeaders["set-cookie"]))
+			Expect([]string{"text/plain; charset=utf-8"}).To(Equal(proxyResponse.MultiValueHeaders["content-type"]))
+			Expect(proxyResponse.MultiValueHeaders).ToNot(HaveKey("Set-Cookie"))
+		})
+	})
+})
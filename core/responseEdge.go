@@ -0,0 +1,217 @@
+// Package core provides utility methods that help convert proxy events
+// into an http.Request and http.ResponseWriter
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Size limits of the responses Lambda@Edge functions generate, headers and
+// body included. CloudFront answers with a 502 when a viewer request trigger
+// returns more than MaxViewerResponseBytes or an origin request trigger
+// returns more than MaxOriginResponseBytes.
+const (
+	MaxViewerResponseBytes = 40 * 1024
+	MaxOriginResponseBytes = 1 << 20
+)
+
+// edgeDisallowedHeaders are the headers CloudFront rejects in responses
+// generated by Lambda@Edge functions, see also edgeDisallowedPrefixes.
+var edgeDisallowedHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Expect":            true,
+	"Keep-Alive":        true,
+	"Proxy-Connection":  true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"Via":               true,
+}
+
+var edgeDisallowedPrefixes = []string{"X-Amz-Cf-", "X-Edge-"}
+
+// ProxyResponseWriterEdge implements http.ResponseWriter and adds the method
+// necessary to return a CloudFrontResponse object
+type ProxyResponseWriterEdge struct {
+	headers     http.Header
+	body        bytes.Buffer
+	status      int
+	observers   []chan<- bool
+	binaryTypes []string
+	eventType   string
+
+	deadlines
+}
+
+// NewProxyResponseWriterEdge returns a new ProxyResponseWriterEdge object.
+// The object is initialized with an empty map of headers and a
+// status code of -1
+func NewProxyResponseWriterEdge() *ProxyResponseWriterEdge {
+	return &ProxyResponseWriterEdge{
+		headers: make(http.Header),
+		status:  defaultStatusCode,
+	}
+}
+
+// prepareForRequest picks the response size limit of the trigger the
+// request was received from.
+func (r *ProxyResponseWriterEdge) prepareForRequest(req *http.Request) {
+	if v, ok := req.Context().Value(ctxKey{}).(requestContextEdge); ok {
+		r.eventType = v.cf.Config.EventType
+	}
+}
+
+// SetBinaryContentTypes lists content types whose bodies are always
+// returned base64 encoded, like the binary media types of an API. See
+// ProxyResponseWriter.SetBinaryContentTypes for the patterns.
+func (r *ProxyResponseWriterEdge) SetBinaryContentTypes(contentTypes []string) {
+	r.binaryTypes = contentTypes
+}
+
+// CloseNotify returns a channel that receives a value when GetProxyResponse
+// is called. The observers are only allocated once CloseNotify is used.
+func (r *ProxyResponseWriterEdge) CloseNotify() <-chan bool {
+	ch := make(chan bool, 1)
+
+	r.observers = append(r.observers, ch)
+
+	return ch
+}
+
+func (r *ProxyResponseWriterEdge) notifyClosed() {
+	for _, v := range r.observers {
+		select {
+		case v <- true:
+		default:
+		}
+	}
+}
+
+// Header implementation from the http.ResponseWriter interface.
+func (r *ProxyResponseWriterEdge) Header() http.Header {
+	return r.headers
+}
+
+// Write sets the response body in the object. If no status code
+// was set before with the WriteHeader method it sets the status
+// for the response to 200 OK.
+func (r *ProxyResponseWriterEdge) Write(body []byte) (int, error) {
+	if err := r.checkWrite(); err != nil {
+		return 0, err
+	}
+	if r.status == defaultStatusCode {
+		r.status = http.StatusOK
+	}
+
+	// if the content type header is not set when we write the body we try to
+	// detect one and set it by default. If the content type cannot be detected
+	// it is automatically set to "application/octet-stream" by the
+	// DetectContentType method
+	if r.Header().Get(contentTypeHeaderKey) == "" {
+		r.Header().Add(contentTypeHeaderKey, http.DetectContentType(body))
+	}
+
+	return (&r.body).Write(body)
+}
+
+// WriteHeader sets a status code for the response. This method is used
+// for error responses. Informational (1xx) status codes can't be returned
+// through the proxy and are ignored.
+func (r *ProxyResponseWriterEdge) WriteHeader(status int) {
+	if isInformational(status) {
+		return
+	}
+	r.status = status
+}
+
+// Flush implements http.Flusher. The response is buffered until the handler
+// returns, so it only sets the status to 200 OK if none was written, see
+// ProxyResponseWriter.Flush.
+func (r *ProxyResponseWriterEdge) Flush() {
+	if r.status == defaultStatusCode {
+		r.status = http.StatusOK
+	}
+}
+
+// GetProxyResponse converts the data passed to the response writer into
+// a CloudFrontResponse object.
+// Returns a populated proxy response object. If the response is invalid, for
+// example has no status code or exceeds the size limit of the trigger, it
+// returns an error. Headers are keyed by their lowercase name and the
+// headers CloudFront rejects in generated responses, such as Content-Length
+// and Transfer-Encoding, are dropped. The limit is MaxOriginResponseBytes
+// for origin request triggers and MaxViewerResponseBytes otherwise.
+func (r *ProxyResponseWriterEdge) GetProxyResponse() (CloudFrontResponse, error) {
+	r.notifyClosed()
+	foldTrailers(r.headers)
+
+	if r.status == defaultStatusCode {
+		return CloudFrontResponse{}, errors.New("Status code not set on response")
+	}
+
+	output := ""
+	encoding := "text"
+
+	bb := (&r.body).Bytes()
+
+	if contentType := r.headers.Get(contentTypeHeaderKey); utf8.Valid(bb) && !isBinaryContentType(contentType) && !matchesMediaType(contentType, r.binaryTypes) {
+		output = string(bb)
+	} else {
+		output = base64.StdEncoding.EncodeToString(bb)
+		encoding = "base64"
+	}
+
+	size := len(output)
+	headers := make(CloudFrontHeaders)
+	for key, values := range r.headers {
+		key = http.CanonicalHeaderKey(key)
+		if isEdgeDisallowedHeader(key) {
+			continue
+		}
+		name := strings.ToLower(key)
+		for _, value := range values {
+			headers[name] = append(headers[name], CloudFrontHeader{Key: key, Value: value})
+			size += len(key) + len(value)
+		}
+	}
+
+	limit := MaxViewerResponseBytes
+	if r.eventType == CloudFrontOriginRequest {
+		limit = MaxOriginResponseBytes
+	}
+	if size > limit {
+		eventType := r.eventType
+		if eventType == "" {
+			eventType = CloudFrontViewerRequest
+		}
+		return CloudFrontResponse{}, fmt.Errorf("%w: %d bytes exceed the %d byte limit of %s triggers", ErrResponseTooLarge, size, limit, eventType)
+	}
+
+	return CloudFrontResponse{
+		Status:            strconv.Itoa(r.status),
+		StatusDescription: http.StatusText(r.status),
+		Headers:           headers,
+		Body:              output,
+		BodyEncoding:      encoding,
+	}, nil
+}
+
+func isEdgeDisallowedHeader(key string) bool {
+	if edgeDisallowedHeaders[key] {
+		return true
+	}
+	for _, prefix := range edgeDisallowedPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
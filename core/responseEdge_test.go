@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResponseWriterEdge tests", func() {
+	Context("Export CloudFront response", func() {
+		It("Refuses empty responses with default status code", func() {
+			resp := NewProxyResponseWriterEdge()
+			_, err := resp.GetProxyResponse()
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("Writes headers in the CloudFront format", func() {
+			resp := NewProxyResponseWriterEdge()
+			resp.Header().Add("Content-Type", "text/plain")
+			http.SetCookie(resp, &http.Cookie{Name: "session", Value: "abc"})
+			http.SetCookie(resp, &http.Cookie{Name: "theme", Value: "dark"})
+			resp.WriteHeader(http.StatusFound)
+			resp.Write([]byte("moved"))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect("302").To(Equal(proxyResp.Status))
+			Expect("Found").To(Equal(proxyResp.StatusDescription))
+			Expect([]CloudFrontHeader{{Key: "Content-Type", Value: "text/plain"}}).To(Equal(proxyResp.Headers["content-type"]))
+			Expect([]CloudFrontHeader{{Key: "Set-Cookie", Value: "session=abc"}, {Key: "Set-Cookie", Value: "theme=dark"}}).To(Equal(proxyResp.Headers["set-cookie"]))
+			Expect("moved").To(Equal(proxyResp.Body))
+			Expect("text").To(Equal(proxyResp.BodyEncoding))
+		})
+
+		It("Drops the headers CloudFront rejects", func() {
+			resp := NewProxyResponseWriterEdge()
+			resp.Header().Set("Content-Length", "2")
+			resp.Header().Set("Transfer-Encoding", "chunked")
+			resp.Header().Set("X-Amz-Cf-Id", "abc")
+			resp.Header().Set("X-Frame-Options", "DENY")
+			resp.Write([]byte("ok"))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(proxyResp.Headers).ToNot(HaveKey("content-length"))
+			Expect(proxyResp.Headers).ToNot(HaveKey("transfer-encoding"))
+			Expect(proxyResp.Headers).ToNot(HaveKey("x-amz-cf-id"))
+			Expect(proxyResp.Headers).To(HaveKey("x-frame-options"))
+		})
+
+		It("Encodes binary responses with base64", func() {
+			binaryBody := []byte{0xff, 0xd8, 0xff, 0xe0}
+			resp := NewProxyResponseWriterEdge()
+			resp.Header().Set("Content-Type", "image/jpeg")
+			resp.Write(binaryBody)
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect("base64").To(Equal(proxyResp.BodyEncoding))
+			Expect(base64.StdEncoding.EncodeToString(binaryBody)).To(Equal(proxyResp.Body))
+		})
+
+		It("Enforces the size limit of the trigger", func() {
+			body := []byte(strings.Repeat("a", MaxViewerResponseBytes+1))
+
+			resp := NewProxyResponseWriterEdge()
+			resp.prepareForRequest(edgeRequest(CloudFrontViewerRequest))
+			resp.Write(body)
+			_, err := resp.GetProxyResponse()
+			Expect(errors.Is(err, ErrResponseTooLarge)).To(BeTrue())
+
+			resp = NewProxyResponseWriterEdge()
+			resp.prepareForRequest(edgeRequest(CloudFrontOriginRequest))
+			resp.Write(body)
+			_, err = resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+		})
+	})
+})
+
+func edgeRequest(eventType string) *http.Request {
+	req, _ := http.NewRequest("GET", "https://www.example.com/", nil)
+	rc := requestContextEdge{cf: CloudFrontRecordCF{Config: CloudFrontConfig{EventType: eventType}}}
+	return req.WithContext(context.WithValue(req.Context(), ctxKey{}, rc))
+}
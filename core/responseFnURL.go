@@ -0,0 +1,157 @@
+// Package core provides utility methods that help convert proxy events
+// into an http.Request and http.ResponseWriter
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ProxyResponseWriterFnURL implements http.ResponseWriter and adds the method
+// necessary to return an events.LambdaFunctionURLResponse object
+type ProxyResponseWriterFnURL struct {
+	headers     http.Header
+	body        bytes.Buffer
+	status      int
+	observers   []chan<- bool
+	binaryTypes []string
+
+	deadlines
+}
+
+// NewProxyResponseWriterFnURL returns a new ProxyResponseWriterFnURL object.
+// The object is initialized with an empty map of headers and a
+// status code of -1
+func NewProxyResponseWriterFnURL() *ProxyResponseWriterFnURL {
+	return &ProxyResponseWriterFnURL{
+		headers: make(http.Header),
+		status:  defaultStatusCode,
+	}
+
+}
+
+// SetBinaryContentTypes lists content types whose bodies are always
+// returned base64 encoded, like the binary media types of an API. See
+// ProxyResponseWriter.SetBinaryContentTypes for the patterns.
+func (r *ProxyResponseWriterFnURL) SetBinaryContentTypes(contentTypes []string) {
+	r.binaryTypes = contentTypes
+}
+
+// CloseNotify returns a channel that receives a value when GetProxyResponse
+// is called. The observers are only allocated once CloseNotify is used.
+func (r *ProxyResponseWriterFnURL) CloseNotify() <-chan bool {
+	ch := make(chan bool, 1)
+
+	r.observers = append(r.observers, ch)
+
+	return ch
+}
+
+func (r *ProxyResponseWriterFnURL) notifyClosed() {
+	for _, v := range r.observers {
+		select {
+		case v <- true:
+		default:
+		}
+	}
+}
+
+// Header implementation from the http.ResponseWriter interface.
+func (r *ProxyResponseWriterFnURL) Header() http.Header {
+	return r.headers
+}
+
+// Write sets the response body in the object. If no status code
+// was set before with the WriteHeader method it sets the status
+// for the response to 200 OK.
+func (r *ProxyResponseWriterFnURL) Write(body []byte) (int, error) {
+	if err := r.checkWrite(); err != nil {
+		return 0, err
+	}
+	if r.status == defaultStatusCode {
+		r.status = http.StatusOK
+	}
+
+	// if the content type header is not set when we write the body we try to
+	// detect one and set it by default. If the content type cannot be detected
+	// it is automatically set to "application/octet-stream" by the
+	// DetectContentType method
+	if r.Header().Get(contentTypeHeaderKey) == "" {
+		r.Header().Add(contentTypeHeaderKey, http.DetectContentType(body))
+	}
+
+	return (&r.body).Write(body)
+}
+
+// WriteHeader sets a status code for the response. This method is used
+// for error responses. Informational (1xx) status codes, such as a 100
+// Continue written for an Expect header, can't be returned through the
+// proxy and are ignored.
+func (r *ProxyResponseWriterFnURL) WriteHeader(status int) {
+	if isInformational(status) {
+		return
+	}
+	r.status = status
+}
+
+// Flush implements http.Flusher. The response is buffered until the handler
+// returns, so it only sets the status to 200 OK if none was written, see
+// ProxyResponseWriter.Flush.
+func (r *ProxyResponseWriterFnURL) Flush() {
+	if r.status == defaultStatusCode {
+		r.status = http.StatusOK
+	}
+}
+
+// GetProxyResponse converts the data passed to the response writer into
+// an events.LambdaFunctionURLResponse object.
+// Returns a populated proxy response object. If the response is invalid, for example
+// has no headers or an invalid status code returns an error.
+// Function URL responses only support single-value headers: multiple values
+// are joined with a comma and every Set-Cookie header is moved to the
+// Cookies array. Function URLs can't send HTTP trailers, so the values of
+// declared trailers are returned as regular headers.
+func (r *ProxyResponseWriterFnURL) GetProxyResponse() (events.LambdaFunctionURLResponse, error) {
+	r.notifyClosed()
+	foldTrailers(r.headers)
+
+	if r.status == defaultStatusCode {
+		return events.LambdaFunctionURLResponse{}, errors.New("Status code not set on response")
+	}
+
+	var output string
+	isBase64 := false
+
+	bb := (&r.body).Bytes()
+
+	if contentType := r.headers.Get(contentTypeHeaderKey); utf8.Valid(bb) && !isBinaryContentType(contentType) && !matchesMediaType(contentType, r.binaryTypes) {
+		output = string(bb)
+	} else {
+		output = base64.StdEncoding.EncodeToString(bb)
+		isBase64 = true
+	}
+
+	headers := make(map[string]string)
+	var cookies []string
+	for key, values := range r.headers {
+		if http.CanonicalHeaderKey(key) == "Set-Cookie" {
+			cookies = append(cookies, values...)
+			continue
+		}
+		headers[key] = strings.Join(values, ",")
+	}
+
+	return events.LambdaFunctionURLResponse{
+		StatusCode:      r.status,
+		Headers:         headers,
+		Body:            output,
+		IsBase64Encoded: isBase64,
+		Cookies:         cookies,
+	}, nil
+}
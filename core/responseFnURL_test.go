@@ -0,0 +1,63 @@
+package core
+
+import (
+	"encoding/base64"
+	"math/rand"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResponseWriterFnURL tests", func() {
+	Context("Export Function URL response", func() {
+		It("Refuses empty responses with default status code", func() {
+			resp := NewProxyResponseWriterFnURL()
+			_, err := resp.GetProxyResponse()
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("Writes single-value headers and the body", func() {
+			resp := NewProxyResponseWriterFnURL()
+			resp.Header().Add("Content-Type", "application/json")
+			resp.Header().Add("Vary", "Accept")
+			resp.Header().Add("Vary", "Origin")
+			resp.WriteHeader(http.StatusCreated)
+			resp.Write([]byte("{}"))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusCreated).To(Equal(proxyResp.StatusCode))
+			Expect("application/json").To(Equal(proxyResp.Headers["Content-Type"]))
+			Expect("Accept,Origin").To(Equal(proxyResp.Headers["Vary"]))
+			Expect("{}").To(Equal(proxyResp.Body))
+			Expect(false).To(Equal(proxyResp.IsBase64Encoded))
+		})
+
+		It("Moves Set-Cookie headers to the cookies array", func() {
+			resp := NewProxyResponseWriterFnURL()
+			http.SetCookie(resp, &http.Cookie{Name: "session", Value: "abc"})
+			http.SetCookie(resp, &http.Cookie{Name: "theme", Value: "dark"})
+			resp.WriteHeader(http.StatusOK)
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect([]string{"session=abc", "theme=dark"}).To(Equal(proxyResp.Cookies))
+			Expect(proxyResp.Headers).ToNot(HaveKey("Set-Cookie"))
+		})
+
+		It("Encodes binary responses correctly", func() {
+			binaryBody := make([]byte, 256)
+			_, err := rand.Read(binaryBody)
+			Expect(err).To(BeNil())
+
+			resp := NewProxyResponseWriterFnURL()
+			resp.Write(binaryBody)
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(true).To(Equal(proxyResp.IsBase64Encoded))
+			Expect(base64.StdEncoding.EncodeToString(binaryBody)).To(Equal(proxyResp.Body))
+		})
+	})
+})
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// MultipartStreamWriter writes a multipart/x-mixed-replace stream, as used
+// for MJPEG style server push, through a StreamingResponseWriter. Each part
+// is followed by a flush, so the client receives a part as soon as
+// WritePart returns.
+type MultipartStreamWriter struct {
+	w        *StreamingResponseWriter
+	boundary string
+	closed   bool
+}
+
+// NewMultipartStreamWriter returns a MultipartStreamWriter that writes to w
+// with a random boundary. It sets the Content-Type header of the response,
+// so it must be created before the first write to w.
+func NewMultipartStreamWriter(w *StreamingResponseWriter) *MultipartStreamWriter {
+	var b [16]byte
+	rand.Read(b[:])
+	m := &MultipartStreamWriter{w: w, boundary: fmt.Sprintf("%x", b[:])}
+	w.Header().Set(contentTypeHeaderKey, "multipart/x-mixed-replace; boundary="+m.boundary)
+	return m
+}
+
+// Boundary returns the boundary that separates the parts.
+func (m *MultipartStreamWriter) Boundary() string {
+	return m.boundary
+}
+
+// WritePart writes a part with the given headers, such as its Content-Type,
+// and body, then flushes the stream. The part is terminated right away so
+// clients don't wait for the next boundary to display it.
+func (m *MultipartStreamWriter) WritePart(headers http.Header, body []byte) error {
+	if m.closed {
+		return errors.New("Multipart stream already closed")
+	}
+
+	var part strings.Builder
+	part.WriteString("--" + m.boundary + "\r\n")
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range headers[key] {
+			part.WriteString(key + ": " + value + "\r\n")
+		}
+	}
+	part.WriteString("\r\n")
+
+	if _, err := m.w.Write([]byte(part.String())); err != nil {
+		return err
+	}
+	if _, err := m.w.Write(body); err != nil {
+		return err
+	}
+	if _, err := m.w.Write([]byte("\r\n")); err != nil {
+		return err
+	}
+	return m.w.FlushError()
+}
+
+// Close writes the final boundary and flushes the stream.
+func (m *MultipartStreamWriter) Close() error {
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	if _, err := m.w.Write([]byte("--" + m.boundary + "--\r\n")); err != nil {
+		return err
+	}
+	return m.w.FlushError()
+}
@@ -0,0 +1,52 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MultipartStreamWriter tests", func() {
+	It("Flushes each part with its boundary framing", func() {
+		out := &flushRecorder{}
+		response := core.NewStreamingResponseWriter(context.Background(), out)
+		stream := core.NewMultipartStreamWriter(response)
+		boundary := stream.Boundary()
+
+		jpeg := http.Header{"Content-Type": {"image/jpeg"}}
+		Expect(stream.WritePart(jpeg, []byte("frame-1"))).To(BeNil())
+		Expect(stream.WritePart(jpeg, []byte("frame-2"))).To(BeNil())
+		Expect(stream.Close()).To(BeNil())
+		Expect(stream.WritePart(jpeg, []byte("frame-3"))).ToNot(BeNil())
+
+		Expect([]string{
+			"--" + boundary + "\r\nContent-Type: image/jpeg\r\n\r\nframe-1\r\n",
+			"--" + boundary + "\r\nContent-Type: image/jpeg\r\n\r\nframe-2\r\n",
+			"--" + boundary + "--\r\n",
+		}).To(Equal(out.flushed))
+
+		mediaType, params, err := mime.ParseMediaType(response.Header().Get("Content-Type"))
+		Expect(err).To(BeNil())
+		Expect("multipart/x-mixed-replace").To(Equal(mediaType))
+		Expect(boundary).To(Equal(params["boundary"]))
+
+		reader := multipart.NewReader(bytes.NewReader([]byte(strings.Join(out.flushed, ""))), boundary)
+		for _, frame := range []string{"frame-1", "frame-2"} {
+			part, err := reader.NextPart()
+			Expect(err).To(BeNil())
+			Expect("image/jpeg").To(Equal(part.Header.Get("Content-Type")))
+			body, err := ioutil.ReadAll(part)
+			Expect(err).To(BeNil())
+			Expect(frame).To(Equal(string(body)))
+		}
+	})
+})
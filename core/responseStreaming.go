@@ -0,0 +1,152 @@
+// Package core provides utility methods that help convert proxy events
+// into an http.Request and http.ResponseWriter
+package core
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// StreamingResponseWriter implements http.ResponseWriter and http.Flusher and
+// writes the body to an io.Writer as the handler produces it, instead of
+// buffering the whole response like ProxyResponseWriter. Writes are buffered
+// until the handler calls Flush or the buffer fills up. When Flush returns,
+// every byte written before it has been passed to the underlying writer, which
+// is flushed too if it implements http.Flusher. Handlers that interleave
+// writes and flushes, for example one json.Encoder.Encode call per line of an
+// NDJSON stream, are therefore delivered incrementally.
+//
+// The writer watches the request context: once its deadline, minus the margin
+// set with SetDeadlineMargin, has passed, Write and FlushError return an
+// error so handlers can stop before the Lambda runtime aborts the invocation.
+type StreamingResponseWriter struct {
+	ctx            context.Context
+	headers        http.Header
+	status         int
+	out            io.Writer
+	buf            *bufio.Writer
+	committed      bool
+	onCommit       func(status int, headers http.Header)
+	deadlineMargin time.Duration
+
+	deadlines
+}
+
+// NewStreamingResponseWriter returns a new StreamingResponseWriter that writes
+// the body to out. The writer stops accepting writes once ctx is done or its
+// deadline has passed.
+func NewStreamingResponseWriter(ctx context.Context, out io.Writer) *StreamingResponseWriter {
+	return &StreamingResponseWriter{
+		ctx:     ctx,
+		headers: make(http.Header),
+		status:  defaultStatusCode,
+		out:     out,
+		buf:     bufio.NewWriter(out),
+	}
+}
+
+// SetDeadlineMargin makes the writer stop accepting writes the given
+// duration before the deadline of the request context, leaving the handler
+// time to clean up.
+func (r *StreamingResponseWriter) SetDeadlineMargin(margin time.Duration) {
+	r.deadlineMargin = margin
+}
+
+// Header implementation from the http.ResponseWriter interface. Changes to
+// the headers after the first Write or Flush are not sent.
+func (r *StreamingResponseWriter) Header() http.Header {
+	return r.headers
+}
+
+// Status returns the status code of the response, or -1 if the handler has
+// not set one yet.
+func (r *StreamingResponseWriter) Status() int {
+	return r.status
+}
+
+// WriteHeader sets a status code for the response. Informational (1xx) status
+// codes and calls after the response was committed by a Write or Flush are
+// ignored.
+func (r *StreamingResponseWriter) WriteHeader(status int) {
+	if isInformational(status) || r.committed {
+		return
+	}
+	r.status = status
+}
+
+// Write adds the bytes to the response body. If no status code was set
+// before with the WriteHeader method it sets the status for the response to
+// 200 OK. Returns an error once the request context is done.
+func (r *StreamingResponseWriter) Write(body []byte) (int, error) {
+	if err := r.checkDeadline(); err != nil {
+		return 0, err
+	}
+
+	if !r.committed && r.headers.Get(contentTypeHeaderKey) == "" {
+		r.headers.Add(contentTypeHeaderKey, http.DetectContentType(body))
+	}
+	r.commit()
+
+	return r.buf.Write(body)
+}
+
+// Flush implementation from the http.Flusher interface. It sends the buffered
+// body to the underlying writer, see FlushError.
+func (r *StreamingResponseWriter) Flush() {
+	r.FlushError()
+}
+
+// FlushError sends the buffered body to the underlying writer, and flushes
+// the underlying writer if it implements http.Flusher. Returns an error once
+// the request context is done.
+func (r *StreamingResponseWriter) FlushError() error {
+	if err := r.checkDeadline(); err != nil {
+		return err
+	}
+
+	r.commit()
+	if err := r.buf.Flush(); err != nil {
+		return err
+	}
+	if flusher, ok := r.out.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// commit fixes the status and headers of the response the first time the
+// handler writes or flushes.
+func (r *StreamingResponseWriter) commit() {
+	if r.committed {
+		return
+	}
+	if r.status == defaultStatusCode {
+		r.status = http.StatusOK
+	}
+	r.committed = true
+	if r.onCommit != nil {
+		r.onCommit(r.status, r.headers)
+	}
+}
+
+// checkDeadline returns an error if the request context is done, its
+// deadline is closer than the configured margin or the write deadline set
+// with SetWriteDeadline has passed.
+func (r *StreamingResponseWriter) checkDeadline() error {
+	if err := r.checkWrite(); err != nil {
+		return err
+	}
+	if r.ctx == nil {
+		return nil
+	}
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	if deadline, ok := r.ctx.Deadline(); ok && time.Until(deadline) <= r.deadlineMargin {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
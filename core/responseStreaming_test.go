@@ -0,0 +1,106 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StreamingResponseWriter tests", func() {
+	Context("writing to the stream", func() {
+		It("Sends the body to the underlying writer on flush", func() {
+			var out bytes.Buffer
+			response := core.NewStreamingResponseWriter(context.Background(), &out)
+			response.Header().Set("Content-Type", "text/plain")
+			response.WriteHeader(http.StatusAccepted)
+
+			_, err := response.Write([]byte("hello"))
+			Expect(err).To(BeNil())
+			Expect("").To(Equal(out.String()))
+
+			Expect(response.FlushError()).To(BeNil())
+			Expect("hello").To(Equal(out.String()))
+			Expect(http.StatusAccepted).To(Equal(response.Status()))
+
+			// the response is committed, later status codes are ignored
+			response.WriteHeader(http.StatusInternalServerError)
+			Expect(http.StatusAccepted).To(Equal(response.Status()))
+		})
+	})
+
+	Context("incremental flushes", func() {
+		It("Delivers each flushed NDJSON line to the underlying writer", func() {
+			out := &flushRecorder{}
+			response := core.NewStreamingResponseWriter(context.Background(), out)
+			response.Header().Set("Content-Type", "application/x-ndjson")
+
+			encoder := json.NewEncoder(response)
+			for i := 0; i < 5; i++ {
+				Expect(encoder.Encode(map[string]int{"event": i})).To(BeNil())
+				Expect(i).To(Equal(len(out.flushed)))
+				response.Flush()
+			}
+
+			Expect([]string{
+				"{\"event\":0}\n",
+				"{\"event\":1}\n",
+				"{\"event\":2}\n",
+				"{\"event\":3}\n",
+				"{\"event\":4}\n",
+			}).To(Equal(out.flushed))
+		})
+	})
+
+	Context("request deadline", func() {
+		It("Returns an error from writes after the deadline", func() {
+			var out bytes.Buffer
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			response := core.NewStreamingResponseWriter(ctx, &out)
+
+			_, err := response.Write([]byte("first"))
+			Expect(err).To(BeNil())
+			Expect(response.FlushError()).To(BeNil())
+
+			<-ctx.Done()
+			_, err = response.Write([]byte("second"))
+			Expect(err).To(Equal(context.DeadlineExceeded))
+			Expect(response.FlushError()).To(Equal(context.DeadlineExceeded))
+			Expect("first").To(Equal(out.String()))
+		})
+
+		It("Stops writing the margin before the deadline", func() {
+			var out bytes.Buffer
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			response := core.NewStreamingResponseWriter(ctx, &out)
+			response.SetDeadlineMargin(2 * time.Minute)
+
+			_, err := response.Write([]byte("late"))
+			Expect(err).To(Equal(context.DeadlineExceeded))
+			Expect("").To(Equal(out.String()))
+		})
+	})
+})
+
+// flushRecorder records the bytes written to it between each call to Flush.
+type flushRecorder struct {
+	pending bytes.Buffer
+	flushed []string
+}
+
+func (f *flushRecorder) Write(p []byte) (int, error) {
+	return f.pending.Write(p)
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushed = append(f.flushed, f.pending.String())
+	f.pending.Reset()
+}
@@ -1,10 +1,20 @@
 package core
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
+	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -50,6 +60,76 @@ var _ = Describe("ResponseWriter tests", func() {
 		})
 	})
 
+	Context("Close notifications", func() {
+		It("Only allocates observers when CloseNotify is used", func() {
+			resp := NewProxyResponseWriter()
+			Expect(resp.observers).To(BeNil())
+			resp.WriteHeader(http.StatusOK)
+			_, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+		})
+
+		It("Notifies observers when the response is generated", func() {
+			resp := NewProxyResponseWriter()
+			closed := resp.CloseNotify()
+			Expect(1).To(Equal(len(resp.observers)))
+			resp.WriteHeader(http.StatusOK)
+			_, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(closed).To(Receive(BeTrue()))
+		})
+
+		It("Does not block on observers that are never read", func() {
+			resp := NewProxyResponseWriter()
+			resp.CloseNotify()
+			resp.WriteHeader(http.StatusOK)
+
+			done := make(chan bool)
+			go func() {
+				resp.GetProxyResponse()
+				resp.GetProxyResponse()
+				close(done)
+			}()
+			Eventually(done, time.Second).Should(BeClosed())
+		})
+	})
+
+	Context("Writes after GetProxyResponse", func() {
+		It("Drops writes from goroutines after the response is finalized", func() {
+			resp := NewProxyResponseWriter()
+			resp.Write([]byte("hello"))
+			proxyResponse, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			errs := make(chan error, 1)
+			go func() {
+				resp.WriteHeader(http.StatusInternalServerError)
+				_, err := resp.Write([]byte(" world"))
+				errs <- err
+			}()
+			Eventually(errs, time.Second).Should(Receive(Equal(ErrResponseFinalized)))
+
+			Expect("hello").To(Equal(proxyResponse.Body))
+			Expect(http.StatusOK).To(Equal(resp.Status()))
+			Expect(5).To(Equal(resp.BodyLen()))
+		})
+
+		It("Is safe for writes racing GetProxyResponse", func() {
+			resp := NewProxyResponseWriter()
+			resp.WriteHeader(http.StatusOK)
+			done := make(chan bool)
+			go func() {
+				for i := 0; i < 100; i++ {
+					resp.Write([]byte("a"))
+				}
+				close(done)
+			}()
+			_, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Eventually(done, time.Second).Should(BeClosed())
+		})
+	})
+
 	Context("Automatically set response content type", func() {
 		xmlBodyContent := "<?xml version=\"1.0\" encoding=\"UTF-8\"?><note><to>Tove</to><from>Jani</from><heading>Reminder</heading><body>Don't forget me this weekend!</body></note>"
 		htmlBodyContent := " <!DOCTYPE html><html><head><meta charset=\"UTF-8\"><title>Title of the document</title></head><body>Content of the document......</body></html>"
@@ -94,6 +174,42 @@ var _ = Describe("ResponseWriter tests", func() {
 		})
 	})
 
+	Context("Deferred content type detection", func() {
+		It("Keeps a content type set by the handler after writing", func() {
+			resp := NewProxyResponseWriter()
+			resp.SetDeferContentTypeDetection(true)
+			resp.Write([]byte("{\"hello\": \"world\"}"))
+			Expect("").To(Equal(resp.Header().Get("Content-Type")))
+			resp.Header().Set("Content-Type", "application/json")
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect([]string{"application/json"}).To(Equal(proxyResp.MultiValueHeaders["Content-Type"]))
+		})
+
+		It("Detects the content type from the full body", func() {
+			resp := NewProxyResponseWriter()
+			resp.SetDeferContentTypeDetection(true)
+			resp.Write([]byte("   "))
+			resp.Write([]byte("<!DOCTYPE html><html></html>"))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(1).To(Equal(len(proxyResp.MultiValueHeaders["Content-Type"])))
+			Expect(true).To(Equal(strings.HasPrefix(proxyResp.MultiValueHeaders["Content-Type"][0], "text/html;")))
+		})
+
+		It("Does not set a content type for empty bodies", func() {
+			resp := NewProxyResponseWriter()
+			resp.SetDeferContentTypeDetection(true)
+			resp.WriteHeader(http.StatusNoContent)
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect("").To(Equal(http.Header(proxyResp.MultiValueHeaders).Get("Content-Type")))
+		})
+	})
+
 	Context("Export API Gateway proxy response", func() {
 		emtpyResponse := NewProxyResponseWriter()
 		emtpyResponse.Header().Add("Content-Type", "application/json")
@@ -144,6 +260,543 @@ var _ = Describe("ResponseWriter tests", func() {
 		})
 	})
 
+	Context("Redirect responses", func() {
+		It("Keeps the status, Location header and HTML body for GET redirects", func() {
+			req := httptest.NewRequest(http.MethodGet, "/old", nil)
+			response := NewProxyResponseWriter()
+			http.Redirect(response, req, "/new", http.StatusFound)
+
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusFound).To(Equal(proxyResponse.StatusCode))
+			Expect([]string{"/new"}).To(Equal(proxyResponse.MultiValueHeaders["Location"]))
+			Expect(true).To(Equal(strings.HasPrefix(proxyResponse.MultiValueHeaders["Content-Type"][0], "text/html")))
+			Expect(proxyResponse.Body).To(ContainSubstring("/new"))
+			Expect(proxyResponse.IsBase64Encoded).To(BeFalse())
+		})
+
+		It("Returns no body and no content type for other methods", func() {
+			req := httptest.NewRequest(http.MethodPost, "/old", nil)
+			response := NewProxyResponseWriter()
+			http.Redirect(response, req, "/new", http.StatusSeeOther)
+
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusSeeOther).To(Equal(proxyResponse.StatusCode))
+			Expect([]string{"/new"}).To(Equal(proxyResponse.MultiValueHeaders["Location"]))
+			Expect(proxyResponse.MultiValueHeaders).ToNot(HaveKey("Content-Type"))
+			Expect("").To(Equal(proxyResponse.Body))
+		})
+	})
+
+	Context("gRPC-Web responses", func() {
+		It("Encodes framed bodies and keeps the grpc headers", func() {
+			// a data frame with a 5 byte message, which is valid UTF-8
+			body := []byte{0x00, 0x00, 0x00, 0x00, 0x05, 'h', 'e', 'l', 'l', 'o'}
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/grpc-web+proto")
+				w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+				w.Write(body)
+				w.Header().Set("Grpc-Status", "0")
+				w.Header().Set("Grpc-Message", "OK")
+			})
+
+			resp := NewProxyResponseWriter()
+			handler.ServeHTTP(resp, httptest.NewRequest("POST", "/svc.Greeter/SayHello", nil))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(true).To(Equal(proxyResp.IsBase64Encoded))
+			Expect(base64.StdEncoding.EncodeToString(body)).To(Equal(proxyResp.Body))
+			Expect([]string{"application/grpc-web+proto"}).To(Equal(proxyResp.MultiValueHeaders["Content-Type"]))
+			Expect([]string{"0"}).To(Equal(proxyResp.MultiValueHeaders["Grpc-Status"]))
+			Expect([]string{"OK"}).To(Equal(proxyResp.MultiValueHeaders["Grpc-Message"]))
+		})
+		It("Matches the media type case-insensitively", func() {
+			body := []byte{0x00, 0x00, 0x00, 0x00, 0x05, 'h', 'e', 'l', 'l', 'o'}
+
+			resp := NewProxyResponseWriter()
+			resp.Header().Set("Content-Type", "Application/GRPC-Web; charset=utf-8")
+			resp.Write(body)
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(true).To(Equal(proxyResp.IsBase64Encoded))
+			Expect(base64.StdEncoding.EncodeToString(body)).To(Equal(proxyResp.Body))
+		})
+
+		It("Returns grpc-web-text bodies as they are", func() {
+			body := base64.StdEncoding.EncodeToString([]byte{0x00, 0x00, 0x00, 0x00, 0x05, 'h', 'e', 'l', 'l', 'o'})
+
+			resp := NewProxyResponseWriter()
+			resp.Header().Set("Content-Type", "application/grpc-web-text+proto")
+			resp.Write([]byte(body))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(false).To(Equal(proxyResp.IsBase64Encoded))
+			Expect(body).To(Equal(proxyResp.Body))
+		})
+	})
+
+	Context("Already encoded bodies", func() {
+		It("Does not encode the body a second time", func() {
+			binaryBody := make([]byte, 256)
+			_, err := rand.Read(binaryBody)
+			Expect(err).To(BeNil())
+			encodedBody := base64.StdEncoding.EncodeToString(binaryBody)
+
+			resp := NewProxyResponseWriter()
+			resp.SetBodyAlreadyEncoded(true)
+			resp.Header().Set("Content-Type", "image/png")
+			resp.Write([]byte(encodedBody))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(true).To(Equal(proxyResp.IsBase64Encoded))
+			Expect(encodedBody).To(Equal(proxyResp.Body))
+
+			decodedBody, err := base64.StdEncoding.DecodeString(proxyResp.Body)
+			Expect(err).To(BeNil())
+			Expect(binaryBody).To(Equal(decodedBody))
+		})
+	})
+
+	Context("Forced base64 content types", func() {
+		It("Encodes the listed content types without changing the header", func() {
+			csvBody := "id,name\n1,a\x00b\n"
+			response := NewProxyResponseWriter()
+			response.ForceBase64ForContentTypes([]string{"text/csv"})
+			response.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			response.Write([]byte(csvBody))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(proxyResponse.IsBase64Encoded).To(BeTrue())
+			Expect(base64.StdEncoding.EncodeToString([]byte(csvBody))).To(Equal(proxyResponse.Body))
+			Expect("text/csv; charset=utf-8").To(Equal(proxyResponse.MultiValueHeaders["Content-Type"][0]))
+
+			response = NewProxyResponseWriter()
+			response.ForceBase64ForContentTypes([]string{"text/csv"})
+			response.Header().Set("Content-Type", "text/plain")
+			response.Write([]byte("hello"))
+			proxyResponse, err = response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(proxyResponse.IsBase64Encoded).To(BeFalse())
+			Expect("hello").To(Equal(proxyResponse.Body))
+		})
+	})
+
+	Context("Flush", func() {
+		It("Commits the status and keeps buffering", func() {
+			response := NewProxyResponseWriter()
+			var w http.ResponseWriter = response
+			flusher, ok := w.(http.Flusher)
+			Expect(ok).To(BeTrue())
+
+			response.Header().Set("Content-Type", "text/event-stream")
+			flusher.Flush()
+			response.Write([]byte("data: 1\n\n"))
+			flusher.Flush()
+			response.Write([]byte("data: 2\n\n"))
+
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusOK).To(Equal(proxyResponse.StatusCode))
+			Expect("data: 1\n\ndata: 2\n\n").To(Equal(proxyResponse.Body))
+		})
+
+		It("Keeps the status written before", func() {
+			response := NewProxyResponseWriterV2()
+			response.WriteHeader(http.StatusAccepted)
+			response.Flush()
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusAccepted).To(Equal(proxyResponse.StatusCode))
+		})
+	})
+
+	Context("Binary content types", func() {
+		It("Matches exact types, wildcards and the catch-all", func() {
+			encoded := func(binaryTypes []string, contentType string) bool {
+				response := NewProxyResponseWriter()
+				response.SetBinaryContentTypes(binaryTypes)
+				response.Header().Set("Content-Type", contentType)
+				response.Write([]byte("GIF89a"))
+				proxyResponse, err := response.GetProxyResponse()
+				Expect(err).To(BeNil())
+				return proxyResponse.IsBase64Encoded
+			}
+
+			Expect(true).To(Equal(encoded([]string{"image/*"}, "image/gif")))
+			Expect(true).To(Equal(encoded([]string{"application/pdf", "image/*"}, "Image/GIF; q=1")))
+			Expect(false).To(Equal(encoded([]string{"image/*"}, "text/plain")))
+			Expect(true).To(Equal(encoded([]string{"*/*"}, "text/plain")))
+			Expect(true).To(Equal(encoded([]string{"application/pdf"}, "application/pdf")))
+			Expect(false).To(Equal(encoded(nil, "image/gif")))
+		})
+
+		It("Keeps the forced base64 content types", func() {
+			response := NewProxyResponseWriter()
+			response.ForceBase64ForContentTypes([]string{"text/csv"})
+			response.SetBinaryContentTypes([]string{"image/*"})
+			response.Header().Set("Content-Type", "text/csv")
+			response.Write([]byte("id,name\n"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(true).To(Equal(proxyResponse.IsBase64Encoded))
+
+			handler := NewAPIGatewayProxyHandler(&RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+				w.Write([]byte("GIF89a"))
+			}))
+			handler.SetResponseWriterFactory(func() ProxyResponder[events.APIGatewayProxyResponse] {
+				w := NewProxyResponseWriter()
+				w.ForceBase64ForContentTypes([]string{"text/csv"})
+				return w
+			})
+			handler.SetBinaryContentTypes([]string{"image/*"})
+			for _, contentType := range []string{"text/csv", "image/gif"} {
+				req := events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/", QueryStringParameters: map[string]string{"type": contentType}}
+				proxyResponse, err := handler.ProxyWithContext(context.Background(), req)
+				Expect(err).To(BeNil())
+				Expect(true).To(Equal(proxyResponse.IsBase64Encoded))
+			}
+
+			req := events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/", QueryStringParameters: map[string]string{"type": "text/plain"}}
+			proxyResponse, err = handler.ProxyWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(false).To(Equal(proxyResponse.IsBase64Encoded))
+		})
+	})
+
+	Context("Append charset", func() {
+		It("Appends the charset to text content types", func() {
+			expected := map[string]string{
+				"text/html":                    "text/html; charset=utf-8",
+				"application/json":             "application/json; charset=utf-8",
+				"text/plain; charset=us-ascii": "text/plain; charset=us-ascii",
+				"image/png":                    "image/png",
+			}
+			for contentType, result := range expected {
+				response := NewProxyResponseWriter()
+				response.SetAppendCharset("utf-8")
+				response.Header().Set("Content-Type", contentType)
+				response.Write([]byte("body"))
+				proxyResponse, err := response.GetProxyResponse()
+				Expect(err).To(BeNil())
+				Expect([]string{result}).To(Equal(proxyResponse.MultiValueHeaders["Content-Type"]))
+			}
+		})
+	})
+
+	Context("JSON validation", func() {
+		It("Rejects invalid JSON bodies", func() {
+			response := NewProxyResponseWriter()
+			response.SetValidateJSON(true)
+			response.Header().Set("Content-Type", "application/json; charset=utf-8")
+			response.Write([]byte(`{"id": 1}`))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(`{"id": 1}`).To(Equal(proxyResponse.Body))
+
+			response = NewProxyResponseWriter()
+			response.SetValidateJSON(true)
+			response.Header().Set("Content-Type", "application/json")
+			response.Write([]byte(`{"id": 1`))
+			_, err = response.GetProxyResponse()
+			Expect(err).ToNot(BeNil())
+
+			response = NewProxyResponseWriter()
+			response.SetValidateJSON(true)
+			response.Header().Set("Content-Type", "text/plain")
+			response.Write([]byte(`{"id": 1`))
+			_, err = response.GetProxyResponse()
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Context("Base64 encoding report", func() {
+		It("Reports whether the body was base64 encoded", func() {
+			binary := NewProxyResponseWriter()
+			binary.Header().Set("Content-Type", "image/gif")
+			binary.Write([]byte{0x47, 0x49, 0x46, 0xff, 0xfe})
+			Expect(binary.WasBase64Encoded()).To(BeFalse())
+			_, err := binary.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(binary.WasBase64Encoded()).To(BeTrue())
+
+			text := NewProxyResponseWriter()
+			text.Write([]byte("hello"))
+			_, err = text.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(text.WasBase64Encoded()).To(BeFalse())
+		})
+	})
+
+	Context("Canonical JSON", func() {
+		It("Sorts the keys of JSON bodies", func() {
+			response := NewProxyResponseWriter()
+			response.SetCanonicalizeJSON(true)
+			response.Header().Set("Content-Type", "application/json")
+			response.Write([]byte(`{"zone": "a<b", "id": 12345678901234567890, "items": [{"b": 2, "a": 1}]}`))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(`{"id":12345678901234567890,"items":[{"a":1,"b":2}],"zone":"a<b"}`).To(Equal(proxyResponse.Body))
+		})
+
+		It("Leaves other bodies untouched", func() {
+			response := NewProxyResponseWriter()
+			response.SetCanonicalizeJSON(true)
+			response.Header().Set("Content-Type", "text/plain")
+			response.Write([]byte(`{"b": 2, "a": 1}`))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(`{"b": 2, "a": 1}`).To(Equal(proxyResponse.Body))
+
+			response = NewProxyResponseWriter()
+			response.SetCanonicalizeJSON(true)
+			response.Header().Set("Content-Type", "application/json")
+			response.Write([]byte(`{"b": 2, "a": `))
+			proxyResponse, err = response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(`{"b": 2, "a": `).To(Equal(proxyResponse.Body))
+		})
+	})
+
+	Context("Body transformer", func() {
+		It("Wraps the body in an envelope", func() {
+			envelope := func(status int, body []byte, headers http.Header) []byte {
+				if !strings.HasPrefix(headers.Get("Content-Type"), "application/json") {
+					return body
+				}
+				return []byte(`{"data":` + string(body) + `,"meta":{"status":` + strconv.Itoa(status) + `}}`)
+			}
+
+			response := NewProxyResponseWriter()
+			response.SetBodyTransformer(envelope)
+			response.Header().Set("Content-Type", "application/json")
+			response.Header().Set("Content-Length", "9")
+			response.Write([]byte(`{"id": 1}`))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(`{"data":{"id": 1},"meta":{"status":200}}`).To(Equal(proxyResponse.Body))
+			Expect([]string{strconv.Itoa(len(proxyResponse.Body))}).To(Equal(proxyResponse.MultiValueHeaders["Content-Length"]))
+
+			response = NewProxyResponseWriter()
+			response.SetBodyTransformer(envelope)
+			response.Header().Set("Content-Type", "text/plain")
+			response.Write([]byte("hello"))
+			proxyResponse, err = response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect("hello").To(Equal(proxyResponse.Body))
+		})
+	})
+
+	Context("Response size", func() {
+		It("Returns the number of buffered bytes", func() {
+			response := NewProxyResponseWriter()
+			Expect(0).To(Equal(response.BodyLen()))
+			response.Write([]byte("hello"))
+			response.Write([]byte(" world"))
+			Expect(11).To(Equal(response.BodyLen()))
+		})
+
+		It("Tracks the largest response body", func() {
+			size := MaxObservedResponseBytes() + 10
+			response := NewProxyResponseWriter()
+			response.Write(bytes.Repeat([]byte("a"), size))
+			Expect(size).ToNot(Equal(MaxObservedResponseBytes()))
+			_, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(size).To(Equal(MaxObservedResponseBytes()))
+
+			response = NewProxyResponseWriter()
+			response.Write([]byte("small"))
+			_, err = response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(size).To(Equal(MaxObservedResponseBytes()))
+		})
+	})
+
+	Context("Status rewriter", func() {
+		It("Computes the final status from the handler output", func() {
+			created := func(status int, headers http.Header) int {
+				if status == http.StatusOK && headers.Get("Location") != "" {
+					return http.StatusCreated
+				}
+				return status
+			}
+
+			response := NewProxyResponseWriter()
+			response.SetStatusRewriter(created)
+			response.Header().Set("Location", "/items/1")
+			response.Write([]byte("{}"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusCreated).To(Equal(proxyResponse.StatusCode))
+
+			response = NewProxyResponseWriter()
+			response.SetStatusRewriter(created)
+			response.Write([]byte("{}"))
+			proxyResponse, err = response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusOK).To(Equal(proxyResponse.StatusCode))
+		})
+	})
+
+	Context("Cache control", func() {
+		It("Composes the Cache-Control header", func() {
+			expected := map[string]struct {
+				maxAge     time.Duration
+				directives []string
+			}{
+				"max-age=3600, public":                {time.Hour, []string{"public"}},
+				"max-age=90":                          {90*time.Second + 500*time.Millisecond, nil},
+				"max-age=0, private, must-revalidate": {0, []string{"private", "must-revalidate"}},
+				"no-store":                            {time.Hour, []string{"no-store"}},
+				"no-cache":                            {-1, []string{"no-cache"}},
+			}
+			for header, args := range expected {
+				response := NewProxyResponseWriter()
+				response.SetCacheControl(args.maxAge, args.directives...)
+				response.WriteHeader(http.StatusOK)
+				proxyResponse, err := response.GetProxyResponse()
+				Expect(err).To(BeNil())
+				Expect([]string{header}).To(Equal(proxyResponse.MultiValueHeaders["Cache-Control"]))
+			}
+		})
+	})
+
+	Context("Retry after", func() {
+		It("Sets the Retry-After header in seconds", func() {
+			response := NewProxyResponseWriter()
+			response.SetRetryAfter(30 * time.Second)
+			response.WriteHeader(http.StatusServiceUnavailable)
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusServiceUnavailable).To(Equal(proxyResponse.StatusCode))
+			Expect([]string{"30"}).To(Equal(proxyResponse.MultiValueHeaders["Retry-After"]))
+
+			response = NewProxyResponseWriter()
+			response.SetRetryAfter(1500 * time.Millisecond)
+			Expect("2").To(Equal(response.Header().Get("Retry-After")))
+		})
+	})
+
+	Context("Finalize hooks", func() {
+		It("Lets hooks change the headers of the final response", func() {
+			resp := NewProxyResponseWriter()
+			resp.FinalizeHook(func(h http.Header) {
+				h.Set("Strict-Transport-Security", "max-age=63072000")
+			})
+			resp.FinalizeHook(func(h http.Header) {
+				h.Del("X-Powered-By")
+			})
+			resp.Header().Set("X-Powered-By", "Go")
+			resp.Write([]byte("hello"))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect([]string{"max-age=63072000"}).To(Equal(proxyResp.MultiValueHeaders["Strict-Transport-Security"]))
+			Expect(proxyResp.MultiValueHeaders).ToNot(HaveKey("X-Powered-By"))
+		})
+	})
+
+	Context("Peek at the body", func() {
+		It("Returns the beginning of the body without consuming it", func() {
+			body := strings.Repeat("0123456789", 10000)
+			resp := NewProxyResponseWriter()
+			resp.Write([]byte(body))
+
+			Expect([]byte(body[:100])).To(Equal(resp.PeekBody(100)))
+			Expect([]byte(body)).To(Equal(resp.PeekBody(len(body) + 10)))
+			Expect(0).To(Equal(len(resp.PeekBody(-1))))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(body).To(Equal(proxyResp.Body))
+		})
+
+		It("Reads the body without consuming it", func() {
+			resp := NewProxyResponseWriter()
+			resp.Header().Set("Content-Type", "text/html")
+			resp.Write([]byte("<p>\n  Hello  \n</p>"))
+
+			read, err := ioutil.ReadAll(resp.BodyReader())
+			Expect(err).To(BeNil())
+			Expect("<p>\n  Hello  \n</p>").To(Equal(string(read)))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect("<p>\n  Hello  \n</p>").To(Equal(proxyResp.Body))
+		})
+
+		It("Replaces the body read in a finalize hook", func() {
+			resp := NewProxyResponseWriter()
+			resp.FinalizeHook(func(http.Header) {
+				read, _ := ioutil.ReadAll(resp.BodyReader())
+				minified := strings.Join(strings.Fields(string(read)), "")
+				resp.SetBodyTransformer(func(int, []byte, http.Header) []byte {
+					return []byte(minified)
+				})
+			})
+			resp.Write([]byte("<p>\n  Hello  \n</p>"))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect("<p>Hello</p>").To(Equal(proxyResp.Body))
+		})
+	})
+
+	Context("Informational status codes", func() {
+		It("Returns the final status after a 100 Continue", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusContinue)
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("done"))
+			})
+
+			resp := NewProxyResponseWriter()
+			handler.ServeHTTP(resp, httptest.NewRequest("POST", "/", nil))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusOK).To(Equal(proxyResp.StatusCode))
+			Expect("done").To(Equal(proxyResp.Body))
+		})
+
+		It("Treats a lone 100 Continue as an unset status", func() {
+			resp := NewProxyResponseWriter()
+			resp.WriteHeader(http.StatusContinue)
+			_, err := resp.GetProxyResponse()
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Context("Trailers", func() {
+		It("Folds declared trailers into the response headers", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Trailer", "X-Checksum")
+				w.Header().Set("Content-Type", "text/plain")
+				w.Write([]byte("hello"))
+				w.Header().Set("X-Checksum", "5d41402a")
+				w.Header().Set(http.TrailerPrefix+"X-Row-Count", "1")
+			})
+
+			resp := NewProxyResponseWriter()
+			handler.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect([]string{"5d41402a"}).To(Equal(proxyResp.MultiValueHeaders["X-Checksum"]))
+			Expect([]string{"1"}).To(Equal(proxyResp.MultiValueHeaders["X-Row-Count"]))
+			Expect(proxyResp.MultiValueHeaders).ToNot(HaveKey("Trailer"))
+			Expect(proxyResp.MultiValueHeaders).ToNot(HaveKey(http.TrailerPrefix + "X-Row-Count"))
+			Expect("hello").To(Equal(proxyResp.Body))
+		})
+	})
+
 	Context("Handle multi-value headers", func() {
 
 		It("Writes single-value headers correctly", func() {
@@ -175,6 +828,224 @@ var _ = Describe("ResponseWriter tests", func() {
 			Expect("csrftoken=foobar").To(Equal(proxyResponse.MultiValueHeaders["Set-Cookie"][0]))
 			Expect("session_id=barfoo").To(Equal(proxyResponse.MultiValueHeaders["Set-Cookie"][1]))
 		})
+
+		It("Never writes a header to both maps", func() {
+			response := NewProxyResponseWriter()
+			response.Header().Add("Content-Type", "text/plain")
+			response.Header().Add("X-Single", "one")
+			response.Header().Add("X-Multi", "one")
+			response.Header().Add("X-Multi", "two")
+			response.Write([]byte("hello"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			for key := range proxyResponse.Headers {
+				Expect(proxyResponse.MultiValueHeaders).ToNot(HaveKey(key))
+			}
+			Expect([]string{"one"}).To(Equal(proxyResponse.MultiValueHeaders["X-Single"]))
+			Expect([]string{"one", "two"}).To(Equal(proxyResponse.MultiValueHeaders["X-Multi"]))
+		})
+
+		It("Keeps Set-Cookie out of the single-value headers", func() {
+			response := NewProxyResponseWriter()
+			response.SetSingleValueHeaders(true)
+			response.Header().Add("Content-Type", "text/plain")
+			response.Header().Add("X-Multi", "one")
+			response.Header().Add("X-Multi", "two")
+			response.Header().Add("Set-Cookie", "csrftoken=foobar")
+			response.Write([]byte("hello"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect("text/plain").To(Equal(proxyResponse.Headers["Content-Type"]))
+			Expect(proxyResponse.Headers).ToNot(HaveKey("Set-Cookie"))
+			Expect(proxyResponse.Headers).ToNot(HaveKey("X-Multi"))
+			Expect([]string{"csrftoken=foobar"}).To(Equal(proxyResponse.MultiValueHeaders["Set-Cookie"]))
+			Expect([]string{"one", "two"}).To(Equal(proxyResponse.MultiValueHeaders["X-Multi"]))
+			Expect(proxyResponse.MultiValueHeaders).ToNot(HaveKey("Content-Type"))
+
+			response = NewProxyResponseWriter()
+			response.SetSingleValueHeaders(true)
+			response.Header().Add("Set-Cookie", "csrftoken=foobar")
+			response.Header().Add("Set-Cookie", "session_id=barfoo")
+			response.WriteHeader(http.StatusNoContent)
+			proxyResponse, err = response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(proxyResponse.Headers).ToNot(HaveKey("Set-Cookie"))
+			Expect([]string{"csrftoken=foobar", "session_id=barfoo"}).To(Equal(proxyResponse.MultiValueHeaders["Set-Cookie"]))
+		})
+
+		It("Does not detect the content type when disabled", func() {
+			response := NewProxyResponseWriter()
+			response.SetDisableContentTypeDetection(true)
+			response.Write([]byte("<html><body>hello</body></html>"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(proxyResponse.MultiValueHeaders).ToNot(HaveKey("Content-Type"))
+			Expect("<html><body>hello</body></html>").To(Equal(proxyResponse.Body))
+
+			response = NewProxyResponseWriter()
+			response.SetDisableContentTypeDetection(true)
+			response.SetDeferContentTypeDetection(true)
+			response.Write([]byte("hello"))
+			proxyResponse, err = response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(proxyResponse.MultiValueHeaders).ToNot(HaveKey("Content-Type"))
+
+			response = NewProxyResponseWriter()
+			response.SetDisableContentTypeDetection(true)
+			response.Header().Set("Content-Type", "text/csv")
+			response.Write([]byte("a,b"))
+			proxyResponse, err = response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect([]string{"text/csv"}).To(Equal(proxyResponse.MultiValueHeaders["Content-Type"]))
+		})
+
+		It("Returns the raw response components", func() {
+			response := NewProxyResponseWriter()
+			response.Header().Set("Content-Type", "application/json")
+			response.Header().Add("Set-Cookie", "a=1")
+			response.Header().Add("Set-Cookie", "b=2")
+			response.WriteHeader(http.StatusAccepted)
+			response.Write([]byte("{\"ok\":true}"))
+			status, headers, body, isBase64, err := response.GetRawResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusAccepted).To(Equal(status))
+			Expect("application/json").To(Equal(headers.Get("Content-Type")))
+			Expect([]string{"a=1", "b=2"}).To(Equal(headers.Values("Set-Cookie")))
+			Expect([]byte("{\"ok\":true}")).To(Equal(body))
+			Expect(isBase64).To(BeFalse())
+
+			binary := NewProxyResponseWriter()
+			binary.SetSingleValueHeaders(true)
+			binary.Header().Set("Content-Type", "image/png")
+			binary.Write([]byte{0x89, 0x50, 0x4e, 0x47})
+			status, headers, body, isBase64, err = binary.GetRawResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusOK).To(Equal(status))
+			Expect("image/png").To(Equal(headers.Get("Content-Type")))
+			Expect([]byte(base64.StdEncoding.EncodeToString([]byte{0x89, 0x50, 0x4e, 0x47}))).To(Equal(body))
+			Expect(isBase64).To(BeTrue())
+
+			_, _, _, _, err = NewProxyResponseWriter().GetRawResponse()
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("Writes lowercase header keys when enabled", func() {
+			response := NewProxyResponseWriter()
+			response.SetLowercaseHeaderKeys(true)
+			response.Header().Add("Content-Type", "text/plain")
+			response.Header().Add("Set-Cookie", "a=1")
+			response.Header().Add("Set-Cookie", "b=2")
+			response.Write([]byte("hello"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			Expect(proxyResponse.MultiValueHeaders).ToNot(HaveKey("Content-Type"))
+			Expect([]string{"text/plain"}).To(Equal(proxyResponse.MultiValueHeaders["content-type"]))
+			Expect([]string{"a=1", "b=2"}).To(Equal(proxyResponse.MultiValueHeaders["set-cookie"]))
+			for key := range proxyResponse.Headers {
+				Expect(strings.ToLower(key)).To(Equal(key))
+			}
+		})
+
+		It("Handles header values with newlines per the sanitization mode", func() {
+			newResponse := func(mode HeaderSanitization) *ProxyResponseWriter {
+				response := NewProxyResponseWriter()
+				response.SetHeaderSanitization(mode)
+				response.Header().Add("X-Injected", "value\r\nSet-Cookie: evil=1")
+				response.Header().Add("X-Tabbed", "a\tb")
+				response.WriteHeader(http.StatusOK)
+				return response
+			}
+
+			proxyResponse, err := newResponse(HeaderSanitizationOff).GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect([]string{"value\r\nSet-Cookie: evil=1"}).To(Equal(proxyResponse.MultiValueHeaders["X-Injected"]))
+
+			_, err = newResponse(HeaderSanitizationReject).GetProxyResponse()
+			Expect(err).ToNot(BeNil())
+			invalidHeader, ok := err.(*InvalidHeaderError)
+			Expect(ok).To(BeTrue())
+			Expect("X-Injected").To(Equal(invalidHeader.Header))
+
+			proxyResponse, err = newResponse(HeaderSanitizationStrip).GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect([]string{"valueSet-Cookie: evil=1"}).To(Equal(proxyResponse.MultiValueHeaders["X-Injected"]))
+			Expect([]string{"a\tb"}).To(Equal(proxyResponse.MultiValueHeaders["X-Tabbed"]))
+		})
+
+		It("Truncates headers over the value limit", func() {
+			response := NewProxyResponseWriter()
+			response.SetMaxHeaderValuesPerKey(10)
+			for i := 0; i < 1000; i++ {
+				response.Header().Add("X-Repeated", strconv.Itoa(i))
+			}
+			response.Header().Add("X-Single", "one")
+			response.WriteHeader(http.StatusOK)
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			Expect(10).To(Equal(len(proxyResponse.MultiValueHeaders["X-Repeated"])))
+			Expect("0").To(Equal(proxyResponse.MultiValueHeaders["X-Repeated"][0]))
+			Expect("9").To(Equal(proxyResponse.MultiValueHeaders["X-Repeated"][9]))
+			Expect([]string{"one"}).To(Equal(proxyResponse.MultiValueHeaders["X-Single"]))
+		})
+
+		It("Returns an error over the value limit when configured", func() {
+			response := NewProxyResponseWriter()
+			response.SetMaxHeaderValuesPerKey(10)
+			response.SetFailOnHeaderLimit(true)
+			for i := 0; i < 1000; i++ {
+				response.Header().Add("X-Repeated", strconv.Itoa(i))
+			}
+			response.WriteHeader(http.StatusOK)
+			_, err := response.GetProxyResponse()
+			Expect(err).ToNot(BeNil())
+		})
 	})
 
 })
+
+func BenchmarkProxyResponseWriter(b *testing.B) {
+	body := []byte("{\"hello\": \"world\"}")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		resp := NewProxyResponseWriter()
+		resp.Header().Set("Content-Type", "application/json")
+		resp.Write(body)
+		if _, err := resp.GetProxyResponse(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProxyResponseWriterPooled(b *testing.B) {
+	body := []byte("{\"hello\": \"world\"}")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		resp := AcquireProxyResponseWriter()
+		resp.Header().Set("Content-Type", "application/json")
+		resp.Write(body)
+		if _, err := resp.GetProxyResponse(); err != nil {
+			b.Fatal(err)
+		}
+		ReleaseProxyResponseWriter(resp)
+	}
+}
+
+func BenchmarkProxyResponseWriterContentTypeDetection(b *testing.B) {
+	body := bytes.Repeat([]byte("<p>hello</p>"), 100)
+	for _, disabled := range []bool{false, true} {
+		b.Run(fmt.Sprintf("disabled=%v", disabled), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				resp := NewProxyResponseWriter()
+				resp.SetDisableContentTypeDetection(disabled)
+				resp.Write(body)
+				if _, err := resp.GetProxyResponse(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
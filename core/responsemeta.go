@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+const responseMetaCtxKey = contextKey("responseMeta")
+
+// responseMeta collects the metadata handlers attach to a response.
+type responseMeta struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+// WithResponseMeta returns a copy of ctx with an empty response metadata
+// collector. The EventToRequestWithContext methods add one to every request
+// whose context has none, so a caller only needs WithResponseMeta to read the
+// metadata with GetResponseMeta after ProxyWithContext returns.
+func WithResponseMeta(ctx context.Context) context.Context {
+	return context.WithValue(ctx, responseMetaCtxKey, &responseMeta{values: make(map[string]interface{})})
+}
+
+// SetResponseMeta attaches a metadata value, such as the matched route or
+// the name of the handler, to the response of the request. The metadata is
+// meant for logging and is never added to the response. It is ignored when
+// the context has no collector.
+func SetResponseMeta(ctx context.Context, key string, value interface{}) {
+	meta, ok := ctx.Value(responseMetaCtxKey).(*responseMeta)
+	if !ok {
+		return
+	}
+	meta.mu.Lock()
+	defer meta.mu.Unlock()
+	meta.values[key] = value
+}
+
+// GetResponseMeta returns a copy of the metadata set with SetResponseMeta.
+// Returns nil when the context has no collector.
+func GetResponseMeta(ctx context.Context) map[string]interface{} {
+	meta, ok := ctx.Value(responseMetaCtxKey).(*responseMeta)
+	if !ok {
+		return nil
+	}
+	meta.mu.Lock()
+	defer meta.mu.Unlock()
+	values := make(map[string]interface{}, len(meta.values))
+	for k, v := range meta.values {
+		values[k] = v
+	}
+	return values
+}
+
+// withResponseMetaIfMissing adds a response metadata collector to ctx
+// unless the caller already provided one.
+func withResponseMetaIfMissing(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(responseMetaCtxKey).(*responseMeta); ok {
+		return ctx
+	}
+	return WithResponseMeta(ctx)
+}
@@ -0,0 +1,51 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Response metadata tests", func() {
+	routeHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		core.SetResponseMeta(r.Context(), "route", "/orders/{id}")
+		core.SetResponseMeta(r.Context(), "handler", "getOrder")
+		w.Write([]byte("order"))
+	})
+
+	It("Returns the metadata set by the handler after the proxy call", func() {
+		proxy := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, routeHandler)
+
+		ctx := core.WithResponseMeta(context.Background())
+		resp, err := proxy.ProxyWithContext(ctx, getProxyRequest("/orders/1", "GET"))
+		Expect(err).To(BeNil())
+		Expect("order").To(Equal(resp.Body))
+		Expect(resp.MultiValueHeaders).ToNot(HaveKey("Route"))
+
+		meta := core.GetResponseMeta(ctx)
+		Expect("/orders/{id}").To(Equal(meta["route"]))
+		Expect("getOrder").To(Equal(meta["handler"]))
+
+		Expect(core.GetResponseMeta(context.Background())).To(BeNil())
+		core.SetResponseMeta(context.Background(), "ignored", true)
+	})
+
+	It("Adds the metadata to the access log", func() {
+		var buf bytes.Buffer
+		proxy := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, core.AccessLogMiddleware(log.New(&buf, "", 0))(routeHandler))
+
+		_, err := proxy.ProxyWithContext(context.Background(), getProxyRequest("/orders/1", "GET"))
+		Expect(err).To(BeNil())
+
+		entry := map[string]interface{}{}
+		Expect(json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry)).To(BeNil())
+		Expect(map[string]interface{}{"route": "/orders/{id}", "handler": "getOrder"}).To(Equal(entry["meta"]))
+	})
+})
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Payload limits of the responses of synchronous invocations. Lambda
+// rejects responses over MaxLambdaResponseBytes, and load balancers answer
+// with a 502 when a Lambda target returns more than MaxALBResponseBytes.
+const (
+	MaxLambdaResponseBytes = 6291556
+	MaxALBResponseBytes    = 1 << 20
+)
+
+// ErrResponseTooLarge is returned, wrapped with the sizes, by handlers with
+// a limit set by SetMaxResponseBytes when a response exceeds it.
+var ErrResponseTooLarge = errors.New("Response payload too large")
+
+// ResponseOffloader stores the body of an oversized response, for example
+// with PutObject in an S3 bucket, and returns a URL the client can download
+// it from, such as a presigned GetObject URL. See
+// ProxyHandler.SetResponseOffloader.
+type ResponseOffloader func(ctx context.Context, body []byte, contentType string) (string, error)
+
+// payloadFields are the fields all response types share under the same
+// JSON names.
+type payloadFields struct {
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+	Headers           map[string]string   `json:"headers"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+}
+
+// guardResponseSize checks the JSON encoded size of the response against the
+// limit set with SetMaxResponseBytes. Oversized responses are offloaded, or
+// answered with a 413 or ErrResponseTooLarge.
+func (p *ProxyHandler[ReqT, RespT]) guardResponseSize(req *http.Request, resp RespT) (RespT, error) {
+	if p.maxResponseBytes <= 0 {
+		return resp, nil
+	}
+	payload, err := json.Marshal(resp)
+	if err != nil || len(payload) <= p.maxResponseBytes {
+		return resp, nil
+	}
+
+	if p.offloader != nil {
+		location, err := p.offload(req.Context(), payload)
+		if err == nil {
+			w := p.newWriter()
+			w.Header().Set("Location", location)
+			w.WriteHeader(http.StatusSeeOther)
+			return w.GetProxyResponse()
+		}
+		p.warnf("Could not offload response of %s: %v", requestRoute(req), err)
+	}
+	if p.responseTooLarge {
+		w := p.newWriter()
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return w.GetProxyResponse()
+	}
+	return p.internalError(req.Context(), NewLoggedError("%w: %d bytes exceed the limit of %d", ErrResponseTooLarge, len(payload), p.maxResponseBytes))
+}
+
+// offload decodes the body of the encoded response and hands it to the
+// offloader.
+func (p *ProxyHandler[ReqT, RespT]) offload(ctx context.Context, payload []byte) (string, error) {
+	var fields payloadFields
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return "", err
+	}
+	body := []byte(fields.Body)
+	if fields.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(fields.Body)
+		if err != nil {
+			return "", err
+		}
+		body = decoded
+	}
+
+	contentType := http.Header(fields.MultiValueHeaders).Get(contentTypeHeaderKey)
+	if contentType == "" {
+		for key, value := range fields.Headers {
+			if http.CanonicalHeaderKey(key) == contentTypeHeaderKey {
+				contentType = value
+			}
+		}
+	}
+	return p.offloader(ctx, body, contentType)
+}
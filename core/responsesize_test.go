@@ -0,0 +1,127 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Response size guard tests", func() {
+	largeBody := strings.Repeat("x", 2048)
+	sizedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		if r.URL.Path == "/large" {
+			w.Write([]byte(largeBody))
+		} else {
+			w.Write([]byte("small"))
+		}
+	})
+
+	It("Leaves responses under the limit alone", func() {
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, sizedHandler)
+		handler.SetMaxResponseBytes(1024)
+
+		resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/small", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusOK).To(Equal(resp.StatusCode))
+		Expect("small").To(Equal(resp.Body))
+	})
+
+	It("Returns ErrResponseTooLarge for oversized responses", func() {
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, sizedHandler)
+		handler.SetMaxResponseBytes(1024)
+
+		resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/large", "GET"))
+		Expect(errors.Is(err, core.ErrResponseTooLarge)).To(BeTrue())
+		Expect(http.StatusGatewayTimeout).To(Equal(resp.StatusCode))
+
+		handler.SetMaxResponseBytes(0)
+		resp, err = handler.ProxyWithContext(context.Background(), getProxyRequest("/large", "GET"))
+		Expect(err).To(BeNil())
+		Expect(largeBody).To(Equal(resp.Body))
+	})
+
+	It("Answers oversized responses with a 413 when enabled", func() {
+		handler := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, sizedHandler)
+		handler.SetMaxResponseBytes(1024)
+		handler.SetResponseTooLargeResponse(true)
+
+		resp, err := handler.ProxyWithContext(context.Background(), getProxyRequestV2("/large", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusRequestEntityTooLarge).To(Equal(resp.StatusCode))
+		Expect("").To(Equal(resp.Body))
+	})
+
+	It("Redirects to the offloaded body", func() {
+		var offloaded []byte
+		var offloadedType string
+		handler := core.NewALBProxyHandler(&core.RequestAccessorALB{}, sizedHandler)
+		handler.SetMaxResponseBytes(1024)
+		handler.SetResponseOffloader(func(ctx context.Context, body []byte, contentType string) (string, error) {
+			offloaded, offloadedType = body, contentType
+			return "https://bucket.s3.amazonaws.com/responses/1?X-Amz-Signature=abc", nil
+		})
+
+		resp, err := handler.ProxyWithContext(context.Background(), getALBRequest("/large", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusSeeOther).To(Equal(resp.StatusCode))
+		Expect("https://bucket.s3.amazonaws.com/responses/1?X-Amz-Signature=abc").To(Equal(resp.Headers["Location"]))
+		Expect(largeBody).To(Equal(string(offloaded)))
+		Expect("text/csv").To(Equal(offloadedType))
+	})
+
+	It("Decodes binary bodies before offloading them", func() {
+		binary := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte(strings.Repeat("\xff", 2048)))
+		})
+		var offloaded []byte
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, binary)
+		handler.SetMaxResponseBytes(1024)
+		handler.SetResponseOffloader(func(ctx context.Context, body []byte, contentType string) (string, error) {
+			offloaded = body
+			return "https://example.com/download", nil
+		})
+
+		resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/binary", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusSeeOther).To(Equal(resp.StatusCode))
+		Expect(strings.Repeat("\xff", 2048)).To(Equal(string(offloaded)))
+	})
+
+	It("Falls back when offloading fails", func() {
+		handler := core.NewFunctionURLProxyHandler(&core.RequestAccessorFnURL{}, sizedHandler)
+		handler.SetLogger(log.New(ioutil.Discard, "", 0))
+		handler.SetMaxResponseBytes(1024)
+		handler.SetResponseTooLargeResponse(true)
+		handler.SetResponseOffloader(func(ctx context.Context, body []byte, contentType string) (string, error) {
+			return "", errors.New("Access denied")
+		})
+
+		resp, err := handler.ProxyWithContext(context.Background(), getFunctionURLRequest("/large", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusRequestEntityTooLarge).To(Equal(resp.StatusCode))
+	})
+
+	It("Uses the internal error responder", func() {
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, sizedHandler)
+		handler.SetMaxResponseBytes(1024)
+		handler.SetInternalErrorResponder(func(err error) events.APIGatewayProxyResponse {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError, Body: err.Error()}
+		})
+
+		resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/large", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusInternalServerError).To(Equal(resp.StatusCode))
+		Expect(resp.Body).To(HavePrefix("Response payload too large: "))
+	})
+})
@@ -15,10 +15,14 @@ import (
 // ProxyResponseWriterV2 implements http.ResponseWriter and adds the method
 // necessary to return an events.APIGatewayProxyResponse object
 type ProxyResponseWriterV2 struct {
-	headers   http.Header
-	body      bytes.Buffer
-	status    int
-	observers []chan<- bool
+	headers       http.Header
+	body          bytes.Buffer
+	status        int
+	defaultStatus int
+	observers     []chan<- bool
+	binaryTypes   []string
+
+	deadlines
 }
 
 // NewProxyResponseWriter returns a new ProxyResponseWriter object.
@@ -26,13 +30,14 @@ type ProxyResponseWriterV2 struct {
 // status code of -1
 func NewProxyResponseWriterV2() *ProxyResponseWriterV2 {
 	return &ProxyResponseWriterV2{
-		headers:   make(http.Header),
-		status:    defaultStatusCode,
-		observers: make([]chan<- bool, 0),
+		headers: make(http.Header),
+		status:  defaultStatusCode,
 	}
 
 }
 
+// CloseNotify returns a channel that receives a value when GetProxyResponse
+// is called. The observers are only allocated once CloseNotify is used.
 func (r *ProxyResponseWriterV2) CloseNotify() <-chan bool {
 	ch := make(chan bool, 1)
 
@@ -43,7 +48,10 @@ func (r *ProxyResponseWriterV2) CloseNotify() <-chan bool {
 
 func (r *ProxyResponseWriterV2) notifyClosed() {
 	for _, v := range r.observers {
-		v <- true
+		select {
+		case v <- true:
+		default:
+		}
 	}
 }
 
@@ -56,6 +64,9 @@ func (r *ProxyResponseWriterV2) Header() http.Header {
 // was set before with the WriteHeader method it sets the status
 // for the response to 200 OK.
 func (r *ProxyResponseWriterV2) Write(body []byte) (int, error) {
+	if err := r.checkWrite(); err != nil {
+		return 0, err
+	}
 	if r.status == defaultStatusCode {
 		r.status = http.StatusOK
 	}
@@ -71,39 +82,115 @@ func (r *ProxyResponseWriterV2) Write(body []byte) (int, error) {
 	return (&r.body).Write(body)
 }
 
+// PeekBody returns a copy of up to the first n bytes of the body written so
+// far, for example to log a truncated response. It does not consume the
+// body and has no effect on GetProxyResponse.
+func (r *ProxyResponseWriterV2) PeekBody(n int) []byte {
+	bb := (&r.body).Bytes()
+	if n < 0 {
+		n = 0
+	}
+	if n > len(bb) {
+		n = len(bb)
+	}
+	peek := make([]byte, n)
+	copy(peek, bb)
+	return peek
+}
+
 // WriteHeader sets a status code for the response. This method is used
-// for error responses.
+// for error responses. Informational (1xx) status codes, such as a 100
+// Continue written for an Expect header, can't be returned through the
+// proxy and are ignored.
 func (r *ProxyResponseWriterV2) WriteHeader(status int) {
+	if isInformational(status) {
+		return
+	}
 	r.status = status
 }
 
+// Flush implements http.Flusher. The response is buffered until the handler
+// returns, so it only sets the status to 200 OK if none was written, see
+// ProxyResponseWriter.Flush.
+func (r *ProxyResponseWriterV2) Flush() {
+	if r.status == defaultStatusCode {
+		r.status = http.StatusOK
+	}
+}
+
+// SetDefaultStatus sets the status code returned by GetProxyResponse when
+// the handler never called WriteHeader or Write, for example 200 for an
+// implicit empty response. Without a default status such responses are
+// rejected with an error.
+func (r *ProxyResponseWriterV2) SetDefaultStatus(status int) {
+	r.defaultStatus = status
+}
+
+// SetBinaryContentTypes lists content types whose bodies are always
+// returned base64 encoded, like the binary media types of an API. See
+// ProxyResponseWriter.SetBinaryContentTypes for the patterns.
+func (r *ProxyResponseWriterV2) SetBinaryContentTypes(contentTypes []string) {
+	r.binaryTypes = contentTypes
+}
+
 // GetProxyResponse converts the data passed to the response writer into
 // an events.APIGatewayProxyResponse object.
 // Returns a populated proxy response object. If the response is invalid, for example
 // has no headers or an invalid status code returns an error.
+// Response headers are emitted in MultiValueHeaders and the single-value
+// Headers map is left empty, so a header never appears in both maps.
+// Set-Cookie headers are moved to the Cookies array instead: HTTP APIs only
+// return every cookie of a response from there.
+// API Gateway can't send HTTP trailers, so the values of declared trailers
+// are returned as regular headers. 204 No Content and 304 Not Modified
+// responses are returned without a body, Content-Type or Content-Length.
 func (r *ProxyResponseWriterV2) GetProxyResponse() (events.APIGatewayV2HTTPResponse, error) {
 	r.notifyClosed()
+	foldTrailers(r.headers)
+
+	if r.status == defaultStatusCode && r.defaultStatus != 0 {
+		r.status = r.defaultStatus
+	}
 
 	if r.status == defaultStatusCode {
 		return events.APIGatewayV2HTTPResponse{}, errors.New("Status code not set on response")
 	}
 
+	// 204 and 304 responses have no content, the empty body is omitted from
+	// the JSON response
+	if r.status == http.StatusNoContent || r.status == http.StatusNotModified {
+		r.body.Reset()
+		r.headers.Del(contentTypeHeaderKey)
+		r.headers.Del("Content-Length")
+	}
+
 	var output string
 	isBase64 := false
 
 	bb := (&r.body).Bytes()
 
-	if utf8.Valid(bb) {
+	if contentType := r.headers.Get(contentTypeHeaderKey); utf8.Valid(bb) && !isBinaryContentType(contentType) && !matchesMediaType(contentType, r.binaryTypes) {
 		output = string(bb)
 	} else {
 		output = base64.StdEncoding.EncodeToString(bb)
 		isBase64 = true
 	}
 
-	return events.APIGatewayV2HTTPResponse{
-		StatusCode:        r.status,
-		MultiValueHeaders: http.Header(r.headers),
-		Body:              output,
-		IsBase64Encoded:   isBase64,
-	}, nil
+	resp := events.APIGatewayV2HTTPResponse{
+		StatusCode:      r.status,
+		Body:            output,
+		IsBase64Encoded: isBase64,
+	}
+	for key, values := range r.headers {
+		if http.CanonicalHeaderKey(key) == "Set-Cookie" {
+			resp.Cookies = append(resp.Cookies, values...)
+			delete(r.headers, key)
+		}
+	}
+	// Headers is never set, the headers map and cookies are left nil too
+	// when they are empty for consumers that reject empty collections
+	if len(r.headers) > 0 {
+		resp.MultiValueHeaders = http.Header(r.headers)
+	}
+	return resp, nil
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 
 	. "github.com/onsi/ginkgo"
@@ -104,6 +105,24 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 			Expect("Status code not set on response").To(Equal(err.Error()))
 		})
 
+		It("Uses the default status when nothing was written", func() {
+			response := NewProxyResponseWriterV2()
+			response.SetDefaultStatus(http.StatusOK)
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusOK).To(Equal(proxyResponse.StatusCode))
+			Expect("").To(Equal(proxyResponse.Body))
+		})
+
+		It("Prefers the handler status over the default status", func() {
+			response := NewProxyResponseWriterV2()
+			response.SetDefaultStatus(http.StatusOK)
+			response.WriteHeader(http.StatusNoContent)
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusNoContent).To(Equal(proxyResponse.StatusCode))
+		})
+
 		simpleResponse := NewProxyResponseWriterV2()
 		simpleResponse.Write([]byte("hello"))
 		simpleResponse.Header().Add("Content-Type", "text/plain")
@@ -142,6 +161,68 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 			Expect("application/octet-stream").To(Equal(proxyResponse.MultiValueHeaders["Content-Type"][0]))
 			Expect(http.StatusAccepted).To(Equal(proxyResponse.StatusCode))
 		})
+
+		It("Returns no body or content headers for a 204", func() {
+			noContent := NewProxyResponseWriterV2()
+			noContent.Header().Set("Content-Length", "2")
+			noContent.Header().Set("X-Request-Id", "abc")
+			noContent.WriteHeader(http.StatusNoContent)
+			noContent.Write([]byte("{}"))
+			proxyResponse, err := noContent.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(http.StatusNoContent).To(Equal(proxyResponse.StatusCode))
+			Expect("").To(Equal(proxyResponse.Body))
+			Expect(proxyResponse.IsBase64Encoded).To(BeFalse())
+			Expect(proxyResponse.MultiValueHeaders).ToNot(HaveKey("Content-Type"))
+			Expect(proxyResponse.MultiValueHeaders).ToNot(HaveKey("Content-Length"))
+			Expect([]string{"abc"}).To(Equal(proxyResponse.MultiValueHeaders["X-Request-Id"]))
+		})
+
+		It("Leaves the header maps and cookies nil without headers", func() {
+			noHeaders := NewProxyResponseWriterV2()
+			noHeaders.WriteHeader(http.StatusNoContent)
+			proxyResponse, err := noHeaders.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(proxyResponse.Headers).To(BeNil())
+			Expect(proxyResponse.MultiValueHeaders).To(BeNil())
+			Expect(proxyResponse.Cookies).To(BeNil())
+		})
+	})
+
+	Context("Trailers", func() {
+		It("Folds declared trailers into the response headers", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Trailer", "X-Checksum")
+				w.Header().Set("Content-Type", "text/plain")
+				w.Write([]byte("hello"))
+				w.Header().Set("X-Checksum", "5d41402a")
+				w.Header().Set(http.TrailerPrefix+"X-Row-Count", "1")
+			})
+
+			resp := NewProxyResponseWriterV2()
+			handler.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+
+			proxyResp, err := resp.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect([]string{"5d41402a"}).To(Equal(proxyResp.MultiValueHeaders["X-Checksum"]))
+			Expect([]string{"1"}).To(Equal(proxyResp.MultiValueHeaders["X-Row-Count"]))
+			Expect(proxyResp.MultiValueHeaders).ToNot(HaveKey("Trailer"))
+			Expect(proxyResp.MultiValueHeaders).ToNot(HaveKey(http.TrailerPrefix + "X-Row-Count"))
+			Expect("hello").To(Equal(proxyResp.Body))
+		})
+	})
+
+	Context("Binary content types", func() {
+		It("Encodes bodies of the listed types", func() {
+			response := NewProxyResponseWriterV2()
+			response.SetBinaryContentTypes([]string{"image/*"})
+			response.Header().Set("Content-Type", "image/svg+xml")
+			response.Write([]byte("<svg/>"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(true).To(Equal(proxyResponse.IsBase64Encoded))
+			Expect(base64.StdEncoding.EncodeToString([]byte("<svg/>"))).To(Equal(proxyResponse.Body))
+		})
 	})
 
 	Context("Handle multi-value headers", func() {
@@ -161,8 +242,8 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 
 		It("Writes multi-value headers correctly", func() {
 			response := NewProxyResponseWriterV2()
-			response.Header().Add("Set-Cookie", "csrftoken=foobar")
-			response.Header().Add("Set-Cookie", "session_id=barfoo")
+			response.Header().Add("Vary", "Accept")
+			response.Header().Add("Vary", "Origin")
 			response.Write([]byte("hello"))
 			proxyResponse, err := response.GetProxyResponse()
 			Expect(err).To(BeNil())
@@ -170,10 +251,39 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 			// Headers are not also written to `Headers` field
 			Expect(0).To(Equal(len(proxyResponse.Headers)))
 
-			// There are two headers here because Content-Type is always written implicitly
-			Expect(2).To(Equal(len(proxyResponse.MultiValueHeaders["Set-Cookie"])))
-			Expect("csrftoken=foobar").To(Equal(proxyResponse.MultiValueHeaders["Set-Cookie"][0]))
-			Expect("session_id=barfoo").To(Equal(proxyResponse.MultiValueHeaders["Set-Cookie"][1]))
+			Expect(2).To(Equal(len(proxyResponse.MultiValueHeaders["Vary"])))
+			Expect("Accept").To(Equal(proxyResponse.MultiValueHeaders["Vary"][0]))
+			Expect("Origin").To(Equal(proxyResponse.MultiValueHeaders["Vary"][1]))
+		})
+
+		It("Moves Set-Cookie headers to the cookies array", func() {
+			response := NewProxyResponseWriterV2()
+			response.Header().Add("Set-Cookie", "csrftoken=foobar")
+			response.Header().Add("Set-Cookie", "session_id=barfoo")
+			response.Write([]byte("hello"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			Expect([]string{"csrftoken=foobar", "session_id=barfoo"}).To(Equal(proxyResponse.Cookies))
+			Expect(proxyResponse.MultiValueHeaders).ToNot(HaveKey("Set-Cookie"))
+			Expect(proxyResponse.Headers).ToNot(HaveKey("Set-Cookie"))
+		})
+
+		It("Never writes a header to both maps", func() {
+			response := NewProxyResponseWriterV2()
+			response.Header().Add("Content-Type", "text/plain")
+			response.Header().Add("X-Single", "one")
+			response.Header().Add("X-Multi", "one")
+			response.Header().Add("X-Multi", "two")
+			response.Write([]byte("hello"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			for key := range proxyResponse.Headers {
+				Expect(proxyResponse.MultiValueHeaders).ToNot(HaveKey(key))
+			}
+			Expect([]string{"one"}).To(Equal(proxyResponse.MultiValueHeaders["X-Single"]))
+			Expect([]string{"one", "two"}).To(Equal(proxyResponse.MultiValueHeaders["X-Multi"]))
 		})
 	})
 
@@ -0,0 +1,49 @@
+package core
+
+import "context"
+
+type matchedRouteKey struct{}
+
+// matchedRoute holds the route template the framework matched for a
+// request. It is stored as a pointer so adapters can fill it in once the
+// router ran, after the request context was created.
+type matchedRoute struct {
+	route  string
+	lookup func(ctx context.Context) (string, bool)
+}
+
+// WithMatchedRoute returns a copy of ctx that can carry the route template
+// matched by a framework router. Adapters call it before routing and either
+// record the template with SetMatchedRoute once the router matched it, or
+// pass a lookup function that GetMatchedRoute calls to resolve the template
+// from the context the handler sees. lookup can be nil.
+func WithMatchedRoute(ctx context.Context, lookup func(ctx context.Context) (string, bool)) context.Context {
+	return context.WithValue(ctx, matchedRouteKey{}, &matchedRoute{lookup: lookup})
+}
+
+// SetMatchedRoute records the route template matched for the request, such
+// as /users/:id. It has no effect on contexts not created with
+// WithMatchedRoute.
+func SetMatchedRoute(ctx context.Context, route string) {
+	if mr, ok := ctx.Value(matchedRouteKey{}).(*matchedRoute); ok {
+		mr.route = route
+	}
+}
+
+// GetMatchedRoute returns the route template the framework router matched
+// for the request, for example to group metrics by route rather than by
+// path. Returns false when the adapter does not support it or no route
+// matched.
+func GetMatchedRoute(ctx context.Context) (string, bool) {
+	mr, ok := ctx.Value(matchedRouteKey{}).(*matchedRoute)
+	if !ok {
+		return "", false
+	}
+	if mr.route != "" {
+		return mr.route, true
+	}
+	if mr.lookup != nil {
+		return mr.lookup(ctx)
+	}
+	return "", false
+}
@@ -0,0 +1,38 @@
+package core_test
+
+import (
+	"context"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetMatchedRoute tests", func() {
+	It("Returns the route set after the context was created", func() {
+		ctx := core.WithMatchedRoute(context.Background(), nil)
+		_, ok := core.GetMatchedRoute(ctx)
+		Expect(ok).To(BeFalse())
+
+		core.SetMatchedRoute(ctx, "/users/:id")
+		route, ok := core.GetMatchedRoute(ctx)
+		Expect(ok).To(BeTrue())
+		Expect("/users/:id").To(Equal(route))
+	})
+
+	It("Falls back to the lookup function", func() {
+		ctx := core.WithMatchedRoute(context.Background(), func(context.Context) (string, bool) {
+			return "/orders/{id}", true
+		})
+		route, ok := core.GetMatchedRoute(ctx)
+		Expect(ok).To(BeTrue())
+		Expect("/orders/{id}").To(Equal(route))
+	})
+
+	It("Ignores contexts without a matched route", func() {
+		core.SetMatchedRoute(context.Background(), "/users/:id")
+		_, ok := core.GetMatchedRoute(context.Background())
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// FunctionURLStreamingHandler sends Lambda Function URL events to an
+// http.Handler and streams the response, for Function URLs configured with
+// the RESPONSE_STREAM invoke mode. The handler writes to a
+// StreamingResponseWriter, the bytes it flushes are sent to the client while
+// it keeps running, which suits large responses and server-sent events.
+// Streaming responses require the provided runtimes or the lambda.norpc
+// build tag, see events.LambdaFunctionURLStreamingResponse.
+type FunctionURLStreamingHandler struct {
+	accessor *RequestAccessorFnURL
+	handler  http.Handler
+}
+
+// NewFunctionURLStreamingHandler returns a FunctionURLStreamingHandler that
+// converts events with the given RequestAccessorFnURL.
+func NewFunctionURLStreamingHandler(accessor *RequestAccessorFnURL, handler http.Handler) *FunctionURLStreamingHandler {
+	return &FunctionURLStreamingHandler{accessor: accessor, handler: handler}
+}
+
+// ProxyStream receives context and a Function URL event, transforms them into
+// an http.Request object, and starts the http.Handler in a goroutine.
+// It returns once the handler has written or flushed for the first time, or
+// returned, with the status and headers the handler set and a body that
+// reads what the handler writes until it returns. Multiple header values are
+// joined with a comma and every Set-Cookie header is moved to the Cookies
+// array. A handler that panics before writing is answered with a 500, a
+// panic after that ends the body with an error.
+func (h *FunctionURLStreamingHandler) ProxyStream(ctx context.Context, event events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error) {
+	req, err := h.accessor.EventToRequestWithContext(ctx, event)
+	if err != nil {
+		return nil, NewLoggedError("Could not convert proxy event to request: %v", err)
+	}
+
+	pr, pw := io.Pipe()
+	w := NewStreamingResponseWriter(req.Context(), pw)
+	withReadDeadline(req, w)
+
+	resp := &events.LambdaFunctionURLStreamingResponse{Body: pr}
+	committed := make(chan struct{})
+	var once sync.Once
+	w.onCommit = func(status int, headers http.Header) {
+		resp.StatusCode = status
+		resp.Headers, resp.Cookies = streamingHeaders(headers)
+		once.Do(func() { close(committed) })
+	}
+
+	go func() {
+		var panicErr error
+		defer func() {
+			if v := recover(); v != nil {
+				panicErr = fmt.Errorf("Handler panicked: %v", v)
+				if !w.committed {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}
+			w.commit()
+			if err := w.buf.Flush(); err != nil && panicErr == nil {
+				panicErr = err
+			}
+			pw.CloseWithError(panicErr)
+		}()
+		h.handler.ServeHTTP(w, req)
+	}()
+
+	<-committed
+	return resp, nil
+}
+
+// streamingHeaders converts the response headers to the single-value headers
+// and cookies of a Function URL response.
+func streamingHeaders(headers http.Header) (map[string]string, []string) {
+	single := make(map[string]string, len(headers))
+	var cookies []string
+	for key, values := range headers {
+		if http.CanonicalHeaderKey(key) == "Set-Cookie" {
+			cookies = append(cookies, values...)
+			continue
+		}
+		single[key] = strings.Join(values, ",")
+	}
+	return single, cookies
+}
@@ -0,0 +1,68 @@
+package core_test
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FunctionURLStreamingHandler tests", func() {
+	It("Streams the body while the handler runs", func() {
+		release := make(chan struct{})
+		handler := core.NewFunctionURLStreamingHandler(&core.RequestAccessorFnURL{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Add("Set-Cookie", "session=1")
+			w.Header().Add("Cache-Control", "no-cache")
+			w.Header().Add("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("data: 1\n\n"))
+			w.(http.Flusher).Flush()
+			<-release
+			w.Write([]byte("data: 2\n\n"))
+		}))
+
+		resp, err := handler.ProxyStream(context.Background(), getFunctionURLRequest("/events", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusAccepted).To(Equal(resp.StatusCode))
+		Expect("text/event-stream").To(Equal(resp.Headers["Content-Type"]))
+		Expect("no-cache,no-store").To(Equal(resp.Headers["Cache-Control"]))
+		Expect([]string{"session=1"}).To(Equal(resp.Cookies))
+
+		body := bufio.NewReader(resp.Body)
+		line, err := body.ReadString('\n')
+		Expect(err).To(BeNil())
+		Expect("data: 1\n").To(Equal(line))
+
+		close(release)
+		rest, err := ioutil.ReadAll(body)
+		Expect(err).To(BeNil())
+		Expect("\ndata: 2\n\n").To(Equal(string(rest)))
+	})
+
+	It("Returns a 200 with an empty body for handlers that write nothing", func() {
+		handler := core.NewFunctionURLStreamingHandler(&core.RequestAccessorFnURL{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		resp, err := handler.ProxyStream(context.Background(), getFunctionURLRequest("/empty", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusOK).To(Equal(resp.StatusCode))
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).To(BeNil())
+		Expect(body).To(BeEmpty())
+	})
+
+	It("Answers a handler that panics before writing with a 500", func() {
+		handler := core.NewFunctionURLStreamingHandler(&core.RequestAccessorFnURL{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+		resp, err := handler.ProxyStream(context.Background(), getFunctionURLRequest("/panic", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusInternalServerError).To(Equal(resp.StatusCode))
+		_, err = ioutil.ReadAll(resp.Body)
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// APIGatewayProxyFunc is the signature of the ProxyWithContext method of the
+// API Gateway REST API (v1) adapters.
+type APIGatewayProxyFunc func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// NewTestServer starts an httptest.Server that converts every request it
+// receives into an API Gateway proxy event on the "test" stage, sends it to
+// proxy and writes the proxy response back to the client. Integration tests
+// can then use a plain http.Client to exercise the whole Lambda path of an
+// adapter by URL.
+// Errors returned by proxy are answered with a 502 Bad Gateway, like API
+// Gateway does for failed invocations. The caller must Close the server.
+func NewTestServer(proxy APIGatewayProxyFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event, err := NewAPIGatewayRequestFromHTTP(r, "test")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp, err := proxy(r.Context(), event)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		WriteAPIGatewayResponse(w, resp)
+	}))
+}
+
+// NewAPIGatewayRequestFromHTTP returns the API Gateway REST API (v1) proxy
+// event of a greedy {proxy+} resource on the stage for the request, with a
+// random request ID. Headers and query parameters are copied into the single
+// and multi-value maps, and bodies that are not valid UTF-8 are base64
+// encoded.
+func NewAPIGatewayRequestFromHTTP(r *http.Request, stage string) (events.APIGatewayProxyRequest, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			return events.APIGatewayProxyRequest{}, err
+		}
+	}
+
+	headers := r.Header.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	if r.Host != "" {
+		headers.Set("Host", r.Host)
+	}
+	sourceIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		sourceIP = r.RemoteAddr
+	}
+	query := r.URL.Query()
+	now := time.Now()
+
+	event := events.APIGatewayProxyRequest{
+		Resource:                        "/{proxy+}",
+		Path:                            r.URL.Path,
+		HTTPMethod:                      r.Method,
+		Headers:                         lastValues(headers),
+		MultiValueHeaders:               headers,
+		QueryStringParameters:           lastValues(query),
+		MultiValueQueryStringParameters: query,
+		PathParameters:                  map[string]string{"proxy": strings.TrimPrefix(r.URL.Path, "/")},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:        "123456789012",
+			ResourceID:       stage,
+			APIID:            stage,
+			Stage:            stage,
+			RequestID:        newRequestID(),
+			ResourcePath:     "/{proxy+}",
+			Path:             "/" + stage + r.URL.Path,
+			HTTPMethod:       r.Method,
+			Protocol:         r.Proto,
+			DomainName:       r.Host,
+			RequestTime:      now.Format("02/Jan/2006:15:04:05 -0700"),
+			RequestTimeEpoch: now.UnixNano() / int64(time.Millisecond),
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  sourceIP,
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}
+	if utf8.Valid(body) {
+		event.Body = string(body)
+	} else {
+		event.Body = base64.StdEncoding.EncodeToString(body)
+		event.IsBase64Encoded = true
+	}
+	return event, nil
+}
+
+// lastValues returns the last value of every key, or nil for empty maps.
+func lastValues(values map[string][]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	last := make(map[string]string, len(values))
+	for key, v := range values {
+		if len(v) > 0 {
+			last[key] = v[len(v)-1]
+		}
+	}
+	return last
+}
+
+// WriteAPIGatewayResponse writes an API Gateway proxy response to w.
+// Multi-value headers take precedence over single value headers of the same
+// name, as they do in API Gateway. Bodies that can't be base64 decoded are
+// answered with a 502 Bad Gateway.
+func WriteAPIGatewayResponse(w http.ResponseWriter, resp events.APIGatewayProxyResponse) {
+	for key, values := range resp.MultiValueHeaders {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	for key, value := range resp.Headers {
+		if _, ok := resp.MultiValueHeaders[key]; !ok {
+			w.Header().Set(key, value)
+		}
+	}
+
+	body := []byte(resp.Body)
+	if resp.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(resp.Body)
+		if err != nil {
+			http.Error(w, "Could not decode the response body: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		body = decoded
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	w.Write(body)
+}
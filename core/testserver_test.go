@@ -0,0 +1,77 @@
+package core_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewTestServer tests", func() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		apiGwContext, _ := core.GetAPIGatewayContextFromContext(r.Context())
+		w.Header().Set("X-Stage", apiGwContext.Stage)
+		w.Write([]byte("hello " + r.URL.Query().Get("name")))
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+	handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, mux)
+
+	It("Serves requests through the proxy", func() {
+		server := core.NewTestServer(handler.ProxyWithContext)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/hello?name=lambda")
+		Expect(err).To(BeNil())
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).To(BeNil())
+
+		Expect(http.StatusOK).To(Equal(resp.StatusCode))
+		Expect("hello lambda").To(Equal(string(body)))
+		Expect("test").To(Equal(resp.Header.Get("X-Stage")))
+	})
+
+	It("Round-trips binary bodies", func() {
+		server := core.NewTestServer(handler.ProxyWithContext)
+		defer server.Close()
+
+		binaryBody := string([]byte{0xff, 0x00, 0xfe})
+		resp, err := http.Post(server.URL+"/echo", "application/octet-stream", strings.NewReader(binaryBody))
+		Expect(err).To(BeNil())
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).To(BeNil())
+
+		Expect(http.StatusCreated).To(Equal(resp.StatusCode))
+		Expect(binaryBody).To(Equal(string(body)))
+	})
+	It("Builds the proxy event of a request", func() {
+		req := httptest.NewRequest("POST", "http://example.com/orders/42?tag=a&tag=b", strings.NewReader("order"))
+		req.Header.Add("Accept", "text/plain")
+
+		event, err := core.NewAPIGatewayRequestFromHTTP(req, "dev")
+		Expect(err).To(BeNil())
+
+		Expect("/{proxy+}").To(Equal(event.Resource))
+		Expect("orders/42").To(Equal(event.PathParameters["proxy"]))
+		Expect("/dev/orders/42").To(Equal(event.RequestContext.Path))
+		Expect("dev").To(Equal(event.RequestContext.Stage))
+		Expect(event.RequestContext.RequestID).To(HaveLen(36))
+		Expect([]string{"a", "b"}).To(Equal(event.MultiValueQueryStringParameters["tag"]))
+		Expect("b").To(Equal(event.QueryStringParameters["tag"]))
+		Expect([]string{"example.com"}).To(Equal(event.MultiValueHeaders["Host"]))
+		Expect("order").To(Equal(event.Body))
+		Expect(event.IsBase64Encoded).To(BeFalse())
+	})
+})
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// TraceIDHeader is the header API Gateway and ALB use to pass the X-Ray
+// trace ID of the request.
+const TraceIDHeader = "X-Amzn-Trace-Id"
+
+// lambdaTraceIDKey is the context key the aws-lambda-go runtime stores the
+// trace header of the invocation under. The X-Ray SDK reads the same key.
+const lambdaTraceIDKey = "x-amzn-trace-id"
+
+// CaptureFunc runs fn in a trace subsegment with the given name, passing it a
+// context that carries the subsegment. xray.Capture of the X-Ray SDK has
+// this signature, see ProxyHandler.SetCaptureFunc.
+type CaptureFunc func(ctx context.Context, name string, fn func(context.Context) error) error
+
+// GetTraceIDFromContext returns the X-Ray trace header of the Lambda
+// invocation, which the runtime stores in the context passed to the handler
+// function, or the _X_AMZN_TRACE_ID variable when the context has none.
+// Returns false when tracing is not active.
+func GetTraceIDFromContext(ctx context.Context) (string, bool) {
+	if traceID, ok := ctx.Value(lambdaTraceIDKey).(string); ok && traceID != "" {
+		return traceID, true
+	}
+	traceID := os.Getenv("_X_AMZN_TRACE_ID")
+	return traceID, traceID != ""
+}
+
+// propagateTraceID sets the X-Amzn-Trace-Id header of a request whose event
+// did not carry one to the trace header of the invocation, so handlers that
+// forward the header to downstream calls stay in the trace of the function.
+func propagateTraceID(req *http.Request) {
+	if req.Header.Get(TraceIDHeader) != "" {
+		return
+	}
+	if traceID, ok := GetTraceIDFromContext(req.Context()); ok {
+		req.Header.Set(TraceIDHeader, traceID)
+	}
+}
+
+// ParseTraceID parses the X-Amzn-Trace-Id header of the request, in the
+// Root=...;Parent=...;Sampled=1 format, so handlers can correlate requests
+// without the X-Ray SDK. Parent is empty and sampled is false when the
+// header does not carry them. Returns false if the request has no header
+// or the header has no Root.
+func ParseTraceID(r *http.Request) (root string, parent string, sampled bool, ok bool) {
+	for _, field := range strings.Split(r.Header.Get(TraceIDHeader), ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(field), "=")
+		if !found {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "root":
+			root = value
+		case "parent":
+			parent = value
+		case "sampled":
+			sampled = value == "1"
+		}
+	}
+	return root, parent, sampled, root != ""
+}
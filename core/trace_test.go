@@ -0,0 +1,110 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseTraceID tests", func() {
+	newRequest := func(traceID string) *http.Request {
+		req, _ := http.NewRequest("GET", "/orders", nil)
+		if traceID != "" {
+			req.Header.Set(core.TraceIDHeader, traceID)
+		}
+		return req
+	}
+
+	It("Parses a full header", func() {
+		root, parent, sampled, ok := core.ParseTraceID(newRequest("Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"))
+		Expect(ok).To(BeTrue())
+		Expect("1-5759e988-bd862e3fe1be46a994272793").To(Equal(root))
+		Expect("53995c3f42cd8ad8").To(Equal(parent))
+		Expect(sampled).To(BeTrue())
+	})
+
+	It("Parses a header with only a root", func() {
+		root, parent, sampled, ok := core.ParseTraceID(newRequest("Root=1-5759e988-bd862e3fe1be46a994272793"))
+		Expect(ok).To(BeTrue())
+		Expect("1-5759e988-bd862e3fe1be46a994272793").To(Equal(root))
+		Expect("").To(Equal(parent))
+		Expect(sampled).To(BeFalse())
+	})
+
+	It("Reports a missing header", func() {
+		_, _, _, ok := core.ParseTraceID(newRequest(""))
+		Expect(ok).To(BeFalse())
+		_, _, _, ok = core.ParseTraceID(newRequest("Parent=53995c3f42cd8ad8"))
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("Trace propagation tests", func() {
+	const lambdaTraceID = "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+	traceHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Seen-Trace", r.Header.Get(core.TraceIDHeader))
+		w.Header().Set("X-Segment", fmt.Sprint(r.Context().Value(segmentKey{})))
+		w.WriteHeader(http.StatusOK)
+	})
+	lambdaContext := func() context.Context {
+		return context.WithValue(context.Background(), "x-amzn-trace-id", lambdaTraceID)
+	}
+
+	It("Reads the trace header of the invocation", func() {
+		traceID, ok := core.GetTraceIDFromContext(lambdaContext())
+		Expect(ok).To(BeTrue())
+		Expect(lambdaTraceID).To(Equal(traceID))
+
+		_, ok = core.GetTraceIDFromContext(context.Background())
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Adds the invocation trace header to requests without one", func() {
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, traceHandler)
+		resp, err := handler.ProxyWithContext(lambdaContext(), getProxyRequest("/orders", "GET"))
+		Expect(err).To(BeNil())
+		Expect(lambdaTraceID).To(Equal(resp.MultiValueHeaders["X-Seen-Trace"][0]))
+
+		event := getProxyRequest("/orders", "GET")
+		event.Headers = map[string]string{core.TraceIDHeader: "Root=1-00000000-000000000000000000000000"}
+		resp, err = handler.ProxyWithContext(lambdaContext(), event)
+		Expect(err).To(BeNil())
+		Expect("Root=1-00000000-000000000000000000000000").To(Equal(resp.MultiValueHeaders["X-Seen-Trace"][0]))
+	})
+
+	It("Serves the request in the subsegment of the capture function", func() {
+		var names []string
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, traceHandler)
+		handler.SetCaptureFunc(func(ctx context.Context, name string, fn func(context.Context) error) error {
+			names = append(names, name)
+			return fn(context.WithValue(ctx, segmentKey{}, "orders-segment"))
+		})
+
+		resp, err := handler.ProxyWithContext(lambdaContext(), getProxyRequest("/orders", "GET"))
+		Expect(err).To(BeNil())
+		Expect([]string{"GET /orders"}).To(Equal(names))
+		Expect("orders-segment").To(Equal(resp.MultiValueHeaders["X-Segment"][0]))
+	})
+
+	It("Serves the request when the capture function fails", func() {
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, traceHandler)
+		handler.SetLogger(log.New(ioutil.Discard, "", 0))
+		handler.SetCaptureFunc(func(ctx context.Context, name string, fn func(context.Context) error) error {
+			return errors.New("No segment in context")
+		})
+
+		resp, err := handler.ProxyWithContext(context.Background(), getProxyRequest("/orders", "GET"))
+		Expect(err).To(BeNil())
+		Expect(http.StatusOK).To(Equal(resp.StatusCode))
+	})
+})
+
+type segmentKey struct{}
@@ -18,3 +18,9 @@ func NewLoggedError(format string, a ...interface{}) error {
 	fmt.Println(err.Error())
 	return err
 }
+
+// Logger is the minimal logging interface used by the helpers in this
+// package. The standard library *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
@@ -0,0 +1,86 @@
+package core
+
+import "encoding/json"
+
+// CloudFront trigger types of Lambda@Edge functions, found in the EventType
+// of the CloudFrontConfig of an event. Only the request triggers can be
+// answered by an http.Handler.
+const (
+	CloudFrontViewerRequest  = "viewer-request"
+	CloudFrontOriginRequest  = "origin-request"
+	CloudFrontViewerResponse = "viewer-response"
+	CloudFrontOriginResponse = "origin-response"
+)
+
+// CloudFrontEvent is the event a Lambda@Edge function receives from a
+// CloudFront trigger. The aws-lambda-go events package has no type for it.
+type CloudFrontEvent struct {
+	Records []CloudFrontRecord `json:"Records"`
+}
+
+// CloudFrontRecord is a record of a CloudFrontEvent. CloudFront always sends
+// a single record.
+type CloudFrontRecord struct {
+	CF CloudFrontRecordCF `json:"cf"`
+}
+
+// CloudFrontRecordCF holds the distribution configuration and the viewer
+// request of a CloudFrontRecord.
+type CloudFrontRecordCF struct {
+	Config  CloudFrontConfig  `json:"config"`
+	Request CloudFrontRequest `json:"request"`
+}
+
+// CloudFrontConfig describes the distribution and the trigger of an event.
+type CloudFrontConfig struct {
+	DistributionDomainName string `json:"distributionDomainName"`
+	DistributionID         string `json:"distributionId"`
+	EventType              string `json:"eventType"`
+	RequestID              string `json:"requestId"`
+}
+
+// CloudFrontRequest is the request of a CloudFrontRecord. The URI is the
+// escaped path of the request and the query string is sent without the
+// leading question mark. The origin, only sent to origin request triggers,
+// is kept as it was received.
+type CloudFrontRequest struct {
+	ClientIP    string                 `json:"clientIp"`
+	Method      string                 `json:"method"`
+	URI         string                 `json:"uri"`
+	QueryString string                 `json:"querystring"`
+	Headers     CloudFrontHeaders      `json:"headers"`
+	Body        *CloudFrontRequestBody `json:"body,omitempty"`
+	Origin      json.RawMessage        `json:"origin,omitempty"`
+}
+
+// CloudFrontRequestBody is the body of a CloudFrontRequest, only sent to
+// triggers with the include body option. CloudFront truncates bodies over
+// the size limit of the trigger and sets InputTruncated.
+type CloudFrontRequestBody struct {
+	InputTruncated bool   `json:"inputTruncated"`
+	Action         string `json:"action"`
+	Encoding       string `json:"encoding"`
+	Data           string `json:"data"`
+}
+
+// CloudFrontHeaders are the headers of a CloudFront request or response,
+// keyed by the lowercase header name. Each entry lists the values of the
+// header with the name in its original case.
+type CloudFrontHeaders map[string][]CloudFrontHeader
+
+// CloudFrontHeader is a value of a header in CloudFrontHeaders.
+type CloudFrontHeader struct {
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value"`
+}
+
+// CloudFrontResponse is the response a Lambda@Edge request trigger returns
+// to answer the viewer without forwarding the request. The status is a
+// string and the body encoding is either text or base64.
+type CloudFrontResponse struct {
+	Status            string            `json:"status"`
+	StatusDescription string            `json:"statusDescription,omitempty"`
+	Headers           CloudFrontHeaders `json:"headers,omitempty"`
+	Body              string            `json:"body,omitempty"`
+	BodyEncoding      string            `json:"bodyEncoding,omitempty"`
+}
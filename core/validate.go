@@ -0,0 +1,70 @@
+package core
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// disallowedResponseHeaders are the hop-by-hop headers that only make sense
+// on the connection between API Gateway and the client. API Gateway manages
+// them itself and rejects or drops them in integration responses.
+var disallowedResponseHeaders = map[string]bool{
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Proxy-Connection":  true,
+	"Te":                true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+// ValidateProxyResponse checks that the response is one API Gateway can
+// return: the status is between 100 and 599, no hop-by-hop headers such as
+// Connection or Transfer-Encoding are set, header values have no control
+// characters and the body is valid for its encoding, base64 when
+// IsBase64Encoded is set and UTF-8 otherwise. Returns an error describing the
+// first problem found, or nil for a valid response.
+func ValidateProxyResponse(resp events.APIGatewayProxyResponse) error {
+	if resp.StatusCode < 100 || resp.StatusCode > 599 {
+		return fmt.Errorf("Invalid status code %d, must be between 100 and 599", resp.StatusCode)
+	}
+
+	for key, value := range resp.Headers {
+		if err := validateResponseHeader(key, value); err != nil {
+			return err
+		}
+	}
+	for key, values := range resp.MultiValueHeaders {
+		for _, value := range values {
+			if err := validateResponseHeader(key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	if resp.IsBase64Encoded {
+		if _, err := base64.StdEncoding.DecodeString(resp.Body); err != nil {
+			return fmt.Errorf("Body is not valid base64: %v", err)
+		}
+	} else if !utf8.ValidString(resp.Body) {
+		return errors.New("Body is not valid UTF-8, binary bodies must be base64 encoded")
+	}
+
+	return nil
+}
+
+func validateResponseHeader(key string, value string) error {
+	if disallowedResponseHeaders[http.CanonicalHeaderKey(key)] {
+		return fmt.Errorf("Header %s can't be set on a proxy response", key)
+	}
+	if strings.IndexFunc(value, isHeaderControl) >= 0 {
+		return fmt.Errorf("Header %s has a value with control characters: %q", key, value)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package core_test
+
+import (
+	"encoding/base64"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ValidateProxyResponse tests", func() {
+	It("Accepts a valid response", func() {
+		err := core.ValidateProxyResponse(events.APIGatewayProxyResponse{
+			StatusCode:        200,
+			Headers:           map[string]string{"X-Single": "one"},
+			MultiValueHeaders: map[string][]string{"Set-Cookie": {"a=1", "b=2"}},
+			Body:              "{\"hello\": \"world\"}",
+		})
+		Expect(err).To(BeNil())
+
+		err = core.ValidateProxyResponse(events.APIGatewayProxyResponse{
+			StatusCode:      200,
+			Body:            base64.StdEncoding.EncodeToString([]byte{0xff, 0x00}),
+			IsBase64Encoded: true,
+		})
+		Expect(err).To(BeNil())
+	})
+
+	It("Rejects an out of range status", func() {
+		err := core.ValidateProxyResponse(events.APIGatewayProxyResponse{StatusCode: 600})
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("600"))
+
+		err = core.ValidateProxyResponse(events.APIGatewayProxyResponse{StatusCode: -1})
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("Rejects disallowed headers", func() {
+		err := core.ValidateProxyResponse(events.APIGatewayProxyResponse{
+			StatusCode:        200,
+			MultiValueHeaders: map[string][]string{"transfer-encoding": {"chunked"}},
+		})
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("transfer-encoding"))
+
+		err = core.ValidateProxyResponse(events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    map[string]string{"X-Injected": "a\r\nb"},
+		})
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("Rejects bodies that don't match the encoding", func() {
+		err := core.ValidateProxyResponse(events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Body:       string([]byte{0xff, 0xfe}),
+		})
+		Expect(err).ToNot(BeNil())
+
+		err = core.ValidateProxyResponse(events.APIGatewayProxyResponse{
+			StatusCode:      200,
+			Body:            "not base64!",
+			IsBase64Encoded: true,
+		})
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// serverlessWarmupSource is the source of the pings sent by
+// serverless-plugin-warmup.
+const serverlessWarmupSource = "serverless-plugin-warmup"
+
+// IsWarmupEvent reports whether the JSON payload of an invocation is a
+// keep-warm ping rather than a request: an object with "warmup": true, as
+// sent by an EventBridge schedule with a constant input, an EventBridge
+// scheduled event sent without a custom input, or a ping of
+// serverless-plugin-warmup.
+func IsWarmupEvent(payload []byte) bool {
+	return isWarmupPayload(payload, nil)
+}
+
+// WarmupConfig configures how a ProxyHandler detects and answers keep-warm
+// pings, see SetWarmupConfig.
+type WarmupConfig struct {
+	// Sources are values of the "source" field of the payload that mark a
+	// ping, in addition to the payloads detected by IsWarmupEvent.
+	Sources []string
+	// Header is a request header that marks the event as a ping when it is
+	// set, for schedules that invoke the function with an API event.
+	Header string
+	// OnWarmup runs for every ping before it is answered, for pre-warming
+	// work such as opening database connections or filling caches.
+	OnWarmup func(ctx context.Context)
+}
+
+// SetWarmupConfig makes the handler answer keep-warm pings right away,
+// without converting the event or running the http.Handler, so they don't
+// reach the router or the access logs. ProxyRaw detects the payloads of
+// IsWarmupEvent and the configured sources. Proxy and ProxyWithContext
+// detect events with the configured header and events without an HTTP
+// method, which is what a ping decodes to as a typed event. Pings are
+// answered with the response set with SetWarmupResponse, or an empty 200 OK.
+func (p *ProxyHandler[ReqT, RespT]) SetWarmupConfig(config WarmupConfig) {
+	p.warmupConfig = &config
+}
+
+func isWarmupPayload(payload []byte, sources []string) bool {
+	var event struct {
+		Warmup     bool   `json:"warmup"`
+		Source     string `json:"source"`
+		DetailType string `json:"detail-type"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return false
+	}
+	if event.Warmup || event.Source == serverlessWarmupSource || (event.Source == "aws.events" && event.DetailType == "Scheduled Event") {
+		return true
+	}
+	for _, source := range sources {
+		if event.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// isWarmupPayload reports whether ProxyRaw should answer the payload as a
+// keep-warm ping.
+func (p *ProxyHandler[ReqT, RespT]) isWarmupPayload(payload []byte) bool {
+	if p.warmupConfig != nil {
+		return isWarmupPayload(payload, p.warmupConfig.Sources)
+	}
+	return p.warmup != nil && isWarmupPayload(payload, nil)
+}
+
+// isWarmupEvent reports whether Proxy and ProxyWithContext should answer the
+// typed event as a keep-warm ping.
+func (p *ProxyHandler[ReqT, RespT]) isWarmupEvent(event ReqT) bool {
+	if p.warmupConfig == nil {
+		return false
+	}
+	method, headers, ok := eventMethodAndHeaders(event)
+	if !ok {
+		return false
+	}
+	if method == "" {
+		return true
+	}
+	return p.warmupConfig.Header != "" && headers.Get(p.warmupConfig.Header) != ""
+}
+
+// warmupResponse runs the OnWarmup hook and returns the response to a
+// keep-warm ping.
+func (p *ProxyHandler[ReqT, RespT]) warmupResponse(ctx context.Context) (RespT, error) {
+	if p.warmupConfig != nil && p.warmupConfig.OnWarmup != nil {
+		p.warmupConfig.OnWarmup(ctx)
+	}
+	if p.warmup != nil {
+		return *p.warmup, nil
+	}
+	w := p.newWriter()
+	w.WriteHeader(http.StatusOK)
+	return w.GetProxyResponse()
+}
+
+// eventMethodAndHeaders returns the HTTP method and headers of the API
+// events of this package. Returns false for other events.
+func eventMethodAndHeaders(event interface{}) (string, http.Header, bool) {
+	headers := make(http.Header)
+	switch e := event.(type) {
+	case events.APIGatewayProxyRequest:
+		addEventHeaders(headers, e.Headers, e.MultiValueHeaders)
+		return e.HTTPMethod, headers, true
+	case events.APIGatewayV2HTTPRequest:
+		addEventHeaders(headers, e.Headers, nil)
+		return e.RequestContext.HTTP.Method, headers, true
+	case events.ALBTargetGroupRequest:
+		addEventHeaders(headers, e.Headers, e.MultiValueHeaders)
+		return e.HTTPMethod, headers, true
+	case events.LambdaFunctionURLRequest:
+		addEventHeaders(headers, e.Headers, nil)
+		return e.RequestContext.HTTP.Method, headers, true
+	}
+	return "", nil, false
+}
+
+func addEventHeaders(headers http.Header, single map[string]string, multi map[string][]string) {
+	for key, value := range single {
+		headers.Add(key, value)
+	}
+	for key, values := range multi {
+		for _, value := range values {
+			headers.Add(key, value)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package core_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Warmup tests", func() {
+	It("Detects keep-warm pings", func() {
+		Expect(core.IsWarmupEvent([]byte(`{"warmup": true}`))).To(BeTrue())
+		Expect(core.IsWarmupEvent([]byte(`{"source": "aws.events", "detail-type": "Scheduled Event", "detail": {}}`))).To(BeTrue())
+		Expect(core.IsWarmupEvent([]byte(`{"source": "serverless-plugin-warmup"}`))).To(BeTrue())
+		Expect(core.IsWarmupEvent([]byte(`{"warmup": false}`))).To(BeFalse())
+		Expect(core.IsWarmupEvent([]byte(`{"httpMethod": "GET", "path": "/warmup"}`))).To(BeFalse())
+		Expect(core.IsWarmupEvent([]byte(`not json`))).To(BeFalse())
+	})
+
+	It("Answers pings with the warmup response without routing", func() {
+		calls := 0
+		handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusOK)
+		}))
+		handler.SetWarmupResponse(events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: "warm"})
+
+		payload, err := handler.ProxyRaw(context.Background(), []byte(`{"warmup": true}`))
+		Expect(err).To(BeNil())
+		resp := events.APIGatewayProxyResponse{}
+		Expect(json.Unmarshal(payload, &resp)).To(BeNil())
+		Expect("warm").To(Equal(resp.Body))
+		Expect(0).To(Equal(calls))
+
+		_, err = handler.ProxyRaw(context.Background(), []byte(`{"httpMethod": "GET", "path": "/orders"}`))
+		Expect(err).To(BeNil())
+		Expect(1).To(Equal(calls))
+	})
+
+	Context("Warmup config", func() {
+		It("Answers configured pings with a 200 and runs the warmup hook", func() {
+			calls, warmed := 0, 0
+			handler := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(http.StatusOK)
+			}))
+			handler.SetWarmupConfig(core.WarmupConfig{
+				Sources:  []string{"keep-warm"},
+				Header:   "X-Warmup",
+				OnWarmup: func(ctx context.Context) { warmed++ },
+			})
+
+			payload, err := handler.ProxyRaw(context.Background(), []byte(`{"source": "keep-warm"}`))
+			Expect(err).To(BeNil())
+			resp := events.APIGatewayProxyResponse{}
+			Expect(json.Unmarshal(payload, &resp)).To(BeNil())
+			Expect(http.StatusOK).To(Equal(resp.StatusCode))
+
+			ping := getProxyRequest("/orders", "GET")
+			ping.Headers = map[string]string{"x-warmup": "1"}
+			resp, err = handler.ProxyWithContext(context.Background(), ping)
+			Expect(err).To(BeNil())
+			Expect(http.StatusOK).To(Equal(resp.StatusCode))
+
+			// a scheduled event decodes to an event without a method
+			resp, err = handler.Proxy(events.APIGatewayProxyRequest{})
+			Expect(err).To(BeNil())
+			Expect(http.StatusOK).To(Equal(resp.StatusCode))
+
+			Expect(3).To(Equal(warmed))
+			Expect(0).To(Equal(calls))
+
+			_, err = handler.ProxyWithContext(context.Background(), getProxyRequest("/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect(1).To(Equal(calls))
+			Expect(3).To(Equal(warmed))
+		})
+
+		It("Answers typed pings with the warmup response", func() {
+			handler := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, http.NotFoundHandler())
+			handler.SetWarmupResponse(events.APIGatewayV2HTTPResponse{StatusCode: http.StatusOK, Body: "warm"})
+			handler.SetWarmupConfig(core.WarmupConfig{})
+
+			resp, err := handler.ProxyWithContext(context.Background(), events.APIGatewayV2HTTPRequest{})
+			Expect(err).To(BeNil())
+			Expect("warm").To(Equal(resp.Body))
+		})
+	})
+})
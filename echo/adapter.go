@@ -12,11 +12,34 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	// APIGatewayContextKey is the echo.Context key under which the adapter
+	// stores the events.APIGatewayProxyRequestContext of the request.
+	APIGatewayContextKey = "apiGatewayContext"
+
+	// StageVarsKey is the echo.Context key under which the adapter stores
+	// the API Gateway stage variables of the request.
+	StageVarsKey = "apiGatewayStageVars"
+)
+
 // EchoLambda makes it easy to send API Gateway proxy events to a echo.Echo.
 // The library transforms the proxy event into an HTTP request and then
-// creates a proxy response object from the http.ResponseWriter
+// creates a proxy response object from the http.ResponseWriter.
+// The embedded RequestAccessor and APIGatewayProxyHandler configure REST API
+// (v1) events, the exported accessor and handler fields configure HTTP API
+// (v2), Application Load Balancer and Function URL events.
 type EchoLambda struct {
 	core.RequestAccessor
+	*core.APIGatewayProxyHandler
+
+	RequestAccessorV2        core.RequestAccessorV2
+	APIGatewayV2ProxyHandler *core.APIGatewayV2ProxyHandler
+
+	RequestAccessorALB core.RequestAccessorALB
+	ALBProxyHandler    *core.ALBProxyHandler
+
+	RequestAccessorFnURL    core.RequestAccessorFnURL
+	FunctionURLProxyHandler *core.FunctionURLProxyHandler
 
 	Echo *echo.Echo
 }
@@ -25,38 +48,125 @@ type EchoLambda struct {
 // Receives an initialized *echo.Echo object - normally created with echo.New().
 // It returns the initialized instance of the EchoLambda object.
 func New(e *echo.Echo) *EchoLambda {
-	return &EchoLambda{Echo: e}
+	l := &EchoLambda{Echo: e}
+	e.Pre(setRequestContext)
+	e.Use(setMatchedRoute)
+	l.APIGatewayProxyHandler = core.NewAPIGatewayProxyHandler(&l.RequestAccessor, http.HandlerFunc(l.serveHTTP))
+	l.APIGatewayV2ProxyHandler = core.NewAPIGatewayV2ProxyHandler(&l.RequestAccessorV2, http.HandlerFunc(l.serveHTTP))
+	l.ALBProxyHandler = core.NewALBProxyHandler(&l.RequestAccessorALB, http.HandlerFunc(l.serveHTTP))
+	l.FunctionURLProxyHandler = core.NewFunctionURLProxyHandler(&l.RequestAccessorFnURL, http.HandlerFunc(l.serveHTTP))
+	return l
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
 // object, and sends it to the echo.Echo for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (e *EchoLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	echoRequest, err := e.ProxyEventToHTTPRequest(req)
-	return e.proxyInternal(echoRequest, err)
+	return e.APIGatewayProxyHandler.Proxy(req)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
 // transforms them into an http.Request object, and sends it to the echo.Echo for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (e *EchoLambda) ProxyWithContext(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	echoRequest, err := e.EventToRequestWithContext(ctx, req)
-	return e.proxyInternal(echoRequest, err)
+	return e.APIGatewayProxyHandler.ProxyWithContext(ctx, req)
+}
+
+// ProxyV2 receives an API Gateway HTTP API (v2) event, transforms it into an
+// http.Request object, and sends it to the echo.Echo for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (e *EchoLambda) ProxyV2(req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return e.APIGatewayV2ProxyHandler.Proxy(req)
+}
+
+// ProxyWithContextV2 receives context and an API Gateway HTTP API (v2) event,
+// transforms them into an http.Request object, and sends it to the echo.Echo for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (e *EchoLambda) ProxyWithContextV2(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return e.APIGatewayV2ProxyHandler.ProxyWithContext(ctx, req)
 }
 
-func (e *EchoLambda) proxyInternal(req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
+// ProxyALB receives an ALB target group event, transforms it into an
+// http.Request object, and sends it to the echo.Echo for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (e *EchoLambda) ProxyALB(req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return e.ALBProxyHandler.Proxy(req)
+}
 
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+// ProxyWithContextALB receives context and an ALB target group event,
+// transforms them into an http.Request object, and sends it to the echo.Echo for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (e *EchoLambda) ProxyWithContextALB(ctx context.Context, req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return e.ALBProxyHandler.ProxyWithContext(ctx, req)
+}
+
+// ProxyFunctionURL receives a Function URL event, transforms it into an
+// http.Request object, and sends it to the echo.Echo for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (e *EchoLambda) ProxyFunctionURL(req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return e.FunctionURLProxyHandler.Proxy(req)
+}
+
+// ProxyWithContextFunctionURL receives context and a Function URL event,
+// transforms them into an http.Request object, and sends it to the echo.Echo for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (e *EchoLambda) ProxyWithContextFunctionURL(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return e.FunctionURLProxyHandler.ProxyWithContext(ctx, req)
+}
+
+// serveHTTP looks up the Echo field on every request so that replacing
+// the exported field after New is still honored.
+func (e *EchoLambda) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	e.Echo.ServeHTTP(w, req.WithContext(core.WithMatchedRoute(req.Context(), nil)))
+}
+
+// GetStageVariables returns the API Gateway stage variables of the request
+// handled by the echo.Context, or nil if the event did not carry any.
+func GetStageVariables(c echo.Context) map[string]string {
+	return core.GetStageVariables(c.Request().Context())
+}
+
+// GetAPIGatewayContext returns the API Gateway request context, including the
+// authorizer data, of the request handled by the echo.Context. It is only
+// available for events sent through ProxyWithContext.
+func GetAPIGatewayContext(c echo.Context) (events.APIGatewayProxyRequestContext, bool) {
+	if apiGwContext, ok := c.Get(APIGatewayContextKey).(events.APIGatewayProxyRequestContext); ok {
+		return apiGwContext, true
 	}
+	return core.GetAPIGatewayContextFromContext(c.Request().Context())
+}
 
-	respWriter := core.NewProxyResponseWriter()
-	e.Echo.ServeHTTP(http.ResponseWriter(respWriter), req)
+// GetStageVars returns the API Gateway stage variables of the request
+// handled by the echo.Context. They are only available for events sent
+// through ProxyWithContext.
+func GetStageVars(c echo.Context) (map[string]string, bool) {
+	if stageVars, ok := c.Get(StageVarsKey).(map[string]string); ok {
+		return stageVars, true
+	}
+	return core.GetStageVarsFromContext(c.Request().Context())
+}
 
-	proxyResponse, err := respWriter.GetProxyResponse()
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
+// setRequestContext copies the API Gateway request context and stage
+// variables from the request context to the echo.Context.
+func setRequestContext(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		if apiGwContext, ok := core.GetAPIGatewayContextFromContext(ctx); ok {
+			c.Set(APIGatewayContextKey, apiGwContext)
+		}
+		if stageVars, ok := core.GetStageVarsFromContext(ctx); ok {
+			c.Set(StageVarsKey, stageVars)
+		}
+		return next(c)
 	}
+}
 
-	return proxyResponse, nil
+// setMatchedRoute records the path of the route echo matched, so
+// core.GetMatchedRoute returns it. Echo runs the middleware added with Use
+// after routing, also for routes added before New was called.
+func setMatchedRoute(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		core.SetMatchedRoute(c.Request().Context(), c.Path())
+		return next(c)
+	}
 }
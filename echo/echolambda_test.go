@@ -1,10 +1,13 @@
 package echoadapter_test
 
 import (
+	"context"
 	"log"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
 	echoadapter "github.com/awslabs/aws-lambda-go-api-proxy/echo"
+	"github.com/awslabs/aws-lambda-go-api-proxy/proxytest"
 	"github.com/labstack/echo/v4"
 
 	. "github.com/onsi/ginkgo"
@@ -34,4 +37,235 @@ var _ = Describe("EchoLambda tests", func() {
 			Expect(resp.StatusCode).To(Equal(200))
 		})
 	})
+
+	Context("Stage variables", func() {
+		It("Exposes the stage variables to the handler", func() {
+			e := echo.New()
+			e.GET("/ping", func(c echo.Context) error {
+				return c.String(200, echoadapter.GetStageVariables(c)["feature"])
+			})
+
+			adapter := echoadapter.New(e)
+
+			req := events.APIGatewayProxyRequest{
+				Path:           "/ping",
+				HTTPMethod:     "GET",
+				StageVariables: map[string]string{"feature": "enabled"},
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("enabled"))
+		})
+	})
+
+	Context("API Gateway context", func() {
+		It("Exposes the authorizer data and stage variables to the handler", func() {
+			e := echo.New()
+			e.GET("/ping", func(c echo.Context) error {
+				apiGwContext, ok := echoadapter.GetAPIGatewayContext(c)
+				Expect(ok).To(BeTrue())
+				stageVars, ok := echoadapter.GetStageVars(c)
+				Expect(ok).To(BeTrue())
+				Expect(c.Get(echoadapter.StageVarsKey)).To(Equal(stageVars))
+				return c.String(200, apiGwContext.Authorizer["principalId"].(string)+" "+stageVars["env"])
+			})
+
+			adapter := echoadapter.New(e)
+
+			req := events.APIGatewayProxyRequest{
+				Path:           "/ping",
+				HTTPMethod:     "GET",
+				StageVariables: map[string]string{"env": "prod"},
+				RequestContext: events.APIGatewayProxyRequestContext{
+					Authorizer: map[string]interface{}{"principalId": "user-1"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("user-1 prod"))
+		})
+	})
+
+	Context("Error handling", func() {
+		It("Runs the echo error handler for handler errors", func() {
+			e := echo.New()
+			e.GET("/missing", func(c echo.Context) error {
+				return echo.NewHTTPError(404, "nope")
+			})
+
+			adapter := echoadapter.New(e)
+
+			req := events.APIGatewayProxyRequest{
+				Path:       "/missing",
+				HTTPMethod: "GET",
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(404))
+			Expect(resp.Body).To(MatchJSON(`{"message": "nope"}`))
+		})
+
+		It("Captures the output of a custom error handler", func() {
+			e := echo.New()
+			e.HTTPErrorHandler = func(err error, c echo.Context) {
+				if he, ok := err.(*echo.HTTPError); ok {
+					c.String(he.Code, "custom: "+he.Message.(string))
+				}
+			}
+			e.GET("/missing", func(c echo.Context) error {
+				return echo.NewHTTPError(404, "nope")
+			})
+
+			adapter := echoadapter.New(e)
+
+			req := events.APIGatewayProxyRequest{
+				Path:       "/missing",
+				HTTPMethod: "GET",
+			}
+
+			resp, err := adapter.Proxy(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(404))
+			Expect(resp.Body).To(Equal("custom: nope"))
+		})
+	})
+
+	Context("Matched route", func() {
+		It("Exposes the route template to the handler", func() {
+			e := echo.New()
+			e.GET("/users/:id", func(c echo.Context) error {
+				route, _ := core.GetMatchedRoute(c.Request().Context())
+				return c.String(200, route)
+			})
+
+			adapter := echoadapter.New(e)
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{Path: "/users/42", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("/users/:id"))
+		})
+	})
+
+	Context("HTTP API v2 request", func() {
+		It("Proxies the event correctly", func() {
+			e := echo.New()
+			e.GET("/ping", func(c echo.Context) error {
+				session, _ := c.Cookie("session")
+				return c.String(200, c.QueryParam("name")+" "+session.Value+" "+c.Request().Header.Get("X-Tenant"))
+			})
+
+			adapter := echoadapter.New(e)
+
+			req := events.APIGatewayV2HTTPRequest{
+				RawPath:        "/ping",
+				RawQueryString: "name=go",
+				Cookies:        []string{"session=abc"},
+				Headers:        map[string]string{"x-tenant": "acme"},
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET", Path: "/ping"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContextV2(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.ProxyV2(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Application Load Balancer request", func() {
+		It("Proxies the event correctly", func() {
+			e := echo.New()
+			e.GET("/ping", func(c echo.Context) error {
+				session, _ := c.Cookie("session")
+				return c.String(200, c.QueryParam("name")+" "+session.Value+" "+c.Request().Header.Get("X-Tenant"))
+			})
+
+			adapter := echoadapter.New(e)
+
+			req := events.ALBTargetGroupRequest{
+				HTTPMethod:                      "GET",
+				Path:                            "/ping",
+				MultiValueQueryStringParameters: map[string][]string{"name": {"go"}},
+				MultiValueHeaders: map[string][]string{
+					"cookie":   {"session=abc"},
+					"x-tenant": {"acme"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContextALB(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.StatusDescription).To(Equal("200 OK"))
+			Expect(resp.MultiValueHeaders).To(HaveKey("Content-Type"))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.ProxyALB(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Function URL request", func() {
+		It("Proxies the event correctly", func() {
+			e := echo.New()
+			e.GET("/ping", func(c echo.Context) error {
+				session, _ := c.Cookie("session")
+				return c.String(200, c.QueryParam("name")+" "+session.Value+" "+c.Request().Header.Get("X-Tenant"))
+			})
+
+			adapter := echoadapter.New(e)
+
+			req := events.LambdaFunctionURLRequest{
+				RawPath:        "/ping",
+				RawQueryString: "name=go",
+				Cookies:        []string{"session=abc"},
+				Headers:        map[string]string{"x-tenant": "acme"},
+				RequestContext: events.LambdaFunctionURLRequestContext{
+					HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET", Path: "/ping"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContextFunctionURL(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.ProxyFunctionURL(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Concurrent requests", func() {
+		It("Keeps the state of simultaneous events apart", func() {
+			e := echo.New()
+			e.GET("/items/:id", func(c echo.Context) error {
+				return c.String(200, c.Param("id"))
+			})
+
+			adapter := echoadapter.New(e)
+
+			Expect(proxytest.ProxyConcurrently(100, adapter.ProxyWithContext, adapter.ProxyWithContextV2)).To(BeEmpty())
+		})
+	})
 })
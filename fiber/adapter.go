@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/awslabs/aws-lambda-go-api-proxy/core"
@@ -18,9 +19,23 @@ import (
 
 // FiberLambda makes it easy to send API Gateway proxy events to a fiber.App.
 // The library transforms the proxy event into an HTTP request and then
-// creates a proxy response object from the *fiber.Ctx
+// creates a proxy response object from the *fiber.Ctx.
+// The embedded RequestAccessor and APIGatewayProxyHandler configure REST API
+// (v1) events, the exported fields configure HTTP API (v2), Application Load
+// Balancer and Function URL events.
 type FiberLambda struct {
 	core.RequestAccessor
+	*core.APIGatewayProxyHandler
+
+	RequestAccessorV2        core.RequestAccessorV2
+	APIGatewayV2ProxyHandler *core.APIGatewayV2ProxyHandler
+
+	RequestAccessorALB core.RequestAccessorALB
+	ALBProxyHandler    *core.ALBProxyHandler
+
+	RequestAccessorFnURL    core.RequestAccessorFnURL
+	FunctionURLProxyHandler *core.FunctionURLProxyHandler
+
 	app *fiber.App
 }
 
@@ -28,42 +43,86 @@ type FiberLambda struct {
 // Receives an initialized *fiber.App object - normally created with fiber.New().
 // It returns the initialized instance of the FiberLambda object.
 func New(app *fiber.App) *FiberLambda {
-	return &FiberLambda{
+	f := &FiberLambda{
 		app: app,
 	}
+	f.APIGatewayProxyHandler = core.NewAPIGatewayProxyHandler(&f.RequestAccessor, http.HandlerFunc(f.adaptor))
+	f.APIGatewayV2ProxyHandler = core.NewAPIGatewayV2ProxyHandler(&f.RequestAccessorV2, http.HandlerFunc(f.adaptor))
+	f.ALBProxyHandler = core.NewALBProxyHandler(&f.RequestAccessorALB, http.HandlerFunc(f.adaptor))
+	f.FunctionURLProxyHandler = core.NewFunctionURLProxyHandler(&f.RequestAccessorFnURL, http.HandlerFunc(f.adaptor))
+	return f
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
 // object, and sends it to the fiber.App for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (f *FiberLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	fiberRequest, err := f.ProxyEventToHTTPRequest(req)
-	return f.proxyInternal(fiberRequest, err)
+	return f.APIGatewayProxyHandler.Proxy(req)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
 // transforms them into an http.Request object, and sends it to the echo.Echo for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (f *FiberLambda) ProxyWithContext(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	fiberRequest, err := f.EventToRequestWithContext(ctx, req)
-	return f.proxyInternal(fiberRequest, err)
+	return f.APIGatewayProxyHandler.ProxyWithContext(ctx, req)
 }
 
-func (f *FiberLambda) proxyInternal(req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
+// ProxyV2 receives an API Gateway HTTP API (v2) event, transforms it into an
+// http.Request object, and sends it to the fiber.App for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (f *FiberLambda) ProxyV2(req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return f.APIGatewayV2ProxyHandler.Proxy(req)
+}
 
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
-	}
+// ProxyWithContextV2 receives context and an API Gateway HTTP API (v2) event,
+// transforms them into an http.Request object, and sends it to the fiber.App for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (f *FiberLambda) ProxyWithContextV2(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return f.APIGatewayV2ProxyHandler.ProxyWithContext(ctx, req)
+}
 
-	resp := core.NewProxyResponseWriter()
-	f.adaptor(resp, req)
+// ProxyALB receives an ALB target group event, transforms it into an
+// http.Request object, and sends it to the fiber.App for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (f *FiberLambda) ProxyALB(req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return f.ALBProxyHandler.Proxy(req)
+}
 
-	proxyResponse, err := resp.GetProxyResponse()
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
-	}
+// ProxyWithContextALB receives context and an ALB target group event,
+// transforms them into an http.Request object, and sends it to the fiber.App for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (f *FiberLambda) ProxyWithContextALB(ctx context.Context, req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return f.ALBProxyHandler.ProxyWithContext(ctx, req)
+}
 
-	return proxyResponse, nil
+// ProxyFunctionURL receives a Function URL event, transforms it into an
+// http.Request object, and sends it to the fiber.App for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (f *FiberLambda) ProxyFunctionURL(req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return f.FunctionURLProxyHandler.Proxy(req)
+}
+
+// ProxyWithContextFunctionURL receives context and a Function URL event,
+// transforms them into an http.Request object, and sends it to the fiber.App for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (f *FiberLambda) ProxyWithContextFunctionURL(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return f.FunctionURLProxyHandler.ProxyWithContext(ctx, req)
+}
+
+// requestContextKey is the user value of the fasthttp.RequestCtx holding
+// the context of the http.Request, see GetRequestContext.
+const requestContextKey = "aws-lambda-go-api-proxy.context"
+
+// GetRequestContext returns the context of the request built from the
+// Lambda event, for the context helpers of the core package such as
+// core.GetAPIGatewayContextFromContext. Fiber handlers receive a
+// fasthttp.RequestCtx, which does not carry it. Returns
+// context.Background() for requests that were not sent by a FiberLambda.
+func GetRequestContext(c *fiber.Ctx) context.Context {
+	if ctx, ok := c.Context().UserValue(requestContextKey).(context.Context); ok {
+		return ctx
+	}
+	return context.Background()
 }
 
 func (f *FiberLambda) adaptor(w http.ResponseWriter, r *http.Request) {
@@ -89,22 +148,17 @@ func (f *FiberLambda) adaptor(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	remoteAddr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr)
-	if err != nil {
-		http.Error(w, utils.StatusMessage(fiber.StatusInternalServerError), fiber.StatusInternalServerError)
-		return
-	}
-
 	// New fasthttp Ctx
 	var fctx fasthttp.RequestCtx
-	fctx.Init(req, remoteAddr, nil)
+	fctx.Init(req, remoteAddr(r), nil)
+	fctx.SetUserValue(requestContextKey, r.Context())
 
 	// Pass RequestCtx to Fiber router
 	f.app.Handler()(&fctx)
 
-	// Set response headers
+	// Set response headers, repeated ones such as Set-Cookie included
 	fctx.Response.Header.VisitAll(func(k, v []byte) {
-		w.Header().Set(utils.UnsafeString(k), utils.UnsafeString(v))
+		w.Header().Add(string(k), string(v))
 	})
 
 	// Set response statuscode
@@ -113,3 +167,23 @@ func (f *FiberLambda) adaptor(w http.ResponseWriter, r *http.Request) {
 	// Set response body
 	_, _ = w.Write(fctx.Response.Body())
 }
+
+// remoteAddr returns the address of the client. The accessors set the
+// RemoteAddr of Function URL and ALB requests to the bare source IP and
+// leave it empty for API Gateway requests, whose source IP is read from
+// the request context instead.
+func remoteAddr(r *http.Request) *net.TCPAddr {
+	host, port := r.RemoteAddr, 0
+	if h, p, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		host = h
+		port, _ = strconv.Atoi(p)
+	}
+	if host == "" {
+		if apiGwContext, ok := core.GetAPIGatewayContextFromContext(r.Context()); ok {
+			host = apiGwContext.Identity.SourceIP
+		} else if apiGwContext, ok := core.GetAPIGatewayV2ContextFromContext(r.Context()); ok {
+			host = apiGwContext.HTTP.SourceIP
+		}
+	}
+	return &net.TCPAddr{IP: net.ParseIP(host), Port: port}
+}
@@ -5,7 +5,9 @@ import (
 	"log"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
 	fiberadaptor "github.com/awslabs/aws-lambda-go-api-proxy/fiber"
+	"github.com/awslabs/aws-lambda-go-api-proxy/proxytest"
 	"github.com/gofiber/fiber/v2"
 
 	. "github.com/onsi/ginkgo"
@@ -40,4 +42,158 @@ var _ = Describe("FiberLambda tests", func() {
 			Expect(resp.StatusCode).To(Equal(200))
 		})
 	})
+
+	Context("HTTP API v2 request", func() {
+		It("Proxies the event correctly", func() {
+			app := fiber.New()
+			app.Get("/ping", func(c *fiber.Ctx) error {
+				return c.SendString(c.Query("name") + " " + c.Cookies("session") + " " + c.Get("X-Tenant"))
+			})
+
+			adapter := fiberadaptor.New(app)
+
+			req := events.APIGatewayV2HTTPRequest{
+				RawPath:        "/ping",
+				RawQueryString: "name=go",
+				Cookies:        []string{"session=abc"},
+				Headers:        map[string]string{"x-tenant": "acme"},
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET", Path: "/ping"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContextV2(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.ProxyV2(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Application Load Balancer request", func() {
+		It("Proxies the event correctly", func() {
+			app := fiber.New()
+			app.Get("/ping", func(c *fiber.Ctx) error {
+				return c.SendString(c.Query("name") + " " + c.Cookies("session") + " " + c.Get("X-Tenant"))
+			})
+
+			adapter := fiberadaptor.New(app)
+
+			req := events.ALBTargetGroupRequest{
+				HTTPMethod:                      "GET",
+				Path:                            "/ping",
+				MultiValueQueryStringParameters: map[string][]string{"name": {"go"}},
+				MultiValueHeaders: map[string][]string{
+					"cookie":   {"session=abc"},
+					"x-tenant": {"acme"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContextALB(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.StatusDescription).To(Equal("200 OK"))
+			Expect(resp.MultiValueHeaders).To(HaveKey("Content-Type"))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.ProxyALB(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Function URL request", func() {
+		It("Proxies the event correctly", func() {
+			app := fiber.New()
+			app.Get("/ping", func(c *fiber.Ctx) error {
+				return c.SendString(c.Query("name") + " " + c.Cookies("session") + " " + c.Get("X-Tenant"))
+			})
+
+			adapter := fiberadaptor.New(app)
+
+			req := events.LambdaFunctionURLRequest{
+				RawPath:        "/ping",
+				RawQueryString: "name=go",
+				Cookies:        []string{"session=abc"},
+				Headers:        map[string]string{"x-tenant": "acme"},
+				RequestContext: events.LambdaFunctionURLRequestContext{
+					HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET", Path: "/ping"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContextFunctionURL(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.ProxyFunctionURL(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Request context and client address", func() {
+		app := fiber.New()
+		app.Get("/whoami", func(c *fiber.Ctx) error {
+			stage := "none"
+			if apiGwContext, ok := core.GetAPIGatewayContextFromContext(fiberadaptor.GetRequestContext(c)); ok {
+				stage = apiGwContext.Stage
+			}
+			c.Cookie(&fiber.Cookie{Name: "session", Value: "abc"})
+			c.Cookie(&fiber.Cookie{Name: "theme", Value: "dark"})
+			return c.SendString(c.IP() + " " + stage)
+		})
+		adapter := fiberadaptor.New(app)
+
+		It("Exposes the request context and source IP of REST API events", func() {
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+				Path:       "/whoami",
+				HTTPMethod: "GET",
+				RequestContext: events.APIGatewayProxyRequestContext{
+					Stage:    "prod",
+					Identity: events.APIGatewayRequestIdentity{SourceIP: "192.0.2.1"},
+				},
+			})
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("192.0.2.1 prod"))
+			Expect(resp.MultiValueHeaders["Set-Cookie"]).To(HaveLen(2))
+		})
+
+		It("Serves Function URL events with a source IP", func() {
+			resp, err := adapter.ProxyWithContextFunctionURL(context.Background(), events.LambdaFunctionURLRequest{
+				RawPath: "/whoami",
+				RequestContext: events.LambdaFunctionURLRequestContext{
+					HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET", Path: "/whoami", SourceIP: "198.51.100.7"},
+				},
+			})
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("198.51.100.7 none"))
+			Expect(resp.Cookies).To(HaveLen(2))
+		})
+	})
+
+	Context("Concurrent requests", func() {
+		It("Keeps the state of simultaneous events apart", func() {
+			app := fiber.New()
+			app.Get("/items/:id", func(c *fiber.Ctx) error {
+				return c.SendString(c.Params("id"))
+			})
+
+			adapter := fiberadaptor.New(app)
+
+			Expect(proxytest.ProxyConcurrently(100, adapter.ProxyWithContext, adapter.ProxyWithContextV2)).To(BeEmpty())
+		})
+	})
 })
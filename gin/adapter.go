@@ -5,7 +5,10 @@ package ginadapter
 
 import (
 	"context"
+	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/awslabs/aws-lambda-go-api-proxy/core"
@@ -14,49 +17,344 @@ import (
 
 // GinLambda makes it easy to send API Gateway proxy events to a Gin
 // Engine. The library transforms the proxy event into an HTTP request and then
-// creates a proxy response object from the http.ResponseWriter
+// creates a proxy response object from the http.ResponseWriter.
+// Besides API Gateway REST API (v1) events, the same engine can serve HTTP API
+// (v2), Application Load Balancer, Function URL and Lambda@Edge events through
+// the V2, ALB, FunctionURL and Edge proxy methods. The embedded RequestAccessor and
+// APIGatewayProxyHandler configure v1 events, the exported fields configure
+// the other event types.
 type GinLambda struct {
 	core.RequestAccessor
+	*core.APIGatewayProxyHandler
 
-	ginEngine *gin.Engine
+	RequestAccessorV2        core.RequestAccessorV2
+	APIGatewayV2ProxyHandler *core.APIGatewayV2ProxyHandler
+
+	RequestAccessorALB core.RequestAccessorALB
+	ALBProxyHandler    *core.ALBProxyHandler
+
+	RequestAccessorFnURL        core.RequestAccessorFnURL
+	FunctionURLProxyHandler     *core.FunctionURLProxyHandler
+	FunctionURLStreamingHandler *core.FunctionURLStreamingHandler
+
+	RequestAccessorEdge    core.RequestAccessorEdge
+	LambdaEdgeProxyHandler *core.LambdaEdgeProxyHandler
+
+	ginEngine      *gin.Engine
+	handlerTimeout time.Duration
+	slots          chan struct{}
 }
 
 // New creates a new instance of the GinLambda object.
 // Receives an initialized *gin.Engine object - normally created with gin.Default().
 // It returns the initialized instance of the GinLambda object.
 func New(gin *gin.Engine) *GinLambda {
-	return &GinLambda{ginEngine: gin}
+	g := &GinLambda{ginEngine: gin}
+	handler := http.HandlerFunc(g.serveHTTP)
+	g.APIGatewayProxyHandler = core.NewAPIGatewayProxyHandler(&g.RequestAccessor, handler)
+	g.APIGatewayV2ProxyHandler = core.NewAPIGatewayV2ProxyHandler(&g.RequestAccessorV2, handler)
+	g.ALBProxyHandler = core.NewALBProxyHandler(&g.RequestAccessorALB, handler)
+	g.FunctionURLProxyHandler = core.NewFunctionURLProxyHandler(&g.RequestAccessorFnURL, handler)
+	g.FunctionURLStreamingHandler = core.NewFunctionURLStreamingHandler(&g.RequestAccessorFnURL, handler)
+	g.LambdaEdgeProxyHandler = core.NewLambdaEdgeProxyHandler(&g.RequestAccessorEdge, handler)
+	return g
+}
+
+// serveHTTP sends the request to the gin.Engine. Gin adds middleware to
+// routes when they are registered, so the route template returned by
+// core.GetMatchedRoute is looked up in the routes of the engine instead of
+// being recorded by a middleware.
+func (g *GinLambda) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	method, path := r.Method, r.URL.Path
+	r = r.WithContext(core.WithMatchedRoute(r.Context(), func(context.Context) (string, bool) {
+		return matchRoute(g.ginEngine.Routes(), method, path)
+	}))
+	g.ginEngine.ServeHTTP(w, r)
+}
+
+// matchRoute returns the path template of the route matching the request
+// method and path. Gin does not allow conflicting routes, so at most one
+// template of a method matches a path.
+func matchRoute(routes gin.RoutesInfo, method, path string) (string, bool) {
+	for _, route := range routes {
+		if route.Method == method && routeMatches(route.Path, path) {
+			return route.Path, true
+		}
+	}
+	return "", false
+}
+
+func routeMatches(template, path string) bool {
+	templateSegments := strings.Split(template, "/")
+	pathSegments := strings.Split(path, "/")
+	for i, segment := range templateSegments {
+		if strings.HasPrefix(segment, "*") {
+			return i <= len(pathSegments)
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if strings.HasPrefix(segment, ":") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+	return len(templateSegments) == len(pathSegments)
+}
+
+// SetHandlerTimeout limits the time the proxy methods wait for the gin.Engine
+// to handle an event. It covers ProxyRaw and the Proxy and ProxyWithContext
+// methods of every event type, ProxyStream and ProxyWithWriter aside. If the
+// handler doesn't complete within d, a 504 Gateway Timeout response is
+// returned instead of letting the invocation run to the Lambda timeout.
+// The handler goroutine keeps running until it returns. With the
+// ProxyWithContext methods and ProxyRaw the request context is canceled when
+// the timeout expires, handlers should watch it to stop their work. A timeout
+// of 0 disables the limit. With SetMaxConcurrent, an event that timed out
+// counts against the limit until its handler returns.
+func (g *GinLambda) SetHandlerTimeout(d time.Duration) {
+	g.handlerTimeout = d
+}
+
+// SetMaxConcurrent limits the number of events the proxy methods handle at
+// the same time, for processes that receive events concurrently, such as a
+// custom runtime loop calling ProxyRaw. The limit is shared by every event
+// type and covers ProxyRaw and the Proxy and ProxyWithContext methods of
+// all of them, ProxyStream and ProxyWithWriter aside. Events received while n
+// are being handled are answered with a 503 Service Unavailable without
+// running the gin.Engine. Call it before the first event is received. A limit
+// of 0 disables it.
+func (g *GinLambda) SetMaxConcurrent(n int) {
+	g.slots = nil
+	if n > 0 {
+		g.slots = make(chan struct{}, n)
+	}
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
 // object, and sends it to the gin.Engine for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (g *GinLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	ginRequest, err := g.ProxyEventToHTTPRequest(req)
-	return g.proxyInternal(ginRequest, err)
+	return withConcurrencyLimit(g, g.APIGatewayProxyHandler, func(release func()) (events.APIGatewayProxyResponse, error) {
+		return withHandlerTimeout(g, g.APIGatewayProxyHandler, context.Background(), release, func(context.Context) (events.APIGatewayProxyResponse, error) {
+			return g.APIGatewayProxyHandler.Proxy(req)
+		})
+	})
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
 // transforms them into an http.Request object, and sends it to the gin.Engine for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (g *GinLambda) ProxyWithContext(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	ginRequest, err := g.EventToRequestWithContext(ctx, req)
-	return g.proxyInternal(ginRequest, err)
+	return withConcurrencyLimit(g, g.APIGatewayProxyHandler, func(release func()) (events.APIGatewayProxyResponse, error) {
+		return withHandlerTimeout(g, g.APIGatewayProxyHandler, ctx, release, func(ctx context.Context) (events.APIGatewayProxyResponse, error) {
+			return g.APIGatewayProxyHandler.ProxyWithContext(ctx, req)
+		})
+	})
 }
 
-func (g *GinLambda) proxyInternal(req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
+// ProxyRaw receives context and a JSON encoded API Gateway proxy event, for
+// example the payload read from the Runtime API by a custom runtime, and
+// sends it through ProxyWithContext, so the concurrency limit and the
+// handler timeout apply.
+// It returns the JSON encoded response object.
+func (g *GinLambda) ProxyRaw(ctx context.Context, payload []byte) ([]byte, error) {
+	return g.APIGatewayProxyHandler.ProxyRawWith(ctx, payload, g.ProxyWithContext)
+}
 
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+// withConcurrencyLimit runs proxy if fewer events than the limit set with
+// SetMaxConcurrent are being handled, and returns a 503 Service Unavailable
+// response built by the proxy handler of the event type otherwise. proxy
+// must call release once the handler returned, which frees the slot of the
+// event.
+func withConcurrencyLimit[ReqT, RespT any](g *GinLambda, p *core.ProxyHandler[ReqT, RespT], proxy func(release func()) (RespT, error)) (RespT, error) {
+	if g.slots == nil {
+		return proxy(func() {})
 	}
 
-	respWriter := core.NewProxyResponseWriter()
-	g.ginEngine.ServeHTTP(http.ResponseWriter(respWriter), req)
+	select {
+	case g.slots <- struct{}{}:
+		return proxy(func() { <-g.slots })
+	default:
+		return p.StatusResponse(http.StatusServiceUnavailable)
+	}
+}
+
+// withHandlerTimeout runs proxy and returns its response, or a 504 Gateway
+// Timeout response built by the proxy handler of the event type if it doesn't
+// return within the handler timeout. release is called when proxy returns,
+// also after a timeout, so a handler that overran keeps its concurrency slot
+// until it completes.
+func withHandlerTimeout[ReqT, RespT any](g *GinLambda, p *core.ProxyHandler[ReqT, RespT], ctx context.Context, release func(), proxy func(ctx context.Context) (RespT, error)) (RespT, error) {
+	if g.handlerTimeout <= 0 {
+		defer release()
+		return proxy(ctx)
+	}
 
-	proxyResponse, err := respWriter.GetProxyResponse()
+	ctx, cancel := context.WithTimeout(ctx, g.handlerTimeout)
+	defer cancel()
+
+	type result struct {
+		resp RespT
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer release()
+		resp, err := proxy(ctx)
+		done <- result{resp, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.resp, res.err
+	case <-ctx.Done():
+		log.Printf("Handler did not complete within %v\n", g.handlerTimeout)
+		return p.StatusResponse(http.StatusGatewayTimeout)
+	}
+}
+
+// ProxyV2 receives an API Gateway HTTP API (v2) event, transforms it into an
+// http.Request object, and sends it to the gin.Engine for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (g *GinLambda) ProxyV2(req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return withConcurrencyLimit(g, g.APIGatewayV2ProxyHandler, func(release func()) (events.APIGatewayV2HTTPResponse, error) {
+		return withHandlerTimeout(g, g.APIGatewayV2ProxyHandler, context.Background(), release, func(context.Context) (events.APIGatewayV2HTTPResponse, error) {
+			return g.APIGatewayV2ProxyHandler.Proxy(req)
+		})
+	})
+}
+
+// ProxyWithContextV2 receives context and an API Gateway HTTP API (v2) event,
+// transforms them into an http.Request object, and sends it to the gin.Engine for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (g *GinLambda) ProxyWithContextV2(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return withConcurrencyLimit(g, g.APIGatewayV2ProxyHandler, func(release func()) (events.APIGatewayV2HTTPResponse, error) {
+		return withHandlerTimeout(g, g.APIGatewayV2ProxyHandler, ctx, release, func(ctx context.Context) (events.APIGatewayV2HTTPResponse, error) {
+			return g.APIGatewayV2ProxyHandler.ProxyWithContext(ctx, req)
+		})
+	})
+}
+
+// ProxyALB receives an ALB target group event, transforms it into an
+// http.Request object, and sends it to the gin.Engine for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (g *GinLambda) ProxyALB(req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return withConcurrencyLimit(g, g.ALBProxyHandler, func(release func()) (events.ALBTargetGroupResponse, error) {
+		return withHandlerTimeout(g, g.ALBProxyHandler, context.Background(), release, func(context.Context) (events.ALBTargetGroupResponse, error) {
+			return g.ALBProxyHandler.Proxy(req)
+		})
+	})
+}
+
+// ProxyWithContextALB receives context and an ALB target group event,
+// transforms them into an http.Request object, and sends it to the gin.Engine for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (g *GinLambda) ProxyWithContextALB(ctx context.Context, req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return withConcurrencyLimit(g, g.ALBProxyHandler, func(release func()) (events.ALBTargetGroupResponse, error) {
+		return withHandlerTimeout(g, g.ALBProxyHandler, ctx, release, func(ctx context.Context) (events.ALBTargetGroupResponse, error) {
+			return g.ALBProxyHandler.ProxyWithContext(ctx, req)
+		})
+	})
+}
+
+// ProxyFunctionURL receives a Function URL event, transforms it into an
+// http.Request object, and sends it to the gin.Engine for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (g *GinLambda) ProxyFunctionURL(req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return withConcurrencyLimit(g, g.FunctionURLProxyHandler, func(release func()) (events.LambdaFunctionURLResponse, error) {
+		return withHandlerTimeout(g, g.FunctionURLProxyHandler, context.Background(), release, func(context.Context) (events.LambdaFunctionURLResponse, error) {
+			return g.FunctionURLProxyHandler.Proxy(req)
+		})
+	})
+}
+
+// ProxyWithContextFunctionURL receives context and a Function URL event,
+// transforms them into an http.Request object, and sends it to the gin.Engine for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (g *GinLambda) ProxyWithContextFunctionURL(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return withConcurrencyLimit(g, g.FunctionURLProxyHandler, func(release func()) (events.LambdaFunctionURLResponse, error) {
+		return withHandlerTimeout(g, g.FunctionURLProxyHandler, ctx, release, func(ctx context.Context) (events.LambdaFunctionURLResponse, error) {
+			return g.FunctionURLProxyHandler.ProxyWithContext(ctx, req)
+		})
+	})
+}
+
+// ProxyEdge receives the CloudFront event of a Lambda@Edge viewer request or
+// origin request trigger, transforms it into an http.Request object, and
+// sends it to the gin.Engine for routing.
+// It returns a CloudFront response object generated from the http.ResponseWriter.
+func (g *GinLambda) ProxyEdge(req core.CloudFrontEvent) (core.CloudFrontResponse, error) {
+	return withConcurrencyLimit(g, g.LambdaEdgeProxyHandler, func(release func()) (core.CloudFrontResponse, error) {
+		return withHandlerTimeout(g, g.LambdaEdgeProxyHandler, context.Background(), release, func(context.Context) (core.CloudFrontResponse, error) {
+			return g.LambdaEdgeProxyHandler.Proxy(req)
+		})
+	})
+}
+
+// ProxyWithContextEdge receives context and the CloudFront event of a
+// Lambda@Edge trigger, transforms them into an http.Request object, and sends
+// it to the gin.Engine for routing.
+// It returns a CloudFront response object generated from the http.ResponseWriter.
+func (g *GinLambda) ProxyWithContextEdge(ctx context.Context, req core.CloudFrontEvent) (core.CloudFrontResponse, error) {
+	return withConcurrencyLimit(g, g.LambdaEdgeProxyHandler, func(release func()) (core.CloudFrontResponse, error) {
+		return withHandlerTimeout(g, g.LambdaEdgeProxyHandler, ctx, release, func(ctx context.Context) (core.CloudFrontResponse, error) {
+			return g.LambdaEdgeProxyHandler.ProxyWithContext(ctx, req)
+		})
+	})
+}
+
+// ProxyStream receives context and a Function URL event, transforms them into
+// an http.Request object, and sends it to the gin.Engine for routing.
+// It returns a streaming response whose body carries what the handler writes
+// while it runs, for Function URLs with the RESPONSE_STREAM invoke mode.
+func (g *GinLambda) ProxyStream(ctx context.Context, req events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error) {
+	return g.FunctionURLStreamingHandler.ProxyStream(ctx, req)
+}
+
+// ProxyWithWriter receives context and an API Gateway proxy event, transforms
+// them into an http.Request object, and sends it to the gin.Engine for routing
+// through the same pipeline as ProxyWithContext, see
+// core.ProxyHandler.ProxyWithWriter for the options that apply.
+// It returns the response writer populated by the handler, before
+// GetProxyResponse is called, so callers can inspect the status, headers and
+// body or finalize the response themselves. The handler timeout and the
+// concurrency limit don't apply.
+func (g *GinLambda) ProxyWithWriter(ctx context.Context, req events.APIGatewayProxyRequest) (*core.ProxyResponseWriter, error) {
+	w, err := g.APIGatewayProxyHandler.ProxyWithWriter(ctx, req)
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return nil, err
 	}
 
-	return proxyResponse, nil
+	proxyWriter, ok := w.(*core.ProxyResponseWriter)
+	if !ok {
+		return nil, core.NewLoggedError("Response writer factory returned a %T, not a *core.ProxyResponseWriter", w)
+	}
+	return proxyWriter, nil
+}
+
+// GetStageVariables returns the API Gateway stage variables of the request
+// handled by the gin.Context, or nil if the event did not carry any.
+func GetStageVariables(c *gin.Context) map[string]string {
+	return core.GetStageVariables(c.Request.Context())
+}
+
+// GetStage returns the API Gateway stage of the REST API (v1) or HTTP API
+// (v2) request handled by the gin.Context, such as prod or staging. Returns
+// false for other events and events received without a context, through
+// Proxy.
+func GetStage(c *gin.Context) (string, bool) {
+	ctx := c.Request.Context()
+	if apiGwContext, ok := core.GetAPIGatewayContextFromContext(ctx); ok {
+		return apiGwContext.Stage, apiGwContext.Stage != ""
+	}
+	if v2Context, ok := core.GetAPIGatewayV2ContextFromContext(ctx); ok {
+		return v2Context.Stage, v2Context.Stage != ""
+	}
+	return "", false
 }
@@ -2,10 +2,16 @@ package ginadapter_test
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
 	"log"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
 	ginadapter "github.com/awslabs/aws-lambda-go-api-proxy/gin"
+	"github.com/awslabs/aws-lambda-go-api-proxy/proxytest"
 	"github.com/gin-gonic/gin"
 
 	. "github.com/onsi/ginkgo"
@@ -42,4 +48,651 @@ var _ = Describe("GinLambda tests", func() {
 			Expect(resp.StatusCode).To(Equal(200))
 		})
 	})
+
+	Context("Stage variables", func() {
+		It("Exposes the stage variables to the handler", func() {
+			r := gin.Default()
+			r.GET("/ping", func(c *gin.Context) {
+				c.String(200, ginadapter.GetStageVariables(c)["feature"])
+			})
+
+			adapter := ginadapter.New(r)
+
+			req := events.APIGatewayProxyRequest{
+				Path:           "/ping",
+				HTTPMethod:     "GET",
+				StageVariables: map[string]string{"feature": "enabled"},
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("enabled"))
+		})
+	})
+
+	Context("Stage", func() {
+		It("Exposes the stage to the handler", func() {
+			r := gin.Default()
+			r.GET("/ping", func(c *gin.Context) {
+				stage, ok := ginadapter.GetStage(c)
+				if !ok {
+					stage = "none"
+				}
+				c.String(200, stage)
+			})
+
+			adapter := ginadapter.New(r)
+
+			req := events.APIGatewayProxyRequest{
+				Path:           "/ping",
+				HTTPMethod:     "GET",
+				RequestContext: events.APIGatewayProxyRequestContext{Stage: "staging"},
+			}
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("staging"))
+
+			reqV2 := events.APIGatewayV2HTTPRequest{
+				RawPath:        "/ping",
+				RequestContext: events.APIGatewayV2HTTPRequestContext{Stage: "prod", HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET"}},
+			}
+			respV2, err := adapter.ProxyWithContextV2(context.Background(), reqV2)
+			Expect(err).To(BeNil())
+			Expect(respV2.Body).To(Equal("prod"))
+
+			respALB, err := adapter.ProxyWithContextALB(context.Background(), events.ALBTargetGroupRequest{Path: "/ping", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(respALB.Body).To(Equal("none"))
+		})
+	})
+
+	Context("Raw JSON request", func() {
+		It("Returns the raw JSON response", func() {
+			r := gin.Default()
+			r.GET("/ping", func(c *gin.Context) {
+				c.String(200, "pong")
+			})
+
+			adapter := ginadapter.New(r)
+
+			payload, err := adapter.ProxyRaw(context.Background(), []byte(`{"resource": "/ping", "path": "/ping", "httpMethod": "GET", "isBase64Encoded": false}`))
+
+			Expect(err).To(BeNil())
+			Expect(string(payload)).To(Equal(`{"statusCode":200,"headers":null,"multiValueHeaders":{"Content-Type":["text/plain; charset=utf-8"]},"body":"pong"}`))
+		})
+	})
+
+	Context("CORS preflight request", func() {
+		It("Answers the preflight before routing", func() {
+			r := gin.Default()
+			adapter := ginadapter.New(r)
+			adapter.SetCORSConfig(core.CORSConfig{
+				AllowedOrigins: []string{"https://app.example.com"},
+				AllowedMethods: []string{"GET", "POST"},
+			})
+
+			req := events.APIGatewayProxyRequest{
+				Path:       "/ping",
+				HTTPMethod: "OPTIONS",
+				MultiValueHeaders: map[string][]string{
+					"Origin":                        {"https://app.example.com"},
+					"Access-Control-Request-Method": {"POST"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(204))
+			Expect(resp.MultiValueHeaders["Access-Control-Allow-Origin"]).To(Equal([]string{"https://app.example.com"}))
+			Expect(resp.MultiValueHeaders["Access-Control-Allow-Methods"]).To(Equal([]string{"GET, POST"}))
+		})
+	})
+
+	Context("Proxy with writer", func() {
+		It("Returns the populated response writer", func() {
+			r := gin.Default()
+			r.GET("/ping", func(c *gin.Context) {
+				c.Header("X-Handler", "ping")
+				c.String(201, "pong")
+			})
+
+			adapter := ginadapter.New(r)
+
+			req := events.APIGatewayProxyRequest{
+				Path:       "/ping",
+				HTTPMethod: "GET",
+			}
+
+			w, err := adapter.ProxyWithWriter(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(w.Status()).To(Equal(201))
+			Expect(w.Header().Get("X-Handler")).To(Equal("ping"))
+			Expect(w.PeekBody(100)).To(Equal([]byte("pong")))
+
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(201))
+			Expect(resp.Body).To(Equal("pong"))
+		})
+		It("Applies the proxy handler options", func() {
+			r := gin.Default()
+			r.GET("/ping", func(c *gin.Context) {
+				c.String(200, "pong")
+			})
+
+			adapter := ginadapter.New(r)
+			adapter.SetAllowedMethods([]string{"GET"})
+			adapter.SetCORSConfig(core.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}})
+
+			w, err := adapter.ProxyWithWriter(context.Background(), events.APIGatewayProxyRequest{
+				Path:       "/ping",
+				HTTPMethod: "OPTIONS",
+				MultiValueHeaders: map[string][]string{
+					"Origin":                        {"https://app.example.com"},
+					"Access-Control-Request-Method": {"GET"},
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(w.Status()).To(Equal(204))
+			Expect(w.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.com"))
+
+			w, err = adapter.ProxyWithWriter(context.Background(), events.APIGatewayProxyRequest{Path: "/ping", HTTPMethod: "DELETE"})
+			Expect(err).To(BeNil())
+			Expect(w.Status()).To(Equal(405))
+		})
+	})
+
+	Context("Multiple event types", func() {
+		It("Serves every event type with the same engine", func() {
+			r := gin.Default()
+			r.GET("/ping", func(c *gin.Context) {
+				c.String(200, "pong "+core.GetEventSource(c.Request.Context()).String())
+			})
+
+			adapter := ginadapter.New(r)
+
+			v1Resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+				Path:       "/ping",
+				HTTPMethod: "GET",
+			})
+			Expect(err).To(BeNil())
+			Expect(v1Resp.StatusCode).To(Equal(200))
+			Expect(v1Resp.Body).To(Equal("pong apigateway-v1"))
+
+			v2Resp, err := adapter.ProxyWithContextV2(context.Background(), events.APIGatewayV2HTTPRequest{
+				RawPath: "/ping",
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+						Method: "GET",
+						Path:   "/ping",
+					},
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(v2Resp.StatusCode).To(Equal(200))
+			Expect(v2Resp.Body).To(Equal("pong apigateway-v2"))
+
+			albResp, err := adapter.ProxyWithContextALB(context.Background(), events.ALBTargetGroupRequest{
+				Path:       "/ping",
+				HTTPMethod: "GET",
+			})
+			Expect(err).To(BeNil())
+			Expect(albResp.StatusCode).To(Equal(200))
+			Expect(albResp.StatusDescription).To(Equal("200 OK"))
+			Expect(albResp.Body).To(Equal("pong alb"))
+
+			fnURLResp, err := adapter.ProxyWithContextFunctionURL(context.Background(), events.LambdaFunctionURLRequest{
+				RawPath: "/ping",
+				RequestContext: events.LambdaFunctionURLRequestContext{
+					HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{
+						Method: "GET",
+						Path:   "/ping",
+					},
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(fnURLResp.StatusCode).To(Equal(200))
+			Expect(fnURLResp.Body).To(Equal("pong function-url"))
+
+			edgeResp, err := adapter.ProxyWithContextEdge(context.Background(), core.CloudFrontEvent{
+				Records: []core.CloudFrontRecord{{CF: core.CloudFrontRecordCF{
+					Config:  core.CloudFrontConfig{EventType: core.CloudFrontViewerRequest},
+					Request: core.CloudFrontRequest{Method: "GET", URI: "/ping"},
+				}}},
+			})
+			Expect(err).To(BeNil())
+			Expect(edgeResp.Status).To(Equal("200"))
+			Expect(edgeResp.Body).To(Equal("pong lambda-edge"))
+		})
+
+		It("Configures each event type separately", func() {
+			r := gin.Default()
+			r.GET("/ping", func(c *gin.Context) {
+				c.String(200, "pong")
+			})
+
+			adapter := ginadapter.New(r)
+			adapter.RequestAccessorV2.StripBasePath("v2")
+
+			v2Resp, err := adapter.ProxyV2(events.APIGatewayV2HTTPRequest{
+				RawPath: "/v2/ping",
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+						Method: "GET",
+						Path:   "/v2/ping",
+					},
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(v2Resp.StatusCode).To(Equal(200))
+
+			v1Resp, err := adapter.Proxy(events.APIGatewayProxyRequest{
+				Path:       "/v2/ping",
+				HTTPMethod: "GET",
+			})
+			Expect(err).To(BeNil())
+			Expect(v1Resp.StatusCode).To(Equal(404))
+		})
+	})
+
+	Context("Session cookies", func() {
+		It("Returns every cookie of a v2 response in the cookies array", func() {
+			r := gin.Default()
+			r.POST("/login", func(c *gin.Context) {
+				session, _ := c.Cookie("session")
+				c.SetCookie("session", session+"-rotated", 3600, "/", "", true, true)
+				c.SetCookie("csrf", "token", 3600, "/", "", true, false)
+				c.Status(200)
+			})
+
+			adapter := ginadapter.New(r)
+			resp, err := adapter.ProxyWithContextV2(context.Background(), events.APIGatewayV2HTTPRequest{
+				RawPath: "/login",
+				Cookies: []string{"session=abc"},
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "POST", Path: "/login"},
+				},
+			})
+
+			Expect(err).To(BeNil())
+			Expect(resp.Cookies).To(HaveLen(2))
+			Expect(resp.Cookies[0]).To(HavePrefix("session=abc-rotated;"))
+			Expect(resp.Cookies[1]).To(HavePrefix("csrf=token;"))
+			Expect(resp.MultiValueHeaders).ToNot(HaveKey("Set-Cookie"))
+		})
+	})
+
+	Context("Handler timeout", func() {
+		It("Returns a gateway timeout when the handler overruns", func() {
+			r := gin.Default()
+			r.GET("/slow", func(c *gin.Context) {
+				select {
+				case <-c.Request.Context().Done():
+				case <-time.After(time.Second):
+				}
+				c.String(200, "done")
+			})
+			r.GET("/fast", func(c *gin.Context) {
+				c.String(200, "done")
+			})
+
+			adapter := ginadapter.New(r)
+			adapter.SetHandlerTimeout(50 * time.Millisecond)
+
+			start := time.Now()
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{Path: "/slow", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(504))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+
+			resp, err = adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{Path: "/fast", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("done"))
+		})
+
+		It("Applies the timeout to raw and other event types", func() {
+			r := gin.Default()
+			r.GET("/slow", func(c *gin.Context) {
+				select {
+				case <-c.Request.Context().Done():
+				case <-time.After(time.Second):
+				}
+				c.String(200, "done")
+			})
+
+			adapter := ginadapter.New(r)
+			adapter.SetHandlerTimeout(50 * time.Millisecond)
+
+			payload, err := adapter.ProxyRaw(context.Background(), []byte(`{"httpMethod": "GET", "path": "/slow"}`))
+			Expect(err).To(BeNil())
+			var rawResp events.APIGatewayProxyResponse
+			Expect(json.Unmarshal(payload, &rawResp)).To(BeNil())
+			Expect(rawResp.StatusCode).To(Equal(504))
+
+			v2Resp, err := adapter.ProxyWithContextV2(context.Background(), events.APIGatewayV2HTTPRequest{
+				RawPath: "/slow",
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET", Path: "/slow"},
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(v2Resp.StatusCode).To(Equal(504))
+
+			albResp, err := adapter.ProxyWithContextALB(context.Background(), events.ALBTargetGroupRequest{Path: "/slow", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(albResp.StatusCode).To(Equal(504))
+
+			urlResp, err := adapter.ProxyWithContextFunctionURL(context.Background(), events.LambdaFunctionURLRequest{
+				RawPath: "/slow",
+				RequestContext: events.LambdaFunctionURLRequestContext{
+					HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET", Path: "/slow"},
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(urlResp.StatusCode).To(Equal(504))
+		})
+	})
+
+	Context("Allowed request content types", func() {
+		It("Rejects unexpected content types before routing", func() {
+			routed := false
+			r := gin.Default()
+			r.POST("/orders", func(c *gin.Context) {
+				routed = true
+				c.String(201, "created")
+			})
+
+			adapter := ginadapter.New(r)
+			adapter.SetAllowedRequestContentTypes([]string{"application/json"})
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+				Path:              "/orders",
+				HTTPMethod:        "POST",
+				Body:              "<order/>",
+				MultiValueHeaders: map[string][]string{"Content-Type": {"text/xml"}},
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(415))
+			Expect(routed).To(BeFalse())
+		})
+	})
+
+	Context("Event hooks", func() {
+		It("Checks raw events and decorates responses", func() {
+			r := gin.Default()
+			r.POST("/webhook", func(c *gin.Context) {
+				c.String(200, "accepted")
+			})
+
+			adapter := ginadapter.New(r)
+			adapter.Use(func(ctx context.Context, evt *events.APIGatewayProxyRequest) error {
+				if evt.Headers["X-Signature"] != "valid" {
+					return &core.EventRejection{StatusCode: 401, Message: "Invalid signature"}
+				}
+				return nil
+			})
+			adapter.UseResponse(func(resp *events.APIGatewayProxyResponse) {
+				resp.MultiValueHeaders["X-Frame-Options"] = []string{"DENY"}
+			})
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+				Path:       "/webhook",
+				HTTPMethod: "POST",
+				Headers:    map[string]string{"X-Signature": "valid"},
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("accepted"))
+			Expect(resp.MultiValueHeaders["X-Frame-Options"]).To(Equal([]string{"DENY"}))
+
+			resp, err = adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{Path: "/webhook", HTTPMethod: "POST"})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(401))
+			Expect(resp.Body).To(Equal("Invalid signature"))
+		})
+	})
+
+	Context("Fast paths", func() {
+		It("Answers fast paths without routing", func() {
+			calls := 0
+			r := gin.Default()
+			r.GET("/ping", func(c *gin.Context) {
+				calls++
+				c.String(200, "routed")
+			})
+			r.GET("/orders", func(c *gin.Context) {
+				calls++
+				c.String(200, "orders")
+			})
+
+			adapter := ginadapter.New(r)
+			adapter.SetFastPaths(map[string]events.APIGatewayProxyResponse{
+				"/ping": {StatusCode: 200, Body: "pong"},
+			})
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{Path: "/ping", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("pong"))
+			Expect(calls).To(Equal(0))
+
+			resp, err = adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{Path: "/orders", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("orders"))
+			Expect(calls).To(Equal(1))
+		})
+	})
+
+	Context("Matched route", func() {
+		It("Exposes the route template to the handler", func() {
+			r := gin.Default()
+			r.GET("/users/:id", func(c *gin.Context) {
+				route, _ := core.GetMatchedRoute(c.Request.Context())
+				c.String(200, route)
+			})
+			r.GET("/files/*filepath", func(c *gin.Context) {
+				route, _ := core.GetMatchedRoute(c.Request.Context())
+				c.String(200, route)
+			})
+
+			adapter := ginadapter.New(r)
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{Path: "/users/42", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("/users/:id"))
+
+			resp, err = adapter.Proxy(events.APIGatewayProxyRequest{Path: "/files/css/site.css", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("/files/*filepath"))
+		})
+	})
+
+	Context("Concurrency limit", func() {
+		It("Answers events over the limit with a 503", func() {
+			entered := make(chan struct{}, 10)
+			release := make(chan struct{})
+			r := gin.Default()
+			r.GET("/slow", func(c *gin.Context) {
+				entered <- struct{}{}
+				<-release
+				c.String(200, "done")
+			})
+
+			adapter := ginadapter.New(r)
+			adapter.SetMaxConcurrent(2)
+
+			statuses := make(chan int, 10)
+			for i := 0; i < 10; i++ {
+				go func() {
+					resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{Path: "/slow", HTTPMethod: "GET"})
+					Expect(err).To(BeNil())
+					statuses <- resp.StatusCode
+				}()
+			}
+
+			for i := 0; i < 8; i++ {
+				Expect(<-statuses).To(Equal(503))
+			}
+			<-entered
+			<-entered
+			close(release)
+			Expect(<-statuses).To(Equal(200))
+			Expect(<-statuses).To(Equal(200))
+
+			resp, err := adapter.Proxy(events.APIGatewayProxyRequest{Path: "/slow", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+		})
+
+		It("Counts timed out handlers until they return", func() {
+			release := make(chan struct{})
+			r := gin.Default()
+			r.GET("/stuck", func(c *gin.Context) {
+				<-release
+				c.String(200, "done")
+			})
+			r.GET("/fast", func(c *gin.Context) {
+				c.String(200, "done")
+			})
+
+			adapter := ginadapter.New(r)
+			adapter.SetMaxConcurrent(1)
+			adapter.SetHandlerTimeout(20 * time.Millisecond)
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{Path: "/stuck", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(504))
+
+			resp, err = adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{Path: "/fast", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(503))
+
+			close(release)
+			Eventually(func() int {
+				resp, _ := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{Path: "/fast", HTTPMethod: "GET"})
+				return resp.StatusCode
+			}).Should(Equal(200))
+		})
+
+		It("Applies the limit to raw and other event types", func() {
+			entered := make(chan struct{})
+			release := make(chan struct{})
+			r := gin.Default()
+			r.GET("/slow", func(c *gin.Context) {
+				entered <- struct{}{}
+				<-release
+				c.String(200, "done")
+			})
+
+			adapter := ginadapter.New(r)
+			adapter.SetMaxConcurrent(1)
+
+			done := make(chan []byte)
+			go func() {
+				payload, err := adapter.ProxyRaw(context.Background(), []byte(`{"httpMethod": "GET", "path": "/slow"}`))
+				Expect(err).To(BeNil())
+				done <- payload
+			}()
+			<-entered
+
+			payload, err := adapter.ProxyRaw(context.Background(), []byte(`{"httpMethod": "GET", "path": "/slow"}`))
+			Expect(err).To(BeNil())
+			var rawResp events.APIGatewayProxyResponse
+			Expect(json.Unmarshal(payload, &rawResp)).To(BeNil())
+			Expect(rawResp.StatusCode).To(Equal(503))
+
+			v2Resp, err := adapter.ProxyWithContextV2(context.Background(), events.APIGatewayV2HTTPRequest{
+				RawPath: "/slow",
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET", Path: "/slow"},
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(v2Resp.StatusCode).To(Equal(503))
+
+			albResp, err := adapter.ProxyALB(events.ALBTargetGroupRequest{Path: "/slow", HTTPMethod: "GET"})
+			Expect(err).To(BeNil())
+			Expect(albResp.StatusCode).To(Equal(503))
+
+			close(release)
+			Expect(json.Unmarshal(<-done, &rawResp)).To(BeNil())
+			Expect(rawResp.StatusCode).To(Equal(200))
+			Expect(rawResp.Body).To(Equal("done"))
+		})
+	})
+
+	Context("Server-sent events through API Gateway", func() {
+		It("Returns the flushed events in one response", func() {
+			r := gin.Default()
+			r.GET("/events", func(c *gin.Context) {
+				i := 0
+				c.Stream(func(w io.Writer) bool {
+					c.SSEvent("tick", i)
+					i++
+					return i < 3
+				})
+			})
+
+			adapter := ginadapter.New(r)
+
+			req := events.APIGatewayProxyRequest{
+				Path:       "/events",
+				HTTPMethod: "GET",
+			}
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"text/event-stream"}))
+			Expect(resp.Body).To(Equal("event:tick\ndata:0\n\nevent:tick\ndata:1\n\nevent:tick\ndata:2\n\n"))
+		})
+	})
+
+	Context("Streaming Function URL request", func() {
+		It("Streams the response body", func() {
+			r := gin.Default()
+			r.GET("/events", func(c *gin.Context) {
+				c.Header("Content-Type", "text/event-stream")
+				for i := 0; i < 3; i++ {
+					c.SSEvent("tick", i)
+					c.Writer.Flush()
+				}
+			})
+
+			adapter := ginadapter.New(r)
+
+			req := events.LambdaFunctionURLRequest{
+				RawPath: "/events",
+				RequestContext: events.LambdaFunctionURLRequestContext{
+					HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET", Path: "/events"},
+				},
+			}
+			resp, err := adapter.ProxyStream(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Headers["Content-Type"]).To(Equal("text/event-stream"))
+
+			body, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(string(body)).To(Equal("event:tick\ndata:0\n\nevent:tick\ndata:1\n\nevent:tick\ndata:2\n\n"))
+		})
+	})
+
+	Context("Concurrent requests", func() {
+		It("Keeps the state of simultaneous events apart", func() {
+			gin.SetMode(gin.ReleaseMode)
+			r := gin.New()
+			r.GET("/items/:id", func(c *gin.Context) {
+				c.String(200, c.Param("id"))
+			})
+
+			adapter := ginadapter.New(r)
+
+			Expect(proxytest.ProxyConcurrently(100, adapter.ProxyWithContext, adapter.ProxyWithContextV2)).To(BeEmpty())
+		})
+	})
 })
@@ -2,7 +2,6 @@ package gorillamux
 
 import (
 	"context"
-	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/awslabs/aws-lambda-go-api-proxy/core"
@@ -11,43 +10,128 @@ import (
 
 type GorillaMuxAdapter struct {
 	core.RequestAccessor
+	*core.APIGatewayProxyHandler
 	router *mux.Router
 }
 
 func New(router *mux.Router) *GorillaMuxAdapter {
-	return &GorillaMuxAdapter{
+	h := &GorillaMuxAdapter{
 		router: router,
 	}
+	h.APIGatewayProxyHandler = core.NewAPIGatewayProxyHandler(&h.RequestAccessor, router)
+	return h
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
 // object, and sends it to the mux.Router for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *GorillaMuxAdapter) Proxy(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	req, err := h.ProxyEventToHTTPRequest(event)
-	return h.proxyInternal(req, err)
+	return h.APIGatewayProxyHandler.Proxy(event)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
 // transforms them into an http.Request object, and sends it to the mux.Router for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *GorillaMuxAdapter) ProxyWithContext(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	req, err := h.EventToRequestWithContext(ctx, event)
-	return h.proxyInternal(req, err)
+	return h.APIGatewayProxyHandler.ProxyWithContext(ctx, event)
 }
 
-func (h *GorillaMuxAdapter) proxyInternal(req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+// GorillaMuxAdapterV2 sends API Gateway HTTP API (v2) events to a mux.Router.
+type GorillaMuxAdapterV2 struct {
+	core.RequestAccessorV2
+	*core.APIGatewayV2ProxyHandler
+	router *mux.Router
+}
+
+// NewV2 creates a GorillaMuxAdapterV2 for the given router.
+func NewV2(router *mux.Router) *GorillaMuxAdapterV2 {
+	h := &GorillaMuxAdapterV2{
+		router: router,
 	}
+	h.APIGatewayV2ProxyHandler = core.NewAPIGatewayV2ProxyHandler(&h.RequestAccessorV2, router)
+	return h
+}
 
-	w := core.NewProxyResponseWriter()
-	h.router.ServeHTTP(http.ResponseWriter(w), req)
+// Proxy receives an API Gateway HTTP API (v2) event, transforms it into an
+// http.Request object, and sends it to the mux.Router for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (h *GorillaMuxAdapterV2) Proxy(event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return h.APIGatewayV2ProxyHandler.Proxy(event)
+}
 
-	resp, err := w.GetProxyResponse()
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
+// ProxyWithContext receives context and an API Gateway HTTP API (v2) event,
+// transforms them into an http.Request object, and sends it to the mux.Router for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (h *GorillaMuxAdapterV2) ProxyWithContext(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return h.APIGatewayV2ProxyHandler.ProxyWithContext(ctx, event)
+}
+
+// GorillaMuxAdapterALB sends Application Load Balancer target group events to a mux.Router.
+type GorillaMuxAdapterALB struct {
+	core.RequestAccessorALB
+	*core.ALBProxyHandler
+	router *mux.Router
+}
+
+// NewALB creates a GorillaMuxAdapterALB for the given router.
+func NewALB(router *mux.Router) *GorillaMuxAdapterALB {
+	h := &GorillaMuxAdapterALB{
+		router: router,
 	}
+	h.ALBProxyHandler = core.NewALBProxyHandler(&h.RequestAccessorALB, router)
+	return h
+}
+
+// Proxy receives an ALB target group event, transforms it into an
+// http.Request object, and sends it to the mux.Router for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (h *GorillaMuxAdapterALB) Proxy(event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return h.ALBProxyHandler.Proxy(event)
+}
+
+// ProxyWithContext receives context and an ALB target group event,
+// transforms them into an http.Request object, and sends it to the mux.Router for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (h *GorillaMuxAdapterALB) ProxyWithContext(ctx context.Context, event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return h.ALBProxyHandler.ProxyWithContext(ctx, event)
+}
+
+// GorillaMuxAdapterFnURL sends Lambda Function URL events to a mux.Router.
+type GorillaMuxAdapterFnURL struct {
+	core.RequestAccessorFnURL
+	*core.FunctionURLProxyHandler
+	streaming *core.FunctionURLStreamingHandler
+	router    *mux.Router
+}
+
+// NewFunctionURL creates a GorillaMuxAdapterFnURL for the given router.
+func NewFunctionURL(router *mux.Router) *GorillaMuxAdapterFnURL {
+	h := &GorillaMuxAdapterFnURL{
+		router: router,
+	}
+	h.FunctionURLProxyHandler = core.NewFunctionURLProxyHandler(&h.RequestAccessorFnURL, router)
+	h.streaming = core.NewFunctionURLStreamingHandler(&h.RequestAccessorFnURL, router)
+	return h
+}
+
+// Proxy receives a Function URL event, transforms it into an http.Request
+// object, and sends it to the mux.Router for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (h *GorillaMuxAdapterFnURL) Proxy(event events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return h.FunctionURLProxyHandler.Proxy(event)
+}
+
+// ProxyWithContext receives context and a Function URL event,
+// transforms them into an http.Request object, and sends it to the mux.Router for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (h *GorillaMuxAdapterFnURL) ProxyWithContext(ctx context.Context, event events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return h.FunctionURLProxyHandler.ProxyWithContext(ctx, event)
+}
 
-	return resp, nil
+// ProxyStream receives context and a Function URL event, transforms them into
+// an http.Request object, and sends it to the mux.Router for routing.
+// It returns a streaming response whose body carries what the handler writes
+// while it runs, for Function URLs with the RESPONSE_STREAM invoke mode.
+func (h *GorillaMuxAdapterFnURL) ProxyStream(ctx context.Context, event events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error) {
+	return h.streaming.ProxyStream(ctx, event)
 }
@@ -3,6 +3,7 @@ package gorillamux_test
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -14,6 +15,28 @@ import (
 )
 
 var _ = Describe("GorillaMuxAdapter tests", func() {
+	Context("Base path", func() {
+		It("Strips the base path and restores it in redirects", func() {
+			r := mux.NewRouter()
+			r.HandleFunc("/orders", func(w http.ResponseWriter, req *http.Request) {
+				http.Redirect(w, req, "/login", http.StatusFound)
+			})
+
+			adapter := gorillamux.New(r)
+			adapter.StripBasePath("/prod")
+			adapter.SetRewriteRedirects(true)
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+				Path:       "/prod/orders",
+				HTTPMethod: "GET",
+			})
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusFound))
+			Expect(resp.MultiValueHeaders["Location"]).To(Equal([]string{"/prod/login"}))
+		})
+	})
+
 	Context("Simple ping request", func() {
 		It("Proxies the event correctly", func() {
 			homeHandler := func(w http.ResponseWriter, req *http.Request) {
@@ -55,4 +78,188 @@ var _ = Describe("GorillaMuxAdapter tests", func() {
 			Expect(productsPageResp.Body).To(Equal("Products Page"))
 		})
 	})
+
+	Context("Function URL request", func() {
+		It("Proxies the event correctly", func() {
+			r := mux.NewRouter()
+			r.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) {
+				session, err := req.Cookie("session")
+				Expect(err).To(BeNil())
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: session.Value})
+				http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+				fmt.Fprintf(w, "OK")
+			}).Methods("GET")
+
+			adapter := gorillamux.NewFunctionURL(r)
+
+			healthReq := events.LambdaFunctionURLRequest{
+				RawPath: "/health",
+				Cookies: []string{"session=abc", "other=1"},
+				RequestContext: events.LambdaFunctionURLRequestContext{
+					HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{
+						Method: "GET",
+						Path:   "/health",
+					},
+				},
+			}
+
+			healthResp, healthReqErr := adapter.ProxyWithContext(context.Background(), healthReq)
+
+			Expect(healthReqErr).To(BeNil())
+			Expect(healthResp.StatusCode).To(Equal(200))
+			Expect(healthResp.Body).To(Equal("OK"))
+			Expect(healthResp.Cookies).To(Equal([]string{"session=abc", "theme=dark"}))
+			Expect(healthResp.Headers).ToNot(HaveKey("Set-Cookie"))
+		})
+	})
+
+	Context("Method override", func() {
+		It("Routes a POST with the override header as DELETE", func() {
+			r := mux.NewRouter()
+			r.HandleFunc("/items/1", func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}).Methods("DELETE")
+
+			adapter := gorillamux.New(r)
+			adapter.SetHonorMethodOverride(true)
+
+			deleteReq := events.APIGatewayProxyRequest{
+				Path:       "/items/1",
+				HTTPMethod: "POST",
+				Headers: map[string]string{
+					"X-HTTP-Method-Override": "DELETE",
+				},
+			}
+
+			deleteResp, deleteReqErr := adapter.ProxyWithContext(context.Background(), deleteReq)
+
+			Expect(deleteReqErr).To(BeNil())
+			Expect(deleteResp.StatusCode).To(Equal(http.StatusNoContent))
+		})
+	})
+
+	Context("Allowed methods", func() {
+		It("Rejects a TRACE request before routing", func() {
+			r := mux.NewRouter()
+			r.HandleFunc("/items", func(w http.ResponseWriter, req *http.Request) {
+				fmt.Fprintf(w, "Items")
+			})
+
+			adapter := gorillamux.New(r)
+			adapter.SetAllowedMethods([]string{"GET", "POST"})
+
+			traceReq := events.APIGatewayProxyRequest{
+				Path:       "/items",
+				HTTPMethod: "TRACE",
+			}
+
+			traceResp, traceReqErr := adapter.ProxyWithContext(context.Background(), traceReq)
+
+			Expect(traceReqErr).To(BeNil())
+			Expect(traceResp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+			Expect(traceResp.MultiValueHeaders["Allow"]).To(Equal([]string{"GET, POST"}))
+		})
+	})
+
+	Context("Streaming Function URL request", func() {
+		It("Streams the response body", func() {
+			r := mux.NewRouter()
+			r.HandleFunc("/events", func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				for i := 0; i < 3; i++ {
+					fmt.Fprintf(w, "data: %d\n\n", i)
+					w.(http.Flusher).Flush()
+				}
+			}).Methods("GET")
+
+			adapter := gorillamux.NewFunctionURL(r)
+
+			eventsReq := events.LambdaFunctionURLRequest{
+				RawPath: "/events",
+				RequestContext: events.LambdaFunctionURLRequestContext{
+					HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{
+						Method: "GET",
+						Path:   "/events",
+					},
+				},
+			}
+
+			eventsResp, eventsReqErr := adapter.ProxyStream(context.Background(), eventsReq)
+
+			Expect(eventsReqErr).To(BeNil())
+			Expect(eventsResp.StatusCode).To(Equal(200))
+			Expect(eventsResp.Headers["Content-Type"]).To(Equal("text/event-stream"))
+			body, err := ioutil.ReadAll(eventsResp.Body)
+			Expect(err).To(BeNil())
+			Expect(string(body)).To(Equal("data: 0\n\ndata: 1\n\ndata: 2\n\n"))
+		})
+	})
+
+	Context("HTTP API v2 request", func() {
+		It("Proxies the event correctly", func() {
+			r := mux.NewRouter()
+			r.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+				session, _ := r.Cookie("session")
+				fmt.Fprintf(w, "%s %s %s", r.URL.Query().Get("name"), session.Value, r.Header.Get("X-Tenant"))
+			}).Methods("GET")
+
+			adapter := gorillamux.NewV2(r)
+
+			req := events.APIGatewayV2HTTPRequest{
+				RawPath:        "/ping",
+				RawQueryString: "name=go",
+				Cookies:        []string{"session=abc"},
+				Headers:        map[string]string{"x-tenant": "acme"},
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET", Path: "/ping"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.Proxy(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Application Load Balancer request", func() {
+		It("Proxies the event correctly", func() {
+			r := mux.NewRouter()
+			r.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+				session, _ := r.Cookie("session")
+				fmt.Fprintf(w, "%s %s %s", r.URL.Query().Get("name"), session.Value, r.Header.Get("X-Tenant"))
+			}).Methods("GET")
+
+			adapter := gorillamux.NewALB(r)
+
+			req := events.ALBTargetGroupRequest{
+				HTTPMethod:                      "GET",
+				Path:                            "/ping",
+				MultiValueQueryStringParameters: map[string][]string{"name": {"go"}},
+				MultiValueHeaders: map[string][]string{
+					"cookie":   {"session=abc"},
+					"x-tenant": {"acme"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.StatusDescription).To(Equal("200 OK"))
+			Expect(resp.MultiValueHeaders).To(HaveKey("Content-Type"))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.Proxy(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
 })
@@ -0,0 +1,169 @@
+// Package grpcwebadapter serves gRPC-web and Connect protocol requests
+// received through API Gateway, ALB or Function URL events with a gRPC
+// http.Handler, such as a *grpc.Server or a connect-go handler. Lambda
+// responses have no HTTP/2 trailers, so the requests are translated into
+// gRPC requests and the trailers of the responses are moved into the body
+// the way the browser protocols expect.
+package grpcwebadapter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// protocol is the protocol of a request, and therefore of its response.
+type protocol int
+
+const (
+	protocolNone protocol = iota
+	protocolGRPCWeb
+	protocolGRPCWebText
+	protocolConnectStream
+	protocolConnectUnary
+)
+
+// Handler translates gRPC-web and Connect requests into gRPC requests for
+// the wrapped handler. Other requests, including gRPC requests, are passed
+// through unchanged. Connect unary requests sent with GET are not
+// supported.
+type Handler struct {
+	next http.Handler
+}
+
+// New returns a Handler that sends the translated requests to next.
+func New(next http.Handler) *Handler {
+	return &Handler{next: next}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	proto, codec := detectProtocol(r)
+	if proto == protocolNone || r.Method != http.MethodPost {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Could not read request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if proto == protocolGRPCWebText {
+		if body, err = decodeText(body); err != nil {
+			http.Error(w, "Could not decode grpc-web-text body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if proto == protocolConnectUnary {
+		compressed := r.Header.Get("Content-Encoding") != "" && r.Header.Get("Content-Encoding") != "identity"
+		body = frame(dataFlag(compressed), body)
+	}
+
+	grpcReq := r.Clone(r.Context())
+	grpcReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+	grpcReq.ContentLength = int64(len(body))
+	grpcReq.Proto, grpcReq.ProtoMajor, grpcReq.ProtoMinor = "HTTP/2", 2, 0
+	translateRequestHeaders(grpcReq.Header, proto, codec)
+
+	rec := newRecorder()
+	h.next.ServeHTTP(rec, grpcReq)
+
+	switch proto {
+	case protocolConnectUnary:
+		writeConnectUnary(w, rec, codec)
+	case protocolConnectStream:
+		writeConnectStream(w, rec, codec)
+	default:
+		writeGRPCWeb(w, rec, proto, codec)
+	}
+}
+
+// detectProtocol returns the protocol of the request and the codec named in
+// its content type, such as proto or json.
+func detectProtocol(r *http.Request) (protocol, string) {
+	contentType := strings.ToLower(strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0]))
+	switch {
+	case strings.HasPrefix(contentType, "application/grpc-web-text"):
+		return protocolGRPCWebText, codecOf(contentType, "application/grpc-web-text")
+	case strings.HasPrefix(contentType, "application/grpc-web"):
+		return protocolGRPCWeb, codecOf(contentType, "application/grpc-web")
+	case strings.HasPrefix(contentType, "application/connect+"):
+		return protocolConnectStream, strings.TrimPrefix(contentType, "application/connect+")
+	case r.Header.Get("Connect-Protocol-Version") != "" && strings.HasPrefix(contentType, "application/"):
+		return protocolConnectUnary, strings.TrimPrefix(contentType, "application/")
+	}
+	return protocolNone, ""
+}
+
+// codecOf returns the codec suffix of a gRPC-web content type, proto when
+// the content type has none.
+func codecOf(contentType string, base string) string {
+	if codec := strings.TrimPrefix(strings.TrimPrefix(contentType, base), "+"); codec != "" {
+		return codec
+	}
+	return "proto"
+}
+
+// translateRequestHeaders replaces the protocol headers of a gRPC-web or
+// Connect request with their gRPC equivalents.
+func translateRequestHeaders(header http.Header, proto protocol, codec string) {
+	header.Set("Content-Type", "application/grpc+"+codec)
+	header.Set("Te", "trailers")
+	header.Del("Content-Length")
+
+	switch proto {
+	case protocolConnectUnary:
+		if encoding := header.Get("Content-Encoding"); encoding != "" {
+			header.Set("Grpc-Encoding", encoding)
+		}
+		if accept := header.Get("Accept-Encoding"); accept != "" {
+			header.Set("Grpc-Accept-Encoding", accept)
+		}
+		header.Del("Content-Encoding")
+		header.Del("Accept-Encoding")
+	case protocolConnectStream:
+		if encoding := header.Get("Connect-Content-Encoding"); encoding != "" {
+			header.Set("Grpc-Encoding", encoding)
+		}
+		if accept := header.Get("Connect-Accept-Encoding"); accept != "" {
+			header.Set("Grpc-Accept-Encoding", accept)
+		}
+		header.Del("Connect-Content-Encoding")
+		header.Del("Connect-Accept-Encoding")
+	}
+
+	if proto == protocolConnectUnary || proto == protocolConnectStream {
+		if timeout := header.Get("Connect-Timeout-Ms"); timeout != "" {
+			header.Set("Grpc-Timeout", timeout+"m")
+		}
+		header.Del("Connect-Timeout-Ms")
+		header.Del("Connect-Protocol-Version")
+	}
+}
+
+// decodeText decodes a grpc-web-text body. Clients encode every message
+// separately, so the body can hold several padded base64 chunks.
+func decodeText(body []byte) ([]byte, error) {
+	var decoded []byte
+	text := strings.Join(strings.Fields(string(body)), "")
+	for len(text) > 0 {
+		end := strings.Index(text, "=")
+		if end < 0 {
+			end = len(text)
+		} else {
+			for end < len(text) && text[end] == '=' {
+				end++
+			}
+		}
+		chunk, err := base64.StdEncoding.DecodeString(text[:end])
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, chunk...)
+		text = text[end:]
+	}
+	return decoded, nil
+}
@@ -0,0 +1,166 @@
+package grpcwebadapter_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/awslabs/aws-lambda-go-api-proxy/grpcwebadapter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func frame(flags byte, payload string) []byte {
+	framed := make([]byte, 5)
+	framed[0] = flags
+	binary.BigEndian.PutUint32(framed[1:], uint32(len(payload)))
+	return append(framed, payload...)
+}
+
+// greeter behaves like the HTTP handler of a gRPC server: it only accepts
+// HTTP/2 gRPC requests and declares its trailers.
+var greeter = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	name := string(body[5:])
+	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+	w.Header().Set("X-Timeout", r.Header.Get("Grpc-Timeout"))
+	if name == "" {
+		w.Header().Set("Grpc-Status", "3")
+		w.Header().Set("Grpc-Message", "Name%20is%20required")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message, X-Served-By")
+	w.WriteHeader(http.StatusOK)
+	w.Write(frame(0, "Hello "+name))
+	w.Header().Set("Grpc-Status", "0")
+	w.Header().Set("X-Served-By", "lambda")
+})
+
+var _ = Describe("GRPC-web adapter tests", func() {
+	handler := grpcwebadapter.New(greeter)
+
+	serve := func(contentType string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/greet.v1.Greeter/Greet", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	Context("gRPC-web", func() {
+		It("Moves the trailers into a trailer frame", func() {
+			rec := serve("application/grpc-web+proto", frame(0, "Lambda"), nil)
+
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("Content-Type")).To(Equal("application/grpc-web+proto"))
+			Expect(rec.Header()).ToNot(HaveKey("Trailer"))
+			expected := append(frame(0, "Hello Lambda"), frame(0x80, "grpc-status: 0\r\nx-served-by: lambda\r\n")...)
+			Expect(rec.Body.Bytes()).To(Equal(expected))
+		})
+
+		It("Encodes grpc-web-text bodies", func() {
+			body := base64.StdEncoding.EncodeToString(frame(0, "Lambda"))
+			rec := serve("application/grpc-web-text", []byte(body), nil)
+
+			Expect(rec.Header().Get("Content-Type")).To(Equal("application/grpc-web-text+proto"))
+			decoded, err := base64.StdEncoding.DecodeString(rec.Body.String())
+			Expect(err).To(BeNil())
+			Expect(decoded).To(HavePrefix(string(frame(0, "Hello Lambda"))))
+		})
+
+		It("Decodes grpc-web-text bodies of several chunks", func() {
+			body := base64.StdEncoding.EncodeToString(frame(0, "La")[:4]) + base64.StdEncoding.EncodeToString(frame(0, "La")[4:])
+			rec := serve("application/grpc-web-text+proto", []byte(body), nil)
+
+			decoded, err := base64.StdEncoding.DecodeString(rec.Body.String())
+			Expect(err).To(BeNil())
+			Expect(decoded).To(HavePrefix(string(frame(0, "Hello La"))))
+		})
+
+		It("Returns trailers-only errors in the trailer frame", func() {
+			rec := serve("application/grpc-web", frame(0, ""), nil)
+
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header()).ToNot(HaveKey("Grpc-Status"))
+			Expect(rec.Body.Bytes()).To(Equal(frame(0x80, "grpc-message: Name%20is%20required\r\ngrpc-status: 3\r\n")))
+		})
+	})
+
+	Context("Connect", func() {
+		It("Unwraps unary responses", func() {
+			rec := serve("application/proto", []byte("Lambda"), map[string]string{
+				"Connect-Protocol-Version": "1",
+				"Connect-Timeout-Ms":       "1500",
+			})
+
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("Content-Type")).To(Equal("application/proto"))
+			Expect(rec.Header().Get("X-Timeout")).To(Equal("1500m"))
+			Expect(rec.Header().Get("Trailer-X-Served-By")).To(Equal("lambda"))
+			Expect(rec.Body.String()).To(Equal("Hello Lambda"))
+		})
+
+		It("Maps unary errors to their HTTP status", func() {
+			rec := serve("application/json", []byte(""), map[string]string{"Connect-Protocol-Version": "1"})
+
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+			var connectErr map[string]string
+			Expect(json.Unmarshal(rec.Body.Bytes(), &connectErr)).To(BeNil())
+			Expect(connectErr).To(Equal(map[string]string{"code": "invalid_argument", "message": "Name is required"}))
+		})
+
+		It("Ends streams with an end-of-stream message", func() {
+			rec := serve("application/connect+proto", frame(0, "Lambda"), nil)
+
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("Content-Type")).To(Equal("application/connect+proto"))
+			expected := append(frame(0, "Hello Lambda"), frame(0x02, `{"metadata":{"X-Served-By":["lambda"]}}`)...)
+			Expect(rec.Body.Bytes()).To(Equal(expected))
+
+			rec = serve("application/connect+json", frame(0, ""), nil)
+			Expect(rec.Body.Bytes()).To(Equal(frame(0x02, `{"error":{"code":"invalid_argument","message":"Name is required"}}`)))
+		})
+	})
+
+	It("Passes other requests through", func() {
+		rec := serve("application/json", []byte("{}"), nil)
+		Expect(rec.Code).To(Equal(http.StatusUnsupportedMediaType))
+	})
+
+	It("Serves gRPC-web calls received through API Gateway", func() {
+		proxy := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, handler)
+		resp, err := proxy.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+			Path:            "/greet.v1.Greeter/Greet",
+			HTTPMethod:      "POST",
+			Headers:         map[string]string{"Content-Type": "application/grpc-web+proto"},
+			Body:            base64.StdEncoding.EncodeToString(frame(0, "Lambda")),
+			IsBase64Encoded: true,
+		})
+
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.IsBase64Encoded).To(BeTrue())
+		body, err := base64.StdEncoding.DecodeString(resp.Body)
+		Expect(err).To(BeNil())
+		Expect(body).To(HavePrefix(string(frame(0, "Hello Lambda"))))
+		Expect(body[len(frame(0, "Hello Lambda"))]).To(Equal(byte(0x80)))
+	})
+})
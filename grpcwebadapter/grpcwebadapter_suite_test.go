@@ -0,0 +1,13 @@
+package grpcwebadapter_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestGRPCWebAdapter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GRPCWebAdapter Suite")
+}
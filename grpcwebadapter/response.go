@@ -0,0 +1,271 @@
+package grpcwebadapter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Flags of the first byte of a gRPC message frame.
+const (
+	flagCompressed = 0x01
+	flagEndStream  = 0x02 // Connect end-of-stream message
+	flagTrailer    = 0x80 // gRPC-web trailer frame
+)
+
+// statusHeaders are the gRPC headers that end a call. gRPC servers send
+// them as headers instead of trailers when the call fails before the first
+// message, in a trailers-only response.
+var statusHeaders = []string{"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"}
+
+// connectCodes are the Connect names and HTTP statuses of the gRPC status
+// codes, indexed by code.
+var connectCodes = []struct {
+	name   string
+	status int
+}{
+	{"ok", http.StatusOK},
+	{"canceled", 499},
+	{"unknown", http.StatusInternalServerError},
+	{"invalid_argument", http.StatusBadRequest},
+	{"deadline_exceeded", http.StatusGatewayTimeout},
+	{"not_found", http.StatusNotFound},
+	{"already_exists", http.StatusConflict},
+	{"permission_denied", http.StatusForbidden},
+	{"resource_exhausted", http.StatusTooManyRequests},
+	{"failed_precondition", http.StatusBadRequest},
+	{"aborted", http.StatusConflict},
+	{"out_of_range", http.StatusBadRequest},
+	{"unimplemented", http.StatusNotImplemented},
+	{"internal", http.StatusInternalServerError},
+	{"unavailable", http.StatusServiceUnavailable},
+	{"data_loss", http.StatusInternalServerError},
+	{"unauthenticated", http.StatusUnauthorized},
+}
+
+// recorder collects the response of the gRPC handler. It implements
+// http.Flusher, which gRPC servers require of their response writers.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: make(http.Header)}
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
+
+func (r *recorder) Flush() {
+	r.WriteHeader(http.StatusOK)
+}
+
+// split separates the headers of the response from its trailers: the
+// headers declared in the Trailer header, those set with the
+// http.TrailerPrefix and the status headers of trailers-only responses.
+func (r *recorder) split() (http.Header, http.Header) {
+	headers := r.header.Clone()
+	trailers := make(http.Header)
+	for _, declared := range headers.Values("Trailer") {
+		for _, key := range strings.Split(declared, ",") {
+			key = http.CanonicalHeaderKey(strings.TrimSpace(key))
+			if values, ok := headers[key]; ok {
+				trailers[key] = values
+				delete(headers, key)
+			}
+		}
+	}
+	headers.Del("Trailer")
+	for key, values := range headers {
+		if strings.HasPrefix(key, http.TrailerPrefix) {
+			trailers[http.CanonicalHeaderKey(strings.TrimPrefix(key, http.TrailerPrefix))] = values
+			delete(headers, key)
+		}
+	}
+	for _, key := range statusHeaders {
+		if values, ok := headers[key]; ok {
+			trailers[key] = values
+			delete(headers, key)
+		}
+	}
+	headers.Del("Content-Length")
+	return headers, trailers
+}
+
+// writeGRPCWeb writes the response with the trailers in a trailer frame at
+// the end of the body, base64 encoded for grpc-web-text.
+func writeGRPCWeb(w http.ResponseWriter, rec *recorder, proto protocol, codec string) {
+	headers, trailers := rec.split()
+	copyHeaders(w.Header(), headers)
+
+	var block bytes.Buffer
+	keys := make([]string, 0, len(trailers))
+	for key := range trailers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range trailers[key] {
+			block.WriteString(strings.ToLower(key) + ": " + value + "\r\n")
+		}
+	}
+	body := append(rec.body.Bytes(), frame(flagTrailer, block.Bytes())...)
+
+	contentType := "application/grpc-web+" + codec
+	if proto == protocolGRPCWebText {
+		contentType = "application/grpc-web-text+" + codec
+		body = []byte(base64.StdEncoding.EncodeToString(body))
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusOf(rec))
+	w.Write(body)
+}
+
+// writeConnectStream writes the response of a Connect streaming call, which
+// ends with an end-of-stream message holding the error and the trailers as
+// JSON.
+func writeConnectStream(w http.ResponseWriter, rec *recorder, codec string) {
+	headers, trailers := rec.split()
+	translateResponseEncoding(headers, "Connect-Content-Encoding")
+	copyHeaders(w.Header(), headers)
+
+	endStream := map[string]interface{}{}
+	if code, message := grpcStatus(trailers); code != 0 {
+		endStream["error"] = map[string]string{"code": codeName(code), "message": message}
+	}
+	if metadata := userMetadata(trailers); len(metadata) > 0 {
+		endStream["metadata"] = metadata
+	}
+	payload, _ := json.Marshal(endStream)
+
+	w.Header().Set("Content-Type", "application/connect+"+codec)
+	w.WriteHeader(statusOf(rec))
+	w.Write(append(rec.body.Bytes(), frame(flagEndStream, payload)...))
+}
+
+// writeConnectUnary writes the response of a Connect unary call: the bare
+// message, or a JSON error with the HTTP status of the code. Trailers are
+// sent as headers with a Trailer- prefix.
+func writeConnectUnary(w http.ResponseWriter, rec *recorder, codec string) {
+	headers, trailers := rec.split()
+	translateResponseEncoding(headers, "Content-Encoding")
+	copyHeaders(w.Header(), headers)
+	for key, values := range userMetadata(trailers) {
+		for _, value := range values {
+			w.Header().Add("Trailer-"+key, value)
+		}
+	}
+
+	if code, message := grpcStatus(trailers); code != 0 || rec.status >= 300 {
+		if code == 0 {
+			code = 2
+		}
+		w.Header().Del("Content-Encoding")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(connectCodes[code].status)
+		payload, _ := json.Marshal(map[string]string{"code": codeName(code), "message": message})
+		w.Write(payload)
+		return
+	}
+
+	message := rec.body.Bytes()
+	if len(message) >= 5 {
+		length := int(binary.BigEndian.Uint32(message[1:5]))
+		if len(message) >= 5+length {
+			message = message[5 : 5+length]
+		}
+	}
+	w.Header().Set("Content-Type", "application/"+codec)
+	w.WriteHeader(http.StatusOK)
+	w.Write(message)
+}
+
+// statusOf returns the HTTP status of the gRPC response, which is 200 for
+// calls that failed with a gRPC status as well.
+func statusOf(rec *recorder) int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+// grpcStatus returns the status code and the decoded message of the call.
+// Responses without a status count as failed with code unknown.
+func grpcStatus(trailers http.Header) (int, string) {
+	code, err := strconv.Atoi(trailers.Get("Grpc-Status"))
+	if err != nil || code < 0 || code >= len(connectCodes) {
+		return 2, "Missing or invalid grpc-status"
+	}
+	message, err := url.PathUnescape(trailers.Get("Grpc-Message"))
+	if err != nil {
+		message = trailers.Get("Grpc-Message")
+	}
+	return code, message
+}
+
+func codeName(code int) string {
+	return connectCodes[code].name
+}
+
+// userMetadata returns the trailers without the gRPC status headers.
+func userMetadata(trailers http.Header) http.Header {
+	metadata := trailers.Clone()
+	for _, key := range statusHeaders {
+		metadata.Del(key)
+	}
+	return metadata
+}
+
+// translateResponseEncoding renames the Grpc-Encoding header of the
+// response to the header of the Connect protocol.
+func translateResponseEncoding(headers http.Header, name string) {
+	if encoding := headers.Get("Grpc-Encoding"); encoding != "" && encoding != "identity" {
+		headers.Set(name, encoding)
+	}
+	headers.Del("Grpc-Encoding")
+	headers.Del("Grpc-Accept-Encoding")
+}
+
+func copyHeaders(dst http.Header, src http.Header) {
+	for key, values := range src {
+		for _, value := range values {
+			dst.Add(key, value)
+		}
+	}
+}
+
+// dataFlag returns the flags of a data frame.
+func dataFlag(compressed bool) byte {
+	if compressed {
+		return flagCompressed
+	}
+	return 0
+}
+
+// frame returns the payload prefixed with the flags and its length.
+func frame(flags byte, payload []byte) []byte {
+	framed := make([]byte, 5, 5+len(payload))
+	framed[0] = flags
+	binary.BigEndian.PutUint32(framed[1:], uint32(len(payload)))
+	return append(framed, payload...)
+}
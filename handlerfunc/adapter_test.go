@@ -41,4 +41,29 @@ var _ = Describe("HandlerFuncAdapter tests", func() {
 			Expect(resp.StatusCode).To(Equal(200))
 		})
 	})
+
+	Context("Internal error responder", func() {
+		It("Returns a JSON error when the handler leaves the status unset", func() {
+			adapter := handlerfunc.New(func(w http.ResponseWriter, req *http.Request) {})
+			adapter.SetInternalErrorResponder(func(err error) events.APIGatewayProxyResponse {
+				return events.APIGatewayProxyResponse{
+					StatusCode:        http.StatusInternalServerError,
+					MultiValueHeaders: map[string][]string{"Content-Type": {"application/json"}},
+					Body:              "{\"message\":\"Internal Server Error\"}",
+				}
+			})
+
+			req := events.APIGatewayProxyRequest{
+				Path:       "/ping",
+				HTTPMethod: "GET",
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"application/json"}))
+			Expect(resp.Body).To(Equal("{\"message\":\"Internal Server Error\"}"))
+		})
+	})
 })
@@ -0,0 +1,37 @@
+package handlerfunc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+type HandlerFuncAdapterALB struct {
+	core.RequestAccessorALB
+	*core.ALBProxyHandler
+	handlerFunc http.HandlerFunc
+}
+
+func NewALB(handlerFunc http.HandlerFunc) *HandlerFuncAdapterALB {
+	h := &HandlerFuncAdapterALB{
+		handlerFunc: handlerFunc,
+	}
+	h.ALBProxyHandler = core.NewALBProxyHandler(&h.RequestAccessorALB, handlerFunc)
+	return h
+}
+
+// Proxy receives an ALB target group event, transforms it into an
+// http.Request object, and sends it to the http.HandlerFunc for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (h *HandlerFuncAdapterALB) Proxy(event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return h.ALBProxyHandler.Proxy(event)
+}
+
+// ProxyWithContext receives context and an ALB target group event,
+// transforms them into an http.Request object, and sends it to the http.HandlerFunc for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (h *HandlerFuncAdapterALB) ProxyWithContext(ctx context.Context, event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return h.ALBProxyHandler.ProxyWithContext(ctx, event)
+}
@@ -0,0 +1,42 @@
+package handlerfunc_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/handlerfunc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HandlerFuncAdapterALB tests", func() {
+	Context("Simple ping request", func() {
+		It("Proxies the event correctly", func() {
+			handler := func(w http.ResponseWriter, req *http.Request) {
+				fmt.Fprintf(w, "Go Lambda!!")
+			}
+
+			adapter := handlerfunc.NewALB(handler)
+
+			req := events.ALBTargetGroupRequest{
+				HTTPMethod: http.MethodGet,
+				Path:       "/ping",
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.StatusDescription).To(Equal("200 OK"))
+			Expect(resp.Headers).To(HaveKey("Content-Type"))
+
+			resp, err = adapter.Proxy(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("Go Lambda!!"))
+		})
+	})
+})
@@ -0,0 +1,37 @@
+package handlerfunc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+type HandlerFuncAdapterFnURL struct {
+	core.RequestAccessorFnURL
+	*core.FunctionURLProxyHandler
+	handlerFunc http.HandlerFunc
+}
+
+func NewFunctionURL(handlerFunc http.HandlerFunc) *HandlerFuncAdapterFnURL {
+	h := &HandlerFuncAdapterFnURL{
+		handlerFunc: handlerFunc,
+	}
+	h.FunctionURLProxyHandler = core.NewFunctionURLProxyHandler(&h.RequestAccessorFnURL, handlerFunc)
+	return h
+}
+
+// Proxy receives a Function URL event, transforms it into an http.Request
+// object, and sends it to the http.HandlerFunc for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (h *HandlerFuncAdapterFnURL) Proxy(event events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return h.FunctionURLProxyHandler.Proxy(event)
+}
+
+// ProxyWithContext receives context and a Function URL event,
+// transforms them into an http.Request object, and sends it to the http.HandlerFunc for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (h *HandlerFuncAdapterFnURL) ProxyWithContext(ctx context.Context, event events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return h.FunctionURLProxyHandler.ProxyWithContext(ctx, event)
+}
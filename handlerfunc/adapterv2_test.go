@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
 	"github.com/awslabs/aws-lambda-go-api-proxy/handlerfunc"
 
 	. "github.com/onsi/ginkgo"
@@ -45,4 +46,39 @@ var _ = Describe("HandlerFuncAdapter tests", func() {
 			Expect(resp.StatusCode).To(Equal(200))
 		})
 	})
+
+	Context("Handler without output", func() {
+		req := events.APIGatewayV2HTTPRequest{
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+					Method: http.MethodGet,
+					Path:   "/ping",
+				},
+			},
+		}
+		handler := func(w http.ResponseWriter, req *http.Request) {}
+
+		It("Returns an error without a default status", func() {
+			adapter := handlerfunc.NewV2(handler)
+
+			_, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("Returns the default status when configured", func() {
+			adapter := handlerfunc.NewV2(handler)
+			adapter.SetResponseWriterFactory(func() core.ProxyResponder[events.APIGatewayV2HTTPResponse] {
+				w := core.NewProxyResponseWriterV2()
+				w.SetDefaultStatus(http.StatusOK)
+				return w
+			})
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal(""))
+		})
+	})
 })
@@ -0,0 +1,37 @@
+package httpadapter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+type HandlerAdapterALB struct {
+	core.RequestAccessorALB
+	*core.ALBProxyHandler
+	handler http.Handler
+}
+
+func NewALB(handler http.Handler) *HandlerAdapterALB {
+	h := &HandlerAdapterALB{
+		handler: handler,
+	}
+	h.ALBProxyHandler = core.NewALBProxyHandler(&h.RequestAccessorALB, handler)
+	return h
+}
+
+// Proxy receives an ALB target group event, transforms it into an
+// http.Request object, and sends it to the http.Handler for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (h *HandlerAdapterALB) Proxy(event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return h.ALBProxyHandler.Proxy(event)
+}
+
+// ProxyWithContext receives context and an ALB target group event,
+// transforms them into an http.Request object, and sends it to the http.Handler for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (h *HandlerAdapterALB) ProxyWithContext(ctx context.Context, event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return h.ALBProxyHandler.ProxyWithContext(ctx, event)
+}
@@ -0,0 +1,49 @@
+package httpadapter_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HandlerAdapterALB tests", func() {
+	Context("Application Load Balancer request", func() {
+		It("Proxies the event correctly", func() {
+			var httpHandler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				session, _ := r.Cookie("session")
+				fmt.Fprintf(w, "%s %s %s", r.URL.Query().Get("name"), session.Value, r.Header.Get("X-Tenant"))
+			})
+
+			adapter := httpadapter.NewALB(httpHandler)
+
+			req := events.ALBTargetGroupRequest{
+				HTTPMethod:                      "GET",
+				Path:                            "/ping",
+				MultiValueQueryStringParameters: map[string][]string{"name": {"go"}},
+				MultiValueHeaders: map[string][]string{
+					"cookie":   {"session=abc"},
+					"x-tenant": {"acme"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.StatusDescription).To(Equal("200 OK"))
+			Expect(resp.MultiValueHeaders).To(HaveKey("Content-Type"))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.Proxy(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+})
@@ -0,0 +1,38 @@
+package httpadapter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+type HandlerAdapterEdge struct {
+	core.RequestAccessorEdge
+	*core.LambdaEdgeProxyHandler
+	handler http.Handler
+}
+
+// NewEdge creates a HandlerAdapterEdge for Lambda@Edge viewer request and
+// origin request triggers of a CloudFront distribution.
+func NewEdge(handler http.Handler) *HandlerAdapterEdge {
+	h := &HandlerAdapterEdge{
+		handler: handler,
+	}
+	h.LambdaEdgeProxyHandler = core.NewLambdaEdgeProxyHandler(&h.RequestAccessorEdge, handler)
+	return h
+}
+
+// Proxy receives a CloudFront event, transforms it into an http.Request
+// object, and sends it to the http.Handler for routing.
+// It returns a CloudFront response object generated from the http.ResponseWriter.
+func (h *HandlerAdapterEdge) Proxy(event core.CloudFrontEvent) (core.CloudFrontResponse, error) {
+	return h.LambdaEdgeProxyHandler.Proxy(event)
+}
+
+// ProxyWithContext receives context and a CloudFront event,
+// transforms them into an http.Request object, and sends it to the http.Handler for routing.
+// It returns a CloudFront response object generated from the http.ResponseWriter.
+func (h *HandlerAdapterEdge) ProxyWithContext(ctx context.Context, event core.CloudFrontEvent) (core.CloudFrontResponse, error) {
+	return h.LambdaEdgeProxyHandler.ProxyWithContext(ctx, event)
+}
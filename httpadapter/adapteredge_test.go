@@ -0,0 +1,46 @@
+package httpadapter_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HandlerAdapterEdge tests", func() {
+	Context("CloudFront request", func() {
+		It("Proxies the event correctly", func() {
+			var httpHandler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, "%s %s %s", r.Host, r.URL.Path, r.URL.Query().Get("name"))
+			})
+
+			adapter := httpadapter.NewEdge(httpHandler)
+
+			req := core.CloudFrontEvent{Records: []core.CloudFrontRecord{{CF: core.CloudFrontRecordCF{
+				Config: core.CloudFrontConfig{EventType: core.CloudFrontViewerRequest},
+				Request: core.CloudFrontRequest{
+					Method:      "GET",
+					URI:         "/ping",
+					QueryString: "name=go",
+					Headers:     core.CloudFrontHeaders{"host": {{Key: "Host", Value: "www.example.com"}}},
+				},
+			}}}}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Status).To(Equal("200"))
+			Expect(resp.Body).To(Equal("www.example.com /ping go"))
+
+			resp, err = adapter.Proxy(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("www.example.com /ping go"))
+		})
+	})
+})
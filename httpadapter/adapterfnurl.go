@@ -0,0 +1,37 @@
+package httpadapter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+type HandlerAdapterFnURL struct {
+	core.RequestAccessorFnURL
+	*core.FunctionURLProxyHandler
+	handler http.Handler
+}
+
+func NewFunctionURL(handler http.Handler) *HandlerAdapterFnURL {
+	h := &HandlerAdapterFnURL{
+		handler: handler,
+	}
+	h.FunctionURLProxyHandler = core.NewFunctionURLProxyHandler(&h.RequestAccessorFnURL, handler)
+	return h
+}
+
+// Proxy receives a Function URL event, transforms it into an http.Request
+// object, and sends it to the http.Handler for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (h *HandlerAdapterFnURL) Proxy(event events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return h.FunctionURLProxyHandler.Proxy(event)
+}
+
+// ProxyWithContext receives context and a Function URL event,
+// transforms them into an http.Request object, and sends it to the http.Handler for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (h *HandlerAdapterFnURL) ProxyWithContext(ctx context.Context, event events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return h.FunctionURLProxyHandler.ProxyWithContext(ctx, event)
+}
@@ -0,0 +1,47 @@
+package httpadapter_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HandlerAdapterFnURL tests", func() {
+	Context("Function URL request", func() {
+		It("Proxies the event correctly", func() {
+			var httpHandler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				session, _ := r.Cookie("session")
+				fmt.Fprintf(w, "%s %s %s", r.URL.Query().Get("name"), session.Value, r.Header.Get("X-Tenant"))
+			})
+
+			adapter := httpadapter.NewFunctionURL(httpHandler)
+
+			req := events.LambdaFunctionURLRequest{
+				RawPath:        "/ping",
+				RawQueryString: "name=go",
+				Cookies:        []string{"session=abc"},
+				Headers:        map[string]string{"x-tenant": "acme"},
+				RequestContext: events.LambdaFunctionURLRequestContext{
+					HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET", Path: "/ping"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContext(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.Proxy(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+})
@@ -0,0 +1,37 @@
+package httpadapter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+type HandlerAdapterV2 struct {
+	core.RequestAccessorV2
+	*core.APIGatewayV2ProxyHandler
+	handler http.Handler
+}
+
+func NewV2(handler http.Handler) *HandlerAdapterV2 {
+	h := &HandlerAdapterV2{
+		handler: handler,
+	}
+	h.APIGatewayV2ProxyHandler = core.NewAPIGatewayV2ProxyHandler(&h.RequestAccessorV2, handler)
+	return h
+}
+
+// Proxy receives an API Gateway HTTP API (v2) event, transforms it into an
+// http.Request object, and sends it to the http.Handler for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (h *HandlerAdapterV2) Proxy(event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return h.APIGatewayV2ProxyHandler.Proxy(event)
+}
+
+// ProxyWithContext receives context and an API Gateway HTTP API (v2) event,
+// transforms them into an http.Request object, and sends it to the http.Handler for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (h *HandlerAdapterV2) ProxyWithContext(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return h.APIGatewayV2ProxyHandler.ProxyWithContext(ctx, event)
+}
@@ -6,6 +6,7 @@ package irisadapter
 import (
 	"context"
 	"net/http"
+	"sync"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/awslabs/aws-lambda-go-api-proxy/core"
@@ -14,52 +15,135 @@ import (
 
 // IrisLambda makes it easy to send API Gateway proxy events to a iris.Application.
 // The library transforms the proxy event into an HTTP request and then
-// creates a proxy response object from the http.ResponseWriter
+// creates a proxy response object from the http.ResponseWriter.
+// The embedded RequestAccessor and APIGatewayProxyHandler configure REST API
+// (v1) events, the exported fields configure HTTP API (v2), Application Load
+// Balancer and Function URL events.
 type IrisLambda struct {
 	core.RequestAccessor
+	*core.APIGatewayProxyHandler
+
+	RequestAccessorV2        core.RequestAccessorV2
+	APIGatewayV2ProxyHandler *core.APIGatewayV2ProxyHandler
+
+	RequestAccessorALB core.RequestAccessorALB
+	ALBProxyHandler    *core.ALBProxyHandler
+
+	RequestAccessorFnURL    core.RequestAccessorFnURL
+	FunctionURLProxyHandler *core.FunctionURLProxyHandler
 
 	application *iris.Application
+
+	// buildMu guards built, so simultaneous events build the application
+	// only once
+	buildMu sync.Mutex
+	built   bool
 }
 
 // New creates a new instance of the IrisLambda object.
 // Receives an initialized *iris.Application object - normally created with iris.Default().
 // It returns the initialized instance of the IrisLambda object.
 func New(app *iris.Application) *IrisLambda {
-	return &IrisLambda{application: app}
+	i := &IrisLambda{application: app}
+	i.APIGatewayProxyHandler = core.NewAPIGatewayProxyHandler(&i.RequestAccessor, app)
+	i.APIGatewayV2ProxyHandler = core.NewAPIGatewayV2ProxyHandler(&i.RequestAccessorV2, app)
+	i.ALBProxyHandler = core.NewALBProxyHandler(&i.RequestAccessorALB, app)
+	i.FunctionURLProxyHandler = core.NewFunctionURLProxyHandler(&i.RequestAccessorFnURL, app)
+	return i
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
 // object, and sends it to the iris.Application for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (i *IrisLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	irisRequest, err := i.ProxyEventToHTTPRequest(req)
-	return i.proxyInternal(irisRequest, err)
+	if err := i.build(); err != nil {
+		return core.GatewayTimeout(), core.NewLoggedError("Iris set up failed: %v", err)
+	}
+	return i.APIGatewayProxyHandler.Proxy(req)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
 // transforms them into an http.Request object, and sends it to the iris.Application for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (i *IrisLambda) ProxyWithContext(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	irisRequest, err := i.EventToRequestWithContext(ctx, req)
-	return i.proxyInternal(irisRequest, err)
+	if err := i.build(); err != nil {
+		return core.GatewayTimeout(), core.NewLoggedError("Iris set up failed: %v", err)
+	}
+	return i.APIGatewayProxyHandler.ProxyWithContext(ctx, req)
 }
 
-func (i *IrisLambda) proxyInternal(req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+// ProxyV2 receives an API Gateway HTTP API (v2) event, transforms it into an
+// http.Request object, and sends it to the iris.Application for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (i *IrisLambda) ProxyV2(req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	if err := i.build(); err != nil {
+		return events.APIGatewayV2HTTPResponse{StatusCode: http.StatusGatewayTimeout}, core.NewLoggedError("Iris set up failed: %v", err)
 	}
+	return i.APIGatewayV2ProxyHandler.Proxy(req)
+}
 
-	if err := i.application.Build(); err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Iris set up failed: %v", err)
+// ProxyWithContextV2 receives context and an API Gateway HTTP API (v2) event,
+// transforms them into an http.Request object, and sends it to the iris.Application for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (i *IrisLambda) ProxyWithContextV2(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	if err := i.build(); err != nil {
+		return events.APIGatewayV2HTTPResponse{StatusCode: http.StatusGatewayTimeout}, core.NewLoggedError("Iris set up failed: %v", err)
 	}
+	return i.APIGatewayV2ProxyHandler.ProxyWithContext(ctx, req)
+}
 
-	respWriter := core.NewProxyResponseWriter()
-	i.application.ServeHTTP(http.ResponseWriter(respWriter), req)
+// ProxyALB receives an ALB target group event, transforms it into an
+// http.Request object, and sends it to the iris.Application for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (i *IrisLambda) ProxyALB(req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	if err := i.build(); err != nil {
+		return events.ALBTargetGroupResponse{StatusCode: http.StatusGatewayTimeout, StatusDescription: "504 Gateway Timeout"}, core.NewLoggedError("Iris set up failed: %v", err)
+	}
+	return i.ALBProxyHandler.Proxy(req)
+}
+
+// ProxyWithContextALB receives context and an ALB target group event,
+// transforms them into an http.Request object, and sends it to the iris.Application for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (i *IrisLambda) ProxyWithContextALB(ctx context.Context, req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	if err := i.build(); err != nil {
+		return events.ALBTargetGroupResponse{StatusCode: http.StatusGatewayTimeout, StatusDescription: "504 Gateway Timeout"}, core.NewLoggedError("Iris set up failed: %v", err)
+	}
+	return i.ALBProxyHandler.ProxyWithContext(ctx, req)
+}
+
+// ProxyFunctionURL receives a Function URL event, transforms it into an
+// http.Request object, and sends it to the iris.Application for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (i *IrisLambda) ProxyFunctionURL(req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	if err := i.build(); err != nil {
+		return events.LambdaFunctionURLResponse{StatusCode: http.StatusGatewayTimeout}, core.NewLoggedError("Iris set up failed: %v", err)
+	}
+	return i.FunctionURLProxyHandler.Proxy(req)
+}
 
-	proxyResponse, err := respWriter.GetProxyResponse()
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
+// ProxyWithContextFunctionURL receives context and a Function URL event,
+// transforms them into an http.Request object, and sends it to the iris.Application for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (i *IrisLambda) ProxyWithContextFunctionURL(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	if err := i.build(); err != nil {
+		return events.LambdaFunctionURLResponse{StatusCode: http.StatusGatewayTimeout}, core.NewLoggedError("Iris set up failed: %v", err)
 	}
+	return i.FunctionURLProxyHandler.ProxyWithContext(ctx, req)
+}
 
-	return proxyResponse, nil
+// build builds the iris.Application on the first event. Iris builds the
+// router in Build, which is not safe to run alongside requests, so later
+// events skip it. A failed build is retried on the next event.
+func (i *IrisLambda) build() error {
+	i.buildMu.Lock()
+	defer i.buildMu.Unlock()
+	if i.built {
+		return nil
+	}
+	if err := i.application.Build(); err != nil {
+		return err
+	}
+	i.built = true
+	return nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/aws/aws-lambda-go/events"
 	irisadapter "github.com/awslabs/aws-lambda-go-api-proxy/iris"
+	"github.com/awslabs/aws-lambda-go-api-proxy/proxytest"
 	"github.com/kataras/iris/v12"
 
 	. "github.com/onsi/ginkgo"
@@ -41,4 +42,115 @@ var _ = Describe("IrisLambda tests", func() {
 			Expect(resp.StatusCode).To(Equal(200))
 		})
 	})
+
+	Context("HTTP API v2 request", func() {
+		It("Proxies the event correctly", func() {
+			app := iris.New()
+			app.Get("/ping", func(ctx iris.Context) {
+				ctx.WriteString(ctx.URLParam("name") + " " + ctx.GetCookie("session") + " " + ctx.GetHeader("X-Tenant"))
+			})
+
+			adapter := irisadapter.New(app)
+
+			req := events.APIGatewayV2HTTPRequest{
+				RawPath:        "/ping",
+				RawQueryString: "name=go",
+				Cookies:        []string{"session=abc"},
+				Headers:        map[string]string{"x-tenant": "acme"},
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET", Path: "/ping"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContextV2(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.ProxyV2(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Application Load Balancer request", func() {
+		It("Proxies the event correctly", func() {
+			app := iris.New()
+			app.Get("/ping", func(ctx iris.Context) {
+				ctx.WriteString(ctx.URLParam("name") + " " + ctx.GetCookie("session") + " " + ctx.GetHeader("X-Tenant"))
+			})
+
+			adapter := irisadapter.New(app)
+
+			req := events.ALBTargetGroupRequest{
+				HTTPMethod:                      "GET",
+				Path:                            "/ping",
+				MultiValueQueryStringParameters: map[string][]string{"name": {"go"}},
+				MultiValueHeaders: map[string][]string{
+					"cookie":   {"session=abc"},
+					"x-tenant": {"acme"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContextALB(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.StatusDescription).To(Equal("200 OK"))
+			Expect(resp.MultiValueHeaders).To(HaveKey("Content-Type"))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.ProxyALB(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Function URL request", func() {
+		It("Proxies the event correctly", func() {
+			app := iris.New()
+			app.Get("/ping", func(ctx iris.Context) {
+				ctx.WriteString(ctx.URLParam("name") + " " + ctx.GetCookie("session") + " " + ctx.GetHeader("X-Tenant"))
+			})
+
+			adapter := irisadapter.New(app)
+
+			req := events.LambdaFunctionURLRequest{
+				RawPath:        "/ping",
+				RawQueryString: "name=go",
+				Cookies:        []string{"session=abc"},
+				Headers:        map[string]string{"x-tenant": "acme"},
+				RequestContext: events.LambdaFunctionURLRequestContext{
+					HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET", Path: "/ping"},
+				},
+			}
+
+			resp, err := adapter.ProxyWithContextFunctionURL(context.Background(), req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("go abc acme"))
+
+			resp, err = adapter.ProxyFunctionURL(req)
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("go abc acme"))
+		})
+	})
+
+	Context("Concurrent requests", func() {
+		It("Keeps the state of simultaneous events apart", func() {
+			app := iris.New()
+			app.Get("/items/{id}", func(ctx iris.Context) {
+				ctx.WriteString(ctx.Params().Get("id"))
+			})
+
+			adapter := irisadapter.New(app)
+
+			Expect(proxytest.ProxyConcurrently(100, adapter.ProxyWithContext, adapter.ProxyWithContextV2)).To(BeEmpty())
+		})
+	})
 })
@@ -0,0 +1,13 @@
+package localserver_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestLocalServer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LocalServer Suite")
+}
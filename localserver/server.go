@@ -0,0 +1,111 @@
+// Package localserver runs an adapter as a plain net/http server for local
+// development. Every request is converted into the API Gateway proxy event
+// Lambda would deliver, with a request ID, stage, identity and authorizer
+// context, so the handler goes through the same code paths as in production.
+package localserver
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+const (
+	// DefaultStage is the stage of the events when SetStage was not called.
+	DefaultStage = "local"
+
+	// DefaultPrincipalID is the principalId of the authorizer context when
+	// SetAuthorizer was not called.
+	DefaultPrincipalID = "local-user"
+
+	localFunctionARN = "arn:aws:lambda:us-east-1:123456789012:function:local"
+)
+
+// Server is an http.Handler that sends the requests it receives to an
+// adapter as API Gateway REST API (v1) proxy events and writes the proxy
+// responses back.
+type Server struct {
+	proxy      core.APIGatewayProxyFunc
+	stage      string
+	stageVars  map[string]string
+	authorizer map[string]interface{}
+}
+
+// New returns a Server for the ProxyWithContext method of an adapter, for
+// example ginadapter.New(r).ProxyWithContext.
+func New(proxy core.APIGatewayProxyFunc) *Server {
+	return &Server{
+		proxy:      proxy,
+		stage:      DefaultStage,
+		authorizer: map[string]interface{}{"principalId": DefaultPrincipalID},
+	}
+}
+
+// NewFromHandler returns a Server that sends the events to the handler
+// through an APIGatewayProxyHandler.
+func NewFromHandler(handler http.Handler) *Server {
+	return New(core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, handler).ProxyWithContext)
+}
+
+// SetStage sets the stage of the events, "local" by default.
+func (s *Server) SetStage(stage string) {
+	s.stage = stage
+}
+
+// SetStageVariables sets the stage variables of the events.
+func (s *Server) SetStageVariables(stageVars map[string]string) {
+	s.stageVars = stageVars
+}
+
+// SetAuthorizer sets the authorizer context of the events, which defaults
+// to a principalId of "local-user". Pass nil for events without one.
+func (s *Server) SetAuthorizer(authorizer map[string]interface{}) {
+	s.authorizer = authorizer
+}
+
+// ListenAndServe listens on the TCP address and serves the requests it
+// receives, like http.ListenAndServe.
+func (s *Server) ListenAndServe(addr string) error {
+	log.Printf("Serving stage %s on %s\n", s.stage, addr)
+	return http.ListenAndServe(addr, s)
+}
+
+// ServeHTTP implements http.Handler. Errors returned by the adapter are
+// answered with a 502 Bad Gateway, like API Gateway does for failed
+// invocations.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	event, err := s.event(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := lambdacontext.NewContext(r.Context(), &lambdacontext.LambdaContext{
+		AwsRequestID:       event.RequestContext.RequestID,
+		InvokedFunctionArn: localFunctionARN,
+	})
+	resp, err := s.proxy(ctx, event)
+	if err != nil {
+		log.Printf("Invocation for %s %s failed: %v\n", r.Method, r.URL.Path, err)
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+
+	core.WriteAPIGatewayResponse(w, resp)
+}
+
+// event builds the API Gateway proxy event of a greedy {proxy+} resource
+// for the request, with the stage, stage variables and authorizer context of
+// the server.
+func (s *Server) event(r *http.Request) (events.APIGatewayProxyRequest, error) {
+	event, err := core.NewAPIGatewayRequestFromHTTP(r, s.stage)
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+	event.StageVariables = s.stageVars
+	event.RequestContext.Authorizer = s.authorizer
+	return event, nil
+}
@@ -0,0 +1,82 @@
+package localserver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/awslabs/aws-lambda-go-api-proxy/localserver"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server tests", func() {
+	contextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiGwContext, _ := core.GetAPIGatewayContextFromContext(r.Context())
+		runtimeContext, _ := core.GetRuntimeContextFromContext(r.Context())
+		stageVars, _ := core.GetStageVarsFromContext(r.Context())
+		proxyPath := core.GetProxyPath(r.Context())
+		w.Header().Set("X-Request-Id", apiGwContext.RequestID)
+		w.Header().Set("X-Aws-Request-Id", runtimeContext.AwsRequestID)
+		fmt.Fprintf(w, "%s %s %v %s %s", apiGwContext.Stage, apiGwContext.Identity.SourceIP, apiGwContext.Authorizer["principalId"], stageVars["env"], proxyPath)
+	})
+
+	get := func(server *localserver.Server, path string) (*http.Response, string) {
+		httpServer := httptest.NewServer(server)
+		defer httpServer.Close()
+		resp, err := http.Get(httpServer.URL + path)
+		Expect(err).To(BeNil())
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).To(BeNil())
+		return resp, string(body)
+	}
+
+	It("Synthesizes the Lambda request context", func() {
+		resp, body := get(localserver.NewFromHandler(contextHandler), "/orders/42")
+
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(body).To(Equal("local 127.0.0.1 local-user  orders/42"))
+		Expect(resp.Header.Get("X-Request-Id")).To(HaveLen(36))
+		Expect(resp.Header.Get("X-Aws-Request-Id")).To(Equal(resp.Header.Get("X-Request-Id")))
+	})
+
+	It("Uses the configured stage and authorizer", func() {
+		server := localserver.NewFromHandler(contextHandler)
+		server.SetStage("dev")
+		server.SetStageVariables(map[string]string{"env": "development"})
+		server.SetAuthorizer(map[string]interface{}{"principalId": "alice"})
+
+		_, body := get(server, "/orders")
+
+		Expect(body).To(Equal("dev 127.0.0.1 alice development orders"))
+	})
+
+	It("Serves adapters and reports their errors as 502", func() {
+		server := localserver.New(func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			if strings.HasPrefix(req.Path, "/fail") {
+				return events.APIGatewayProxyResponse{}, errors.New("boom")
+			}
+			return events.APIGatewayProxyResponse{
+				StatusCode:        http.StatusCreated,
+				MultiValueHeaders: map[string][]string{"X-Method": {req.HTTPMethod}},
+				Body:              "created",
+			}, nil
+		})
+
+		resp, body := get(server, "/orders")
+		Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+		Expect(resp.Header.Get("X-Method")).To(Equal("GET"))
+		Expect(body).To(Equal("created"))
+
+		resp, _ = get(server, "/fail")
+		Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+	})
+})
@@ -2,7 +2,6 @@ package negroniadapter
 
 import (
 	"context"
-	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/awslabs/aws-lambda-go-api-proxy/core"
@@ -11,43 +10,28 @@ import (
 
 type NegroniAdapter struct {
 	core.RequestAccessor
+	*core.APIGatewayProxyHandler
 	n *negroni.Negroni
 }
 
 func New(n *negroni.Negroni) *NegroniAdapter {
-	return &NegroniAdapter{
+	h := &NegroniAdapter{
 		n: n,
 	}
+	h.APIGatewayProxyHandler = core.NewAPIGatewayProxyHandler(&h.RequestAccessor, n)
+	return h
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
 // object, and sends it to the negroni.Negroni for routing.
 // It returns a proxy response object generated from the http.Handler.
 func (h *NegroniAdapter) Proxy(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	req, err := h.ProxyEventToHTTPRequest(event)
-	return h.proxyInternal(req, err)
+	return h.APIGatewayProxyHandler.Proxy(event)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
 // transforms them into an http.Request object, and sends it to the negroni.Negroni for routing.
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *NegroniAdapter) ProxyWithContext(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	req, err := h.EventToRequestWithContext(ctx, event)
-	return h.proxyInternal(req, err)
-}
-
-func (h *NegroniAdapter) proxyInternal(req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
-	}
-
-	w := core.NewProxyResponseWriter()
-	h.n.ServeHTTP(http.ResponseWriter(w), req)
-
-	resp, err := w.GetProxyResponse()
-	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
-	}
-
-	return resp, nil
+	return h.APIGatewayProxyHandler.ProxyWithContext(ctx, event)
 }
@@ -0,0 +1,37 @@
+package negroniadapter
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/urfave/negroni"
+)
+
+type NegroniAdapterALB struct {
+	core.RequestAccessorALB
+	*core.ALBProxyHandler
+	n *negroni.Negroni
+}
+
+func NewALB(n *negroni.Negroni) *NegroniAdapterALB {
+	h := &NegroniAdapterALB{
+		n: n,
+	}
+	h.ALBProxyHandler = core.NewALBProxyHandler(&h.RequestAccessorALB, n)
+	return h
+}
+
+// Proxy receives an ALB target group event, transforms it into an
+// http.Request object, and sends it to the negroni.Negroni for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (h *NegroniAdapterALB) Proxy(event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return h.ALBProxyHandler.Proxy(event)
+}
+
+// ProxyWithContext receives context and an ALB target group event,
+// transforms them into an http.Request object, and sends it to the negroni.Negroni for routing.
+// It returns an ALB response object generated from the http.ResponseWriter.
+func (h *NegroniAdapterALB) ProxyWithContext(ctx context.Context, event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return h.ALBProxyHandler.ProxyWithContext(ctx, event)
+}
@@ -0,0 +1,37 @@
+package negroniadapter
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/urfave/negroni"
+)
+
+type NegroniAdapterFnURL struct {
+	core.RequestAccessorFnURL
+	*core.FunctionURLProxyHandler
+	n *negroni.Negroni
+}
+
+func NewFunctionURL(n *negroni.Negroni) *NegroniAdapterFnURL {
+	h := &NegroniAdapterFnURL{
+		n: n,
+	}
+	h.FunctionURLProxyHandler = core.NewFunctionURLProxyHandler(&h.RequestAccessorFnURL, n)
+	return h
+}
+
+// Proxy receives a Function URL event, transforms it into an http.Request
+// object, and sends it to the negroni.Negroni for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (h *NegroniAdapterFnURL) Proxy(event events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return h.FunctionURLProxyHandler.Proxy(event)
+}
+
+// ProxyWithContext receives context and a Function URL event,
+// transforms them into an http.Request object, and sends it to the negroni.Negroni for routing.
+// It returns a Function URL response object generated from the http.ResponseWriter.
+func (h *NegroniAdapterFnURL) ProxyWithContext(ctx context.Context, event events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return h.FunctionURLProxyHandler.ProxyWithContext(ctx, event)
+}
@@ -0,0 +1,37 @@
+package negroniadapter
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/urfave/negroni"
+)
+
+type NegroniAdapterV2 struct {
+	core.RequestAccessorV2
+	*core.APIGatewayV2ProxyHandler
+	n *negroni.Negroni
+}
+
+func NewV2(n *negroni.Negroni) *NegroniAdapterV2 {
+	h := &NegroniAdapterV2{
+		n: n,
+	}
+	h.APIGatewayV2ProxyHandler = core.NewAPIGatewayV2ProxyHandler(&h.RequestAccessorV2, n)
+	return h
+}
+
+// Proxy receives an API Gateway HTTP API (v2) event, transforms it into an
+// http.Request object, and sends it to the negroni.Negroni for routing.
+// It returns a proxy response object generated from the http.Handler.
+func (h *NegroniAdapterV2) Proxy(event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return h.APIGatewayV2ProxyHandler.Proxy(event)
+}
+
+// ProxyWithContext receives context and an API Gateway HTTP API (v2) event,
+// transforms them into an http.Request object, and sends it to the negroni.Negroni for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (h *NegroniAdapterV2) ProxyWithContext(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return h.APIGatewayV2ProxyHandler.ProxyWithContext(ctx, event)
+}
@@ -0,0 +1,60 @@
+package proxytest
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+// APIGatewayV2ProxyFunc is the signature of the ProxyWithContextV2 method of
+// the adapters.
+type APIGatewayV2ProxyFunc func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error)
+
+// ProxyConcurrently sends n GET /items/{id} events to an adapter at once,
+// alternating between REST API (v1) events through proxy and HTTP API (v2)
+// events through proxyV2, for tests that check the adapter keeps the state
+// of simultaneous events apart. The router of the adapter must answer the
+// path with the id. It returns an "id: body" entry for every event whose
+// response body was not its id.
+func ProxyConcurrently(n int, proxy core.APIGatewayProxyFunc, proxyV2 APIGatewayV2ProxyFunc) []string {
+	var wg sync.WaitGroup
+	mismatches := make(chan string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := strconv.Itoa(i)
+			body := ""
+			if i%2 == 0 {
+				resp, err := proxy(context.Background(), events.APIGatewayProxyRequest{Path: "/items/" + id, HTTPMethod: "GET"})
+				if err == nil {
+					body = resp.Body
+				}
+			} else {
+				resp, err := proxyV2(context.Background(), events.APIGatewayV2HTTPRequest{
+					RawPath: "/items/" + id,
+					RequestContext: events.APIGatewayV2HTTPRequestContext{
+						HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET", Path: "/items/" + id},
+					},
+				})
+				if err == nil {
+					body = resp.Body
+				}
+			}
+			if body != id {
+				mismatches <- id + ": " + body
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(mismatches)
+
+	var result []string
+	for mismatch := range mismatches {
+		result = append(result, mismatch)
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package proxytest
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// fixtures are POST /orders?tag=a&tag=b requests with a JSON body, in the
+// payload format of each event source. The HTTP API fixture has a base64
+// body and cookies, so both paths of the conversion are covered.
+//
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// LoadFixture decodes the golden JSON event in the file at path into event,
+// which is a pointer to one of the event types.
+func LoadFixture(path string, event interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, event); err != nil {
+		return fmt.Errorf("Could not decode fixture %s: %v", path, err)
+	}
+	return nil
+}
+
+// APIGatewayFixture returns the golden API Gateway REST API (v1) event.
+func APIGatewayFixture() events.APIGatewayProxyRequest {
+	var event events.APIGatewayProxyRequest
+	mustLoadEmbedded("apigateway.json", &event)
+	return event
+}
+
+// APIGatewayV2Fixture returns the golden API Gateway HTTP API (v2) event.
+func APIGatewayV2Fixture() events.APIGatewayV2HTTPRequest {
+	var event events.APIGatewayV2HTTPRequest
+	mustLoadEmbedded("apigatewayv2.json", &event)
+	return event
+}
+
+// ALBFixture returns the golden Application Load Balancer event.
+func ALBFixture() events.ALBTargetGroupRequest {
+	var event events.ALBTargetGroupRequest
+	mustLoadEmbedded("alb.json", &event)
+	return event
+}
+
+// FunctionURLFixture returns the golden Lambda Function URL event.
+func FunctionURLFixture() events.LambdaFunctionURLRequest {
+	var event events.LambdaFunctionURLRequest
+	mustLoadEmbedded("functionurl.json", &event)
+	return event
+}
+
+// mustLoadEmbedded decodes an embedded fixture, each call returns a fresh
+// copy tests can modify.
+func mustLoadEmbedded(name string, event interface{}) {
+	data, err := fixtures.ReadFile("fixtures/" + name)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(data, event); err != nil {
+		panic(fmt.Sprintf("Could not decode fixture %s: %v", name, err))
+	}
+}
@@ -0,0 +1,13 @@
+package proxytest_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestProxyTest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ProxyTest Suite")
+}
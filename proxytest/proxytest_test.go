@@ -0,0 +1,183 @@
+package proxytest_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/awslabs/aws-lambda-go-api-proxy/proxytest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Round-trip tests", func() {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		cookie, _ := r.Cookie("session")
+		w.Header().Add("X-Tag", strings.Join(r.URL.Query()["tag"], ","))
+		w.Header().Add("X-Accept", strings.Join(r.Header.Values("Accept"), ","))
+		if cookie != nil {
+			http.SetCookie(w, &http.Cookie{Name: "seen", Value: cookie.Value})
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.Path, body)
+	})
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest("POST", "/orders?tag=a&tag=b", strings.NewReader(`{"item":"book"}`))
+		req.Header.Add("Accept", "application/json")
+		req.Header.Add("Accept", "text/plain")
+		req.AddCookie(&http.Cookie{Name: "session", Value: "abc123"})
+		return req
+	}
+
+	expectEcho := func(rec *httptest.ResponseRecorder) {
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(rec.Body.String()).To(Equal(`POST /orders {"item":"book"}`))
+		Expect(rec.Header().Get("X-Tag")).To(Equal("a,b"))
+		Expect(rec.Header().Get("Set-Cookie")).To(Equal("seen=abc123"))
+	}
+
+	It("Drives an API Gateway handler", func() {
+		event, err := proxytest.NewAPIGatewayRequestFromHTTP(newRequest())
+		Expect(err).To(BeNil())
+		Expect(event.MultiValueHeaders["Accept"]).To(Equal([]string{"application/json", "text/plain"}))
+		Expect(event.MultiValueQueryStringParameters["tag"]).To(Equal([]string{"a", "b"}))
+		Expect(event.RequestContext.Identity.SourceIP).To(Equal("192.0.2.1"))
+
+		resp, err := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, echo).ProxyWithContext(context.Background(), event)
+		Expect(err).To(BeNil())
+		rec, err := proxytest.ResponseRecorderFromProxyResponse(resp)
+		Expect(err).To(BeNil())
+		expectEcho(rec)
+		Expect(rec.Header().Get("X-Accept")).To(Equal("application/json,text/plain"))
+	})
+
+	It("Drives an HTTP API handler", func() {
+		event, err := proxytest.NewAPIGatewayV2RequestFromHTTP(newRequest())
+		Expect(err).To(BeNil())
+		Expect(event.Cookies).To(Equal([]string{"session=abc123"}))
+		Expect(event.Headers).ToNot(HaveKey("cookie"))
+		Expect(event.Headers["accept"]).To(Equal("application/json,text/plain"))
+		Expect(event.RawQueryString).To(Equal("tag=a&tag=b"))
+
+		resp, err := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, echo).ProxyWithContext(context.Background(), event)
+		Expect(err).To(BeNil())
+		rec, err := proxytest.ResponseRecorderFromV2Response(resp)
+		Expect(err).To(BeNil())
+		expectEcho(rec)
+	})
+
+	It("Drives an Application Load Balancer handler", func() {
+		event, err := proxytest.NewALBRequestFromHTTP(newRequest())
+		Expect(err).To(BeNil())
+		Expect(event.MultiValueHeaders["accept"]).To(Equal([]string{"application/json", "text/plain"}))
+
+		resp, err := core.NewALBProxyHandler(&core.RequestAccessorALB{}, echo).ProxyWithContext(context.Background(), event)
+		Expect(err).To(BeNil())
+		Expect(resp.MultiValueHeaders).ToNot(BeEmpty())
+		rec, err := proxytest.ResponseRecorderFromALBResponse(resp)
+		Expect(err).To(BeNil())
+		expectEcho(rec)
+	})
+
+	It("Drives a Function URL handler", func() {
+		event, err := proxytest.NewFunctionURLRequestFromHTTP(newRequest())
+		Expect(err).To(BeNil())
+		Expect(event.Cookies).To(Equal([]string{"session=abc123"}))
+
+		resp, err := core.NewFunctionURLProxyHandler(&core.RequestAccessorFnURL{}, echo).ProxyWithContext(context.Background(), event)
+		Expect(err).To(BeNil())
+		rec, err := proxytest.ResponseRecorderFromFunctionURLResponse(resp)
+		Expect(err).To(BeNil())
+		expectEcho(rec)
+	})
+
+	It("Base64 encodes binary bodies and decodes binary responses", func() {
+		req := httptest.NewRequest("POST", "/upload", strings.NewReader("\xff\xd8\xff"))
+		event, err := proxytest.NewAPIGatewayRequestFromHTTP(req)
+		Expect(err).To(BeNil())
+		Expect(event.IsBase64Encoded).To(BeTrue())
+		Expect(event.Body).To(Equal("/9j/"))
+
+		rec, err := proxytest.ResponseRecorderFromProxyResponse(events.APIGatewayProxyResponse{
+			StatusCode:      http.StatusOK,
+			Body:            "/9j/",
+			IsBase64Encoded: true,
+		})
+		Expect(err).To(BeNil())
+		Expect(rec.Body.Bytes()).To(Equal([]byte("\xff\xd8\xff")))
+
+		_, err = proxytest.ResponseRecorderFromProxyResponse(events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: "%%", IsBase64Encoded: true})
+		Expect(err).ToNot(BeNil())
+	})
+})
+
+var _ = Describe("Fixture tests", func() {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %v %s", r.Method, r.URL.Path, r.URL.Query()["tag"], body)
+	})
+	expected := `POST /orders [a b] {"item":"book","quantity":2}`
+
+	It("Provides golden events for every payload type", func() {
+		v1, err := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, handler).ProxyWithContext(context.Background(), proxytest.APIGatewayFixture())
+		Expect(err).To(BeNil())
+		Expect(v1.Body).To(Equal(expected))
+
+		v2, err := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, handler).ProxyWithContext(context.Background(), proxytest.APIGatewayV2Fixture())
+		Expect(err).To(BeNil())
+		Expect(v2.Body).To(Equal(expected))
+
+		alb, err := core.NewALBProxyHandler(&core.RequestAccessorALB{}, handler).ProxyWithContext(context.Background(), proxytest.ALBFixture())
+		Expect(err).To(BeNil())
+		Expect(alb.Body).To(Equal(expected))
+
+		fnURL, err := core.NewFunctionURLProxyHandler(&core.RequestAccessorFnURL{}, handler).ProxyWithContext(context.Background(), proxytest.FunctionURLFixture())
+		Expect(err).To(BeNil())
+		Expect(fnURL.Body).To(Equal(expected))
+	})
+
+	It("Returns a fresh copy on every call", func() {
+		event := proxytest.APIGatewayFixture()
+		event.Path = "/changed"
+		Expect(proxytest.APIGatewayFixture().Path).To(Equal("/orders"))
+	})
+
+	It("Loads golden events from files", func() {
+		dir, err := ioutil.TempDir("", "proxytest")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "event.json")
+		Expect(ioutil.WriteFile(path, []byte(`{"httpMethod":"DELETE","path":"/orders/42"}`), 0600)).To(BeNil())
+
+		var event events.APIGatewayProxyRequest
+		Expect(proxytest.LoadFixture(path, &event)).To(BeNil())
+		Expect(event.HTTPMethod).To(Equal("DELETE"))
+		Expect(event.Path).To(Equal("/orders/42"))
+
+		Expect(ioutil.WriteFile(path, []byte(`{`), 0600)).To(BeNil())
+		Expect(proxytest.LoadFixture(path, &event)).ToNot(BeNil())
+	})
+	It("Reports events whose response belongs to another event", func() {
+		items := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/items/")))
+		})
+		v1 := core.NewAPIGatewayProxyHandler(&core.RequestAccessor{}, items)
+		v2 := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, items)
+		Expect(proxytest.ProxyConcurrently(20, v1.ProxyWithContext, v2.ProxyWithContext)).To(BeEmpty())
+
+		wrong := core.NewAPIGatewayV2ProxyHandler(&core.RequestAccessorV2{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("0"))
+		}))
+		Expect(proxytest.ProxyConcurrently(4, v1.ProxyWithContext, wrong.ProxyWithContext)).To(ConsistOf("1: 0", "3: 0"))
+	})
+})
@@ -0,0 +1,67 @@
+package proxytest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ResponseRecorderFromProxyResponse returns an httptest.ResponseRecorder
+// holding the status, headers and decoded body of an API Gateway REST API
+// (v1) proxy response. Multi-value headers take precedence over single
+// value headers of the same name, as they do in API Gateway.
+func ResponseRecorderFromProxyResponse(resp events.APIGatewayProxyResponse) (*httptest.ResponseRecorder, error) {
+	return record(resp.StatusCode, resp.Headers, resp.MultiValueHeaders, nil, resp.Body, resp.IsBase64Encoded)
+}
+
+// ResponseRecorderFromV2Response returns an httptest.ResponseRecorder for an
+// API Gateway HTTP API (v2) response. The cookies of the response become
+// Set-Cookie headers.
+func ResponseRecorderFromV2Response(resp events.APIGatewayV2HTTPResponse) (*httptest.ResponseRecorder, error) {
+	return record(resp.StatusCode, resp.Headers, resp.MultiValueHeaders, resp.Cookies, resp.Body, resp.IsBase64Encoded)
+}
+
+// ResponseRecorderFromALBResponse returns an httptest.ResponseRecorder for an
+// Application Load Balancer response.
+func ResponseRecorderFromALBResponse(resp events.ALBTargetGroupResponse) (*httptest.ResponseRecorder, error) {
+	return record(resp.StatusCode, resp.Headers, resp.MultiValueHeaders, nil, resp.Body, resp.IsBase64Encoded)
+}
+
+// ResponseRecorderFromFunctionURLResponse returns an
+// httptest.ResponseRecorder for a Lambda Function URL response. The cookies
+// of the response become Set-Cookie headers.
+func ResponseRecorderFromFunctionURLResponse(resp events.LambdaFunctionURLResponse) (*httptest.ResponseRecorder, error) {
+	return record(resp.StatusCode, resp.Headers, nil, resp.Cookies, resp.Body, resp.IsBase64Encoded)
+}
+
+func record(status int, headers map[string]string, multiValueHeaders map[string][]string, cookies []string, body string, isBase64 bool) (*httptest.ResponseRecorder, error) {
+	decoded := []byte(body)
+	if isBase64 {
+		var err error
+		if decoded, err = base64.StdEncoding.DecodeString(body); err != nil {
+			return nil, fmt.Errorf("Could not decode the response body: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	for key, values := range multiValueHeaders {
+		for _, value := range values {
+			rec.Header().Add(key, value)
+		}
+	}
+	for key, value := range headers {
+		if _, ok := rec.Header()[http.CanonicalHeaderKey(key)]; !ok {
+			rec.Header().Set(key, value)
+		}
+	}
+	for _, cookie := range cookies {
+		rec.Header().Add("Set-Cookie", cookie)
+	}
+
+	rec.WriteHeader(status)
+	rec.Write(decoded)
+	return rec, nil
+}
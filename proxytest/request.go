@@ -0,0 +1,218 @@
+// Package proxytest builds Lambda proxy events from http.Request values and
+// turns proxy responses back into httptest.ResponseRecorder values, so tests
+// can drive adapters with the standard httptest tooling instead of writing
+// the event structs by hand. Golden JSON fixtures of every payload type are
+// available for tests that start from a recorded event instead.
+package proxytest
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+const (
+	testAccountID   = "123456789012"
+	testRequestID   = "c6af9ac6-7b61-11e6-9a41-93e8deadbeef"
+	testTargetGroup = "arn:aws:elasticloadbalancing:us-east-1:" + testAccountID + ":targetgroup/test/50dc6c495c0c9188"
+)
+
+// NewAPIGatewayRequestFromHTTP returns the API Gateway REST API (v1) proxy
+// event of a greedy {proxy+} resource on the "test" stage for the request,
+// see core.NewAPIGatewayRequestFromHTTP. The request ID is fixed so tests
+// can assert on it.
+func NewAPIGatewayRequestFromHTTP(r *http.Request) (events.APIGatewayProxyRequest, error) {
+	event, err := core.NewAPIGatewayRequestFromHTTP(r, "test")
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+	event.RequestContext.RequestID = testRequestID
+	return event, nil
+}
+
+// NewAPIGatewayV2RequestFromHTTP returns the API Gateway HTTP API (v2)
+// payload of the $default route for the request. Like API Gateway, it moves
+// the Cookie header into the cookies array, lower-cases the header names
+// and joins repeated headers and query parameters with commas.
+func NewAPIGatewayV2RequestFromHTTP(r *http.Request) (events.APIGatewayV2HTTPRequest, error) {
+	body, isBase64, err := readBody(r)
+	if err != nil {
+		return events.APIGatewayV2HTTPRequest{}, err
+	}
+	headers, cookies := v2Headers(r)
+	now := time.Now()
+
+	return events.APIGatewayV2HTTPRequest{
+		Version:               "2.0",
+		RouteKey:              "$default",
+		RawPath:               r.URL.EscapedPath(),
+		RawQueryString:        r.URL.RawQuery,
+		Cookies:               cookies,
+		Headers:               headers,
+		QueryStringParameters: singleValues(r.URL.Query()),
+		Body:                  body,
+		IsBase64Encoded:       isBase64,
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RouteKey:     "$default",
+			AccountID:    testAccountID,
+			Stage:        "$default",
+			RequestID:    testRequestID,
+			APIID:        "test",
+			DomainName:   r.Host,
+			DomainPrefix: strings.SplitN(r.Host, ".", 2)[0],
+			Time:         now.Format("02/Jan/2006:15:04:05 -0700"),
+			TimeEpoch:    now.UnixNano() / int64(time.Millisecond),
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Protocol:  r.Proto,
+				SourceIP:  sourceIP(r),
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}, nil
+}
+
+// NewALBRequestFromHTTP returns the Application Load Balancer event of a
+// target group with multi-value headers enabled for the request.
+func NewALBRequestFromHTTP(r *http.Request) (events.ALBTargetGroupRequest, error) {
+	body, isBase64, err := readBody(r)
+	if err != nil {
+		return events.ALBTargetGroupRequest{}, err
+	}
+
+	return events.ALBTargetGroupRequest{
+		HTTPMethod:                      r.Method,
+		Path:                            r.URL.Path,
+		MultiValueHeaders:               lowerCase(requestHeaders(r)),
+		MultiValueQueryStringParameters: r.URL.Query(),
+		Body:                            body,
+		IsBase64Encoded:                 isBase64,
+		RequestContext: events.ALBTargetGroupRequestContext{
+			ELB: events.ELBContext{TargetGroupArn: testTargetGroup},
+		},
+	}, nil
+}
+
+// NewFunctionURLRequestFromHTTP returns the Lambda Function URL payload for
+// the request, which has the same shape as the HTTP API (v2) payload.
+func NewFunctionURLRequestFromHTTP(r *http.Request) (events.LambdaFunctionURLRequest, error) {
+	body, isBase64, err := readBody(r)
+	if err != nil {
+		return events.LambdaFunctionURLRequest{}, err
+	}
+	headers, cookies := v2Headers(r)
+	now := time.Now()
+
+	return events.LambdaFunctionURLRequest{
+		Version:               "2.0",
+		RawPath:               r.URL.EscapedPath(),
+		RawQueryString:        r.URL.RawQuery,
+		Cookies:               cookies,
+		Headers:               headers,
+		QueryStringParameters: singleValues(r.URL.Query()),
+		Body:                  body,
+		IsBase64Encoded:       isBase64,
+		RequestContext: events.LambdaFunctionURLRequestContext{
+			AccountID:    testAccountID,
+			RequestID:    testRequestID,
+			APIID:        "test",
+			DomainName:   r.Host,
+			DomainPrefix: strings.SplitN(r.Host, ".", 2)[0],
+			Time:         now.Format("02/Jan/2006:15:04:05 -0700"),
+			TimeEpoch:    now.UnixNano() / int64(time.Millisecond),
+			HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Protocol:  r.Proto,
+				SourceIP:  sourceIP(r),
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}, nil
+}
+
+// readBody reads the request body, base64 encoding it when it is not valid
+// UTF-8.
+func readBody(r *http.Request) (string, bool, error) {
+	if r.Body == nil {
+		return "", false, nil
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return "", false, err
+	}
+	if utf8.Valid(body) {
+		return string(body), false, nil
+	}
+	return base64.StdEncoding.EncodeToString(body), true, nil
+}
+
+// requestHeaders returns the request headers with the Host header added,
+// which net/http keeps in the Host field.
+func requestHeaders(r *http.Request) http.Header {
+	headers := r.Header.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	if r.Host != "" {
+		headers.Set("Host", r.Host)
+	}
+	return headers
+}
+
+// v2Headers returns the lower-cased, comma-joined headers of the request and
+// the cookies of its Cookie headers.
+func v2Headers(r *http.Request) (map[string]string, []string) {
+	headers := make(map[string]string)
+	var cookies []string
+	for key, values := range requestHeaders(r) {
+		if key == "Cookie" {
+			for _, value := range values {
+				for _, cookie := range strings.Split(value, ";") {
+					if cookie = strings.TrimSpace(cookie); cookie != "" {
+						cookies = append(cookies, cookie)
+					}
+				}
+			}
+			continue
+		}
+		headers[strings.ToLower(key)] = strings.Join(values, ",")
+	}
+	return headers, cookies
+}
+
+// singleValues returns the values of every key joined with commas.
+func singleValues(values map[string][]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	single := make(map[string]string, len(values))
+	for key, v := range values {
+		single[key] = strings.Join(v, ",")
+	}
+	return single
+}
+
+func lowerCase(headers http.Header) map[string][]string {
+	lower := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		lower[strings.ToLower(key)] = values
+	}
+	return lower
+}
+
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,45 @@
+// Package websocketadapter sends API Gateway WebSocket API events to an
+// http.Handler. Each event becomes a request to the path of its route key,
+// such as POST /sendMessage or GET /$connect, so an existing mux can route
+// them, or a Router can pick the handler by route key directly. The
+// ManagementClient posts messages back to the connected clients.
+package websocketadapter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+// WebSocketAdapter sends API Gateway WebSocket API events to an http.Handler.
+type WebSocketAdapter struct {
+	core.RequestAccessorWebSocket
+	*core.WebSocketProxyHandler
+	handler http.Handler
+}
+
+// New creates a WebSocketAdapter for the given handler, either a Router or
+// a mux with routes for the route key paths.
+func New(handler http.Handler) *WebSocketAdapter {
+	h := &WebSocketAdapter{
+		handler: handler,
+	}
+	h.WebSocketProxyHandler = core.NewWebSocketProxyHandler(&h.RequestAccessorWebSocket, handler)
+	return h
+}
+
+// Proxy receives a WebSocket API event, transforms it into an http.Request
+// object, and sends it to the http.Handler for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (h *WebSocketAdapter) Proxy(event events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return h.WebSocketProxyHandler.Proxy(event)
+}
+
+// ProxyWithContext receives context and a WebSocket API event,
+// transforms them into an http.Request object, and sends it to the http.Handler for routing.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (h *WebSocketAdapter) ProxyWithContext(ctx context.Context, event events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return h.WebSocketProxyHandler.ProxyWithContext(ctx, event)
+}
@@ -0,0 +1,91 @@
+package websocketadapter_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/awslabs/aws-lambda-go-api-proxy/websocketadapter"
+	"github.com/gorilla/mux"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebSocketAdapter tests", func() {
+	Context("Router", func() {
+		router := websocketadapter.NewRouter()
+		router.HandleFunc(websocketadapter.RouteConnect, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		router.HandleFunc("sendMessage", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			wsContext, _ := core.GetWebSocketContextFromContext(r.Context())
+			fmt.Fprintf(w, "%s %s", wsContext.ConnectionID, body)
+		})
+
+		It("Sends events to the handler of their route key", func() {
+			adapter := websocketadapter.New(router)
+
+			resp, err := adapter.ProxyWithContext(context.Background(), getWebSocketRequest("sendMessage", "hi"))
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("L0SM9cOFvHcCIhw= hi"))
+
+			resp, err = adapter.Proxy(getWebSocketRequest(websocketadapter.RouteConnect, ""))
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+		})
+
+		It("Falls back to the $default route", func() {
+			adapter := websocketadapter.New(router)
+
+			resp, err := adapter.ProxyWithContext(context.Background(), getWebSocketRequest("unknown", ""))
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(404))
+
+			router.HandleFunc(websocketadapter.RouteDefault, func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("default"))
+			})
+			resp, err = adapter.ProxyWithContext(context.Background(), getWebSocketRequest("unknown", ""))
+
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("default"))
+		})
+	})
+
+	Context("Existing mux", func() {
+		It("Routes the route key paths", func() {
+			r := mux.NewRouter()
+			r.HandleFunc("/$disconnect", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("bye"))
+			}).Methods("POST")
+
+			adapter := websocketadapter.New(r)
+
+			resp, err := adapter.ProxyWithContext(context.Background(), getWebSocketRequest(websocketadapter.RouteDisconnect, ""))
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Body).To(Equal("bye"))
+		})
+	})
+})
+
+func getWebSocketRequest(routeKey string, body string) events.APIGatewayWebsocketProxyRequest {
+	return events.APIGatewayWebsocketProxyRequest{
+		Body: body,
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{
+			RouteKey:     routeKey,
+			Stage:        "prod",
+			ConnectionID: "L0SM9cOFvHcCIhw=",
+			DomainName:   "abc123.execute-api.us-east-1.amazonaws.com",
+		},
+	}
+}
@@ -0,0 +1,139 @@
+package websocketadapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+// ErrConnectionGone is returned by the ManagementClient methods when the
+// client of the connection has disconnected.
+var ErrConnectionGone = errors.New("Connection is gone")
+
+// Credentials are the AWS credentials a ManagementClient signs its requests
+// with.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Connection describes a connection returned by GetConnection.
+type Connection struct {
+	ConnectedAt  time.Time          `json:"connectedAt"`
+	LastActiveAt time.Time          `json:"lastActiveAt"`
+	Identity     ConnectionIdentity `json:"identity"`
+}
+
+// ConnectionIdentity is the client identity of a Connection.
+type ConnectionIdentity struct {
+	SourceIP  string `json:"sourceIp"`
+	UserAgent string `json:"userAgent"`
+}
+
+// ManagementClient calls the API Gateway Management API of a WebSocket API
+// stage to send messages to connections, read their status and disconnect
+// them. Requests are signed with Signature Version 4.
+type ManagementClient struct {
+	// Endpoint is the URL of the stage, such as
+	// https://abc123.execute-api.us-east-1.amazonaws.com/prod.
+	Endpoint    string
+	Region      string
+	Credentials Credentials
+	// HTTPClient sends the requests, http.DefaultClient when nil.
+	HTTPClient *http.Client
+}
+
+// NewManagementClient returns a ManagementClient for the stage endpoint with
+// the region and credentials of the Lambda execution environment, read from
+// the AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN variables.
+func NewManagementClient(endpoint string) *ManagementClient {
+	return &ManagementClient{
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+		Region:   os.Getenv("AWS_REGION"),
+		Credentials: Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+	}
+}
+
+// NewManagementClientFromContext returns a ManagementClient for the stage the
+// WebSocket request in the context was received on, like NewManagementClient.
+// It returns false for requests that did not come from a WebSocket event.
+// Events received through a custom domain name need a client for the
+// execute-api domain of the API instead.
+func NewManagementClientFromContext(ctx context.Context) (*ManagementClient, bool) {
+	wsContext, ok := core.GetWebSocketContextFromContext(ctx)
+	if !ok || wsContext.DomainName == "" {
+		return nil, false
+	}
+	return NewManagementClient("https://" + wsContext.DomainName + "/" + wsContext.Stage), true
+}
+
+// PostToConnection sends data to the client of the connection.
+func (c *ManagementClient) PostToConnection(ctx context.Context, connectionID string, data []byte) error {
+	_, err := c.do(ctx, http.MethodPost, connectionID, data)
+	return err
+}
+
+// GetConnection returns the status of the connection.
+func (c *ManagementClient) GetConnection(ctx context.Context, connectionID string) (Connection, error) {
+	var connection Connection
+	body, err := c.do(ctx, http.MethodGet, connectionID, nil)
+	if err != nil {
+		return connection, err
+	}
+	if err := json.Unmarshal(body, &connection); err != nil {
+		return connection, fmt.Errorf("Could not decode connection %s: %v", connectionID, err)
+	}
+	return connection, nil
+}
+
+// DeleteConnection disconnects the client of the connection.
+func (c *ManagementClient) DeleteConnection(ctx context.Context, connectionID string) error {
+	_, err := c.do(ctx, http.MethodDelete, connectionID, nil)
+	return err
+}
+
+func (c *ManagementClient) do(ctx context.Context, method string, connectionID string, payload []byte) ([]byte, error) {
+	endpoint := c.Endpoint + "/@connections/" + escapeURIComponent(connectionID)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	signRequest(req, payload, c.Credentials, c.Region, "execute-api", time.Now())
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusGone:
+		return nil, ErrConnectionGone
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("Management API returned status %d for connection %s: %s", resp.StatusCode, connectionID, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,95 @@
+package websocketadapter_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/awslabs/aws-lambda-go-api-proxy/websocketadapter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ManagementClient tests", func() {
+	var requests []*http.Request
+	var bodies []string
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		requests, bodies = nil, nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requests = append(requests, r)
+			bodies = append(bodies, string(body))
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/gone"):
+				w.WriteHeader(http.StatusGone)
+			case r.Method == http.MethodGet:
+				w.Write([]byte(`{"connectedAt": "2024-01-02T03:04:05Z", "lastActiveAt": "2024-01-02T03:05:00Z", "identity": {"sourceIp": "192.0.2.1", "userAgent": "wscat"}}`))
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func() *websocketadapter.ManagementClient {
+		return &websocketadapter.ManagementClient{
+			Endpoint:    server.URL + "/prod",
+			Region:      "us-east-1",
+			Credentials: websocketadapter.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"},
+		}
+	}
+
+	It("Posts signed messages to the connection", func() {
+		err := newClient().PostToConnection(context.Background(), "L0SM9cOFvHcCIhw=", []byte("hello"))
+
+		Expect(err).To(BeNil())
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Method).To(Equal("POST"))
+		Expect(requests[0].URL.EscapedPath()).To(Equal("/prod/@connections/L0SM9cOFvHcCIhw%3D"))
+		Expect(bodies[0]).To(Equal("hello"))
+		Expect(requests[0].Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=AKID/"))
+		Expect(requests[0].Header.Get("Authorization")).To(ContainSubstring("/us-east-1/execute-api/aws4_request, SignedHeaders=host;x-amz-date;x-amz-security-token, Signature="))
+		Expect(requests[0].Header.Get("X-Amz-Security-Token")).To(Equal("token"))
+	})
+
+	It("Reads and deletes connections", func() {
+		connection, err := newClient().GetConnection(context.Background(), "abc")
+
+		Expect(err).To(BeNil())
+		Expect(connection.Identity.SourceIP).To(Equal("192.0.2.1"))
+		Expect(connection.ConnectedAt.Year()).To(Equal(2024))
+
+		err = newClient().DeleteConnection(context.Background(), "abc")
+
+		Expect(err).To(BeNil())
+		Expect(requests[1].Method).To(Equal("DELETE"))
+	})
+
+	It("Reports disconnected clients", func() {
+		err := newClient().PostToConnection(context.Background(), "gone", []byte("hello"))
+
+		Expect(err).To(Equal(websocketadapter.ErrConnectionGone))
+	})
+
+	It("Targets the stage of the WebSocket request", func() {
+		accessor := core.RequestAccessorWebSocket{}
+		req, err := accessor.EventToRequestWithContext(context.Background(), getWebSocketRequest("sendMessage", ""))
+		Expect(err).To(BeNil())
+
+		client, ok := websocketadapter.NewManagementClientFromContext(req.Context())
+
+		Expect(ok).To(BeTrue())
+		Expect(client.Endpoint).To(Equal("https://abc123.execute-api.us-east-1.amazonaws.com/prod"))
+
+		_, ok = websocketadapter.NewManagementClientFromContext(context.Background())
+
+		Expect(ok).To(BeFalse())
+	})
+})
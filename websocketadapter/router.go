@@ -0,0 +1,64 @@
+package websocketadapter
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+// Route keys API Gateway reserves for the connection lifecycle and for
+// messages that match no other route.
+const (
+	RouteConnect    = "$connect"
+	RouteDisconnect = "$disconnect"
+	RouteDefault    = "$default"
+)
+
+// Router is an http.Handler that sends WebSocket requests to the handler
+// registered for their route key. Requests for route keys without a handler
+// go to the $default handler, or receive a 404 if there is none.
+type Router struct {
+	mu     sync.RWMutex
+	routes map[string]http.Handler
+}
+
+// NewRouter returns a Router without routes.
+func NewRouter() *Router {
+	return &Router{routes: make(map[string]http.Handler)}
+}
+
+// Handle registers the handler for the route key.
+func (rt *Router) Handle(routeKey string, handler http.Handler) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.routes[routeKey] = handler
+}
+
+// HandleFunc registers the handler function for the route key.
+func (rt *Router) HandleFunc(routeKey string, handler func(http.ResponseWriter, *http.Request)) {
+	rt.Handle(routeKey, http.HandlerFunc(handler))
+}
+
+// ServeHTTP implements http.Handler. The route key is read from the
+// WebSocket request context, requests that did not come from a WebSocket
+// event receive a 404.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	wsContext, ok := core.GetWebSocketContextFromContext(r.Context())
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	rt.mu.RLock()
+	handler, ok := rt.routes[wsContext.RouteKey]
+	if !ok {
+		handler, ok = rt.routes[RouteDefault]
+	}
+	rt.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,13 @@
+package websocketadapter_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestWebSocketAdapter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "WebSocketAdapter Suite")
+}